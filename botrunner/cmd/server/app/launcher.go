@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
+	"voyager.com/botrunner/internal/driver"
 	"voyager.com/gamescript"
 	"voyager.com/logging"
 )
@@ -28,6 +29,7 @@ func NewLauncher() *Launcher {
 		batches:     make(map[string]*BotRunnerBatch),
 		humanGames:  make(map[string]*HumanGame),
 		tournaments: make(map[uint64]*Tournament),
+		sitAndGos:   make(map[uint64]*SitAndGo),
 	}
 }
 
@@ -43,6 +45,9 @@ type Launcher struct {
 
 	// tournaments
 	tournaments map[uint64]*Tournament
+
+	// sit-and-go tournaments hosted by the game server
+	sitAndGos map[uint64]*SitAndGo
 }
 
 // ApplyToBatch schedules the requested number of games to be applied to the batch.
@@ -144,13 +149,14 @@ func (l *Launcher) StartAppGame(clubCode string, name string, players *gamescrip
 	return nil
 }
 
-// RegisterTournament starts a BotRunner and register bots to play in tournament
-func (l *Launcher) RegisterTournament(clubCode string, tournamentID uint64, botCount int32) error {
+// RegisterTournament starts a BotRunner and register bots to play in tournament.
+// When loadTest is set the tournament is played as a load test.
+func (l *Launcher) RegisterTournament(clubCode string, tournamentID uint64, botCount int32, loadTest *driver.LoadTestConfig) error {
 	_, exists := l.tournaments[tournamentID]
 	if exists {
 		return fmt.Errorf("There is already a tournament registered with id [%d]", tournamentID)
 	}
-	h, err := NewTournament(clubCode, tournamentID, botCount)
+	h, err := NewTournament(clubCode, tournamentID, botCount, loadTest)
 	if err != nil {
 		return err
 	}
@@ -180,3 +186,36 @@ func (l *Launcher) EndTournament(tournamentID uint64) error {
 	tournament.EndTournament()
 	return nil
 }
+
+// TournamentReport returns the result of the load test of the tournament
+func (l *Launcher) TournamentReport(tournamentID uint64) (driver.LoadTestReport, error) {
+	tournament, exists := l.tournaments[tournamentID]
+	if !exists {
+		return driver.LoadTestReport{}, fmt.Errorf("There is no tournament registered with id [%d]", tournamentID)
+	}
+	return tournament.Report()
+}
+
+// SetHandForHand turns the hand-for-hand play of the tournament on or off
+func (l *Launcher) SetHandForHand(tournamentID uint64, enabled bool, playersRemaining uint32) error {
+	tournament, exists := l.tournaments[tournamentID]
+	if !exists {
+		return fmt.Errorf("There is no tournament registered with id [%d]", tournamentID)
+	}
+	return tournament.SetHandForHand(enabled, playersRemaining)
+}
+
+// StartSitAndGo creates a sit-and-go in the game server and fills it with bots
+func (l *Launcher) StartSitAndGo(tournamentID uint64, config driver.SitAndGoConfig) error {
+	_, exists := l.sitAndGos[tournamentID]
+	if exists {
+		return fmt.Errorf("There is already a sit-and-go with id [%d]", tournamentID)
+	}
+	s := NewSitAndGo(tournamentID, config)
+	err := s.Launch()
+	if err != nil {
+		return err
+	}
+	l.sitAndGos[tournamentID] = s
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"voyager.com/botrunner/internal/caches"
+	"voyager.com/botrunner/internal/driver"
 	"voyager.com/botrunner/internal/util"
 	"voyager.com/gamescript"
 	"voyager.com/logging"
@@ -38,6 +39,9 @@ func RunRestServer(portNo uint, logDir string) {
 	r.POST("/register-tournament", registerTournament)
 	r.POST("/join-tournament", joinTournament)
 	r.POST("/end-tournament", endTournament)
+	r.GET("/tournament-report", tournamentReport)
+	r.POST("/hand-for-hand", handForHand)
+	r.POST("/sit-and-go", sitAndGo)
 	r.GET("/app-games", listAppGames)
 	r.Run(fmt.Sprintf(":%d", portNo))
 }
@@ -374,6 +378,8 @@ func registerTournament(c *gin.Context) {
 		TournamentId uint64 `json:"tournamentId"`
 		BotCount     int32  `json:"botCount"`
 		ClubCode     string `json:"clubCode"`
+		// LoadTest plays the tournament as a load test with generated bots.
+		LoadTest *driver.LoadTestConfig `json:"loadTest"`
 	}
 	var payload Payload
 	err := c.BindJSON(&payload)
@@ -386,9 +392,12 @@ func registerTournament(c *gin.Context) {
 	clubCode := payload.ClubCode
 	tournamentID := payload.TournamentId
 	botCount := payload.BotCount
+	if payload.LoadTest != nil && payload.LoadTest.Bots == 0 {
+		payload.LoadTest.Bots = uint32(botCount)
+	}
 
 	launcher := GetLauncher()
-	err = launcher.RegisterTournament(clubCode, tournamentID, botCount)
+	err = launcher.RegisterTournament(clubCode, tournamentID, botCount, payload.LoadTest)
 	if err != nil {
 		errMsg := fmt.Sprintf("Error while starting app game. Error: %s", err)
 		restLogger.Error().Msg(errMsg)
@@ -444,3 +453,100 @@ func endTournament(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "Accepted"})
 }
+
+func tournamentReport(c *gin.Context) {
+	tournamentIDStr := c.Query("tournament-id")
+	if tournamentIDStr == "" {
+		c.String(400, "Failed to read tournament-id param from tournament-report endpoint")
+		return
+	}
+	tournamentID, err := strconv.ParseUint(tournamentIDStr, 10, 64)
+	if err != nil {
+		c.String(400, "Failed to parse tournament-id  [%s] from tournament-report endpoint.", tournamentIDStr)
+		return
+	}
+
+	launcher := GetLauncher()
+	report, err := launcher.TournamentReport(tournamentID)
+	if err != nil {
+		errMsg := fmt.Sprintf("Error while getting the tournament report. Error: %s", err)
+		restLogger.Error().Msg(errMsg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errMsg})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func handForHand(c *gin.Context) {
+	tournamentIDStr := c.Query("tournament-id")
+	if tournamentIDStr == "" {
+		c.String(400, "Failed to read tournament-id param from hand-for-hand endpoint")
+		return
+	}
+	tournamentID, err := strconv.ParseUint(tournamentIDStr, 10, 64)
+	if err != nil {
+		c.String(400, "Failed to parse tournament-id  [%s] from hand-for-hand endpoint.", tournamentIDStr)
+		return
+	}
+	enabled, err := strconv.ParseBool(c.DefaultQuery("enabled", "true"))
+	if err != nil {
+		c.String(400, "Failed to parse enabled [%s] from hand-for-hand endpoint.", c.Query("enabled"))
+		return
+	}
+	// required by the game server when the tournament has no payouts
+	playersRemaining, err := strconv.ParseUint(c.DefaultQuery("players-remaining", "0"), 10, 32)
+	if err != nil {
+		c.String(400, "Failed to parse players-remaining [%s] from hand-for-hand endpoint.", c.Query("players-remaining"))
+		return
+	}
+
+	launcher := GetLauncher()
+	err = launcher.SetHandForHand(tournamentID, enabled, uint32(playersRemaining))
+	if err != nil {
+		errMsg := fmt.Sprintf("Error while setting hand-for-hand play. Error: %s", err)
+		restLogger.Error().Msg(errMsg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errMsg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "Accepted"})
+}
+
+func sitAndGo(c *gin.Context) {
+	tournamentIDStr := c.Query("tournament-id")
+	if tournamentIDStr == "" {
+		c.String(400, "Failed to read tournament-id param from sit-and-go endpoint")
+		return
+	}
+	tournamentID, err := strconv.ParseUint(tournamentIDStr, 10, 64)
+	if err != nil {
+		c.String(400, "Failed to parse tournament-id  [%s] from sit-and-go endpoint.", tournamentIDStr)
+		return
+	}
+	seats, err := strconv.ParseUint(c.DefaultQuery("seats", "6"), 10, 32)
+	if err != nil {
+		c.String(400, "Failed to parse seats [%s] from sit-and-go endpoint.", c.Query("seats"))
+		return
+	}
+	config := driver.DefaultSitAndGoConfig(uint32(seats))
+	if handsPerLevel := c.Query("hands-per-level"); handsPerLevel != "" {
+		hands, err := strconv.ParseUint(handsPerLevel, 10, 32)
+		if err != nil {
+			c.String(400, "Failed to parse hands-per-level [%s] from sit-and-go endpoint.", handsPerLevel)
+			return
+		}
+		config.HandsPerLevel = uint32(hands)
+	}
+
+	launcher := GetLauncher()
+	err = launcher.StartSitAndGo(tournamentID, config)
+	if err != nil {
+		errMsg := fmt.Sprintf("Error while starting sit-and-go. Error: %s", err)
+		restLogger.Error().Msg(errMsg)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errMsg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "Accepted"})
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+	"voyager.com/botrunner/internal/driver"
+	"voyager.com/logging"
+)
+
+// sitAndGoTimeout is how long the bots play before giving up on the sit-and-go.
+const sitAndGoTimeout = 2 * time.Hour
+
+// SitAndGo plays a sit-and-go hosted by the game server with bots.
+type SitAndGo struct {
+	logger       *zerolog.Logger
+	tournamentID uint64
+	instance     *driver.SitAndGoRunner
+}
+
+func NewSitAndGo(tournamentID uint64, config driver.SitAndGoConfig) *SitAndGo {
+	return &SitAndGo{
+		logger:       logging.GetZeroLogger("SitAndGo", nil),
+		tournamentID: tournamentID,
+		instance:     driver.NewSitAndGoRunner(tournamentID, config),
+	}
+}
+
+// Launch creates the sit-and-go and the bots and registers them. The bots
+// play in the background until the sit-and-go ends.
+func (s *SitAndGo) Launch() error {
+	s.logger.Info().Msgf("Launching bots for sit-and-go %d.", s.tournamentID)
+	err := s.instance.Create()
+	if err != nil {
+		return err
+	}
+	err = s.instance.CreateBots()
+	if err != nil {
+		return err
+	}
+	err = s.instance.RegisterBots()
+	if err != nil {
+		return err
+	}
+	go s.wait()
+	return nil
+}
+
+func (s *SitAndGo) wait() {
+	standings, err := s.instance.Wait(sitAndGoTimeout)
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("Sit-and-go %d failed", s.tournamentID)
+	}
+	for _, standing := range standings {
+		s.logger.Info().
+			Msgf("Sit-and-go %d place %d: %s prize: %v", s.tournamentID, standing.Place, standing.Name, standing.Prize)
+	}
+	s.instance.EndTournament()
+}
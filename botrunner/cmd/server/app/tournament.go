@@ -16,14 +16,17 @@ type Tournament struct {
 	botCount     int32
 	instance     *driver.TournamentRunner
 	demoGame     bool
+	// loadTest is set when the tournament is played as a load test
+	loadTest *driver.LoadTestConfig
 }
 
-func NewTournament(clubCode string, tournamentID uint64, botCount int32) (*Tournament, error) {
+func NewTournament(clubCode string, tournamentID uint64, botCount int32, loadTest *driver.LoadTestConfig) (*Tournament, error) {
 	b := Tournament{
 		logger:       logging.GetZeroLogger("Tournament", nil),
 		clubCode:     clubCode,
 		tournamentID: tournamentID,
 		botCount:     botCount,
+		loadTest:     loadTest,
 	}
 	return &b, nil
 }
@@ -36,7 +39,11 @@ func (t *Tournament) Launch(botCount int32) error {
 		t.logger.Error().Msgf("Launching tournament runner %d failed.", t.tournamentID)
 		return err
 	}
-	err = t.instance.CreateBots(botCount)
+	if t.loadTest != nil {
+		err = t.instance.CreateLoadTestBots(*t.loadTest)
+	} else {
+		err = t.instance.CreateBots(botCount)
+	}
 	if err != nil {
 		t.logger.Error().Msgf("Registering bots for tournament %d failed.", t.tournamentID)
 		return err
@@ -80,5 +87,25 @@ func (t *Tournament) EndTournament() error {
 		t.logger.Error().Msgf("Ending tournament %d failed.", t.tournamentID)
 		return err
 	}
+	if t.loadTest != nil {
+		report, err := t.instance.Report()
+		if err == nil {
+			t.logger.Info().Msgf("Load test of tournament %d: %+v", t.tournamentID, report)
+		}
+	}
+	return nil
+}
+
+// Report returns the result of the load test.
+func (t *Tournament) Report() (driver.LoadTestReport, error) {
+	return t.instance.Report()
+}
+
+func (t *Tournament) SetHandForHand(enabled bool, playersRemaining uint32) error {
+	err := t.instance.SetHandForHand(enabled, playersRemaining)
+	if err != nil {
+		t.logger.Error().Msgf("Setting hand-for-hand play of tournament %d failed.", t.tournamentID)
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,227 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"voyager.com/botrunner/internal/player"
+	"voyager.com/botrunner/internal/util"
+	"voyager.com/gamescript"
+	"voyager.com/logging"
+)
+
+// SitAndGoRunner plays a sit-and-go hosted by the game server with bots. The
+// bots don't sign up in the api server, they register in the game server with
+// local player IDs.
+type SitAndGoRunner struct {
+	logger       *zerolog.Logger
+	tournamentID uint64
+	config       SitAndGoConfig
+	bots         []*player.BotPlayer
+}
+
+// SitAndGoConfig is the structure of the sit-and-go.
+type SitAndGoConfig struct {
+	Seats           uint32          `json:"seats"`
+	StartingChips   float64         `json:"startingChips"`
+	HandsPerLevel   uint32          `json:"handsPerLevel"`
+	ActionTime      uint32          `json:"actionTime"`
+	ResultPauseTime uint32          `json:"resultPauseTime"`
+	PrizePool       float64         `json:"prizePool"`
+	Levels          []SitAndGoLevel `json:"levels"`
+}
+
+type SitAndGoLevel struct {
+	SmallBlind   float64 `json:"sb"`
+	BigBlind     float64 `json:"bb"`
+	Ante         float64 `json:"ante"`
+	DurationSecs uint32  `json:"durationSecs"`
+}
+
+// SitAndGoStanding is the final place of a player.
+type SitAndGoStanding struct {
+	PlayerID uint64  `json:"playerId"`
+	Name     string  `json:"name"`
+	Place    int     `json:"place"`
+	Tied     bool    `json:"tied"`
+	Prize    float64 `json:"prize"`
+}
+
+type sitAndGoState struct {
+	Started   bool               `json:"started"`
+	Finished  bool               `json:"finished"`
+	HandNum   uint32             `json:"handNum"`
+	BigBlind  float64            `json:"bigBlind"`
+	Standings []SitAndGoStanding `json:"standings"`
+}
+
+var SIT_AND_GO_DEVICE_START_ID = "f0a675ef-0001-4963-%04x-75a7d1735665"
+
+// sitAndGoPlayerIDBase keeps the local player IDs of the bots apart from the
+// player IDs of the api server.
+const sitAndGoPlayerIDBase = 900000000
+
+// DefaultSitAndGoConfig returns a turbo structure for the bots.
+func DefaultSitAndGoConfig(seats uint32) SitAndGoConfig {
+	return SitAndGoConfig{
+		Seats:           seats,
+		StartingChips:   1500,
+		HandsPerLevel:   10,
+		ActionTime:      30,
+		ResultPauseTime: 1,
+		PrizePool:       float64(seats) * 10,
+		Levels: []SitAndGoLevel{
+			{SmallBlind: 10, BigBlind: 20},
+			{SmallBlind: 20, BigBlind: 40},
+			{SmallBlind: 30, BigBlind: 60, Ante: 5},
+			{SmallBlind: 50, BigBlind: 100, Ante: 10},
+			{SmallBlind: 100, BigBlind: 200, Ante: 20},
+			{SmallBlind: 200, BigBlind: 400, Ante: 40},
+			{SmallBlind: 400, BigBlind: 800, Ante: 80},
+		},
+	}
+}
+
+func NewSitAndGoRunner(tournamentID uint64, config SitAndGoConfig) *SitAndGoRunner {
+	return &SitAndGoRunner{
+		logger:       logging.GetZeroLogger("SitAndGoRunner", nil),
+		tournamentID: tournamentID,
+		config:       config,
+	}
+}
+
+// Create opens the sit-and-go in the game server.
+func (sr *SitAndGoRunner) Create() error {
+	type payload struct {
+		TournamentID uint64 `json:"tournamentId"`
+		SitAndGoConfig
+	}
+	_, err := sr.post("/sit-and-go", payload{
+		TournamentID:   sr.tournamentID,
+		SitAndGoConfig: sr.config,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Cannot create sit-and-go %d", sr.tournamentID)
+	}
+	return nil
+}
+
+// CreateBots creates a bot for each seat.
+func (sr *SitAndGoRunner) CreateBots() error {
+	for i := 0; i < int(sr.config.Seats); i++ {
+		botName := fmt.Sprintf("sng-%d-bot-%d", sr.tournamentID, i+1)
+		gps := gamescript.GpsLocation{Lat: 0, Long: 0}
+		bot, err := player.NewBotPlayer(player.Config{
+			Name:            botName,
+			DeviceID:        fmt.Sprintf(SIT_AND_GO_DEVICE_START_ID, i),
+			Email:           fmt.Sprintf("%s@bot.net", botName),
+			Password:        "password",
+			Gps:             &gps,
+			IpAddress:       "10.0.0.1",
+			MinActionDelay:  500,
+			MaxActionDelay:  1000,
+			APIServerURL:    util.Env.GetAPIServerURL(),
+			NatsURL:         util.Env.GetNatsURL(),
+			GQLTimeoutSec:   util.Env.GetGQLTimeoutSec(),
+			IsTournamentBot: true,
+		}, os.Stdout)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to create bot %s", botName)
+		}
+		bot.UseLocalIdentity(sitAndGoPlayerIDBase + sr.tournamentID*100 + uint64(i+1))
+		bot.Reset()
+		sr.bots = append(sr.bots, bot)
+	}
+	return nil
+}
+
+// RegisterBots registers the bots. The sit-and-go starts when the last bot
+// registers.
+func (sr *SitAndGoRunner) RegisterBots() error {
+	for _, b := range sr.bots {
+		err := b.RegisterSitAndGo(sr.tournamentID)
+		if err != nil {
+			return errors.Wrapf(err, "%s cannot register for sit-and-go %d", b.GetName(), sr.tournamentID)
+		}
+	}
+	return nil
+}
+
+// Wait waits for the sit-and-go to end and returns the final standings.
+func (sr *SitAndGoRunner) Wait(timeout time.Duration) ([]SitAndGoStanding, error) {
+	deadline := time.Now().Add(timeout)
+	lastHand := uint32(0)
+	for time.Now().Before(deadline) {
+		state, err := sr.state()
+		if err != nil {
+			sr.logger.Error().Err(err).Msgf("Could not get the state of sit-and-go %d", sr.tournamentID)
+		} else {
+			if state.Finished {
+				return state.Standings, nil
+			}
+			if state.HandNum != lastHand {
+				sr.logger.Info().
+					Msgf("Sit-and-go %d hand %d. Big blind: %v. Players out: %d", sr.tournamentID, state.HandNum, state.BigBlind, len(state.Standings))
+				lastHand = state.HandNum
+			}
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return nil, fmt.Errorf("Sit-and-go %d did not end in %s", sr.tournamentID, timeout)
+}
+
+// EndTournament makes the bots leave the table.
+func (sr *SitAndGoRunner) EndTournament() {
+	for _, bot := range sr.bots {
+		bot.EndTournament()
+	}
+}
+
+func (sr *SitAndGoRunner) state() (sitAndGoState, error) {
+	var state sitAndGoState
+	url := fmt.Sprintf("%s/sit-and-go?tournament-id=%d", util.Env.GetSitAndGoServerURL(), sr.tournamentID)
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return state, errors.Wrap(err, "Get failed")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return state, errors.Wrap(err, "Unable to read the response body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return state, fmt.Errorf("Game server returned http %d: %s", resp.StatusCode, string(body))
+	}
+	err = json.Unmarshal(body, &state)
+	return state, err
+}
+
+func (sr *SitAndGoRunner) post(path string, data interface{}) ([]byte, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to marshal payload")
+	}
+	url := fmt.Sprintf("%s%s", util.Env.GetSitAndGoServerURL(), path)
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "Post failed")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read the response body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Game server returned http %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
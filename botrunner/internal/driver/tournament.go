@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -24,6 +25,30 @@ type TournamentRunner struct {
 	botsByName        map[string]*player.BotPlayer
 	tables            []*TournamentTable
 	tournamentChannel string
+
+	// load test mode
+	loadTest *LoadTestConfig
+	metrics  *player.Metrics
+}
+
+// LoadTestConfig is the configuration of a tournament load test. The bots are
+// created with generated names, so a tournament can be played by thousands of
+// bots.
+type LoadTestConfig struct {
+	Bots           uint32 `json:"bots"`
+	MinActionDelay uint32 `json:"minActionDelay"`
+	MaxActionDelay uint32 `json:"maxActionDelay"`
+	// Concurrency is the number of bots signing in and registering at the
+	// same time.
+	Concurrency uint32 `json:"concurrency"`
+}
+
+// LoadTestReport is the result of a tournament load test.
+type LoadTestReport struct {
+	player.MetricsReport
+	Bots        int      `json:"bots"`
+	BotsInError int      `json:"botsInError"`
+	BotErrors   []string `json:"botErrors"`
 }
 
 type TournamentTable struct {
@@ -32,6 +57,11 @@ type TournamentTable struct {
 }
 
 var TOURNAMENT_DEVICE_START_ID = "f0a675ef-0000-4963-%04x-75a7d1735665"
+var LOAD_TEST_DEVICE_START_ID = "f0a675ef-0002-4963-0000-%012x"
+
+// maxReportedBotErrors is the number of bot error messages in the load test
+// report.
+const maxReportedBotErrors = 20
 
 func NewTournamentRunner(tournamentID uint64, clubCode string, botCount int32) (*TournamentRunner, error) {
 	return &TournamentRunner{
@@ -97,53 +127,131 @@ func (tr *TournamentRunner) CreateBots(botCount int32) error {
 	return nil
 }
 
+// CreateLoadTestBots creates the bots of a load test.
+func (tr *TournamentRunner) CreateLoadTestBots(config LoadTestConfig) error {
+	if config.Bots == 0 {
+		return fmt.Errorf("Load test needs at least one bot")
+	}
+	if config.MaxActionDelay < config.MinActionDelay {
+		return fmt.Errorf("Max action delay %d is less than min action delay %d", config.MaxActionDelay, config.MinActionDelay)
+	}
+	if config.Concurrency == 0 {
+		config.Concurrency = 1
+	}
+	tr.loadTest = &config
+	tr.metrics = player.NewMetrics()
+	tr.botCount = config.Bots
+
+	for i := 0; i < int(config.Bots); i++ {
+		botName := fmt.Sprintf("loadbot-%05d", i)
+		gps := gamescript.GpsLocation{Lat: 0, Long: 0}
+		bot, err := player.NewBotPlayer(player.Config{
+			Name:            botName,
+			DeviceID:        fmt.Sprintf(LOAD_TEST_DEVICE_START_ID, i),
+			Email:           fmt.Sprintf("%s@bot.net", botName),
+			Password:        "password",
+			Gps:             &gps,
+			IpAddress:       "10.0.0.1",
+			MinActionDelay:  config.MinActionDelay,
+			MaxActionDelay:  config.MaxActionDelay,
+			APIServerURL:    util.Env.GetAPIServerURL(),
+			NatsURL:         util.Env.GetNatsURL(),
+			GQLTimeoutSec:   util.Env.GetGQLTimeoutSec(),
+			IsTournamentBot: true,
+			Metrics:         tr.metrics,
+		}, os.Stdout)
+		if err != nil {
+			tr.logger.Info().Msgf("Unable to create bot %s", botName)
+			tr.metrics.Error(fmt.Sprintf("Unable to create bot %s: %s", botName, err))
+			continue
+		}
+		tr.bots = append(tr.bots, bot)
+		tr.botsByName[botName] = bot
+	}
+	tr.logger.Info().Msgf("Created %d bots for load test of tournament %d", len(tr.bots), tr.tournamentID)
+	return nil
+}
+
+// forEachBot calls fn for the bots that play the tournament. In a load test
+// the bots are called concurrently, and a bot that fails is recorded and
+// does not stop the others. Otherwise the bots are called one by one and the
+// first error is returned.
+func (tr *TournamentRunner) forEachBot(fn func(b *player.BotPlayer) error) error {
+	bots := tr.bots
+	if len(bots) > int(tr.botCount) {
+		bots = bots[:tr.botCount]
+	}
+	if tr.loadTest == nil {
+		for _, b := range bots {
+			err := fn(b)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ch := make(chan *player.BotPlayer)
+	var wg sync.WaitGroup
+	for i := 0; i < int(tr.loadTest.Concurrency); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range ch {
+				err := fn(b)
+				if err != nil {
+					tr.logger.Error().Msg(err.Error())
+					tr.metrics.Error(err.Error())
+				}
+			}
+		}()
+	}
+	for _, b := range bots {
+		ch <- b
+	}
+	close(ch)
+	wg.Wait()
+	return nil
+}
+
 func (tr *TournamentRunner) BotsSignIn() error {
 	// Register bots to the poker service.
-	for _, b := range tr.bots {
+	return tr.forEachBot(func(b *player.BotPlayer) error {
 		var err error
-		signedIn := false
 		maxAttempts := 5
-		for attempts := 0; attempts < maxAttempts && !signedIn; attempts++ {
+		for attempts := 0; attempts < maxAttempts; attempts++ {
 			if attempts > 0 {
 				tr.logger.Info().Msgf("%s could not sign in (%d/%d)", b.GetName(), attempts, maxAttempts)
 			}
 			// Try logging in first. The bot player might've already signed up from some other game.
 			err = b.Login()
 			if err == nil {
-				signedIn = true
-				break
+				return nil
 			}
 			// This bot has never signed up. Go ahead and sign up.
 			err = b.SignUp()
 			if err == nil {
-				signedIn = true
-				break
+				return nil
 			}
 			time.Sleep(2 * time.Second)
 		}
-		if !signedIn {
-			tr.logger.Error().Msgf("%s cannot sign in", b.GetName())
+		tr.logger.Error().Msgf("%s cannot sign in", b.GetName())
+		if tr.loadTest != nil {
+			return errors.Wrapf(err, "%s cannot sign in", b.GetName())
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (tr *TournamentRunner) RegisterBots() error {
-	var err error
 	// register bots for the tournament
-	for i, b := range tr.bots {
-		if i >= int(tr.botCount) {
-			// reached max number of bots
-			break
-		}
-
-		err = b.RegisterTournament(tr.tournamentID)
+	return tr.forEachBot(func(b *player.BotPlayer) error {
+		err := b.RegisterTournament(tr.tournamentID)
 		if err != nil {
 			return errors.Wrapf(err, "%s cannot register for tournament", b.GetName())
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (br *TournamentRunner) ResetBots() {
@@ -161,18 +269,56 @@ func (br *TournamentRunner) EndTournament() error {
 }
 
 func (tr *TournamentRunner) JoinTournament() error {
-	var err error
 	// register bots for the tournament
-	for i, b := range tr.bots {
-		if i >= int(tr.botCount) {
-			// reached max number of bots
-			break
+	return tr.forEachBot(func(b *player.BotPlayer) error {
+		err := b.JoinTournament(tr.tournamentID)
+		if err != nil {
+			return errors.Wrapf(err, "%s cannot join tournament %d", b.GetName(), tr.tournamentID)
 		}
+		return nil
+	})
+}
 
-		err = b.JoinTournament(tr.tournamentID)
+// SetHandForHand turns the hand-for-hand play of the tournament on or off. The
+// game server hosting each table is called through a bot seated there.
+// playersRemaining is the number of players left in the tournament.
+func (tr *TournamentRunner) SetHandForHand(enabled bool, playersRemaining uint32) error {
+	called := make(map[string]bool)
+	for _, b := range tr.bots {
+		gameCode := b.TournamentGameCode()
+		if gameCode == "" || called[gameCode] {
+			continue
+		}
+		err := b.SetHandForHand(tr.tournamentID, enabled, playersRemaining)
 		if err != nil {
-			return errors.Wrapf(err, "%s cannot join tournament %d", b.GetName(), tr.tournamentID)
+			return errors.Wrapf(err, "%s cannot set hand-for-hand play of tournament %d", b.GetName(), tr.tournamentID)
 		}
+		called[gameCode] = true
+	}
+	if len(called) == 0 {
+		return fmt.Errorf("No bot is seated at a table of tournament %d", tr.tournamentID)
 	}
 	return nil
 }
+
+// Report returns the latency, throughput and errors of the load test.
+func (tr *TournamentRunner) Report() (LoadTestReport, error) {
+	if tr.loadTest == nil {
+		return LoadTestReport{}, fmt.Errorf("Tournament %d is not a load test", tr.tournamentID)
+	}
+	report := LoadTestReport{
+		MetricsReport: tr.metrics.Report(),
+		Bots:          len(tr.bots),
+		BotErrors:     make([]string, 0),
+	}
+	for _, b := range tr.bots {
+		if !b.IsErrorState() {
+			continue
+		}
+		report.BotsInError++
+		if len(report.BotErrors) < maxReportedBotErrors {
+			report.BotErrors = append(report.BotErrors, fmt.Sprintf("%s: %s", b.GetName(), b.GetErrorMsg()))
+		}
+	}
+	return report, nil
+}
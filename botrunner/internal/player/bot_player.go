@@ -12,6 +12,7 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -54,6 +55,8 @@ type Config struct {
 	Players         *gamescript.Players
 	Script          *gamescript.Script
 	IsTournamentBot bool
+	// Metrics collects the statistics of a load test (optional).
+	Metrics *Metrics
 }
 
 type GameMessageChannelItem struct {
@@ -111,6 +114,13 @@ type BotPlayer struct {
 	clientLastMsgType string
 	maxRetry          int
 
+	// Send time of the last action for the load test metrics. The time is
+	// stamped before the action is published and is read when the ack for the
+	// same message ID arrives.
+	actionSentLock  sync.Mutex
+	actionSentMsgID string
+	actionSentTime  time.Time
+
 	// bots in game
 	bots []*BotPlayer
 
@@ -394,6 +404,7 @@ func (bp *BotPlayer) handleGameMsg(msg *natsgo.Msg) {
 	if bp.printGameMsg {
 		bp.logger.Info().Msgf("Received game message %s", string(msg.Data))
 	}
+	bp.config.Metrics.gameMessage()
 
 	var message game.GameMessage
 	var nonProtoMsg gamescript.NonProtoMessage
@@ -470,6 +481,7 @@ func (bp *BotPlayer) unmarshalAndQueueHandMsg(data []byte) {
 	if util.Env.ShouldPrintHandMsg() {
 		fmt.Printf("Received hand msg (proto): %s\n", message.String())
 	}
+	bp.config.Metrics.handMessage()
 
 	bp.chHand <- &message
 }
@@ -633,6 +645,7 @@ func (bp *BotPlayer) processMsgItem(message *game.HandMessage, msgItem *game.Han
 		bp.game.table.playersActed = make(map[uint32]*game.PlayerActRound)
 		bp.game.handNum = message.HandNum
 		bp.game.handStatus = message.GetHandStatus()
+		bp.config.Metrics.handStarted(bp.gameCode, message.HandNum)
 		newHand := msgItem.GetNewHand()
 		bp.reloadBotFromGameInfo(newHand)
 		bp.game.table.buttonPos = newHand.GetButtonPos()
@@ -872,6 +885,7 @@ func (bp *BotPlayer) processMsgItem(message *game.HandMessage, msgItem *game.Han
 		// BotEvent__RECEIVE_ACK results in a state error because it is processed before BotEvent__SEND_MY_ACTION.
 		// "Ignoring unexpected MSG_ACK msg - PLAYER_ACTED:114 BotState: MY_TURN"
 		// Adding a sleep here to yield this goroutine so that the other event gets processed first.
+		bp.recordActionAck(msgID)
 		time.Sleep(5 * time.Millisecond)
 		err := bp.event(BotEvent__RECEIVE_ACK)
 		if err != nil {
@@ -882,6 +896,7 @@ func (bp *BotPlayer) processMsgItem(message *game.HandMessage, msgItem *game.Han
 		/* MessageType: RESULT */
 		bp.game.handStatus = message.GetHandStatus()
 		bp.game.handResult2 = msgItem.GetHandResultClient()
+		bp.config.Metrics.handEnded(bp.gameCode, message.HandNum)
 		if bp.IsObserver() {
 			bp.PrintHandResult()
 			bp.verifyResult2()
@@ -2874,16 +2889,39 @@ func (bp *BotPlayer) act(seatAction *game.NextSeatAction, handStatus game.HandSt
 		if actionDelayOverride > 0 {
 			bp.logger.Info().Msgf("Seat %d (%s) sleeping for %d milliseconds", bp.seatNo, playerName, actionDelayOverride)
 		}
-		if bp.tournament {
-			time.Sleep(1 * time.Second)
-		} else {
-			time.Sleep(bp.getActionDelay(actionDelayOverride))
-		}
+		time.Sleep(bp.getActionDelay(actionDelayOverride))
 
+		bp.stampActionSent(actionMsg.GetMessageId())
 		go bp.publishAndWaitForAck(bp.meToHandSubjectName, &actionMsg)
 	}
 }
 
+// stampActionSent remembers when the action with the message ID is sent.
+func (bp *BotPlayer) stampActionSent(msgID string) {
+	if bp.config.Metrics == nil {
+		return
+	}
+	bp.actionSentLock.Lock()
+	defer bp.actionSentLock.Unlock()
+	bp.actionSentMsgID = msgID
+	bp.actionSentTime = time.Now()
+}
+
+// recordActionAck records the ack latency of the last action sent. Acks of
+// other messages are not recorded.
+func (bp *BotPlayer) recordActionAck(msgID string) {
+	if bp.config.Metrics == nil {
+		return
+	}
+	bp.actionSentLock.Lock()
+	defer bp.actionSentLock.Unlock()
+	if msgID == "" || msgID != bp.actionSentMsgID {
+		return
+	}
+	bp.config.Metrics.actionAcked(time.Since(bp.actionSentTime))
+	bp.actionSentMsgID = ""
+}
+
 func (bp *BotPlayer) getActionDelay(override uint32) time.Duration {
 	var actionTimeMillis uint32
 	if override > 0 {
@@ -2936,6 +2974,7 @@ func (bp *BotPlayer) publishAndWaitForAck(subj string, msg *game.HandMessage) {
 			bp.logger.Error().Msg(errMsg)
 			bp.errorStateMsg = errMsg
 			bp.sm.SetState(BotState__ERROR)
+			bp.config.Metrics.Error(errMsg)
 			return
 		}
 		if attempts > 1 {
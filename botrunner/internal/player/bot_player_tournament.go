@@ -1,14 +1,20 @@
 package player
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	natsgo "github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/proto"
 	"voyager.com/botrunner/internal/game"
 	"voyager.com/botrunner/internal/networkcheck"
+	"voyager.com/botrunner/internal/util"
 	"voyager.com/gamescript"
 )
 
@@ -72,6 +78,7 @@ func (bp *BotPlayer) handleTournamentMsg(msg *natsgo.Msg) {
 	if bp.printTournamentMsg {
 		bp.logger.Info().Msgf("Received game message %s", string(msg.Data))
 	}
+	bp.config.Metrics.tournamentMessage()
 	var jsonMessage *gamescript.NonProtoTournamentMsg
 	err := json.Unmarshal(msg.Data, &jsonMessage)
 	if err == nil {
@@ -104,6 +111,8 @@ func (bp *BotPlayer) handleTournamentPrivateMsg(msg *natsgo.Msg) {
 			bp.logger.Info().Msgf("PLAYER_MOVED_TABLE new table: %d", playerMoved.NewTableNo)
 			bp.logger.Info().Msgf("PLAYER_MOVED_TABLE new seat: %d", playerMoved.NewTableSeatNo)
 			//bp.logger.Info().Msgf("PLAYER_MOVED_TABLE game info: %s...", playerMoved.GameInfo[:100])
+		case "TOURNAMENT_BUY_IN":
+			go bp.answerTournamentBuyIn(message.HandNum, msgItem.GetTournamentBuyIn())
 		case "QUERY_CURRENT_HAND":
 			currentHand := msgItem.GetCurrentHandState()
 			bp.logger.Info().Msgf("QUERY_CURRENT_HAND hand num: %d", currentHand.HandNum)
@@ -113,6 +122,43 @@ func (bp *BotPlayer) handleTournamentPrivateMsg(msg *natsgo.Msg) {
 	bp.logger.Info().Msgf("Received private tournament message %v", msgTypes)
 }
 
+// tournamentBuyInChance is the probability that a bot accepts a rebuy, add-on
+// or re-entry prompt.
+const tournamentBuyInChance = 0.5
+
+// answerTournamentBuyIn accepts or declines the buy-in prompt at random.
+func (bp *BotPlayer) answerTournamentBuyIn(handNum uint32, prompt *game.TournamentBuyIn) {
+	if prompt == nil {
+		return
+	}
+	answer := game.TournamentBuyIn{
+		TournamentId: prompt.TournamentId,
+		PlayerId:     bp.PlayerID,
+		BuyInType:    prompt.BuyInType,
+		Chips:        prompt.Chips,
+		PromptId:     prompt.PromptId,
+		Accepted:     util.GetRandomFloat32(0, 1) < tournamentBuyInChance,
+	}
+	bp.logger.Info().Msgf("Tournament %d %s of %v chips. Accepted: %v", prompt.TournamentId, prompt.BuyInType, prompt.Chips, answer.Accepted)
+
+	// answer after a while like a human player
+	time.Sleep(bp.getActionDelay(0))
+	msg := game.HandMessage{
+		GameCode:  bp.gameCode,
+		HandNum:   handNum,
+		PlayerId:  bp.PlayerID,
+		SeatNo:    bp.seatNo,
+		MessageId: fmt.Sprintf("BUYIN:%s:%d", prompt.PromptId, bp.PlayerID),
+		Messages: []*game.HandMessageItem{
+			{
+				MessageType: "TOURNAMENT_BUY_IN",
+				Content:     &game.HandMessageItem_TournamentBuyIn{TournamentBuyIn: &answer},
+			},
+		},
+	}
+	bp.publishHandMsg(bp.meToHandSubjectName, &msg)
+}
+
 func (bp *BotPlayer) processTournamentMessage(message *TournamentMessageChannelItem) {
 	if bp.IsErrorState() {
 		bp.logger.Info().Msgf("Bot is in error state. Ignoring hand message.")
@@ -163,7 +209,11 @@ func (bp *BotPlayer) tournamentAboutToStart(tournamentID uint64) {
 		bp.logger.Error().Err(err).Msgf("Could not join tournament %d", bp.tournamentID)
 		return
 	}
+	bp.joinTournamentTable()
+}
 
+// joinTournamentTable subscribes to the channels of the tournament table.
+func (bp *BotPlayer) joinTournamentTable() {
 	bp.game = &gameView{
 		table: &tableView{
 			playersBySeat: make(map[uint32]*player),
@@ -178,7 +228,7 @@ func (bp *BotPlayer) tournamentAboutToStart(tournamentID uint64) {
 	bp.UpdateLogger()
 
 	playerChannelName := fmt.Sprintf("player.%d", bp.PlayerID)
-	err = bp.Subscribe(bp.tournamentTableInfo.GameToPlayerChannel,
+	err := bp.Subscribe(bp.tournamentTableInfo.GameToPlayerChannel,
 		bp.tournamentTableInfo.HandToAllChannel, bp.tournamentTableInfo.HandToPlayerChannel,
 		bp.tournamentTableInfo.HandToPlayerTextChannel, playerChannelName)
 	if err != nil {
@@ -279,3 +329,129 @@ func (bp *BotPlayer) refreshTournamentTableInfo() error {
 	bp.needsTournamentTableRefresh = false
 	return nil
 }
+
+// SetHandForHand turns the hand-for-hand play of the tournament on or off in
+// the game server hosting the tournament table of the bot. playersRemaining is
+// required when the tournament has no payouts.
+func (bp *BotPlayer) SetHandForHand(tournamentID uint64, enabled bool, playersRemaining uint32) error {
+	type payload struct {
+		TournamentID     uint64 `json:"tournamentId"`
+		Enabled          bool   `json:"enabled"`
+		PlayersRemaining uint32 `json:"playersRemaining"`
+	}
+	data := payload{
+		TournamentID:     tournamentID,
+		Enabled:          enabled,
+		PlayersRemaining: playersRemaining,
+	}
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "Unable to marshal payload")
+	}
+	url := fmt.Sprintf("%s/hand-for-hand", util.Env.GetGameServerURL(bp.tournamentTableInfo.GameCode))
+
+	bp.logger.Info().Msgf("Setting hand-for-hand play. URL: %s, Payload: %s", url, jsonBytes)
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonBytes))
+	if err != nil {
+		return errors.Wrap(err, "Post failed")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Game server returned http %d: %s", resp.StatusCode, string(body))
+	}
+	bp.logger.Info().Msgf("Hand-for-hand play of tournament %d: %s", tournamentID, string(body))
+	return nil
+}
+
+// TournamentGameCode returns the game code of the tournament table of the bot.
+func (bp *BotPlayer) TournamentGameCode() string {
+	return bp.tournamentTableInfo.GameCode
+}
+
+// UseLocalIdentity gives the bot a player ID and an encryption key without
+// signing up in the api server. The sit-and-go tournaments hosted by the game
+// server don't need the api server.
+func (bp *BotPlayer) UseLocalIdentity(playerID uint64) {
+	bp.PlayerID = playerID
+	bp.PlayerUUID = bp.config.DeviceID
+	bp.EncryptionKey = uuid.New().String()
+	bp.UpdateLogger()
+}
+
+// RegisterSitAndGo registers the bot in the sit-and-go hosted by the game
+// server and joins its table.
+func (bp *BotPlayer) RegisterSitAndGo(tournamentID uint64) error {
+	type payload struct {
+		TournamentID  uint64 `json:"tournamentId"`
+		PlayerID      uint64 `json:"playerId"`
+		PlayerUUID    string `json:"playerUuid"`
+		Name          string `json:"name"`
+		EncryptionKey string `json:"encryptionKey"`
+	}
+	type tableInfo struct {
+		GameID                  uint64          `json:"gameId"`
+		GameCode                string          `json:"gameCode"`
+		TableNo                 uint32          `json:"tableNo"`
+		SeatNo                  uint32          `json:"seatNo"`
+		Players                 []game.SeatInfo `json:"players"`
+		GameToPlayerChannel     string          `json:"gameToPlayerChannel"`
+		HandToAllChannel        string          `json:"handToAllChannel"`
+		HandToPlayerChannel     string          `json:"handToPlayerChannel"`
+		HandToPlayerTextChannel string          `json:"handToPlayerTextChannel"`
+		PlayerToHandChannel     string          `json:"playerToHandChannel"`
+		ClientAliveChannel      string          `json:"clientAliveChannel"`
+	}
+	data := payload{
+		TournamentID:  tournamentID,
+		PlayerID:      bp.PlayerID,
+		PlayerUUID:    bp.PlayerUUID,
+		Name:          bp.config.Name,
+		EncryptionKey: bp.EncryptionKey,
+	}
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "Unable to marshal payload")
+	}
+	url := fmt.Sprintf("%s/sit-and-go/register", util.Env.GetSitAndGoServerURL())
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonBytes))
+	if err != nil {
+		return errors.Wrap(err, "Post failed")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "Unable to read the response body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Game server returned http %d: %s", resp.StatusCode, string(body))
+	}
+	var info tableInfo
+	err = json.Unmarshal(body, &info)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to parse the sit-and-go table info: %s", string(body))
+	}
+
+	bp.tournamentID = tournamentID
+	bp.tournamentTableNo = info.TableNo
+	bp.tournamentSeatNo = info.SeatNo
+	bp.seatNo = info.SeatNo
+	bp.tournamentTableInfo = game.TournamentTableInfo{
+		GameID:                  info.GameID,
+		GameCode:                info.GameCode,
+		Players:                 info.Players,
+		GameToPlayerChannel:     info.GameToPlayerChannel,
+		HandToAllChannel:        info.HandToAllChannel,
+		PlayerToHandChannel:     info.PlayerToHandChannel,
+		HandToPlayerChannel:     info.HandToPlayerChannel,
+		HandToPlayerTextChannel: info.HandToPlayerTextChannel,
+		ClientAliveChannel:      info.ClientAliveChannel,
+		Playing:                 true,
+		TableNo:                 int32(info.TableNo),
+	}
+	bp.logger.Info().Msgf("Registered in sit-and-go %d at seat %d", tournamentID, info.SeatNo)
+	bp.joinTournamentTable()
+	return nil
+}
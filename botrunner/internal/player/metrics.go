@@ -0,0 +1,188 @@
+package player
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxErrorSamples is the number of error messages kept in the report.
+const maxErrorSamples = 20
+
+// Metrics collects the load statistics of the bots of a load test. The bots
+// share one Metrics. A nil Metrics collects nothing.
+type Metrics struct {
+	lock    sync.Mutex
+	started time.Time
+
+	handMessages       uint64
+	gameMessages       uint64
+	tournamentMessages uint64
+	errors             uint64
+
+	// start of the hands being played (game code:hand number)
+	handStarts      map[string]time.Time
+	handLatencies   []time.Duration
+	actionLatencies []time.Duration
+	errorSamples    []string
+}
+
+// LatencySummary is the distribution of the latencies in milliseconds.
+type LatencySummary struct {
+	Count int     `json:"count"`
+	Avg   float64 `json:"avgMillis"`
+	P50   float64 `json:"p50Millis"`
+	P95   float64 `json:"p95Millis"`
+	P99   float64 `json:"p99Millis"`
+	Max   float64 `json:"maxMillis"`
+}
+
+// MetricsReport is the summary of a load test.
+type MetricsReport struct {
+	DurationSecs float64 `json:"durationSecs"`
+	// HandLatency is the time from the new hand to the result of a hand.
+	HandLatency LatencySummary `json:"handLatency"`
+	// ActionLatency is the time from an action of a bot to the acknowledgement
+	// of the game server.
+	ActionLatency      LatencySummary `json:"actionLatency"`
+	HandMessages       uint64         `json:"handMessages"`
+	GameMessages       uint64         `json:"gameMessages"`
+	TournamentMessages uint64         `json:"tournamentMessages"`
+	MessagesPerSec     float64        `json:"messagesPerSec"`
+	Errors             uint64         `json:"errors"`
+	ErrorSamples       []string       `json:"errorSamples"`
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		started:    time.Now(),
+		handStarts: make(map[string]time.Time),
+	}
+}
+
+func (m *Metrics) handMessage() {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.handMessages, 1)
+}
+
+func (m *Metrics) gameMessage() {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.gameMessages, 1)
+}
+
+func (m *Metrics) tournamentMessage() {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.tournamentMessages, 1)
+}
+
+// handStarted records the start of a hand. The bots at the table all see the
+// hand, the first one starts the clock.
+func (m *Metrics) handStarted(gameCode string, handNum uint32) {
+	if m == nil {
+		return
+	}
+	key := fmt.Sprintf("%s:%d", gameCode, handNum)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.handStarts[key]; !ok {
+		m.handStarts[key] = time.Now()
+	}
+}
+
+// handEnded records the latency of the hand when the first bot at the table
+// receives the result.
+func (m *Metrics) handEnded(gameCode string, handNum uint32) {
+	if m == nil {
+		return
+	}
+	key := fmt.Sprintf("%s:%d", gameCode, handNum)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	started, ok := m.handStarts[key]
+	if !ok {
+		return
+	}
+	delete(m.handStarts, key)
+	m.handLatencies = append(m.handLatencies, time.Since(started))
+}
+
+func (m *Metrics) actionAcked(latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.actionLatencies = append(m.actionLatencies, latency)
+}
+
+// Error records an error of a bot.
+func (m *Metrics) Error(err string) {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.errors, 1)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.errorSamples) < maxErrorSamples {
+		m.errorSamples = append(m.errorSamples, err)
+	}
+}
+
+// Report returns the statistics collected so far.
+func (m *Metrics) Report() MetricsReport {
+	if m == nil {
+		return MetricsReport{}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	duration := time.Since(m.started)
+	report := MetricsReport{
+		DurationSecs:       duration.Seconds(),
+		HandLatency:        summarize(m.handLatencies),
+		ActionLatency:      summarize(m.actionLatencies),
+		HandMessages:       atomic.LoadUint64(&m.handMessages),
+		GameMessages:       atomic.LoadUint64(&m.gameMessages),
+		TournamentMessages: atomic.LoadUint64(&m.tournamentMessages),
+		Errors:             atomic.LoadUint64(&m.errors),
+		ErrorSamples:       append([]string{}, m.errorSamples...),
+	}
+	if duration > 0 {
+		total := report.HandMessages + report.GameMessages + report.TournamentMessages
+		report.MessagesPerSec = float64(total) / duration.Seconds()
+	}
+	return report
+}
+
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	millis := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	percentile := func(p float64) float64 {
+		return millis(sorted[int(p*float64(len(sorted)-1))])
+	}
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	return LatencySummary{
+		Count: len(sorted),
+		Avg:   millis(total) / float64(len(sorted)),
+		P50:   percentile(0.50),
+		P95:   percentile(0.95),
+		P99:   percentile(0.99),
+		Max:   millis(sorted[len(sorted)-1]),
+	}
+}
@@ -0,0 +1,63 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, LatencySummary{}, summarize(nil))
+
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	summary := summarize(latencies)
+	assert.Equal(t, 100, summary.Count)
+	assert.Equal(t, 50.5, summary.Avg)
+	assert.Equal(t, float64(50), summary.P50)
+	assert.Equal(t, float64(95), summary.P95)
+	assert.Equal(t, float64(99), summary.P99)
+	assert.Equal(t, float64(100), summary.Max)
+	assert.Equal(t, 100*time.Millisecond, latencies[0], "the latencies are not sorted in place")
+}
+
+func TestMetricsReport(t *testing.T) {
+	m := NewMetrics()
+	m.handStarted("game-1", 1)
+	m.handStarted("game-1", 1)
+	m.handEnded("game-1", 1)
+	m.handEnded("game-1", 1)
+	m.handEnded("game-1", 2)
+	m.actionAcked(10 * time.Millisecond)
+	m.handMessage()
+	m.gameMessage()
+	m.tournamentMessage()
+	m.Error("error")
+
+	report := m.Report()
+	assert.Equal(t, 1, report.HandLatency.Count)
+	assert.Equal(t, 1, report.ActionLatency.Count)
+	assert.Equal(t, float64(10), report.ActionLatency.Max)
+	assert.Equal(t, uint64(1), report.HandMessages)
+	assert.Equal(t, uint64(1), report.GameMessages)
+	assert.Equal(t, uint64(1), report.TournamentMessages)
+	assert.Equal(t, uint64(1), report.Errors)
+	assert.Equal(t, []string{"error"}, report.ErrorSamples)
+}
+
+func TestMetricsNil(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.handMessage()
+		m.gameMessage()
+		m.tournamentMessage()
+		m.handStarted("game-1", 1)
+		m.handEnded("game-1", 1)
+		m.actionAcked(time.Millisecond)
+		m.Error("error")
+	})
+	assert.Equal(t, MetricsReport{}, m.Report())
+}
@@ -40,6 +40,7 @@ type environment struct {
 	EnableEncryption     string
 	LogLevel             string
 	GqlTimeoutSec        string
+	SitAndGoServerURL    string
 }
 
 // Env is a helper object for accessing environment variables.
@@ -64,6 +65,7 @@ var Env = &environment{
 	EnableEncryption:     "ENABLE_ENCRYPTION",
 	LogLevel:             "LOG_LEVEL",
 	GqlTimeoutSec:        "GQL_TIMEOUT_SEC",
+	SitAndGoServerURL:    "SIT_AND_GO_SERVER_URL",
 }
 
 func (e *environment) GetNatsURL() string {
@@ -240,6 +242,16 @@ func (e *environment) GetGameServerURL(gameCode string) string {
 	return p.Server.URL
 }
 
+// GetSitAndGoServerURL returns the URL of the game server that hosts the
+// sit-and-go tournaments.
+func (e *environment) GetSitAndGoServerURL() string {
+	url := os.Getenv(e.SitAndGoServerURL)
+	if url == "" {
+		return "http://localhost:8080"
+	}
+	return url
+}
+
 func (e *environment) GetPrintHandMsg() string {
 	v := os.Getenv(e.PrintHandMsg)
 	if v == "" {
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"voyager.com/server/poker"
+)
+
+// The street analysis records, on the flop, the turn and the river, the nuts
+// of each board and the made hand, nuts and draws of the players still in the
+// hand. It goes to the hand log for the hand history annotations, and sets the
+// nuts player stats that the bad beat detection uses at the showdown.
+
+func isOmaha(gameType GameType) bool {
+	switch gameType {
+	case GameType_PLO, GameType_PLO_HILO,
+		GameType_FIVE_CARD_PLO, GameType_FIVE_CARD_PLO_HILO,
+		GameType_SIX_CARD_PLO, GameType_SIX_CARD_PLO_HILO:
+		return true
+	}
+	return false
+}
+
+func isHiLo(gameType GameType) bool {
+	return gameType == GameType_PLO_HILO ||
+		gameType == GameType_FIVE_CARD_PLO_HILO ||
+		gameType == GameType_SIX_CARD_PLO_HILO
+}
+
+// analyzeStreet analyzes the first numBoardCards cards of the boards.
+func (h *HandState) analyzeStreet(street HandStatus, numBoardCards int) {
+	if h.GameType != GameType_HOLDEM && !isOmaha(h.GameType) {
+		return
+	}
+	// the nuts and the draws are for a single standard deck
+	if !h.deckDefinition().IsStandard() {
+		return
+	}
+	omaha := isOmaha(h.GameType)
+	hiLo := isHiLo(h.GameType)
+
+	for boardIdx, board := range h.Boards {
+		if len(board.Cards) < numBoardCards {
+			continue
+		}
+		boardCards := poker.FromUint32ByteCards(board.Cards[:numBoardCards])
+		pokerBoardCards := poker.FromByteCards(boardCards)
+		nuts := poker.FindNuts(pokerBoardCards, omaha, hiLo)
+		analysis := &StreetAnalysis{
+			Street:      street,
+			BoardNo:     board.BoardNo,
+			NutHiRank:   uint32(nuts.HiRank),
+			NutHi:       newHandDescription(poker.DescribeHand(nuts.HiRank, nuts.HiCards)),
+			LowPossible: nuts.LowFound,
+		}
+		if nuts.LowFound {
+			analysis.NutLow = newHandDescription(poker.DescribeLow(nuts.LowCards))
+		}
+
+		for seatNo, playerID := range h.ActiveSeats {
+			if playerID == 0 {
+				continue
+			}
+			playerCards := poker.FromByteCards(h.PlayersCards[uint32(seatNo)])
+			var hiRank, lowRank int32
+			var hiCards []poker.Card
+			lowFound := false
+			if omaha {
+				result := poker.EvaluateOmahaHand(playerCards, pokerBoardCards)
+				hiRank, hiCards = result.HiRank, result.HiCards[:]
+				lowFound, lowRank = result.LowFound, result.LowRank
+			} else {
+				hiRank, hiCards = poker.Evaluate(append(playerCards, pokerBoardCards...))
+			}
+			draws := poker.FindDraws(playerCards, pokerBoardCards, omaha)
+			player := &PlayerStreetAnalysis{
+				SeatNo:       uint32(seatNo),
+				HiRank:       uint32(hiRank),
+				MadeHand:     newHandDescription(poker.DescribeHand(hiRank, hiCards)),
+				Nuts:         hiRank == nuts.HiRank,
+				LowNuts:      hiLo && lowFound && lowRank == nuts.LowRank,
+				FlushDraw:    draws.FlushDraw,
+				NutFlushDraw: draws.NutFlushDraw,
+				StraightOuts: uint32(draws.StraightOuts),
+				StraightDraw: draws.StraightDraw,
+			}
+			analysis.Players = append(analysis.Players, player)
+
+			// the stats are for the first board
+			stats := h.PlayerStats[playerID]
+			if boardIdx != 0 || !player.Nuts || stats == nil {
+				continue
+			}
+			switch street {
+			case HandStatus_FLOP:
+				stats.NutsOnFlop = true
+			case HandStatus_TURN:
+				stats.NutsOnTurn = true
+			case HandStatus_RIVER:
+				stats.NutsOnRiver = true
+			}
+		}
+		h.StreetAnalysis = append(h.StreetAnalysis, analysis)
+	}
+}
+
+// isBadBeat returns whether losing at the showdown with the hand is a bad
+// beat: four of a kind or better, or the nuts on the turn.
+func isBadBeat(hiRank int32, stats *PlayerStats) bool {
+	if hiRank > 0 && hiRank <= poker.MaxFourOfAKind {
+		return true
+	}
+	return stats != nil && stats.NutsOnTurn
+}
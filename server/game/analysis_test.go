@@ -0,0 +1,115 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/poker"
+)
+
+func seatWithCards(h *HandState, cards string) uint32 {
+	for seatNo, playerCards := range h.PlayersCards {
+		if poker.CardsToString(playerCards) == poker.CardsToString(poker.CardsToByteCards(parseTestCards(cards))) {
+			return seatNo
+		}
+	}
+	return 0
+}
+
+func parseTestCards(s string) []poker.Card {
+	cards, err := poker.ParseCards(s)
+	if err != nil {
+		panic(err)
+	}
+	return cards
+}
+
+func TestStreetAnalysisAndBadBeat(t *testing.T) {
+	setup := &TestHandSetup{
+		Flop:  []string{"Ks", "7d", "2c"},
+		Turn:  "7h",
+		River: "Kd",
+		PlayerCards: []*GameSetupSeatCards{
+			{Cards: []string{"9h", "3s"}},
+			{Cards: []string{"7s", "7c"}},
+			{Cards: []string{"Kc", "Kh"}},
+		},
+	}
+	h, _, err := DealScriptedHand(newEngineTestConfig(), newEngineTestSeats(), setup)
+	require.NoError(t, err)
+	sevens := seatWithCards(h, "7s7c")
+	kings := seatWithCards(h, "KcKh")
+	require.NotZero(t, sevens)
+	require.NotZero(t, kings)
+
+	// everyone checks down after the first player folds
+	for h.CurrentState != HandStatus_SHOW_DOWN && h.CurrentState != HandStatus_HAND_CLOSED && h.CurrentState != HandStatus_RESULT {
+		seatNo := h.NextSeatAction.SeatNo
+		action := &HandAction{SeatNo: seatNo, Action: ACTION_CHECK}
+		if h.CurrentState == HandStatus_PREFLOP {
+			if seatNo != sevens && seatNo != kings {
+				action.Action = ACTION_FOLD
+			} else {
+				action.Action = ACTION_CALL
+				action.Amount = 200
+			}
+		}
+		var msgItems []*HandMessageItem
+		h, msgItems, err = Apply(h, action)
+		require.NoError(t, err)
+		if handEnded(msgItems) {
+			break
+		}
+	}
+
+	// flop, turn and river of the single board
+	require.Len(t, h.StreetAnalysis, 3)
+	turn := h.StreetAnalysis[1]
+	assert.Equal(t, HandStatus_TURN, turn.Street)
+	assert.Equal(t, "Four of a Kind, Sevens, King kicker", turn.NutHi.Text)
+	assert.False(t, turn.LowPossible)
+	for _, player := range turn.Players {
+		assert.Equal(t, player.SeatNo == sevens, player.Nuts, "seat %d", player.SeatNo)
+	}
+	river := h.StreetAnalysis[2]
+	assert.Equal(t, "Four of a Kind, Kings, Seven kicker", river.NutHi.Text)
+
+	sevensStats := h.PlayerStats[h.ActiveSeats[sevens]]
+	kingsStats := h.PlayerStats[h.ActiveSeats[kings]]
+	assert.True(t, sevensStats.NutsOnTurn)
+	assert.False(t, sevensStats.NutsOnRiver)
+	assert.True(t, kingsStats.NutsOnRiver)
+	assert.True(t, sevensStats.Badbeat)
+	assert.False(t, kingsStats.Badbeat)
+
+	log := h.getLog()
+	assert.Len(t, log.StreetAnalysis, 3)
+}
+
+func TestStreetAnalysisDraws(t *testing.T) {
+	h := &HandState{
+		GameType:    GameType_HOLDEM,
+		ActiveSeats: []uint64{0, 101, 102},
+		PlayersCards: map[uint32][]byte{
+			1: poker.CardsToByteCards(parseTestCards("9h8h")),
+			2: poker.CardsToByteCards(parseTestCards("AsKd")),
+		},
+		Boards: []*Board{{
+			BoardNo: 1,
+			Cards:   poker.ByteCardsToUint32Cards(poker.CardsToByteCards(parseTestCards("7h6c2hKs3d"))),
+		}},
+		PlayerStats: map[uint64]*PlayerStats{101: {}, 102: {}},
+	}
+	h.analyzeStreet(HandStatus_FLOP, 3)
+	require.Len(t, h.StreetAnalysis, 1)
+	flop := h.StreetAnalysis[0]
+	require.Len(t, flop.Players, 2)
+	drawing := flop.Players[0]
+	assert.Equal(t, uint32(1), drawing.SeatNo)
+	assert.True(t, drawing.FlushDraw)
+	assert.Equal(t, poker.OpenEndedDraw, drawing.StraightDraw)
+	assert.Equal(t, uint32(8), drawing.StraightOuts)
+	assert.Equal(t, "HIGH_CARD", drawing.MadeHand.Type)
+	assert.False(t, flop.Players[1].FlushDraw)
+}
@@ -25,12 +25,21 @@ type endGameResp struct {
 }
 
 func (g *Game) saveHandResult2ToAPIServer(result2 *HandResultServer) (*SaveHandResult, error) {
+	url := fmt.Sprintf("%s/internal/save-hand/gameId/%d/handNum/%d", g.apiServerURL, result2.GameId, result2.HandNum)
+	return g.postHandResultToAPIServer(url, result2)
+}
+
+// tournamentSaveHandURL returns the url the hand results of this tournament table are saved to.
+func (g *Game) tournamentSaveHandURL(tournamentURL string) string {
+	return fmt.Sprintf("%s/internal/save-hand/tournamentId/%d/tableNo/%d", tournamentURL, g.tournamentID, g.tableNo)
+}
+
+func (g *Game) postHandResultToAPIServer(url string, result2 *HandResultServer) (*SaveHandResult, error) {
 	// call the API server to save the hand result
 	var m protojson.MarshalOptions
 	m.EmitUnpopulated = true
 	data, _ := m.Marshal(result2)
 	g.logger.Debug().Msgf("Result to API server: %s", string(data))
-	url := fmt.Sprintf("%s/internal/save-hand/gameId/%d/handNum/%d", g.apiServerURL, result2.GameId, result2.HandNum)
 	retries := 0
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
 	for err != nil && retries < int(g.maxRetries) {
@@ -52,24 +61,28 @@ func (g *Game) saveHandResult2ToAPIServer(result2 *HandResultServer) (*SaveHandR
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Received HTTP status %d from %s. Response body: %s", resp.StatusCode, url, string(bodyBytes))
+		err = fmt.Errorf("Received HTTP status %d from %s. Response body: %s", resp.StatusCode, url, string(bodyBytes))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, resultRejectedError{err}
+		}
+		return nil, err
 	}
 
 	var saveResult SaveHandResult
 	err = json.Unmarshal(bodyBytes, &saveResult)
 	if err != nil {
-		return nil, errors.Wrap(err, "Unable to parse response body json into struct")
+		return nil, resultRejectedError{errors.Wrap(err, "Unable to parse response body json into struct")}
 	}
 	return &saveResult, nil
 }
 
-func (g *Game) saveTournamentHandResult2ToAPIServer(tournamentURL string, result2 *HandResultServer) (*SaveHandResult, error) {
-	// call the API server to save the hand result
-	var m protojson.MarshalOptions
-	m.EmitUnpopulated = true
-	data, _ := m.Marshal(result2)
-	g.logger.Debug().Msgf("Result to API server: %s", string(data))
-	url := fmt.Sprintf("%s/internal/save-hand/tournamentId/%d/tableNo/%d", tournamentURL, g.tournamentID, g.tableNo)
+func (g *Game) saveTournamentBuyInsToAPIServer(buyIns []TournamentBuyInRecord) error {
+	data, err := json.Marshal(buyIns)
+	if err != nil {
+		return errors.Wrap(err, "Unable to marshal tournament buy-ins")
+	}
+	g.logger.Debug().Msgf("Tournament buy-ins to API server: %s", string(data))
+	url := fmt.Sprintf("%s/internal/tournament-buy-ins/tournamentId/%d/tableNo/%d", g.tournamentURL, g.tournamentID, g.tableNo)
 	retries := 0
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
 	for err != nil && retries < int(g.maxRetries) {
@@ -79,27 +92,16 @@ func (g *Game) saveTournamentHandResult2ToAPIServer(tournamentURL string, result
 		time.Sleep(time.Duration(g.retryDelayMillis) * time.Millisecond)
 		resp, err = http.Post(url, "application/json", bytes.NewBuffer(data))
 	}
-	// if the api server returns nil, do nothing
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error from post %s", url)
+		return errors.Wrapf(err, "Error from post %s", url)
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "Unable to read response body from %s", url)
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Received HTTP status %d from %s. Response body: %s", resp.StatusCode, url, string(bodyBytes))
+		bodyBytes, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Received HTTP status %d from %s. Response body: %s", resp.StatusCode, url, string(bodyBytes))
 	}
-
-	var saveResult SaveHandResult
-	err = json.Unmarshal(bodyBytes, &saveResult)
-	if err != nil {
-		return nil, errors.Wrap(err, "Unable to parse response body json into struct")
-	}
-	return &saveResult, nil
+	return nil
 }
 
 func (g *Game) getNewHandInfo() (*NewHandInfo, error) {
@@ -0,0 +1,220 @@
+package game
+
+import (
+	"sort"
+)
+
+// Table balancing keeps the tables of a tournament as full as possible and
+// their sizes within one player of each other. The plan is made from the
+// seating of all the tables:
+//
+// 1. Tables are broken, smallest first, while the other tables have the empty
+//    seats for their players. The players of the broken tables are seated at
+//    the tables that stay, so this also consolidates the final table.
+// 2. Players are moved from the biggest table to the smallest table until no
+//    table has more than one player more than another.
+//
+// The player moved from a table is the next player to post the big blind
+// there, so no one is moved just after posting it. The player takes the empty
+// seat of the new table that posts the big blind the soonest, so the move
+// doesn't buy free hands (a walk around the blinds). A table applies the
+// moves of its players when its hand ends.
+
+// BalanceSeat is a player seated at a table.
+type BalanceSeat struct {
+	SeatNo   uint32
+	PlayerID uint64
+}
+
+// BalanceTable is the seating of a table.
+type BalanceTable struct {
+	TableNo  uint32
+	MaxSeats uint32
+	// BigBlindSeat is the seat that posts the big blind in the next hand.
+	BigBlindSeat uint32
+	Players      []BalanceSeat
+}
+
+// TableMove moves a player to a seat at another table.
+type TableMove struct {
+	PlayerID    uint64
+	FromTableNo uint32
+	FromSeatNo  uint32
+	ToTableNo   uint32
+	ToSeatNo    uint32
+}
+
+// BalancePlan is the result of PlanTableBalance.
+type BalancePlan struct {
+	Moves        []TableMove
+	BrokenTables []uint32
+}
+
+// MovesFrom returns the moves of the players of the table.
+func (p BalancePlan) MovesFrom(tableNo uint32) []TableMove {
+	var moves []TableMove
+	for _, move := range p.Moves {
+		if move.FromTableNo == tableNo {
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
+
+// IsBroken returns whether the plan breaks the table.
+func (p BalancePlan) IsBroken(tableNo uint32) bool {
+	for _, broken := range p.BrokenTables {
+		if broken == tableNo {
+			return true
+		}
+	}
+	return false
+}
+
+type balanceTable struct {
+	BalanceTable
+	// players by seat number
+	seats map[uint32]uint64
+}
+
+func (t *balanceTable) numPlayers() int {
+	return len(t.seats)
+}
+
+func (t *balanceTable) emptySeats() int {
+	return int(t.MaxSeats) - len(t.seats)
+}
+
+// occupiedSeats returns the occupied seats in the order they post the big
+// blind starting from the next big blind.
+func (t *balanceTable) occupiedSeats() []uint32 {
+	seats := make([]uint32, 0, len(t.seats))
+	for i := uint32(0); i < t.MaxSeats; i++ {
+		seatNo := (t.BigBlindSeat+i-1)%t.MaxSeats + 1
+		if _, ok := t.seats[seatNo]; ok {
+			seats = append(seats, seatNo)
+		}
+	}
+	return seats
+}
+
+// fairSeat returns the empty seat that posts the big blind the soonest.
+func (t *balanceTable) fairSeat() uint32 {
+	occupied := 0
+	for i := uint32(0); i < t.MaxSeats; i++ {
+		seatNo := (t.BigBlindSeat+i-1)%t.MaxSeats + 1
+		if _, ok := t.seats[seatNo]; ok {
+			occupied++
+			continue
+		}
+		// an empty seat before the next big blind waits for the whole orbit
+		if occupied > 0 || len(t.seats) == 0 {
+			return seatNo
+		}
+	}
+	// the seats after the big blind are taken, the first empty seat waits the least
+	for i := uint32(0); i < t.MaxSeats; i++ {
+		seatNo := (t.BigBlindSeat+i-1)%t.MaxSeats + 1
+		if _, ok := t.seats[seatNo]; !ok {
+			return seatNo
+		}
+	}
+	return 0
+}
+
+// PlanTableBalance returns the moves that break and balance the tables.
+func PlanTableBalance(tables []BalanceTable) BalancePlan {
+	var plan BalancePlan
+	active := make([]*balanceTable, 0, len(tables))
+	for _, table := range tables {
+		t := &balanceTable{
+			BalanceTable: table,
+			seats:        make(map[uint32]uint64),
+		}
+		if t.BigBlindSeat == 0 || t.BigBlindSeat > t.MaxSeats {
+			t.BigBlindSeat = 1
+		}
+		for _, player := range table.Players {
+			t.seats[player.SeatNo] = player.PlayerID
+		}
+		active = append(active, t)
+	}
+	moved := make(map[uint64]bool)
+
+	move := func(from *balanceTable, seatNo uint32, to *balanceTable) {
+		playerID := from.seats[seatNo]
+		toSeatNo := to.fairSeat()
+		delete(from.seats, seatNo)
+		to.seats[toSeatNo] = playerID
+		moved[playerID] = true
+		plan.Moves = append(plan.Moves, TableMove{
+			PlayerID:    playerID,
+			FromTableNo: from.TableNo,
+			FromSeatNo:  seatNo,
+			ToTableNo:   to.TableNo,
+			ToSeatNo:    toSeatNo,
+		})
+	}
+
+	// break the smallest tables while the other tables can seat their players
+	sortTablesBySize(active)
+	numBroken := 0
+	brokenPlayers := 0
+	for numBroken < len(active)-1 {
+		emptySeats := 0
+		for _, t := range active[numBroken+1:] {
+			emptySeats += t.emptySeats()
+		}
+		if brokenPlayers+active[numBroken].numPlayers() > emptySeats {
+			break
+		}
+		brokenPlayers += active[numBroken].numPlayers()
+		numBroken++
+	}
+	broken := active[:numBroken]
+	active = active[numBroken:]
+	for _, from := range broken {
+		for _, seatNo := range from.occupiedSeats() {
+			sortTablesBySize(active)
+			for _, to := range active {
+				if to.emptySeats() > 0 {
+					move(from, seatNo, to)
+					break
+				}
+			}
+		}
+		plan.BrokenTables = append(plan.BrokenTables, from.TableNo)
+	}
+
+	// move players from the biggest table to the smallest
+	for len(active) > 1 {
+		sortTablesBySize(active)
+		smallest := active[0]
+		biggest := active[len(active)-1]
+		if biggest.numPlayers()-smallest.numPlayers() <= 1 || smallest.emptySeats() == 0 {
+			break
+		}
+		seats := biggest.occupiedSeats()
+		seatNo := seats[0]
+		for _, s := range seats {
+			if !moved[biggest.seats[s]] {
+				seatNo = s
+				break
+			}
+		}
+		move(biggest, seatNo, smallest)
+	}
+	return plan
+}
+
+// sortTablesBySize sorts the tables by the number of players. The tables with
+// the same number of players are sorted by the table number in reverse, so the
+// higher numbered tables are broken first.
+func sortTablesBySize(tables []*balanceTable) {
+	sort.SliceStable(tables, func(i, j int) bool {
+		if tables[i].numPlayers() != tables[j].numPlayers() {
+			return tables[i].numPlayers() < tables[j].numPlayers()
+		}
+		return tables[i].TableNo > tables[j].TableNo
+	})
+}
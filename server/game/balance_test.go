@@ -0,0 +1,100 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func balanceTestTable(tableNo uint32, bigBlindSeat uint32, seats ...uint32) BalanceTable {
+	table := BalanceTable{
+		TableNo:      tableNo,
+		MaxSeats:     9,
+		BigBlindSeat: bigBlindSeat,
+	}
+	for _, seatNo := range seats {
+		table.Players = append(table.Players, BalanceSeat{
+			SeatNo:   seatNo,
+			PlayerID: uint64(tableNo*100 + seatNo),
+		})
+	}
+	return table
+}
+
+func TestBalanceMovesBigBlind(t *testing.T) {
+	plan := PlanTableBalance([]BalanceTable{
+		balanceTestTable(1, 4, 1, 2, 3, 4, 5, 6, 7, 8, 9),
+		balanceTestTable(2, 2, 1, 2, 3, 5, 6, 7, 8),
+	})
+	assert.Empty(t, plan.BrokenTables)
+	// the big blind of table 1 takes the seat after the big blind of table 2
+	assert.Equal(t, []TableMove{
+		{PlayerID: 104, FromTableNo: 1, FromSeatNo: 4, ToTableNo: 2, ToSeatNo: 4},
+	}, plan.Moves)
+	assert.Equal(t, plan.Moves, plan.MovesFrom(1))
+	assert.Empty(t, plan.MovesFrom(2))
+}
+
+func TestBalanceFairSeat(t *testing.T) {
+	// the seats after the big blind are taken, the empty seat waits for the orbit
+	plan := PlanTableBalance([]BalanceTable{
+		balanceTestTable(1, 1, 1, 2, 3, 4, 5, 6, 7, 8, 9),
+		balanceTestTable(2, 3, 3, 4, 5, 6, 7, 8, 9),
+	})
+	assert.Equal(t, []TableMove{
+		{PlayerID: 101, FromTableNo: 1, FromSeatNo: 1, ToTableNo: 2, ToSeatNo: 1},
+	}, plan.Moves)
+
+	// the stale big blind seat is empty
+	plan = PlanTableBalance([]BalanceTable{
+		balanceTestTable(1, 9, 1, 2, 3, 4, 5, 6, 7, 8, 9),
+		balanceTestTable(2, 4, 1, 2, 6, 7, 8, 9, 3),
+	})
+	assert.Equal(t, uint32(2), plan.Moves[0].ToTableNo)
+	assert.Equal(t, uint32(4), plan.Moves[0].ToSeatNo)
+}
+
+func TestBalanceBreakTable(t *testing.T) {
+	plan := PlanTableBalance([]BalanceTable{
+		balanceTestTable(1, 1, 1, 2, 3, 4, 5, 6, 7),
+		balanceTestTable(2, 1, 1, 2, 3, 4, 5, 6, 7),
+		balanceTestTable(3, 5, 2, 5, 8),
+	})
+	assert.Equal(t, []uint32{3}, plan.BrokenTables)
+	assert.True(t, plan.IsBroken(3))
+	assert.Len(t, plan.MovesFrom(3), 3)
+	// the players leave in the big blind order and fill the smaller table first
+	assert.Equal(t, uint64(305), plan.Moves[0].PlayerID)
+	assert.Equal(t, uint64(308), plan.Moves[1].PlayerID)
+	assert.Equal(t, uint64(302), plan.Moves[2].PlayerID)
+	counts := map[uint32]int{1: 7, 2: 7}
+	for _, move := range plan.Moves {
+		counts[move.ToTableNo]++
+	}
+	assert.Equal(t, map[uint32]int{1: 8, 2: 9}, counts)
+}
+
+func TestBalanceFinalTable(t *testing.T) {
+	plan := PlanTableBalance([]BalanceTable{
+		balanceTestTable(1, 1, 1, 3, 5, 7, 9),
+		balanceTestTable(2, 1, 2, 4, 6),
+		balanceTestTable(3, 1, 1),
+	})
+	assert.Equal(t, []uint32{3, 2}, plan.BrokenTables)
+	assert.Len(t, plan.Moves, 4)
+	seats := make(map[uint32]bool)
+	for _, move := range plan.Moves {
+		assert.Equal(t, uint32(1), move.ToTableNo)
+		assert.False(t, seats[move.ToSeatNo])
+		seats[move.ToSeatNo] = true
+	}
+}
+
+func TestBalanceNoMoves(t *testing.T) {
+	plan := PlanTableBalance([]BalanceTable{
+		balanceTestTable(1, 1, 1, 2, 3, 4, 5, 6, 7, 8, 9),
+		balanceTestTable(2, 1, 1, 2, 3, 4, 5, 6, 7, 8),
+	})
+	assert.Empty(t, plan.Moves)
+	assert.Empty(t, plan.BrokenTables)
+}
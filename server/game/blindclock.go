@@ -0,0 +1,152 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The tournament structure is the list of blind levels of a tournament. The
+// blind clock runs through the levels: each level lasts its duration of running
+// clock time, and the clock doesn't run while it is paused. The level is
+// computed from the time when the clock is read, so the clock doesn't need a
+// timer. The tables pick up the blinds of the current level when they deal the
+// next hand. The last level lasts until the end of the tournament.
+
+// BlindLevel is a level of the tournament structure.
+type BlindLevel struct {
+	Level      uint32
+	SmallBlind float64
+	BigBlind   float64
+	Ante       float64
+	Duration   time.Duration
+	// Break is a pause in the play. No hands are dealt during a break.
+	Break bool
+}
+
+// BlindClockState is the state of the blind clock when it was read.
+type BlindClockState struct {
+	// LevelIndex is the index of the current level in the structure.
+	LevelIndex int
+	Level      BlindLevel
+	// Blinds is the current level, or the last level before the break
+	// during a break.
+	Blinds    BlindLevel
+	Remaining time.Duration
+	Started   bool
+	Paused    bool
+}
+
+type BlindClock struct {
+	lock   sync.Mutex
+	levels []BlindLevel
+	now    func() time.Time
+
+	started bool
+	paused  bool
+	// elapsed is the running time of the clock before runningSince.
+	elapsed      time.Duration
+	runningSince time.Time
+}
+
+// NewBlindClock returns a clock for the tournament structure. The clock
+// doesn't run until Start is called. now returns the current time (time.Now
+// if nil).
+func NewBlindClock(levels []BlindLevel, now func() time.Time) (*BlindClock, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("Tournament structure does not have any levels")
+	}
+	hasBlinds := false
+	for i, level := range levels {
+		if level.Duration <= 0 {
+			return nil, fmt.Errorf("Level %d must have a duration", i+1)
+		}
+		if level.Break {
+			continue
+		}
+		if level.BigBlind <= 0 || level.SmallBlind > level.BigBlind || level.Ante < 0 {
+			return nil, fmt.Errorf("Invalid blinds %v/%v ante %v at level %d", level.SmallBlind, level.BigBlind, level.Ante, i+1)
+		}
+		hasBlinds = true
+	}
+	if !hasBlinds || levels[0].Break {
+		return nil, fmt.Errorf("Tournament structure must start with a level that has blinds")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &BlindClock{
+		levels: append([]BlindLevel{}, levels...),
+		now:    now,
+	}, nil
+}
+
+// Start starts the clock at the first level.
+func (c *BlindClock) Start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.started = true
+	c.paused = false
+	c.elapsed = 0
+	c.runningSince = c.now()
+}
+
+// Pause stops the clock until Resume is called.
+func (c *BlindClock) Pause() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.started {
+		return fmt.Errorf("Blind clock is not started")
+	}
+	if c.paused {
+		return fmt.Errorf("Blind clock is already paused")
+	}
+	c.elapsed += c.now().Sub(c.runningSince)
+	c.paused = true
+	return nil
+}
+
+// Resume restarts the clock where it was paused.
+func (c *BlindClock) Resume() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.started {
+		return fmt.Errorf("Blind clock is not started")
+	}
+	if !c.paused {
+		return fmt.Errorf("Blind clock is not paused")
+	}
+	c.runningSince = c.now()
+	c.paused = false
+	return nil
+}
+
+// State returns the current level of the clock.
+func (c *BlindClock) State() BlindClockState {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	state := BlindClockState{
+		Started: c.started,
+		Paused:  c.paused,
+	}
+	running := c.elapsed
+	if c.started && !c.paused {
+		running += c.now().Sub(c.runningSince)
+	}
+	index := 0
+	for ; index < len(c.levels)-1 && running >= c.levels[index].Duration; index++ {
+		running -= c.levels[index].Duration
+	}
+	state.LevelIndex = index
+	state.Level = c.levels[index]
+	if running < state.Level.Duration {
+		state.Remaining = state.Level.Duration - running
+	}
+	for i := index; i >= 0; i-- {
+		if !c.levels[i].Break {
+			state.Blinds = c.levels[i]
+			break
+		}
+	}
+	return state
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlindClock(t *testing.T) {
+	now := time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC)
+	clock, err := NewBlindClock([]BlindLevel{
+		{Level: 1, SmallBlind: 100, BigBlind: 200, Duration: 10 * time.Minute},
+		{Level: 2, SmallBlind: 200, BigBlind: 400, Ante: 50, Duration: 10 * time.Minute},
+		{Break: true, Duration: 5 * time.Minute},
+		{Level: 3, SmallBlind: 300, BigBlind: 600, Ante: 75, Duration: 10 * time.Minute},
+	}, func() time.Time { return now })
+	require.NoError(t, err)
+
+	state := clock.State()
+	assert.False(t, state.Started)
+	assert.Equal(t, 0, state.LevelIndex)
+	assert.Error(t, clock.Pause())
+
+	clock.Start()
+	now = now.Add(9 * time.Minute)
+	state = clock.State()
+	assert.Equal(t, 0, state.LevelIndex)
+	assert.Equal(t, time.Minute, state.Remaining)
+
+	now = now.Add(2 * time.Minute)
+	state = clock.State()
+	assert.Equal(t, 1, state.LevelIndex)
+	assert.Equal(t, float64(400), state.Blinds.BigBlind)
+	assert.Equal(t, 9*time.Minute, state.Remaining)
+
+	// the clock doesn't run while it is paused
+	require.NoError(t, clock.Pause())
+	assert.Error(t, clock.Pause())
+	now = now.Add(time.Hour)
+	state = clock.State()
+	assert.True(t, state.Paused)
+	assert.Equal(t, 1, state.LevelIndex)
+	assert.Equal(t, 9*time.Minute, state.Remaining)
+	require.NoError(t, clock.Resume())
+	assert.Error(t, clock.Resume())
+
+	// the break keeps the blinds of the level before
+	now = now.Add(10 * time.Minute)
+	state = clock.State()
+	assert.Equal(t, 2, state.LevelIndex)
+	assert.True(t, state.Level.Break)
+	assert.Equal(t, float64(400), state.Blinds.BigBlind)
+
+	// the last level lasts until the end
+	now = now.Add(time.Hour)
+	state = clock.State()
+	assert.Equal(t, 3, state.LevelIndex)
+	assert.Equal(t, float64(75), state.Blinds.Ante)
+	assert.Equal(t, time.Duration(0), state.Remaining)
+}
+
+func TestBlindClockStructure(t *testing.T) {
+	_, err := NewBlindClock(nil, nil)
+	assert.Error(t, err)
+	_, err = NewBlindClock([]BlindLevel{{Break: true, Duration: time.Minute}}, nil)
+	assert.Error(t, err)
+	_, err = NewBlindClock([]BlindLevel{{SmallBlind: 100, BigBlind: 200}}, nil)
+	assert.Error(t, err)
+	_, err = NewBlindClock([]BlindLevel{{SmallBlind: 400, BigBlind: 200, Duration: time.Minute}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,275 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// In a knockout tournament each entrant carries a bounty. The players who
+// bust a player win the bounty of the busted player. In a progressive knockout
+// (PKO) tournament half of the bounty is paid to the winner and the other half
+// is added to the bounty of the winner.
+//
+// A player is busted by the winners of the last pot the player put chips in,
+// which is the highest side pot the player is in. When the pot is split (split
+// pots, hi-lo and run it twice), the bounty is split in the proportion of the
+// chips won from that pot.
+//
+// A player is paid a bounty every time the stack goes to zero, so a player who
+// rebuys or re-enters carries the starting bounty again.
+//
+// The bounties are paid once per hand: a hand result saved again (the game
+// server restarted in the middle of saving it) returns the same transfers.
+// The progressive bounties on the heads of the players are kept in the memory
+// of the game server. They are local to this server: the tables of the
+// tournament hosted by other servers don't see them, and they are lost when
+// the server restarts.
+
+// TournamentBountyConfig is the bounty structure of a tournament.
+type TournamentBountyConfig struct {
+	// Bounty is the starting bounty of each entry.
+	Bounty      float64
+	Progressive bool
+}
+
+// Validate checks the bounty structure.
+func (c TournamentBountyConfig) Validate() error {
+	if c.Bounty <= 0 {
+		return fmt.Errorf("Bounty must be positive: %v", c.Bounty)
+	}
+	return nil
+}
+
+// KnockoutShare is the share of a knockout won by a player.
+type KnockoutShare struct {
+	PlayerID uint64
+	SeatNo   uint32
+	// Share is the fraction of the bounty won by the player.
+	Share float64
+}
+
+// Knockout is a player busted in a hand and the players who busted them.
+type Knockout struct {
+	BustedPlayerID uint64
+	Winners        []KnockoutShare
+}
+
+// TournamentBounties is the bounty state of a tournament shared by its tables.
+type TournamentBounties struct {
+	lock         sync.Mutex
+	tournamentID uint32
+	config       TournamentBountyConfig
+	// bounties of the players still in the tournament who won a progressive
+	// bounty. The other players carry the starting bounty.
+	heads map[uint64]float64
+	// total bounty paid to each player
+	won map[uint64]float64
+	// transfers of the hands paid
+	paid map[bountyHand][]*BountyTransfer
+}
+
+type bountyHand struct {
+	tableNo uint32
+	handNum uint32
+}
+
+// NewTournamentBounties returns the bounty state of the tournament.
+func NewTournamentBounties(tournamentID uint32, config TournamentBountyConfig) (*TournamentBounties, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &TournamentBounties{
+		tournamentID: tournamentID,
+		config:       config,
+		heads:        make(map[uint64]float64),
+		won:          make(map[uint64]float64),
+		paid:         make(map[bountyHand][]*BountyTransfer),
+	}, nil
+}
+
+// Config returns the bounty structure of the tournament.
+func (b *TournamentBounties) Config() TournamentBountyConfig {
+	return b.config
+}
+
+func (b *TournamentBounties) head(playerID uint64) float64 {
+	if bounty, ok := b.heads[playerID]; ok {
+		return bounty
+	}
+	return b.config.Bounty
+}
+
+// Bounty returns the bounty on the head of the player.
+func (b *TournamentBounties) Bounty(playerID uint64) float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.head(playerID)
+}
+
+// Won returns the total bounty paid to the player.
+func (b *TournamentBounties) Won(playerID uint64) float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.won[playerID]
+}
+
+// Pay pays the bounties of the knockouts of the hand and returns the
+// transfers. The busted players carry the starting bounty again if they come
+// back. A hand is paid once, paying it again returns the same transfers.
+func (b *TournamentBounties) Pay(tableNo uint32, handNum uint32, knockouts []Knockout) []*BountyTransfer {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	hand := bountyHand{tableNo: tableNo, handNum: handNum}
+	if transfers, ok := b.paid[hand]; ok {
+		return transfers
+	}
+	transfers := make([]*BountyTransfer, 0)
+	for _, ko := range knockouts {
+		if len(ko.Winners) == 0 {
+			continue
+		}
+		bounty := b.head(ko.BustedPlayerID)
+		delete(b.heads, ko.BustedPlayerID)
+		shares := splitBounty(bounty, ko.Winners)
+		for i, winner := range ko.Winners {
+			transfer := &BountyTransfer{
+				BustedPlayerId: ko.BustedPlayerID,
+				WinnerPlayerId: winner.PlayerID,
+				WinnerSeatNo:   winner.SeatNo,
+				Amount:         shares[i],
+			}
+			if b.config.Progressive {
+				transfer.HeadIncrease = roundCents(shares[i] / 2)
+				transfer.Amount = roundCents(shares[i] - transfer.HeadIncrease)
+				b.heads[winner.PlayerID] = b.head(winner.PlayerID) + transfer.HeadIncrease
+			}
+			b.won[winner.PlayerID] += transfer.Amount
+			transfers = append(transfers, transfer)
+		}
+	}
+	if len(transfers) > 0 {
+		b.paid[hand] = transfers
+	}
+	return transfers
+}
+
+// splitBounty splits the bounty in cents in the proportion of the shares. The
+// odd cents go to the first winners.
+func splitBounty(bounty float64, winners []KnockoutShare) []float64 {
+	shares := make([]float64, len(winners))
+	totalCents := math.Round(bounty * 100)
+	paidCents := 0.0
+	for i, winner := range winners {
+		shares[i] = math.Floor(totalCents * winner.Share)
+		paidCents += shares[i]
+	}
+	for i := 0; paidCents < totalCents; i = (i + 1) % len(shares) {
+		shares[i]++
+		paidCents++
+	}
+	for i := range shares {
+		shares[i] /= 100
+	}
+	return shares
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Knockouts returns the players busted in the hand and the players who busted
+// them.
+func Knockouts(result *HandResultClient) []Knockout {
+	knockouts := make([]Knockout, 0)
+	seats := make([]uint32, 0, len(result.GetPlayerInfo()))
+	for seatNo := range result.GetPlayerInfo() {
+		seats = append(seats, seatNo)
+	}
+	sort.Slice(seats, func(i, j int) bool { return seats[i] < seats[j] })
+
+	for _, seatNo := range seats {
+		info := result.PlayerInfo[seatNo]
+		if info.Balance == nil || info.Balance.Before <= 0 || info.Balance.After > 0 {
+			continue
+		}
+		pot := lastPot(result, seatNo)
+		if pot == nil {
+			continue
+		}
+		won := make(map[uint32]float64)
+		for _, board := range pot.BoardWinners {
+			for _, w := range board.HiWinners {
+				won[w.SeatNo] += w.Amount
+			}
+			for _, w := range board.LowWinners {
+				won[w.SeatNo] += w.Amount
+			}
+		}
+		delete(won, seatNo)
+		total := 0.0
+		for _, amount := range won {
+			total += amount
+		}
+		ko := Knockout{BustedPlayerID: info.Id}
+		for winnerSeat, amount := range won {
+			winner, ok := result.PlayerInfo[winnerSeat]
+			if !ok {
+				continue
+			}
+			share := 1 / float64(len(won))
+			if total > 0 {
+				share = amount / total
+			}
+			ko.Winners = append(ko.Winners, KnockoutShare{
+				PlayerID: winner.Id,
+				SeatNo:   winnerSeat,
+				Share:    share,
+			})
+		}
+		sort.Slice(ko.Winners, func(i, j int) bool { return ko.Winners[i].SeatNo < ko.Winners[j].SeatNo })
+		if len(ko.Winners) > 0 {
+			knockouts = append(knockouts, ko)
+		}
+	}
+	return knockouts
+}
+
+// lastPot returns the highest pot the seat is in.
+func lastPot(result *HandResultClient, seatNo uint32) *PotWinnersV2 {
+	var last *PotWinnersV2
+	for _, pot := range result.GetPotWinners() {
+		for _, s := range pot.SeatsInPots {
+			if s == seatNo && (last == nil || pot.PotNo > last.PotNo) {
+				last = pot
+			}
+		}
+	}
+	return last
+}
+
+// SetTournamentBounties sets the bounty state of the tournament of the table.
+func (g *Game) SetTournamentBounties(shared *TournamentBounties) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.bounties = shared
+}
+
+// payBounties pays the bounties of the players busted in the hand.
+func (g *Game) payBounties(result *HandResultClient) []*BountyTransfer {
+	g.lock.Lock()
+	bounties := g.bounties
+	g.lock.Unlock()
+	if bounties == nil {
+		return nil
+	}
+	transfers := bounties.Pay(g.tableNo, result.HandNum, Knockouts(result))
+	for _, t := range transfers {
+		g.logger.Info().
+			Msgf("Tournament %d: player %d busted player %d. Bounty: %v added to bounty: %v",
+				bounties.tournamentID, t.WinnerPlayerId, t.BustedPlayerId, t.Amount, t.HeadIncrease)
+	}
+	return transfers
+}
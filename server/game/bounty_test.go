@@ -0,0 +1,121 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bountyPlayer(id uint64, before float64, after float64) *PlayerHandInfo {
+	return &PlayerHandInfo{Id: id, Balance: &HandPlayerBalance{Before: before, After: after}}
+}
+
+func bountyPot(potNo uint32, seats []uint32, hiWinners ...*Winner) *PotWinnersV2 {
+	winners := make(map[uint32]*Winner)
+	for _, w := range hiWinners {
+		winners[w.SeatNo] = w
+	}
+	return &PotWinnersV2{
+		PotNo:        potNo,
+		SeatsInPots:  seats,
+		BoardWinners: []*BoardWinner{{BoardNo: 1, HiWinners: winners}},
+	}
+}
+
+func TestKnockouts(t *testing.T) {
+	// seat 1 is all in for 100 and busts, seat 2 wins the main pot and seat 3
+	// wins the side pot
+	result := &HandResultClient{
+		PlayerInfo: map[uint32]*PlayerHandInfo{
+			1: bountyPlayer(100, 100, 0),
+			2: bountyPlayer(200, 500, 800),
+			3: bountyPlayer(300, 500, 300),
+		},
+		PotWinners: []*PotWinnersV2{
+			bountyPot(0, []uint32{1, 2, 3}, &Winner{SeatNo: 2, Amount: 300}),
+			bountyPot(1, []uint32{2, 3}, &Winner{SeatNo: 3, Amount: 200}),
+		},
+	}
+	knockouts := Knockouts(result)
+	require.Len(t, knockouts, 1)
+	assert.Equal(t, uint64(100), knockouts[0].BustedPlayerID)
+	assert.Equal(t, []KnockoutShare{{PlayerID: 200, SeatNo: 2, Share: 1}}, knockouts[0].Winners)
+
+	// seat 1 busts in the side pot split by seats 2 and 3
+	result.PlayerInfo[1] = bountyPlayer(100, 400, 0)
+	result.PotWinners[1] = bountyPot(1, []uint32{1, 2, 3},
+		&Winner{SeatNo: 2, Amount: 300}, &Winner{SeatNo: 3, Amount: 300})
+	knockouts = Knockouts(result)
+	require.Len(t, knockouts, 1)
+	assert.Equal(t, []KnockoutShare{
+		{PlayerID: 200, SeatNo: 2, Share: 0.5},
+		{PlayerID: 300, SeatNo: 3, Share: 0.5},
+	}, knockouts[0].Winners)
+
+	// nobody busted
+	result.PlayerInfo[1] = bountyPlayer(100, 400, 50)
+	assert.Empty(t, Knockouts(result))
+}
+
+func TestBountyConfig(t *testing.T) {
+	_, err := NewTournamentBounties(1, TournamentBountyConfig{})
+	assert.Error(t, err)
+}
+
+func TestBountyPay(t *testing.T) {
+	b, err := NewTournamentBounties(1, TournamentBountyConfig{Bounty: 10})
+	require.NoError(t, err)
+	transfers := b.Pay(1, 1, []Knockout{{
+		BustedPlayerID: 100,
+		Winners: []KnockoutShare{
+			{PlayerID: 200, SeatNo: 2, Share: 2.0 / 3},
+			{PlayerID: 300, SeatNo: 3, Share: 1.0 / 3},
+		},
+	}})
+	require.Len(t, transfers, 2)
+	assert.Equal(t, 6.67, transfers[0].Amount)
+	assert.Equal(t, 3.33, transfers[1].Amount)
+	assert.Equal(t, 0.0, transfers[0].HeadIncrease)
+	assert.Equal(t, 10.0, b.Bounty(200), "bounties do not grow")
+	assert.Equal(t, 6.67, b.Won(200))
+
+	// the result of the hand is saved again
+	again := b.Pay(1, 1, []Knockout{{
+		BustedPlayerID: 100,
+		Winners:        []KnockoutShare{{PlayerID: 200, SeatNo: 2, Share: 1}},
+	}})
+	assert.Equal(t, transfers, again)
+	assert.Equal(t, 6.67, b.Won(200), "a hand is paid once")
+	// the same hand number at another table is another hand
+	b.Pay(2, 1, []Knockout{{
+		BustedPlayerID: 400,
+		Winners:        []KnockoutShare{{PlayerID: 200, SeatNo: 2, Share: 1}},
+	}})
+	assert.Equal(t, 16.67, b.Won(200))
+}
+
+func TestProgressiveBountyPay(t *testing.T) {
+	b, err := NewTournamentBounties(1, TournamentBountyConfig{Bounty: 10, Progressive: true})
+	require.NoError(t, err)
+	transfers := b.Pay(1, 2, []Knockout{{
+		BustedPlayerID: 100,
+		Winners:        []KnockoutShare{{PlayerID: 200, SeatNo: 2, Share: 1}},
+	}})
+	require.Len(t, transfers, 1)
+	assert.Equal(t, 5.0, transfers[0].Amount)
+	assert.Equal(t, 5.0, transfers[0].HeadIncrease)
+	assert.Equal(t, 15.0, b.Bounty(200))
+
+	// 300 busts 200 and wins half of the grown bounty
+	transfers = b.Pay(1, 3, []Knockout{{
+		BustedPlayerID: 200,
+		Winners:        []KnockoutShare{{PlayerID: 300, SeatNo: 3, Share: 1}},
+	}})
+	require.Len(t, transfers, 1)
+	assert.Equal(t, uint64(200), transfers[0].BustedPlayerId)
+	assert.Equal(t, 7.5, transfers[0].Amount)
+	assert.Equal(t, 7.5, transfers[0].HeadIncrease)
+	assert.Equal(t, 17.5, b.Bounty(300))
+	assert.Equal(t, 10.0, b.Bounty(200), "re-entry carries the starting bounty")
+}
@@ -0,0 +1,58 @@
+package game
+
+// ButtonPositions is the button and the blind positions of a hand.
+type ButtonPositions struct {
+	ButtonPos uint32
+	SbPos     uint32
+	BbPos     uint32
+}
+
+// NextButtonPositions moves the button for the next hand. last is the
+// positions of the last hand, played the seats dealt in the last hand and
+// seats the seats to deal in the next hand.
+//
+// The button moves to the next seat that played the last hand, so a new player
+// does not take the button. With three or more players the blinds follow the
+// button. Heads-up the button posts the small blind and the other player the
+// big blind. When the table goes heads-up the button is moved if needed so
+// that the player who posted the big blind does not post it again.
+func NextButtonPositions(maxSeats uint32, last ButtonPositions, played []uint32, seats []uint32) ButtonPositions {
+	if len(seats) < 2 {
+		return ButtonPositions{}
+	}
+	inSeat := make(map[uint32]bool)
+	for _, seatNo := range seats {
+		inSeat[seatNo] = true
+	}
+	wasDealt := make(map[uint32]bool)
+	for _, seatNo := range played {
+		if inSeat[seatNo] {
+			wasDealt[seatNo] = true
+		}
+	}
+	if len(wasDealt) == 0 {
+		// nobody from the last hand is left
+		wasDealt = inSeat
+	}
+
+	next := func(seatNo uint32, in map[uint32]bool) uint32 {
+		for i := uint32(1); i <= maxSeats; i++ {
+			s := (seatNo+i-1)%maxSeats + 1
+			if in[s] {
+				return s
+			}
+		}
+		return 0
+	}
+
+	button := next(last.ButtonPos, wasDealt)
+	if len(seats) == 2 {
+		other := next(button, inSeat)
+		if other == last.BbPos {
+			button, other = other, button
+		}
+		return ButtonPositions{ButtonPos: button, SbPos: button, BbPos: other}
+	}
+	sb := next(button, inSeat)
+	return ButtonPositions{ButtonPos: button, SbPos: sb, BbPos: next(sb, inSeat)}
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/poker"
+)
+
+func TestNextButtonPositions(t *testing.T) {
+	first := NextButtonPositions(9, ButtonPositions{}, nil, []uint32{2, 5, 7})
+	assert.Equal(t, ButtonPositions{ButtonPos: 2, SbPos: 5, BbPos: 7}, first)
+
+	last := ButtonPositions{ButtonPos: 1, SbPos: 2, BbPos: 3}
+	played := []uint32{1, 2, 3}
+
+	// 3-handed: the button and the blinds move
+	assert.Equal(t, ButtonPositions{ButtonPos: 2, SbPos: 3, BbPos: 1},
+		NextButtonPositions(9, last, played, []uint32{1, 2, 3}))
+
+	// the button leaves: seat 3 posted the big blind and takes the button
+	assert.Equal(t, ButtonPositions{ButtonPos: 3, SbPos: 3, BbPos: 2},
+		NextButtonPositions(9, last, played, []uint32{2, 3}))
+
+	// the small blind leaves
+	assert.Equal(t, ButtonPositions{ButtonPos: 3, SbPos: 3, BbPos: 1},
+		NextButtonPositions(9, last, played, []uint32{1, 3}))
+
+	// the big blind leaves
+	assert.Equal(t, ButtonPositions{ButtonPos: 2, SbPos: 2, BbPos: 1},
+		NextButtonPositions(9, last, played, []uint32{1, 2}))
+
+	// heads-up the button alternates
+	headsUp := ButtonPositions{ButtonPos: 2, SbPos: 2, BbPos: 5}
+	assert.Equal(t, ButtonPositions{ButtonPos: 5, SbPos: 5, BbPos: 2},
+		NextButtonPositions(9, headsUp, []uint32{2, 5}, []uint32{2, 5}))
+
+	// a third player joins: the new player does not take the button
+	assert.Equal(t, ButtonPositions{ButtonPos: 5, SbPos: 2, BbPos: 3},
+		NextButtonPositions(9, headsUp, []uint32{2, 5}, []uint32{2, 3, 5}))
+	assert.Equal(t, ButtonPositions{ButtonPos: 5, SbPos: 7, BbPos: 2},
+		NextButtonPositions(9, headsUp, []uint32{2, 5}, []uint32{2, 5, 7}))
+
+	// not enough players
+	assert.Equal(t, ButtonPositions{}, NextButtonPositions(9, last, played, []uint32{1}))
+}
+
+// Heads-up the button posts the small blind and acts first before the flop and
+// last after the flop in every game type. The scripts in
+// test/game-scripts/heads-up play the heads-up hands of the game types and the
+// table going from heads-up to three players and back.
+func TestHeadsUpGameTypes(t *testing.T) {
+	for _, gameType := range []GameType{
+		GameType_HOLDEM,
+		GameType_PLO,
+		GameType_PLO_HILO,
+		GameType_FIVE_CARD_PLO,
+		GameType_FIVE_CARD_PLO_HILO,
+		GameType_SIX_CARD_PLO,
+		GameType_SIX_CARD_PLO_HILO,
+	} {
+		for _, buttonPos := range []uint32{1, 5} {
+			t.Run(fmt.Sprintf("%s button %d", gameType, buttonPos), func(t *testing.T) {
+				bbPos := uint32(5)
+				if buttonPos == 5 {
+					bbPos = 1
+				}
+				config := newEngineTestConfig()
+				config.GameType = gameType
+				config.ButtonPos = buttonPos
+				config.Rand = poker.NewSeededRand(uint64(gameType))
+				seats := newEngineTestSeats()[:2]
+				h, _, err := DealHand(config, seats, nil)
+				require.NoError(t, err)
+				assert.Equal(t, buttonPos, h.ButtonPos)
+				assert.Equal(t, buttonPos, h.SmallBlindPos)
+				assert.Equal(t, bbPos, h.BigBlindPos)
+				for _, seat := range seats {
+					assert.Len(t, h.PlayersCards[seat.SeatNo], int(numCards(gameType)))
+				}
+
+				// the button acts first before the flop
+				require.Equal(t, buttonPos, h.NextSeatAction.SeatNo)
+				h, _, err = Apply(h, &HandAction{SeatNo: buttonPos, Action: ACTION_CALL, Amount: 200})
+				require.NoError(t, err)
+				h, _, err = Apply(h, &HandAction{SeatNo: bbPos, Action: ACTION_CHECK})
+				require.NoError(t, err)
+				assert.Equal(t, HandStatus_FLOP, h.CurrentState)
+
+				// and last after the flop
+				require.Equal(t, bbPos, h.NextSeatAction.SeatNo)
+				h, _, err = Apply(h, &HandAction{SeatNo: bbPos, Action: ACTION_CHECK})
+				require.NoError(t, err)
+				assert.Equal(t, buttonPos, h.NextSeatAction.SeatNo)
+			})
+		}
+	}
+}
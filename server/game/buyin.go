@@ -0,0 +1,504 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"voyager.com/logging"
+	"voyager.com/server/timer"
+)
+
+// A tournament with rebuys lets the players buy chips again between hands.
+// During the rebuy period a player whose stack is not above the starting
+// stack is offered a rebuy. When the rebuy period ends, every player still in
+// the tournament is offered a one-time add-on. During late registration a
+// busted player can re-enter with a new stack at a new seat.
+//
+// The buy-in state of a tournament (the periods and the buy-ins of each
+// player) is shared by its tables. A table prompts its players after each
+// hand, and the next hand waits until the players answer or the prompt times
+// out on the action timer. The accepted buy-ins are reported to the api server
+// and applied to the seats of the next hand only when the api server records
+// them, so no chips are added for a buy-in the tournament doesn't know about.
+
+const (
+	TournamentRebuy   string = "REBUY"
+	TournamentAddOn   string = "ADD_ON"
+	TournamentReEntry string = "RE_ENTRY"
+)
+
+const defaultBuyInPromptTimeout = 15 * time.Second
+
+// TournamentBuyInConfig is the buy-in structure of a tournament. The chips are
+// in chips (not cents). A zero number of chips disables the buy-in.
+type TournamentBuyInConfig struct {
+	StartingChips float64
+	RebuyChips    float64
+	// MaxRebuys is the number of rebuys of a player (0 is unlimited).
+	MaxRebuys    int
+	AddOnChips   float64
+	ReEntryChips float64
+	// MaxReEntries is the number of re-entries of a player (0 is unlimited).
+	MaxReEntries  int
+	PromptTimeout time.Duration
+}
+
+// TournamentBuyInRecord is a buy-in accepted by a player.
+type TournamentBuyInRecord struct {
+	TournamentID uint32  `json:"tournamentId"`
+	TableNo      uint32  `json:"tableNo"`
+	HandNum      uint32  `json:"handNum"`
+	PlayerID     uint64  `json:"playerId"`
+	SeatNo       uint32  `json:"seatNo"`
+	Type         string  `json:"type"`
+	Chips        float64 `json:"chips"`
+}
+
+type buyInPlayer struct {
+	rebuys       int
+	reEntries    int
+	addOnOffered bool
+	// the player declined a rebuy and is not offered another one until busted
+	rebuyDeclined bool
+}
+
+// TournamentBuyIns is the buy-in state of a tournament shared by its tables.
+type TournamentBuyIns struct {
+	lock         sync.Mutex
+	tournamentID uint32
+	config       TournamentBuyInConfig
+	rebuyOpen    bool
+	addOnOpen    bool
+	reEntryOpen  bool
+	players      map[uint64]*buyInPlayer
+}
+
+// NewTournamentBuyIns returns the buy-in state of the tournament with all the
+// periods closed.
+func NewTournamentBuyIns(tournamentID uint32, config TournamentBuyInConfig) *TournamentBuyIns {
+	if config.PromptTimeout == 0 {
+		config.PromptTimeout = defaultBuyInPromptTimeout
+	}
+	return &TournamentBuyIns{
+		tournamentID: tournamentID,
+		config:       config,
+		players:      make(map[uint64]*buyInPlayer),
+	}
+}
+
+// SetPeriods opens or closes the rebuy, add-on and re-entry periods.
+func (b *TournamentBuyIns) SetPeriods(rebuyOpen bool, addOnOpen bool, reEntryOpen bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.rebuyOpen = rebuyOpen
+	b.addOnOpen = addOnOpen
+	b.reEntryOpen = reEntryOpen
+}
+
+// Periods returns whether the rebuy, add-on and re-entry periods are open.
+func (b *TournamentBuyIns) Periods() (rebuyOpen bool, addOnOpen bool, reEntryOpen bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.rebuyOpen, b.addOnOpen, b.reEntryOpen
+}
+
+func (b *TournamentBuyIns) player(playerID uint64) *buyInPlayer {
+	p, ok := b.players[playerID]
+	if !ok {
+		p = &buyInPlayer{}
+		b.players[playerID] = p
+	}
+	return p
+}
+
+// offer returns the buy-in offered to the player with the stack after a hand.
+func (b *TournamentBuyIns) offer(playerID uint64, stack float64) (string, float64, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	c := b.config
+	p := b.player(playerID)
+	if stack <= 0 {
+		p.rebuyDeclined = false
+	}
+	switch {
+	case b.rebuyOpen && c.RebuyChips > 0 && stack <= c.StartingChips &&
+		!p.rebuyDeclined && (c.MaxRebuys == 0 || p.rebuys < c.MaxRebuys):
+		return TournamentRebuy, c.RebuyChips, true
+	case b.addOnOpen && c.AddOnChips > 0 && stack > 0 && !p.addOnOffered:
+		p.addOnOffered = true
+		return TournamentAddOn, c.AddOnChips, true
+	case !b.rebuyOpen && b.reEntryOpen && c.ReEntryChips > 0 && stack <= 0 &&
+		(c.MaxReEntries == 0 || p.reEntries < c.MaxReEntries):
+		return TournamentReEntry, c.ReEntryChips, true
+	}
+	return "", 0, false
+}
+
+// answer records the answer of the player to the buy-in prompt.
+func (b *TournamentBuyIns) answer(playerID uint64, buyInType string, accepted bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	p := b.player(playerID)
+	switch buyInType {
+	case TournamentRebuy:
+		if accepted {
+			p.rebuys++
+		} else {
+			p.rebuyDeclined = true
+		}
+	case TournamentReEntry:
+		if accepted {
+			p.reEntries++
+		}
+	}
+}
+
+// revert forgets the accepted buy-in of the player when it is not recorded.
+func (b *TournamentBuyIns) revert(playerID uint64, buyInType string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	p := b.player(playerID)
+	switch buyInType {
+	case TournamentRebuy:
+		if p.rebuys > 0 {
+			p.rebuys--
+		}
+	case TournamentReEntry:
+		if p.reEntries > 0 {
+			p.reEntries--
+		}
+	}
+}
+
+// ReEntries returns the number of re-entries of the tournament.
+func (b *TournamentBuyIns) ReEntries() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	reEntries := 0
+	for _, p := range b.players {
+		reEntries += p.reEntries
+	}
+	return reEntries
+}
+
+func (b *TournamentBuyIns) promptTimeout() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.config.PromptTimeout
+}
+
+type buyInPrompt struct {
+	playerID  uint64
+	seatNo    uint32
+	buyInType string
+	chips     float64
+	answered  bool
+	accepted  bool
+}
+
+// tableBuyIns is the buy-in prompts of a tournament table.
+type tableBuyIns struct {
+	lock     sync.Mutex
+	shared   *TournamentBuyIns
+	handNum  uint32
+	promptID string
+	prompts  map[uint64]*buyInPrompt
+	// closed when all the prompts are answered or timed out
+	done     chan bool
+	accepted []TournamentBuyInRecord
+	// set from the start of the next hand to its end
+	dealing bool
+}
+
+// SetTournamentBuyIns sets the buy-in state of the tournament of the table.
+func (g *Game) SetTournamentBuyIns(shared *TournamentBuyIns) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.buyIns != nil && g.buyIns.shared == shared {
+		return
+	}
+	done := make(chan bool)
+	close(done)
+	g.buyIns = &tableBuyIns{
+		shared:  shared,
+		prompts: make(map[uint64]*buyInPrompt),
+		done:    done,
+	}
+}
+
+func (g *Game) getBuyIns() *tableBuyIns {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.buyIns
+}
+
+// OfferTournamentBuyIns prompts the players of the table when no hand is being
+// dealt, for example when the add-on period starts during a break.
+func (g *Game) OfferTournamentBuyIns() {
+	b := g.getBuyIns()
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	dealing := b.dealing
+	b.lock.Unlock()
+	if dealing {
+		// the players are prompted when the hand ends
+		return
+	}
+	g.offerTournamentBuyIns(g.lastHandNum())
+}
+
+// offerTournamentBuyIns prompts the players of the table who can buy chips
+// with their stacks after the last hand.
+func (g *Game) offerTournamentBuyIns(handNum uint32) {
+	b := g.getBuyIns()
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.dealing = false
+
+	var prompts []*buyInPrompt
+	for _, stack := range g.TableStacks() {
+		if _, prompted := b.prompts[stack.PlayerID]; prompted {
+			continue
+		}
+		buyInType, chips, ok := b.shared.offer(stack.PlayerID, stack.Stack)
+		if !ok {
+			continue
+		}
+		prompts = append(prompts, &buyInPrompt{
+			playerID:  stack.PlayerID,
+			seatNo:    stack.SeatNo,
+			buyInType: buyInType,
+			chips:     chips,
+		})
+	}
+	if len(prompts) == 0 {
+		return
+	}
+
+	if len(b.prompts) == 0 {
+		b.handNum = handNum
+		b.promptID = fmt.Sprintf("BUYIN:%d:%d", handNum, time.Now().UnixNano())
+		b.done = make(chan bool)
+	}
+	timeout := b.shared.promptTimeout()
+	for _, prompt := range prompts {
+		b.prompts[prompt.playerID] = prompt
+		g.sendBuyInPrompt(b, prompt, timeout)
+	}
+	g.actionTimer.NewAction(timer.TimerMsg{
+		SeatNo:          prompts[0].seatNo,
+		PlayerID:        prompts[0].playerID,
+		ExpireAt:        time.Now().Add(timeout),
+		ActionID:        b.promptID,
+		TournamentBuyIn: true,
+	})
+}
+
+func (g *Game) sendBuyInPrompt(b *tableBuyIns, prompt *buyInPrompt, timeout time.Duration) {
+	g.logger.Info().
+		Uint64(logging.PlayerIDKey, prompt.playerID).
+		Msgf("Offering %s of %v chips to the player at seat %d", prompt.buyInType, prompt.chips, prompt.seatNo)
+	tournamentID := uint32(g.tournamentID)
+	buyIn := &TournamentBuyIn{
+		TournamentId: tournamentID,
+		PlayerId:     prompt.playerID,
+		BuyInType:    prompt.buyInType,
+		Chips:        prompt.chips,
+		TimeoutSec:   uint32(timeout.Seconds()),
+		PromptId:     b.promptID,
+	}
+	message := &HandMessage{
+		PlayerId: prompt.playerID,
+		SeatNo:   prompt.seatNo,
+		HandNum:  b.handNum,
+		MessageId: g.GenerateMsgID("BUYIN", b.handNum, HandStatus_HAND_CLOSED,
+			prompt.playerID, b.promptID, 0),
+		Messages: []*HandMessageItem{
+			{
+				MessageType: HandTournamentBuyIn,
+				Content:     &HandMessageItem_TournamentBuyIn{TournamentBuyIn: buyIn},
+			},
+		},
+	}
+	g.sendTournamentMessageToPlayer(message, tournamentID, prompt.playerID)
+}
+
+// onTournamentBuyIn processes the answer of the player to the buy-in prompt.
+func (g *Game) onTournamentBuyIn(message *HandMessage) error {
+	answer := message.GetMessages()[0].GetTournamentBuyIn()
+	if answer == nil {
+		return InvalidMessageError{Msg: "Tournament buy-in answer is missing"}
+	}
+	b := g.getBuyIns()
+	if b == nil {
+		return fmt.Errorf("Table %d does not have tournament buy-ins", g.tableNo)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	prompt, ok := b.prompts[message.PlayerId]
+	if !ok || prompt.answered || answer.PromptId != b.promptID {
+		g.logger.Info().
+			Uint64(logging.PlayerIDKey, message.PlayerId).
+			Msgf("Ignoring the tournament buy-in answer to prompt %s", answer.PromptId)
+		return nil
+	}
+	g.answerBuyIn(b, prompt, answer.Accepted)
+	return nil
+}
+
+// onTournamentBuyInTimeout declines the prompts that are not answered.
+func (g *Game) onTournamentBuyInTimeout(timeoutMsg timer.TimerMsg) {
+	b := g.getBuyIns()
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if timeoutMsg.ActionID != b.promptID {
+		return
+	}
+	for _, prompt := range b.prompts {
+		if !prompt.answered {
+			g.logger.Info().
+				Uint64(logging.PlayerIDKey, prompt.playerID).
+				Msgf("Tournament %s prompt timed out", prompt.buyInType)
+			g.answerBuyIn(b, prompt, false)
+		}
+	}
+}
+
+func (g *Game) answerBuyIn(b *tableBuyIns, prompt *buyInPrompt, accepted bool) {
+	prompt.answered = true
+	prompt.accepted = accepted
+	b.shared.answer(prompt.playerID, prompt.buyInType, accepted)
+	if accepted {
+		b.accepted = append(b.accepted, TournamentBuyInRecord{
+			TournamentID: uint32(g.tournamentID),
+			TableNo:      g.tableNo,
+			HandNum:      b.handNum,
+			PlayerID:     prompt.playerID,
+			SeatNo:       prompt.seatNo,
+			Type:         prompt.buyInType,
+			Chips:        prompt.chips,
+		})
+	}
+	g.logger.Info().
+		Uint64(logging.PlayerIDKey, prompt.playerID).
+		Msgf("Tournament %s accepted: %v", prompt.buyInType, accepted)
+
+	for _, p := range b.prompts {
+		if !p.answered {
+			return
+		}
+	}
+	g.actionTimer.Pause()
+	b.prompts = make(map[uint64]*buyInPrompt)
+	close(b.done)
+}
+
+// waitTournamentBuyIns waits for the answers to the buy-in prompts. Returns the
+// accepted buy-ins.
+func (g *Game) waitTournamentBuyIns() []TournamentBuyInRecord {
+	b := g.getBuyIns()
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	done := b.done
+	promptID := b.promptID
+	b.lock.Unlock()
+	select {
+	case <-done:
+	case <-time.After(2 * b.shared.promptTimeout()):
+		// the action timer didn't expire the prompts
+		g.onTournamentBuyInTimeout(timer.TimerMsg{ActionID: promptID, TournamentBuyIn: true})
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.dealing = true
+	accepted := b.accepted
+	b.accepted = nil
+	return accepted
+}
+
+// applyTournamentBuyIns reports the accepted buy-ins to the api server and
+// adds their chips to the seats of the hand. When the api server doesn't
+// record them, the buy-ins are dropped: the players keep their stacks and can
+// be offered the buy-ins again.
+func (g *Game) applyTournamentBuyIns(newHandInfo *NewHandInfo, buyIns []TournamentBuyInRecord) error {
+	if len(buyIns) == 0 {
+		return nil
+	}
+	g.tournamentURL = newHandInfo.TournamentURL
+	err := g.saveTournamentBuyInsToAPIServer(buyIns)
+	if err != nil {
+		if b := g.getBuyIns(); b != nil {
+			for _, record := range buyIns {
+				b.shared.revert(record.PlayerID, record.Type)
+			}
+		}
+		return err
+	}
+	for _, record := range buyIns {
+		newHandInfo.PlayersInSeats = applyBuyIn(newHandInfo.PlayersInSeats, newHandInfo.MaxPlayers, record)
+	}
+	return nil
+}
+
+// applyBuyIn adds the chips of the buy-in to the stack of the player. A player
+// who re-enters is seated at a new seat. A player who is not seated for the
+// hand any more (busted) takes the old seat back or the next empty seat.
+func applyBuyIn(players []SeatPlayer, maxSeats uint32, record TournamentBuyInRecord) []SeatPlayer {
+	seatIdx := -1
+	taken := make(map[uint32]bool)
+	for i := range players {
+		if players[i].PlayerID == record.PlayerID {
+			seatIdx = i
+		} else if players[i].PlayerID != 0 {
+			taken[players[i].SeatNo] = true
+		}
+	}
+
+	if seatIdx == -1 || record.Type == TournamentReEntry {
+		// a re-entry starts from the seat after the old seat
+		first := uint32(0)
+		if record.Type == TournamentReEntry {
+			first = 1
+		}
+		seatNo := uint32(0)
+		for i := first; i < first+maxSeats && seatNo == 0; i++ {
+			next := (record.SeatNo+i-1)%maxSeats + 1
+			if !taken[next] {
+				seatNo = next
+			}
+		}
+		if seatNo == 0 {
+			return players
+		}
+		if seatIdx == -1 {
+			players = append(players, SeatPlayer{
+				PlayerID: record.PlayerID,
+				Status:   PlayerStatus_PLAYING,
+				Inhand:   true,
+			})
+			seatIdx = len(players) - 1
+		}
+		players[seatIdx].SeatNo = seatNo
+	}
+
+	if record.Type == TournamentReEntry {
+		players[seatIdx].Stack = record.Chips
+	} else {
+		players[seatIdx].Stack += record.Chips
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].SeatNo < players[j].SeatNo })
+	return players
+}
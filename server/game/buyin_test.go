@@ -0,0 +1,201 @@
+package game
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/logging"
+	"voyager.com/server/timer"
+)
+
+type buyInTestSender struct {
+	MessageSender
+	prompts chan *TournamentBuyIn
+}
+
+func (s *buyInTestSender) SendHandMessageToTournamentPlayer(message *HandMessage, tournamentID uint32, playerID uint64) {
+	s.prompts <- message.GetMessages()[0].GetTournamentBuyIn()
+}
+
+func TestTournamentBuyInOffers(t *testing.T) {
+	b := NewTournamentBuyIns(1, TournamentBuyInConfig{
+		StartingChips: 1000,
+		RebuyChips:    1000,
+		MaxRebuys:     1,
+		AddOnChips:    2000,
+		ReEntryChips:  1500,
+	})
+
+	// all the periods are closed
+	_, _, ok := b.offer(100, 0)
+	assert.False(t, ok)
+
+	b.SetPeriods(true, false, true)
+	buyInType, chips, ok := b.offer(100, 800)
+	assert.True(t, ok)
+	assert.Equal(t, TournamentRebuy, buyInType)
+	assert.Equal(t, 1000.0, chips)
+	_, _, ok = b.offer(100, 1200)
+	assert.False(t, ok)
+
+	// a declined rebuy is offered again when the player busts
+	b.answer(100, TournamentRebuy, false)
+	_, _, ok = b.offer(100, 500)
+	assert.False(t, ok)
+	buyInType, _, ok = b.offer(100, 0)
+	assert.True(t, ok)
+	assert.Equal(t, TournamentRebuy, buyInType)
+	b.answer(100, TournamentRebuy, true)
+	_, _, ok = b.offer(100, 0)
+	assert.False(t, ok, "only one rebuy")
+
+	// the add-on is offered once to the players with chips
+	b.SetPeriods(false, true, true)
+	buyInType, chips, ok = b.offer(200, 3000)
+	assert.True(t, ok)
+	assert.Equal(t, TournamentAddOn, buyInType)
+	assert.Equal(t, 2000.0, chips)
+	_, _, ok = b.offer(200, 3000)
+	assert.False(t, ok)
+
+	// busted players re-enter after the rebuy period
+	buyInType, chips, ok = b.offer(300, 0)
+	assert.True(t, ok)
+	assert.Equal(t, TournamentReEntry, buyInType)
+	assert.Equal(t, 1500.0, chips)
+	b.answer(300, TournamentReEntry, true)
+	assert.Equal(t, 1, b.ReEntries())
+	// the re-entry is not recorded by the api server
+	b.revert(300, TournamentReEntry)
+	assert.Equal(t, 0, b.ReEntries())
+}
+
+func TestApplyBuyIn(t *testing.T) {
+	players := []SeatPlayer{
+		{SeatNo: 1, PlayerID: 100, Stack: 500},
+		{SeatNo: 2, PlayerID: 200, Stack: 0},
+		{SeatNo: 4, PlayerID: 400, Stack: 3000},
+	}
+	players = applyBuyIn(players, 4, TournamentBuyInRecord{PlayerID: 100, SeatNo: 1, Type: TournamentRebuy, Chips: 1000})
+	assert.Equal(t, 1500.0, players[0].Stack)
+
+	// the re-entry takes the next empty seat
+	players = applyBuyIn(players, 4, TournamentBuyInRecord{PlayerID: 200, SeatNo: 2, Type: TournamentReEntry, Chips: 1500})
+	assert.Equal(t, []SeatPlayer{
+		{SeatNo: 1, PlayerID: 100, Stack: 1500},
+		{SeatNo: 3, PlayerID: 200, Stack: 1500},
+		{SeatNo: 4, PlayerID: 400, Stack: 3000},
+	}, players)
+
+	// the tournament left out the busted player, who takes the seat back
+	players = applyBuyIn(players, 4, TournamentBuyInRecord{PlayerID: 500, SeatNo: 2, Type: TournamentRebuy, Chips: 1000})
+	assert.Equal(t, SeatPlayer{SeatNo: 2, PlayerID: 500, Stack: 1000, Status: PlayerStatus_PLAYING, Inhand: true}, players[1])
+}
+
+func TestTournamentBuyInPrompts(t *testing.T) {
+	logger := logging.GetZeroLogger("game::Game", nil)
+	sender := &buyInTestSender{prompts: make(chan *TournamentBuyIn, 10)}
+	var messageSender MessageSender = sender
+	g := &Game{
+		tournamentID:   1,
+		tableNo:        2,
+		logger:         logger,
+		messageSender:  &messageSender,
+		chPlayTimedOut: make(chan timer.TimerMsg, 10),
+	}
+	g.actionTimer = timer.NewActionTimer(logger, g.queueActionTimeoutMsg, func() {})
+	g.actionTimer.Run()
+	defer g.actionTimer.Destroy()
+
+	shared := NewTournamentBuyIns(1, TournamentBuyInConfig{
+		StartingChips: 1000,
+		RebuyChips:    1000,
+		MaxRebuys:     1,
+		PromptTimeout: time.Second,
+	})
+	shared.SetPeriods(true, false, false)
+	g.SetTournamentBuyIns(shared)
+	g.tableStacks = []TableStack{
+		{SeatNo: 1, PlayerID: 100, Stack: 0},
+		{SeatNo: 3, PlayerID: 300, Stack: 600},
+		{SeatNo: 5, PlayerID: 500, Stack: 4000},
+	}
+	g.offerTournamentBuyIns(7)
+
+	prompts := make(map[uint64]*TournamentBuyIn)
+	for i := 0; i < 2; i++ {
+		prompt := <-sender.prompts
+		prompts[prompt.PlayerId] = prompt
+	}
+	require.Contains(t, prompts, uint64(100))
+	require.Contains(t, prompts, uint64(300))
+	assert.Equal(t, TournamentRebuy, prompts[100].BuyInType)
+
+	// player 100 rebuys, player 300 doesn't answer
+	answer := &TournamentBuyIn{
+		TournamentId: 1,
+		PlayerId:     100,
+		BuyInType:    TournamentRebuy,
+		PromptId:     prompts[100].PromptId,
+		Accepted:     true,
+	}
+	require.NoError(t, g.onTournamentBuyIn(&HandMessage{
+		PlayerId: 100,
+		Messages: []*HandMessageItem{
+			{
+				MessageType: HandTournamentBuyIn,
+				Content:     &HandMessageItem_TournamentBuyIn{TournamentBuyIn: answer},
+			},
+		},
+	}))
+	select {
+	case timeoutMsg := <-g.chPlayTimedOut:
+		require.NoError(t, g.handlePlayTimeout(timeoutMsg))
+	case <-time.After(3 * time.Second):
+		t.Fatal("Buy-in prompt did not time out")
+	}
+
+	recorded := make(chan string, 10)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded <- r.URL.Path
+	}))
+	defer apiServer.Close()
+	newHand := func() *NewHandInfo {
+		return &NewHandInfo{
+			MaxPlayers:    6,
+			TournamentURL: apiServer.URL,
+			PlayersInSeats: []SeatPlayer{
+				{SeatNo: 3, PlayerID: 300, Stack: 600},
+				{SeatNo: 5, PlayerID: 500, Stack: 4000},
+			},
+		}
+	}
+	buyIns := g.waitTournamentBuyIns()
+	assert.Equal(t, []TournamentBuyInRecord{
+		{TournamentID: 1, TableNo: 2, HandNum: 7, PlayerID: 100, SeatNo: 1, Type: TournamentRebuy, Chips: 1000},
+	}, buyIns)
+
+	// the api server is down: the rebuy is not applied and can be offered again
+	apiServer.Close()
+	hand := newHand()
+	assert.Error(t, g.applyTournamentBuyIns(hand, buyIns))
+	assert.Len(t, hand.PlayersInSeats, 2)
+	buyInType, _, ok := shared.offer(100, 0)
+	assert.True(t, ok)
+	assert.Equal(t, TournamentRebuy, buyInType)
+
+	apiServer = httptest.NewServer(apiServer.Config.Handler)
+	defer apiServer.Close()
+	hand = newHand()
+	require.NoError(t, g.applyTournamentBuyIns(hand, buyIns))
+	assert.Equal(t, "/internal/tournament-buy-ins/tournamentId/1/tableNo/2", <-recorded)
+	assert.Equal(t, []SeatPlayer{
+		{SeatNo: 1, PlayerID: 100, Stack: 1000, Status: PlayerStatus_PLAYING, Inhand: true},
+		{SeatNo: 3, PlayerID: 300, Stack: 600},
+		{SeatNo: 5, PlayerID: 500, Stack: 4000},
+	}, hand.PlayersInSeats)
+}
@@ -27,7 +27,23 @@ func (g *Game) convertToClientUnits(message *HandMessage, outMsg *HandMessage) e
 	ma, _ := proto.Marshal(message)
 	proto.Unmarshal(ma, outMsg)
 
-	for _, msgItem := range outMsg.GetMessages() {
+	s2cMessageItems(outMsg.GetMessages())
+	return nil
+}
+
+// ClientUnitMessages returns a copy of the hand messages with the amounts
+// converted from the server units to the client units (chips).
+func ClientUnitMessages(msgItems []*HandMessageItem) []*HandMessageItem {
+	out := make([]*HandMessageItem, len(msgItems))
+	for i, msgItem := range msgItems {
+		out[i] = proto.Clone(msgItem).(*HandMessageItem)
+	}
+	s2cMessageItems(out)
+	return out
+}
+
+func s2cMessageItems(msgItems []*HandMessageItem) {
+	for _, msgItem := range msgItems {
 		msgType := msgItem.GetMessageType()
 		switch msgType {
 		case HandNewHand:
@@ -55,7 +71,6 @@ func (g *Game) convertToClientUnits(message *HandMessage, outMsg *HandMessage) e
 		default:
 		}
 	}
-	return nil
 }
 
 func s2cNoMoreActions(msgItem *HandMessageItem) {
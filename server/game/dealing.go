@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"voyager.com/logging"
+	"voyager.com/server/poker"
+)
+
+// The dealing policy of a hand (NewHandInfo.DealingPolicy) decides whether a
+// shuffled deck is dealt or thrown away for a new shuffle.
+//
+//   DEALING_PURE_RANDOM: the first shuffle is dealt. Every deal has the same
+//   probability.
+//   DEALING_CAPPED_RARE_HANDS: the deck is reshuffled (up to 10 times) when
+//   anyone would make a straight flush while StraightFlushAllowed is false, or
+//   a four of a kind while FourKindAllowed is false. Only the rare hands that
+//   are capped by the api server are affected.
+//   DEALING_LEGACY (default): the capped rare hands above, plus fewer four of a
+//   kinds in the first 20 hands of a game and fewer deals with the same hole
+//   cards or a paired board. This changes the distribution of the common hands
+//   too (e.g. pocket pairs), see the -test-dealing-policy simulation.
+//
+// Every deck that is thrown away is recorded in HandState.Reshuffles with the
+// reason and sent to the api server in the hand log.
+
+// Reasons of the reshuffles.
+const (
+	ReshuffleStraightFlushNotAllowed    = "STRAIGHT_FLUSH_NOT_ALLOWED"
+	ReshuffleFourOfAKindNotAllowed      = "FOUR_OF_A_KIND_NOT_ALLOWED"
+	ReshuffleEarlyHandFourOfAKind       = "EARLY_HAND_FOUR_OF_A_KIND"
+	ReshuffleFourOfAKind                = "FOUR_OF_A_KIND"
+	ReshuffleBoard2FullHouse            = "BOARD2_FULL_HOUSE"
+	ReshuffleSameHoleCardsOrPairedBoard = "SAME_HOLE_CARDS_OR_PAIRED_BOARD"
+)
+
+const maxRareHandReshuffles = 10
+
+// dealWithPolicy shuffles the deck and picks the cards following the dealing
+// policy of the hand. The decks and the reshuffles only depend on the shuffle
+// seeds and the hand, so the deal can be verified after the seeds are revealed.
+func (h *HandState) dealWithPolicy(newHandInfo *NewHandInfo) (map[uint32][]poker.Card, []poker.Card, []poker.Card, *poker.Deck, int) {
+	h.DealingPolicy = newHandInfo.DealingPolicy
+	h.StraightFlushAllowed = newHandInfo.StraightFlushAllowed
+	h.FourKindAllowed = newHandInfo.FourKindAllowed
+	h.Reshuffles = nil
+
+	if h.DealingPolicy == DealingPolicy_DEALING_PURE_RANDOM {
+		deck := h.shuffleDeck()
+		playerCardsMap, b1Cards, b2Cards, numCardsUsed := h.pickScriptedCardsFromDeck(poker.CopyDeck(deck), nil)
+		return playerCardsMap, b1Cards, b2Cards, deck, numCardsUsed
+	}
+
+	var deck *poker.Deck
+	var playerCardsMap map[uint32][]poker.Card
+	var b1Cards, b2Cards []poker.Card
+	var numCardsUsed int
+	for i := 0; ; i++ {
+		if h.DealingPolicy == DealingPolicy_DEALING_LEGACY {
+			playerCardsMap, b1Cards, b2Cards, deck, numCardsUsed = h.shuffleAndPickCards()
+		} else {
+			deck = h.shuffleDeck()
+			playerCardsMap, b1Cards, b2Cards, numCardsUsed = h.pickScriptedCardsFromDeck(poker.CopyDeck(deck), nil)
+		}
+		if i == maxRareHandReshuffles {
+			break
+		}
+		reason := rareHandReshuffleReason(newHandInfo, h.GameType, playerCardsMap, b1Cards, b2Cards)
+		if reason == "" {
+			break
+		}
+		handLogger.Debug().
+			Uint64(logging.GameIDKey, h.GetGameId()).
+			Uint32(logging.HandNumKey, h.GetHandNum()).
+			Msgf("Reshuffling for too many high hands. TotalHands=%d StraightFlushCount=%d FourKindCount=%d StraightFlushAllowed=%v FourKindAllowed=%v", newHandInfo.TotalHands, newHandInfo.StraightFlushCount, newHandInfo.FourKindCount, newHandInfo.StraightFlushAllowed, newHandInfo.FourKindAllowed)
+		h.addReshuffle(reason)
+	}
+	return playerCardsMap, b1Cards, b2Cards, deck, numCardsUsed
+}
+
+// rareHandReshuffleReason returns the reason to reshuffle when someone makes a
+// straight flush or a four of a kind that is not allowed. Returns an empty
+// string if the deal is fine.
+func rareHandReshuffleReason(newHandInfo *NewHandInfo, gameType GameType, playerCards map[uint32][]poker.Card, b1Cards []poker.Card, b2Cards []poker.Card) string {
+	if !newHandInfo.StraightFlushAllowed {
+		if AnyoneHasHighHand(playerCards, b1Cards, gameType, poker.MaxStraightFlush) ||
+			AnyoneHasHighHand(playerCards, b2Cards, gameType, poker.MaxStraightFlush) {
+			return ReshuffleStraightFlushNotAllowed
+		}
+		return ""
+	}
+
+	if !newHandInfo.FourKindAllowed {
+		if AnyoneHasHighHand(playerCards, b1Cards, gameType, poker.MaxFourOfAKind) ||
+			AnyoneHasHighHand(playerCards, b2Cards, gameType, poker.MaxFourOfAKind) {
+			return ReshuffleFourOfAKindNotAllowed
+		}
+	}
+	return ""
+}
+
+// addReshuffle records that the current deck is thrown away.
+func (h *HandState) addReshuffle(reason string) {
+	h.Reshuffles = append(h.Reshuffles, &Reshuffle{
+		Nonce:  h.ShuffleNonce,
+		Reason: reason,
+	})
+	handLogger.Debug().
+		Uint64(logging.GameIDKey, h.GetGameId()).
+		Uint32(logging.HandNumKey, h.GetHandNum()).
+		Msgf("Reshuffling the deck (nonce %d): %s", h.ShuffleNonce, reason)
+}
+
+// ParseDealingPolicy parses the name of a dealing policy. Accepts the enum
+// name (DEALING_PURE_RANDOM) or the short name (pure-random).
+func ParseDealingPolicy(name string) (DealingPolicy, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if !strings.HasPrefix(normalized, "DEALING_") {
+		normalized = "DEALING_" + normalized
+	}
+	policy, ok := DealingPolicy_value[normalized]
+	if !ok {
+		return DealingPolicy_DEALING_LEGACY, fmt.Errorf("Invalid dealing policy %s", name)
+	}
+	return DealingPolicy(policy), nil
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/poker"
+)
+
+func dealWithPolicyForTest(t *testing.T, policy DealingPolicy, numHands int) map[string]int {
+	reasons := make(map[string]int)
+	config := newEngineTestConfig()
+	config.DealingPolicy = policy
+	config.Rand = poker.NewSeededRand(11)
+	for i := 0; i < numHands; i++ {
+		config.HandNum = uint32(i%30 + 1)
+		h, _, err := DealHand(config, newEngineTestSeats(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, policy, h.DealingPolicy)
+
+		// every thrown away deck is a shuffle before the dealt deck
+		prevNonce := uint32(0)
+		for _, reshuffle := range h.Reshuffles {
+			assert.Greater(t, reshuffle.Nonce, prevNonce)
+			assert.Less(t, reshuffle.Nonce, h.ShuffleNonce)
+			prevNonce = reshuffle.Nonce
+			reasons[reshuffle.Reason]++
+		}
+		dealtDeck := poker.FairDeck(poker.ShuffleSeeds{
+			ServerSeed:  h.ServerSeed,
+			ClientSeeds: h.ClientSeeds,
+			Nonce:       h.ShuffleNonce,
+		})
+		assert.Equal(t, h.Deck, dealtDeck.GetBytes())
+		// the seeds decide the reshuffles, the same deal is verified from the reveal
+		require.NoError(t, VerifyHandShuffle(&HandResultClient{HandNum: h.HandNum, Shuffle: h.shuffleReveal()}, ""))
+
+		log := h.getLog()
+		assert.Equal(t, policy, log.DealingPolicy)
+		assert.Equal(t, h.Reshuffles, log.Reshuffles)
+	}
+	return reasons
+}
+
+func TestDealingPolicy(t *testing.T) {
+	reasons := dealWithPolicyForTest(t, DealingPolicy_DEALING_PURE_RANDOM, 300)
+	assert.Empty(t, reasons)
+
+	reasons = dealWithPolicyForTest(t, DealingPolicy_DEALING_CAPPED_RARE_HANDS, 300)
+	for reason := range reasons {
+		assert.Contains(t, []string{ReshuffleStraightFlushNotAllowed, ReshuffleFourOfAKindNotAllowed}, reason)
+	}
+
+	reasons = dealWithPolicyForTest(t, DealingPolicy_DEALING_LEGACY, 300)
+	assert.NotZero(t, reasons[ReshuffleSameHoleCardsOrPairedBoard])
+}
+
+func TestParseDealingPolicy(t *testing.T) {
+	policy, err := ParseDealingPolicy("pure-random")
+	require.NoError(t, err)
+	assert.Equal(t, DealingPolicy_DEALING_PURE_RANDOM, policy)
+	policy, err = ParseDealingPolicy("DEALING_CAPPED_RARE_HANDS")
+	require.NoError(t, err)
+	assert.Equal(t, DealingPolicy_DEALING_CAPPED_RARE_HANDS, policy)
+	policy, err = ParseDealingPolicy("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, DealingPolicy_DEALING_LEGACY, policy)
+	_, err = ParseDealingPolicy("rigged")
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"voyager.com/server/poker"
+)
+
+// The deck of a hand is NumDecks decks of the deck type shuffled together. The
+// hand state keeps the deck as card bytes (HandState.Deck). The standard cards
+// have the same bytes in every deck type and the jokers have their own byte,
+// so the saved hand states without a deck type are single standard decks.
+
+// deckDefinition returns the poker deck definition of the deck type.
+func deckDefinition(deckType DeckType, numDecks uint32) poker.DeckDefinition {
+	definition := poker.StandardDeck
+	switch deckType {
+	case DeckType_DECK_SHORT:
+		definition = poker.ShortDeck
+	case DeckType_DECK_JOKERS:
+		definition = poker.JokerDeck
+	}
+	definition.NumDecks = int(numDecks)
+	return definition
+}
+
+func (h *HandState) deckDefinition() poker.DeckDefinition {
+	return deckDefinition(h.DeckType, h.NumDecks)
+}
+
+// checkDeckSize makes sure the deck is valid and has enough cards for the player cards and
+// the boards of the hand.
+func (h *HandState) checkDeckSize() error {
+	definition := h.deckDefinition()
+	err := definition.Validate()
+	if err != nil {
+		return err
+	}
+	needed := h.activeSeatsCount() * int(numCards(h.GameType))
+	numBoards := 1
+	if h.DoubleBoard {
+		numBoards = 2
+	}
+	needed += 5 * numBoards
+	if h.BurnCards {
+		needed += 3 * numBoards
+	}
+	size := definition.Size()
+	if needed > size {
+		return fmt.Errorf("Deck %s with %d cards is too small for %d players. %d cards are needed", h.DeckType, size, h.activeSeatsCount(), needed)
+	}
+	return nil
+}
+
+// ParseDeckType parses the name of a deck type. Accepts the enum name
+// (DECK_SHORT) or the short name (short).
+func ParseDeckType(name string) (DeckType, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if !strings.HasPrefix(normalized, "DECK_") {
+		normalized = "DECK_" + normalized
+	}
+	deckType, ok := DeckType_value[normalized]
+	if !ok {
+		return DeckType_DECK_STANDARD, fmt.Errorf("Invalid deck type %s", name)
+	}
+	return DeckType(deckType), nil
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/poker"
+)
+
+func TestDealShortDeck(t *testing.T) {
+	config := newEngineTestConfig()
+	config.DeckType = DeckType_DECK_SHORT
+	h, _, err := DealHand(config, newEngineTestSeats(), nil)
+	require.NoError(t, err)
+	require.Len(t, h.Deck, 36)
+	for _, card := range h.Deck {
+		assert.True(t, card>>4 >= 4, "card %s", poker.NewCardFromByte(card))
+	}
+	for _, cards := range h.PlayersCards {
+		assert.Len(t, cards, 2)
+	}
+
+	// the shuffle can be verified with the deck type in the reveal
+	reveal := h.shuffleReveal()
+	assert.Equal(t, DeckType_DECK_SHORT, reveal.DeckType)
+	deck, err := poker.VerifyShuffle(poker.ShuffleSeeds{
+		ServerSeed:  h.ServerSeed,
+		ClientSeeds: h.ClientSeeds,
+		Nonce:       h.ShuffleNonce,
+		Deck:        deckDefinition(reveal.DeckType, reveal.NumDecks),
+	}, h.SeedCommitment)
+	require.NoError(t, err)
+	assert.Equal(t, h.Deck, deck)
+
+	// six card Omaha for 9 players needs more than 36 cards
+	config.GameType = GameType_SIX_CARD_PLO
+	var seats []SeatPlayer
+	for seatNo := uint32(1); seatNo <= 9; seatNo++ {
+		seats = append(seats, SeatPlayer{SeatNo: seatNo, PlayerID: 100 + uint64(seatNo), Stack: 10000, Status: PlayerStatus_PLAYING, Inhand: true})
+	}
+	_, _, err = DealHand(config, seats, nil)
+	assert.Error(t, err)
+
+	// two standard decks are enough
+	config.DeckType = DeckType_DECK_STANDARD
+	config.NumDecks = 2
+	h, _, err = DealHand(config, seats, nil)
+	require.NoError(t, err)
+	assert.Len(t, h.Deck, 104)
+}
+
+func TestDealJokers(t *testing.T) {
+	config := newEngineTestConfig()
+	config.DeckType = DeckType_DECK_JOKERS
+	// the jokers are the first two cards dealt
+	deck := poker.JokerDeck.Cards()
+	deck[0], deck[52] = deck[52], deck[0]
+	deck[1], deck[53] = deck[53], deck[1]
+	deckBytes := poker.CardsToByteCards(deck)
+	_, _, err := DealHand(newEngineTestConfig(), newEngineTestSeats(), deckBytes)
+	assert.Error(t, err)
+	h, _, err := DealHand(config, newEngineTestSeats(), deckBytes)
+	require.NoError(t, err)
+	jokers := 0
+	for _, cards := range h.PlayersCards {
+		for _, card := range cards {
+			if card == poker.JokerByte {
+				jokers++
+			}
+		}
+	}
+	assert.Equal(t, 2, jokers)
+
+	h, _, err = Apply(h, &HandAction{SeatNo: 1, Action: ACTION_CALL, Amount: 200})
+	require.NoError(t, err)
+	h, _, err = Apply(h, &HandAction{SeatNo: 5, Action: ACTION_CALL, Amount: 200})
+	require.NoError(t, err)
+	h, _, err = Apply(h, &HandAction{SeatNo: 8, Action: ACTION_CHECK})
+	require.NoError(t, err)
+	var msgItems []*HandMessageItem
+	for street := 0; street < 3; street++ {
+		for _, seatNo := range []uint32{5, 8, 1} {
+			h, msgItems, err = Apply(h, &HandAction{SeatNo: seatNo, Action: ACTION_CHECK})
+			require.NoError(t, err)
+		}
+	}
+	var result *HandResultClient
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType == HandResultMessage2 {
+			result = msgItem.GetHandResultClient()
+		}
+	}
+	require.NotNil(t, result)
+	// the wild cards play as the best cards for the board
+	require.NotEmpty(t, result.Boards)
+	require.Len(t, result.Boards[0].PlayerRank, 3)
+	for seatNo, rank := range result.Boards[0].PlayerRank {
+		assert.NotZero(t, rank.HiRank, "seat %d", seatNo)
+		assert.NotEmpty(t, rank.HiDescription.GetText(), "seat %d", seatNo)
+	}
+}
+
+func TestParseDeckType(t *testing.T) {
+	deckType, err := ParseDeckType("short")
+	require.NoError(t, err)
+	assert.Equal(t, DeckType_DECK_SHORT, deckType)
+	deckType, err = ParseDeckType("DECK_JOKERS")
+	require.NoError(t, err)
+	assert.Equal(t, DeckType_DECK_JOKERS, deckType)
+	_, err = ParseDeckType("pinochle")
+	assert.Error(t, err)
+
+	// the hand states saved before the deck types have a standard deck
+	assert.True(t, (&HandState{}).deckDefinition().IsStandard())
+}
+
+func TestShortDeckShowdownRanks(t *testing.T) {
+	cardBytes := func(cards ...string) []byte {
+		var bytes []byte
+		for _, card := range cards {
+			bytes = append(bytes, poker.NewCard(card).GetByte())
+		}
+		return bytes
+	}
+	board := cardBytes("Kh", "Ks", "8h", "9h", "6c")
+	flush := cardBytes("Ah", "Th")
+	fullHouse := cardBytes("Kd", "6d")
+
+	// a flush beats a full house in the short deck
+	h := &HandState{GameType: GameType_HOLDEM, DeckType: DeckType_DECK_SHORT}
+	evaluate := NewHoldemWinnerEvaluate(h, false, 9)
+	assert.Less(t, evaluate.Evaluate2(flush, board).rank, evaluate.Evaluate2(fullHouse, board).rank)
+	rank := getPlayerCardRank(GameType_HOLDEM, h.deckDefinition(), flush, board)
+	assert.Equal(t, "Flush", poker.RankString(h.deckDefinition().StandardRank(rank)))
+
+	h = &HandState{GameType: GameType_HOLDEM}
+	evaluate = NewHoldemWinnerEvaluate(h, false, 9)
+	assert.Greater(t, evaluate.Evaluate2(flush, board).rank, evaluate.Evaluate2(fullHouse, board).rank)
+
+	// A-6-7-8-9 is a straight in short deck Omaha
+	h = &HandState{GameType: GameType_PLO, DeckType: DeckType_DECK_SHORT}
+	plo := NewPloWinnerEvaluate(h, false, false, 9)
+	straight := plo.Evaluate2(cardBytes("As", "7d", "Qc", "Qd"), board)
+	trips := plo.Evaluate2(cardBytes("Kc", "Qs", "Jd", "Jc"), board)
+	assert.Less(t, straight.rank, trips.rank)
+	description := describePlayerHand(GameType_PLO, h.deckDefinition(), cardBytes("As", "7d", "Qc", "Qd"), board)
+	assert.Equal(t, "Straight, Nine high", description.Hi.Text)
+}
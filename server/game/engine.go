@@ -0,0 +1,410 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"voyager.com/server/poker"
+)
+
+// The hand engine runs a hand as a synchronous state machine. A hand state and a
+// player action go in, the updated hand state and the hand messages that the
+// action produced (player acted, flop, next action, result, ...) come out. There
+// are no timers, network, encryption or persistence involved, so simulators and
+// analytics can run hands in-process.
+//
+// The Game runs its hands through the same code and adds the action timers, card
+// rank encryption, persistence and api server calls around it.
+//
+// All amounts are in server units (cents), same as the hand state.
+
+// HandConfig is the table configuration for a hand dealt by the engine.
+type HandConfig struct {
+	GameID            uint64
+	HandNum           uint32
+	GameType          GameType
+	MaxPlayers        uint32
+	SmallBlind        float64
+	BigBlind          float64
+	Ante              float64
+	StraddleBet       float64
+	MandatoryStraddle bool
+	BringIn           float64
+	RakePercentage    float64
+	RakeCap           float64
+	ButtonPos         uint32
+	// SbPos and BbPos are optional. The blind positions are computed from the button position when not set.
+	SbPos             uint32
+	BbPos             uint32
+	ActionTime        uint32
+	RunItTwiceTimeout uint32
+	ResultPauseTime   uint32
+	BombPot           bool
+	BombPotBet        float64
+	DoubleBoard       bool
+	ChipUnit          ChipUnit
+	DealingPolicy     DealingPolicy
+	DeckType          DeckType
+	// NumDecks is the number of decks shuffled together. 1 if 0.
+	NumDecks uint32
+	// Tournament deals a tournament hand.
+	Tournament bool
+	// Rand is the random number generator for the server seeds of the shuffle.
+	// crypto/rand is used when not set.
+	Rand *rand.Rand
+	// ServerSeed is the server seed committed with the previous hand
+	// (HandState.NextServerSeed). A new seed is generated when not set.
+	ServerSeed []byte
+}
+
+func (c *HandConfig) newHandInfo(seats []SeatPlayer) *NewHandInfo {
+	return &NewHandInfo{
+		GameID:            c.GameID,
+		GameType:          c.GameType,
+		MaxPlayers:        c.MaxPlayers,
+		SmallBlind:        c.SmallBlind,
+		BigBlind:          c.BigBlind,
+		Ante:              c.Ante,
+		ButtonPos:         c.ButtonPos,
+		HandNum:           c.HandNum,
+		ActionTime:        c.ActionTime,
+		StraddleBet:       c.StraddleBet,
+		ChipUnit:          c.ChipUnit,
+		RakePercentage:    c.RakePercentage,
+		RakeCap:           c.RakeCap,
+		PlayersInSeats:    seats,
+		SbPos:             c.SbPos,
+		BbPos:             c.BbPos,
+		ResultPauseTime:   c.ResultPauseTime,
+		BombPot:           c.BombPot,
+		DoubleBoard:       c.DoubleBoard,
+		BombPotBet:        c.BombPotBet,
+		BringIn:           c.BringIn,
+		RunItTwiceTimeout: c.RunItTwiceTimeout,
+		MandatoryStraddle: c.MandatoryStraddle,
+		DealingPolicy:     c.DealingPolicy,
+		DeckType:          c.DeckType,
+		NumDecks:          c.NumDecks,
+		Tournament:        c.Tournament,
+	}
+}
+
+// DealHand deals a new hand to the players in the seats. Only the seats with
+// Inhand set are dealt in. The deck is the full deck of the deck type (52
+// cards for the standard deck) in the order the cards are dealt. Pass nil to
+// shuffle a new deck.
+//
+// Returns the hand state waiting for the first action and the messages for the
+// start of the hand (new hand, bomb pot flop, first player to act).
+func DealHand(config *HandConfig, seats []SeatPlayer, deck []byte) (*HandState, []*HandMessageItem, error) {
+	if deck != nil {
+		err := deckDefinition(config.DeckType, config.NumDecks).ValidateDeck(deck)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return dealHand(config, seats, nil, deck)
+}
+
+// DealScriptedHand deals a new hand with the cards arranged by a script test
+// hand setup.
+func DealScriptedHand(config *HandConfig, seats []SeatPlayer, setup *TestHandSetup) (*HandState, []*HandMessageItem, error) {
+	return dealHand(config, seats, setup, nil)
+}
+
+func dealHand(config *HandConfig, seats []SeatPlayer, setup *TestHandSetup, deck []byte) (*HandState, []*HandMessageItem, error) {
+	playersInSeats := make([]SeatPlayer, config.MaxPlayers+1) // 0 is dealer/observer
+	for _, seat := range seats {
+		if seat.SeatNo == 0 || seat.SeatNo > config.MaxPlayers {
+			return nil, nil, fmt.Errorf("Invalid seat number %d. Max players: %d", seat.SeatNo, config.MaxPlayers)
+		}
+		playersInSeats[seat.SeatNo] = seat
+	}
+	newHandInfo := config.newHandInfo(seats)
+
+	h := &HandState{
+		GameId:       config.GameID,
+		HandNum:      config.HandNum,
+		GameType:     config.GameType,
+		CurrentState: HandStatus_DEAL,
+		ServerSeed:   config.ServerSeed,
+	}
+	err := h.initialize(nil, newHandInfo, setup, config.ButtonPos, config.SbPos, config.BbPos, playersInSeats, config.ChipUnit, deck, config.Rand)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error while initializing hand state")
+	}
+	if h.NoActiveSeats < 2 {
+		return nil, nil, fmt.Errorf("Not enough active seats (%d) to deal", h.NoActiveSeats)
+	}
+	h.ResultPauseTime = config.ResultPauseTime
+
+	newHandMsg := h.newHandMessage()
+	_, msgItems, err := h.startHand()
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, append([]*HandMessageItem{newHandMsg}, msgItems...), nil
+}
+
+// Apply applies a player action (or a run-it-twice response) to the hand. The
+// given hand state is not modified. Returns the hand state after the action and
+// the messages that the action produced, starting with the player acted message.
+// The hand is over when the messages include the HandEnded message.
+func Apply(state *HandState, action *HandAction) (*HandState, []*HandMessageItem, error) {
+	h := proto.Clone(state).(*HandState)
+	action = proto.Clone(action).(*HandAction)
+
+	err := h.validateAction(action)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if h.RunItTwicePrompt {
+		msgItems, err := h.runItTwiceResponse(action)
+		if err != nil {
+			return nil, nil, err
+		}
+		return h, msgItems, nil
+	}
+
+	_, msgItems, err := h.applyAction(action, uint64(action.ActionTime))
+	if err != nil {
+		return nil, nil, err
+	}
+	actedMsg := &HandMessageItem{
+		MessageType: HandPlayerActed,
+		Content:     &HandMessageItem_PlayerActed{PlayerActed: action},
+	}
+	return h, append([]*HandMessageItem{actedMsg}, msgItems...), nil
+}
+
+func (h *HandState) validateAction(action *HandAction) error {
+	if h.RunItTwicePrompt {
+		if !(action.Action == ACTION_RUN_IT_TWICE_YES || action.Action == ACTION_RUN_IT_TWICE_NO) {
+			return fmt.Errorf("Unexpected action %s. Was expecting %v or %v", action.Action, ACTION_RUN_IT_TWICE_YES, ACTION_RUN_IT_TWICE_NO)
+		}
+		rit := h.GetRunItTwice()
+		if action.SeatNo == rit.Seat1 && !rit.Seat1Responded ||
+			action.SeatNo == rit.Seat2 && !rit.Seat2Responded {
+			return nil
+		}
+		return fmt.Errorf("Unexpected run-it-twice response from seat %d", action.SeatNo)
+	}
+
+	if h.NextSeatAction == nil || h.CurrentState == HandStatus_SHOW_DOWN || h.CurrentState == HandStatus_RESULT {
+		return fmt.Errorf("Invalid action. There is no next action")
+	}
+	if action.SeatNo != h.NextSeatAction.SeatNo {
+		return fmt.Errorf("Invalid seat made action. The next valid action seat is: %d", h.NextSeatAction.SeatNo)
+	}
+	return nil
+}
+
+// newHandMessage returns the new hand message that tells the players about the
+// table setup of the hand (button, blinds, stacks, first seat to act). The
+// player cards are not included.
+func (h *HandState) newHandMessage() *HandMessageItem {
+	playersActed := make(map[uint32]*PlayerActRound)
+	for seatNo, action := range h.PlayersActed {
+		if action.Action == ACTION_EMPTY_SEAT {
+			continue
+		}
+		playersActed[uint32(seatNo)] = action
+	}
+	bettingState := h.RoundState[uint32(h.CurrentState)]
+	currentBettingRound := bettingState.Betting
+
+	handPlayerInSeats := make(map[uint32]*PlayerInSeatState)
+	for _, playerInSeat := range h.PlayersInSeats {
+		copiedState := &PlayerInSeatState{
+			SeatNo:            playerInSeat.SeatNo,
+			Status:            playerInSeat.Status,
+			Stack:             playerInSeat.Stack,
+			PlayerId:          playerInSeat.PlayerId,
+			Name:              playerInSeat.Name,
+			BuyInExpTime:      playerInSeat.BuyInExpTime,
+			BreakExpTime:      playerInSeat.BreakExpTime,
+			Inhand:            playerInSeat.Inhand,
+			RunItTwice:        playerInSeat.RunItTwice,
+			MissedBlind:       playerInSeat.MissedBlind,
+			ButtonStraddle:    playerInSeat.ButtonStraddle,
+			MuckLosingHand:    playerInSeat.MuckLosingHand,
+			AutoStraddle:      playerInSeat.AutoStraddle,
+			ButtonStraddleBet: playerInSeat.ButtonStraddleBet,
+		}
+		handPlayerInSeats[playerInSeat.SeatNo] = copiedState
+		handPlayerInSeats[playerInSeat.SeatNo].Stack = playerInSeat.Stack - currentBettingRound.SeatBet[playerInSeat.SeatNo]
+	}
+
+	var nextSeatNo uint32
+	if h.NextSeatAction != nil {
+		nextSeatNo = h.NextSeatAction.SeatNo
+	}
+	potUpdates := float64(0)
+	pots := make([]float64, 0)
+
+	currentRoundState, ok := h.RoundState[uint32(h.CurrentState)]
+	if ok {
+		for _, bet := range currentRoundState.Betting.SeatBet {
+			potUpdates = potUpdates + bet
+		}
+	}
+
+	newHand := &NewHand{
+		HandNum:        h.HandNum,
+		ButtonPos:      h.ButtonPos,
+		SbPos:          h.SmallBlindPos,
+		BbPos:          h.BigBlindPos,
+		NextActionSeat: nextSeatNo,
+		NoCards:        numCards(h.GameType),
+		GameType:       h.GameType,
+		SmallBlind:     h.SmallBlind,
+		BigBlind:       h.BigBlind,
+		BringIn:        h.BringIn,
+		Straddle:       h.Straddle,
+		Ante:           h.Ante,
+		PlayersInSeats: handPlayerInSeats,
+		PlayersActed:   playersActed,
+		BombPot:        h.BombPot,
+		BombPotBet:     h.BombPotBet,
+		DoubleBoard:    h.DoubleBoard,
+		PotUpdates:     potUpdates,
+		Pots:           pots,
+		SeedCommitment: h.SeedCommitment,
+	}
+	if h.NextServerSeed != nil {
+		newHand.NextSeedCommitment = poker.SeedCommitment(h.NextServerSeed)
+	}
+	return &HandMessageItem{
+		MessageType: HandNewHand,
+		Content:     &HandMessageItem_NewHand{NewHand: newHand},
+	}
+}
+
+// startHand returns the messages for the start of a freshly dealt hand.
+func (h *HandState) startHand() (handStep, []*HandMessageItem, error) {
+	allMsgItems := make([]*HandMessageItem, 0)
+	if h.BombPot {
+		bombPotMessage := &HandMessageItem{
+			MessageType: HandBombPot,
+		}
+		allMsgItems = append(allMsgItems, bombPotMessage)
+		msgItem, err := h.gotoFlop()
+		if err != nil {
+			return stepNextAction, nil, err
+		}
+		allMsgItems = append(allMsgItems, msgItem)
+	}
+
+	if h.allActionComplete() {
+		msgItems, err := h.allPlayersAllIn()
+		if err != nil {
+			return stepAllPlayersAllIn, nil, err
+		}
+		return stepAllPlayersAllIn, append(allMsgItems, msgItems...), nil
+	}
+
+	if h.NextSeatAction == nil {
+		return stepNextAction, nil, fmt.Errorf("NextSeatAction is nil when dealing new hand")
+	}
+	msgItems, err := h.moveToNextAction()
+	if err != nil {
+		return stepNextAction, nil, err
+	}
+	return stepNextAction, append(allMsgItems, msgItems...), nil
+}
+
+// applyAction updates the hand with the player action and moves the hand
+// forward. The action is updated with the final amount and the stack after the
+// action. Returns the messages for what comes after the action.
+func (h *HandState) applyAction(action *HandAction, actionResponseTime uint64) (handStep, []*HandMessageItem, error) {
+	seatNo := action.SeatNo
+	handStage := h.CurrentState
+
+	err := h.actionReceived(action, actionResponseTime)
+	if err != nil {
+		return stepNextAction, nil, errors.Wrap(err, "Could not update hand state from action")
+	}
+
+	playerAction := h.PlayersActed[seatNo]
+	bettingState := h.RoundState[uint32(handStage)]
+	potUpdates := float64(0)
+	for _, pot := range h.Pots {
+		potUpdates += pot.Pot
+	}
+
+	action.Stack = bettingState.PlayerBalance[seatNo]
+	action.PotUpdates = potUpdates
+	if playerAction.Action != ACTION_FOLD {
+		action.Amount = playerAction.Amount
+	} else {
+		// the game folded this guy's hand
+		action.Action = ACTION_FOLD
+		action.Amount = 0
+	}
+	h.updateTimeoutStats(seatNo, action.TimedOut)
+
+	// This number is used to generate hand message IDs uniquely and deterministically across the server crashes.
+	h.CurrentActionNum++
+
+	var msgItems []*HandMessageItem
+	step := h.nextStep(playerAction)
+	switch step {
+	case stepOnePlayerRemaining:
+		msgItems = h.onePlayerRemaining()
+	case stepRunItTwicePrompt:
+		msgItems = h.runItTwicePrompt()
+	case stepAllPlayersAllIn:
+		msgItems, err = h.allPlayersAllIn()
+	case stepShowdown:
+		msgItems = h.showdown()
+	case stepNextRound:
+		msgItems, err = h.moveToNextRound()
+	default:
+		msgItems, err = h.moveToNextAction()
+	}
+	if err != nil {
+		return step, nil, err
+	}
+	return step, msgItems, nil
+}
+
+func (h *HandState) updateTimeoutStats(seatNo uint32, timedOut bool) {
+	player := h.PlayersInSeats[seatNo]
+	timeoutStats := h.TimeoutStats[player.PlayerId]
+	if timeoutStats == nil {
+		return
+	}
+	if timedOut {
+		timeoutStats.ConsecutiveActionTimeouts++
+	} else {
+		timeoutStats.ConsecutiveActionTimeouts = 0
+
+		// When the consecutive timeout counts get reported to the api server,
+		// the api server needs to know if the player has acted at all this hand
+		// so that it can clear the count from the previous hand and start a new counter
+		// instead of adding to it.
+		timeoutStats.ActedAtLeastOnce = true
+	}
+}
+
+// flowStateAfter returns the flow state the game moves to after a hand step.
+func flowStateAfter(step handStep) FlowState {
+	switch step {
+	case stepOnePlayerRemaining, stepAllPlayersAllIn, stepShowdown:
+		return FlowState_MOVE_TO_NEXT_HAND
+	}
+	return FlowState_WAIT_FOR_NEXT_ACTION
+}
+
+// handEnded returns true if the messages include the hand ended message.
+func handEnded(msgItems []*HandMessageItem) bool {
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType == HandEnded {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"voyager.com/server/poker"
+)
+
+func newEngineTestConfig() *HandConfig {
+	return &HandConfig{
+		GameID:     1,
+		HandNum:    1,
+		GameType:   GameType_HOLDEM,
+		MaxPlayers: 9,
+		SmallBlind: 100,
+		BigBlind:   200,
+		ButtonPos:  1,
+		ActionTime: 30,
+		ChipUnit:   ChipUnit_DOLLAR,
+	}
+}
+
+func newEngineTestSeats() []SeatPlayer {
+	return []SeatPlayer{
+		{SeatNo: 1, PlayerID: 101, Name: "a", Stack: 10000, Status: PlayerStatus_PLAYING, Inhand: true},
+		{SeatNo: 5, PlayerID: 102, Name: "b", Stack: 10000, Status: PlayerStatus_PLAYING, Inhand: true},
+		{SeatNo: 8, PlayerID: 103, Name: "c", Stack: 10000, Status: PlayerStatus_PLAYING, Inhand: true},
+	}
+}
+
+func messageTypes(msgItems []*HandMessageItem) []string {
+	types := make([]string, len(msgItems))
+	for i, msgItem := range msgItems {
+		types[i] = msgItem.MessageType
+	}
+	return types
+}
+
+func TestDealHand(t *testing.T) {
+	deck := poker.NewDeck().Shuffle().GetBytes()
+	h, msgItems, err := DealHand(newEngineTestConfig(), newEngineTestSeats(), deck)
+	require.NoError(t, err)
+	assert.Equal(t, []string{HandNewHand, HandYourAction, HandNextAction}, messageTypes(msgItems))
+	assert.Equal(t, deck, h.Deck)
+	assert.Equal(t, HandStatus_PREFLOP, h.CurrentState)
+	assert.Equal(t, uint32(5), h.SmallBlindPos)
+	assert.Equal(t, uint32(8), h.BigBlindPos)
+	assert.Equal(t, uint32(1), h.NextSeatAction.SeatNo)
+
+	// The same deck deals the same cards.
+	h2, _, err := DealHand(newEngineTestConfig(), newEngineTestSeats(), deck)
+	require.NoError(t, err)
+	assert.Equal(t, h.PlayersCards, h2.PlayersCards)
+	assert.Equal(t, h.BoardCards, h2.BoardCards)
+
+	_, _, err = DealHand(newEngineTestConfig(), newEngineTestSeats(), deck[1:])
+	assert.Error(t, err)
+	badDeck := append([]byte{}, deck...)
+	badDeck[0] = badDeck[1]
+	_, _, err = DealHand(newEngineTestConfig(), newEngineTestSeats(), badDeck)
+	assert.Error(t, err)
+
+	// A seeded generator shuffles the same deck.
+	config := newEngineTestConfig()
+	config.Rand = poker.NewSeededRand(7)
+	h, _, err = DealHand(config, newEngineTestSeats(), nil)
+	require.NoError(t, err)
+	config.Rand = poker.NewSeededRand(7)
+	h2, _, err = DealHand(config, newEngineTestSeats(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, h.ServerSeed, h2.ServerSeed)
+	assert.Equal(t, h.Deck, h2.Deck)
+	assert.Equal(t, h.PlayersCards, h2.PlayersCards)
+
+	seats := newEngineTestSeats()
+	seats[0].SeatNo = 10
+	_, _, err = DealHand(newEngineTestConfig(), seats, nil)
+	assert.Error(t, err)
+}
+
+func TestApply(t *testing.T) {
+	setup := &TestHandSetup{
+		Flop:  []string{"Ac", "Ad", "2c"},
+		Turn:  "Td",
+		River: "4s",
+		PlayerCards: []*GameSetupSeatCards{
+			{Cards: []string{"Kh", "Qd"}},
+			{Cards: []string{"3s", "7s"}},
+			{Cards: []string{"9h", "2s"}},
+		},
+	}
+	dealt, _, err := DealScriptedHand(newEngineTestConfig(), newEngineTestSeats(), setup)
+	require.NoError(t, err)
+
+	// Out of turn.
+	_, _, err = Apply(dealt, &HandAction{SeatNo: 5, Action: ACTION_FOLD})
+	assert.Error(t, err)
+
+	// The given state is not modified.
+	before := proto.Clone(dealt)
+	h, msgItems, err := Apply(dealt, &HandAction{SeatNo: 1, Action: ACTION_CALL, Amount: 200})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(before, dealt))
+	assert.Equal(t, HandPlayerActed, msgItems[0].MessageType)
+	assert.Equal(t, float64(9800), msgItems[0].GetPlayerActed().Stack)
+	assert.Equal(t, uint32(5), h.NextSeatAction.SeatNo)
+
+	h, _, err = Apply(h, &HandAction{SeatNo: 5, Action: ACTION_FOLD})
+	require.NoError(t, err)
+	h, msgItems, err = Apply(h, &HandAction{SeatNo: 8, Action: ACTION_CHECK})
+	require.NoError(t, err)
+	assert.Contains(t, messageTypes(msgItems), HandFlop)
+	assert.Equal(t, HandStatus_FLOP, h.CurrentState)
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType != HandFlop {
+			continue
+		}
+		flop := msgItem.GetFlop()
+		assert.Equal(t, "Pair", flop.PlayerCardRanks[8])
+		var descriptions PlayerHandDescriptions
+		require.NoError(t, protojson.Unmarshal([]byte(flop.PlayerHandDescriptions[8]), &descriptions))
+		require.Len(t, descriptions.Boards, 1)
+		assert.Equal(t, "PAIR", descriptions.Boards[0].Hi.Type)
+		assert.Equal(t, []string{"A"}, descriptions.Boards[0].Hi.Params)
+		assert.Equal(t, []string{"7", "3", "2"}, descriptions.Boards[0].Hi.Kickers)
+		assert.Equal(t, "Pair of Aces, Seven-Three-Two kickers", descriptions.Boards[0].Hi.Text)
+		assert.Nil(t, descriptions.Boards[0].Lo)
+	}
+
+	h, _, err = Apply(h, &HandAction{SeatNo: 8, Action: ACTION_BET, Amount: 400})
+	require.NoError(t, err)
+	h, msgItems, err = Apply(h, &HandAction{SeatNo: 1, Action: ACTION_FOLD})
+	require.NoError(t, err)
+	require.True(t, handEnded(msgItems))
+
+	var result *HandResultClient
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType == HandResultMessage2 {
+			result = msgItem.GetHandResultClient()
+		}
+	}
+	require.NotNil(t, result)
+	assert.Equal(t, HandStatus_FLOP, result.WonAt)
+	assert.Equal(t, float64(10300), result.PlayerInfo[8].Balance.After)
+
+	// No more actions after the hand is over.
+	_, _, err = Apply(h, &HandAction{SeatNo: 8, Action: ACTION_CHECK})
+	assert.Error(t, err)
+
+	// A hand state without the next action is an error, not a panic.
+	h = proto.Clone(dealt).(*HandState)
+	h.NextSeatAction = nil
+	_, err = h.moveToNextAction()
+	assert.Error(t, err)
+}
+
+func TestDescribePlayerHand(t *testing.T) {
+	cards, err := poker.ParseCards("Ad2h8s6c")
+	require.NoError(t, err)
+	playerCards := poker.CardsToByteCards(cards)
+	board, err := poker.ParseCards("4c3d7hKcQd")
+	require.NoError(t, err)
+	boardCards := poker.CardsToByteCards(board)
+
+	description := describePlayerHand(GameType_PLO_HILO, poker.StandardDeck, playerCards, boardCards)
+	require.NotNil(t, description)
+	assert.Equal(t, "High Card, Ace, King-Queen-Eight-Seven kickers", description.Hi.Text)
+	require.NotNil(t, description.Lo)
+	assert.Equal(t, "7-4 low", description.Lo.Text)
+	assert.Equal(t, []string{"7", "4", "3", "2", "A"}, description.Lo.Params)
+
+	// no low in the high only game
+	description = describePlayerHand(GameType_PLO, poker.StandardDeck, playerCards, boardCards)
+	require.NotNil(t, description)
+	assert.Nil(t, description.Lo)
+}
@@ -113,7 +113,7 @@ func AnyoneHasHighHand(playerCards map[uint32][]poker.Card, board []poker.Card,
 			cards = append(cards, board...)
 			rank, _ = poker.Evaluate(cards)
 		} else {
-			result := poker.EvaluateOmaha(pc, board)
+			result := poker.EvaluateOmahaHand(pc, board)
 			rank = result.HiRank
 		}
 		if rank <= int32(maxRank) {
@@ -204,6 +204,6 @@ func PairedAt(board []poker.Card) int {
 	return pairedAtIdx
 }
 
-func QuickShuffleCards(cards []poker.Card) {
-	rand.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+func QuickShuffleCards(rng *rand.Rand, cards []poker.Card) {
+	rng.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
 }
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"voyager.com/server/poker"
+)
+
+// VerifyHandShuffle checks the provably fair shuffle of a published hand. The
+// server seed revealed in the result is checked against the commitment that
+// was published with the previous hand (NewHand.NextSeedCommitment; the
+// commitment in the result is used if commitment is empty). The hand is then
+// dealt again from the seeds with the dealing policy of the hand: the decks
+// thrown away and the dealt deck must match the reveal, and every player card
+// and board card in the result must be the card dealt at its position.
+func VerifyHandShuffle(result *HandResultClient, commitment string) error {
+	reveal := result.GetShuffle()
+	if reveal == nil {
+		return fmt.Errorf("Hand %d does not have the shuffle seeds", result.HandNum)
+	}
+	if commitment == "" {
+		commitment = reveal.Commitment
+	} else if commitment != reveal.Commitment {
+		return fmt.Errorf("Commitment %s in the result does not match the published commitment %s", reveal.Commitment, commitment)
+	}
+
+	serverSeed, err := hex.DecodeString(reveal.ServerSeed)
+	if err != nil {
+		return errors.Wrap(err, "Invalid server seed")
+	}
+	_, err = poker.VerifyShuffle(poker.ShuffleSeeds{
+		ServerSeed:  serverSeed,
+		ClientSeeds: reveal.ClientSeeds,
+		Nonce:       reveal.Nonce,
+		Deck:        deckDefinition(reveal.DeckType, reveal.NumDecks),
+	}, commitment)
+	if err != nil {
+		return err
+	}
+
+	h, err := revealedHand(result.HandNum, reveal, serverSeed)
+	if err != nil {
+		return err
+	}
+	playerCards, b1Cards, b2Cards, deck, numCardsUsed := h.dealWithPolicy(&NewHandInfo{
+		DealingPolicy:        reveal.DealingPolicy,
+		StraightFlushAllowed: reveal.StraightFlushAllowed,
+		FourKindAllowed:      reveal.FourKindAllowed,
+	})
+	if h.ShuffleNonce != reveal.Nonce {
+		return fmt.Errorf("Nonce %d is dealt. The dealing policy deals nonce %d", reveal.Nonce, h.ShuffleNonce)
+	}
+	if len(h.Reshuffles) != len(reveal.Reshuffles) {
+		return fmt.Errorf("%d reshuffles are revealed. The dealing policy reshuffles %d times", len(reveal.Reshuffles), len(h.Reshuffles))
+	}
+	for i, reshuffle := range h.Reshuffles {
+		if reshuffle.Nonce != reveal.Reshuffles[i].Nonce || reshuffle.Reason != reveal.Reshuffles[i].Reason {
+			return fmt.Errorf("Reshuffle %d (nonce %d: %s) does not match the dealing policy (nonce %d: %s)",
+				i+1, reveal.Reshuffles[i].Nonce, reveal.Reshuffles[i].Reason, reshuffle.Nonce, reshuffle.Reason)
+		}
+	}
+
+	boards := [][]uint32{poker.ByteCardsToUint32Cards(poker.CardsToByteCards(b1Cards))}
+	if reveal.DoubleBoard {
+		boards = append(boards, poker.ByteCardsToUint32Cards(poker.CardsToByteCards(b2Cards)))
+	}
+	if reveal.RunItTwiceStage != HandStatus_HandStatus_UNKNOWN {
+		// the second board is dealt from the cards after the first board
+		h.Deck = deck.GetBytes()
+		h.DeckIndex = uint32(numCardsUsed)
+		h.BoardCards = poker.CardsToByteCards(b1Cards)
+		h.RunItTwice = &RunItTwice{Stage: reveal.RunItTwiceStage}
+		h.dealRunItTwiceBoard()
+		numCardsUsed = int(h.DeckIndex)
+		boards = append(boards, poker.ByteCardsToUint32Cards(h.BoardCards_2))
+	}
+	if uint32(numCardsUsed) != reveal.CardsUsed {
+		return fmt.Errorf("Cards used %d does not match the %d cards dealt", reveal.CardsUsed, numCardsUsed)
+	}
+
+	for _, board := range result.Boards {
+		if board.BoardNo == 0 || int(board.BoardNo) > len(boards) {
+			return fmt.Errorf("Board %d is not dealt", board.BoardNo)
+		}
+		dealt := boards[board.BoardNo-1]
+		for i, card := range board.Cards {
+			if i >= len(dealt) || dealt[i] != card {
+				return fmt.Errorf("Board %d card %d %s is not the card dealt from the deck", board.BoardNo, i+1, poker.CardToString(card))
+			}
+		}
+	}
+	for seatNo, playerInfo := range result.PlayerInfo {
+		dealt := playerCards[seatNo]
+		for i, card := range playerInfo.Cards {
+			if i >= len(dealt) || uint32(dealt[i].GetByte()) != card {
+				return fmt.Errorf("Seat %d card %d %s is not the card dealt from the deck", seatNo, i+1, poker.CardToString(card))
+			}
+		}
+	}
+	return nil
+}
+
+// revealedHand returns the hand state at the deal of a revealed hand.
+func revealedHand(handNum uint32, reveal *ShuffleReveal, serverSeed []byte) (*HandState, error) {
+	if reveal.MaxSeats == 0 || reveal.ButtonPos > reveal.MaxSeats {
+		return nil, fmt.Errorf("Invalid button position %d for %d seats", reveal.ButtonPos, reveal.MaxSeats)
+	}
+	if len(reveal.DealtSeats) != len(reveal.ClientSeeds) {
+		return nil, fmt.Errorf("%d seats are dealt with %d client seeds", len(reveal.DealtSeats), len(reveal.ClientSeeds))
+	}
+	h := &HandState{
+		HandNum:        handNum,
+		GameType:       reveal.GameType,
+		MaxSeats:       reveal.MaxSeats,
+		ButtonPos:      reveal.ButtonPos,
+		BurnCards:      reveal.BurnCards,
+		DoubleBoard:    reveal.DoubleBoard,
+		DeckType:       reveal.DeckType,
+		NumDecks:       reveal.NumDecks,
+		ServerSeed:     serverSeed,
+		ClientSeeds:    reveal.ClientSeeds,
+		PlayersInSeats: make([]*PlayerInSeatState, reveal.MaxSeats+1),
+		ActiveSeats:    make([]uint64, reveal.MaxSeats+1),
+		PlayersActed:   make([]*PlayerActRound, reveal.MaxSeats+1),
+	}
+	for seatNo := range h.PlayersInSeats {
+		h.PlayersInSeats[seatNo] = &PlayerInSeatState{SeatNo: uint32(seatNo)}
+		h.PlayersActed[seatNo] = &PlayerActRound{Action: ACTION_NOT_ACTED}
+	}
+	for _, seatNo := range reveal.DealtSeats {
+		if seatNo == 0 || seatNo > reveal.MaxSeats || h.ActiveSeats[seatNo] != 0 {
+			return nil, fmt.Errorf("Invalid dealt seat %d", seatNo)
+		}
+		h.PlayersInSeats[seatNo].Inhand = true
+		h.ActiveSeats[seatNo] = uint64(seatNo)
+	}
+	err := h.checkDeckSize()
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// ParseHandResult parses the JSON of a hand result. It can be the result sent
+// to the players (HandResultClient) or the result sent to the api server
+// (HandResultServer).
+func ParseHandResult(data []byte) (*HandResultClient, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	unmarshaller := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if _, ok := fields["result"]; ok {
+		handResult := &HandResultServer{}
+		err = unmarshaller.Unmarshal(data, handResult)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid hand result")
+		}
+		if handResult.Result == nil {
+			return nil, fmt.Errorf("Hand result does not have the result")
+		}
+		return handResult.Result, nil
+	}
+	result := &HandResultClient{}
+	err = unmarshaller.Unmarshal(data, result)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid hand result")
+	}
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"voyager.com/server/poker"
+)
+
+func dealtResultForTest(t *testing.T, h *HandState, actions []*HandAction) *HandResultClient {
+	var msgItems []*HandMessageItem
+	var err error
+	for _, action := range actions {
+		h, msgItems, err = Apply(h, action)
+		require.NoError(t, err)
+	}
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType == HandResultMessage2 {
+			result := msgItem.GetHandResultClient()
+			require.NotNil(t, result.Shuffle)
+			return result
+		}
+	}
+	require.FailNow(t, "hand did not end")
+	return nil
+}
+
+func TestVerifyHandShuffle(t *testing.T) {
+	seats := newEngineTestSeats()
+	seats[0].ClientSeed = "a-seed"
+	seats[2].ClientSeed = "c-seed"
+	h, msgItems, err := DealHand(newEngineTestConfig(), seats, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a-seed", "", "c-seed"}, h.ClientSeeds)
+	newHand := msgItems[0].GetNewHand()
+	commitment := newHand.SeedCommitment
+	assert.Equal(t, poker.SeedCommitment(h.ServerSeed), commitment)
+	assert.Equal(t, poker.FairDeck(poker.ShuffleSeeds{
+		ServerSeed:  h.ServerSeed,
+		ClientSeeds: h.ClientSeeds,
+		Nonce:       h.ShuffleNonce,
+	}).GetBytes(), h.Deck)
+
+	// The server seed of the next hand is committed in this hand.
+	require.Len(t, h.NextServerSeed, poker.ServerSeedLen)
+	assert.Equal(t, poker.SeedCommitment(h.NextServerSeed), newHand.NextSeedCommitment)
+	config := newEngineTestConfig()
+	config.HandNum = 2
+	config.ServerSeed = h.NextServerSeed
+	h2, msgItems, err := DealHand(config, seats, nil)
+	require.NoError(t, err)
+	assert.Equal(t, newHand.NextSeedCommitment, msgItems[0].GetNewHand().SeedCommitment)
+	assert.NotEqual(t, h.NextServerSeed, h2.NextServerSeed)
+
+	result := dealtResultForTest(t, h, []*HandAction{
+		{SeatNo: 1, Action: ACTION_CALL, Amount: 200},
+		{SeatNo: 5, Action: ACTION_CALL, Amount: 200},
+		{SeatNo: 8, Action: ACTION_CHECK},
+		{SeatNo: 5, Action: ACTION_CHECK},
+		{SeatNo: 8, Action: ACTION_CHECK},
+		{SeatNo: 1, Action: ACTION_BET, Amount: 400},
+		{SeatNo: 5, Action: ACTION_FOLD},
+		{SeatNo: 8, Action: ACTION_FOLD},
+	})
+	require.NoError(t, VerifyHandShuffle(result, commitment))
+	assert.Equal(t, []uint32{1, 5, 8}, result.Shuffle.DealtSeats)
+
+	// The result can be verified from its JSON.
+	data, err := protojson.Marshal(result)
+	require.NoError(t, err)
+	parsed, err := ParseHandResult(data)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyHandShuffle(parsed, ""))
+	data, err = protojson.Marshal(&HandResultServer{HandNum: result.HandNum, Result: result})
+	require.NoError(t, err)
+	parsed, err = ParseHandResult(data)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyHandShuffle(parsed, commitment))
+
+	tamper := func(change func(result *HandResultClient)) error {
+		tampered := proto.Clone(result).(*HandResultClient)
+		change(tampered)
+		return VerifyHandShuffle(tampered, commitment)
+	}
+	// a different server seed
+	assert.Error(t, VerifyHandShuffle(result, poker.SeedCommitment(h.NextServerSeed)))
+	// a nonce the dealing policy did not deal
+	assert.Error(t, tamper(func(result *HandResultClient) { result.Shuffle.Nonce++ }))
+	assert.Error(t, tamper(func(result *HandResultClient) {
+		result.Shuffle.Nonce++
+		result.Shuffle.Reshuffles = []*Reshuffle{{Nonce: 1, Reason: ReshuffleFourOfAKind}}
+	}))
+	// cards dealt from the deck, but not at their positions
+	assert.Error(t, tamper(func(result *HandResultClient) {
+		cards1, cards5 := result.PlayerInfo[1].Cards, result.PlayerInfo[5].Cards
+		cards1[0], cards5[0] = cards5[0], cards1[0]
+	}))
+	assert.Error(t, tamper(func(result *HandResultClient) {
+		cards := result.Boards[0].Cards
+		cards[0], cards[4] = cards[4], cards[0]
+	}))
+	assert.Error(t, tamper(func(result *HandResultClient) { result.Boards[0].Cards[0] = uint32(h.Deck[len(h.Deck)-1]) }))
+	assert.Error(t, tamper(func(result *HandResultClient) { result.Shuffle.ButtonPos = 5 }))
+	assert.Error(t, tamper(func(result *HandResultClient) { result.Shuffle.DealtSeats = []uint32{1, 5, 9} }))
+	assert.Error(t, tamper(func(result *HandResultClient) { result.Shuffle.CardsUsed++ }))
+
+	// Scripted hands don't have the seeds.
+	h, _, err = DealHand(newEngineTestConfig(), newEngineTestSeats(), poker.NewDeck().GetBytes())
+	require.NoError(t, err)
+	assert.Empty(t, h.SeedCommitment)
+	assert.Nil(t, h.shuffleReveal())
+}
+
+func TestVerifyHandShuffleBurnCards(t *testing.T) {
+	h, _, err := DealHand(newEngineTestConfig(), newEngineTestSeats(), nil)
+	require.NoError(t, err)
+	reveal := h.shuffleReveal()
+	reveal.BurnCards = true
+	burnt, err := revealedHand(h.HandNum, reveal, h.ServerSeed)
+	require.NoError(t, err)
+	playerCards, board, _, deck, numCardsUsed := burnt.dealWithPolicy(&NewHandInfo{DealingPolicy: reveal.DealingPolicy})
+	reveal.Nonce = burnt.ShuffleNonce
+	reveal.Reshuffles = burnt.Reshuffles
+	reveal.CardsUsed = uint32(numCardsUsed)
+	result := &HandResultClient{
+		HandNum:    h.HandNum,
+		Boards:     []*Board{{BoardNo: 1, Cards: poker.ByteCardsToUint32Cards(poker.CardsToByteCards(board))}},
+		PlayerInfo: map[uint32]*PlayerHandInfo{},
+		Shuffle:    reveal,
+	}
+	for seatNo, cards := range playerCards {
+		result.PlayerInfo[seatNo] = &PlayerHandInfo{Cards: poker.ByteCardsToUint32Cards(poker.CardsToByteCards(cards))}
+	}
+	require.NoError(t, VerifyHandShuffle(result, ""))
+	// the first card of the flop is the card after the burn card
+	assert.Equal(t, uint32(deck.GetBytes()[7]), result.Boards[0].Cards[0])
+
+	// the board without the burn cards is not at the dealt positions
+	result.Boards[0].Cards = poker.ByteCardsToUint32Cards(h.BoardCards)
+	assert.Error(t, VerifyHandShuffle(result, ""))
+}
+
+func TestVerifyHandShuffleRunItTwice(t *testing.T) {
+	seats := newEngineTestSeats()
+	for i := range seats {
+		seats[i].RunItTwice = true
+	}
+	h, _, err := DealHand(newEngineTestConfig(), seats, nil)
+	require.NoError(t, err)
+	result := dealtResultForTest(t, h, []*HandAction{
+		{SeatNo: 1, Action: ACTION_ALLIN, Amount: 10000},
+		{SeatNo: 5, Action: ACTION_FOLD},
+		{SeatNo: 8, Action: ACTION_CALL, Amount: 10000},
+		{SeatNo: 1, Action: ACTION_RUN_IT_TWICE_YES},
+		{SeatNo: 8, Action: ACTION_RUN_IT_TWICE_YES},
+	})
+	require.Len(t, result.Boards, 2)
+	assert.Equal(t, HandStatus_PREFLOP, result.Shuffle.RunItTwiceStage)
+	require.NoError(t, VerifyHandShuffle(result, ""))
+
+	board2 := result.Boards[1].Cards
+	board2[0], board2[1] = board2[1], board2[0]
+	assert.Error(t, VerifyHandShuffle(result, ""))
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
@@ -73,6 +75,16 @@ type Game struct {
 	maxRetries       uint32
 	retryDelayMillis uint32
 
+	// hand results that are not saved in the api server yet
+	resultOutboxLock  sync.Mutex
+	flushResultOutbox chan bool
+	endResultOutbox   chan bool
+	maxPendingResults int
+
+	// version of the hand state last loaded or saved by this game.
+	// A new hand starts from this version so that the stale write check carries over.
+	handStateVersion uint64
+
 	// Whether to allow fractional chip or not
 	chipUnit ChipUnit
 
@@ -86,6 +98,18 @@ type Game struct {
 	encryptionKeyCache *encryptionkey.Cache
 
 	lostConnectionPlayers cmap.ConcurrentMap // for these players we don't have connection
+
+	// random number generator for the shuffles of this game
+	rng *rand.Rand
+
+	// tournament table state (see terminate.go)
+	closing         bool
+	tournamentHands sync.WaitGroup
+	tableStacks     []TableStack
+	// rebuy, add-on and re-entry prompts (see buyin.go)
+	buyIns *tableBuyIns
+	// bounties of a knockout tournament (see bounty.go)
+	bounties *TournamentBounties
 }
 
 func NewPokerGame(
@@ -123,11 +147,15 @@ func NewPokerGame(
 		timerCushionSec:       5,
 		encryptionKeyCache:    encryptionKeyCache,
 		lostConnectionPlayers: cmap.New(),
+		maxPendingResults:     util.Env.GetMaxPendingResults(),
+		rng:                   poker.NewCryptoRand(),
 	}
 	g.scriptTestPlayers = make(map[uint64]*Player)
 	g.chGame = make(chan []byte, 10)
 	g.chHand = make(chan []byte, 10)
 	g.end = make(chan bool, 10)
+	g.flushResultOutbox = make(chan bool, 1)
+	g.endResultOutbox = make(chan bool)
 	g.chPlayTimedOut = make(chan timer.TimerMsg, 10)
 	timer1Logger := logging.GetZeroLogger("timer::ActionTimer", nil).
 		With().Uint64(logging.GameIDKey, gameID).
@@ -156,6 +184,13 @@ func NewPokerGame(
 	return &g, nil
 }
 
+// SetRand replaces the random number generator of the shuffles (crypto/rand by
+// default), e.g. with a seeded generator to deal the same hands in a test.
+// Must be called before the game starts.
+func (g *Game) SetRand(rng *rand.Rand) {
+	g.rng = rng
+}
+
 func NewTestPokerGame(
 	gameID uint64,
 	gameCode string,
@@ -192,6 +227,8 @@ func NewTestPokerGame(
 	g.chGame = make(chan []byte, 10)
 	g.chHand = make(chan []byte, 10)
 	g.end = make(chan bool, 10)
+	g.flushResultOutbox = make(chan bool, 1)
+	g.endResultOutbox = make(chan bool)
 	g.chPlayTimedOut = make(chan timer.TimerMsg)
 	timer1Logger := logging.GetZeroLogger("ActionTimer", nil).
 		With().Uint64(logging.GameIDKey, gameID).
@@ -238,6 +275,9 @@ func (g *Game) GameStarted() error {
 	g.actionTimer2.Run()
 	g.networkCheck.Run()
 
+	if !g.isScriptTest {
+		go g.runHandResultOutboxWorker()
+	}
 	go g.runGame()
 	return nil
 }
@@ -245,6 +285,9 @@ func (g *Game) GameStarted() error {
 func (g *Game) GameEnded() error {
 	g.logger.Info().Msg("Cleaning up game")
 	g.end <- true
+	if !g.isScriptTest {
+		close(g.endResultOutbox)
+	}
 	g.actionTimer.Destroy()
 	g.actionTimer2.Destroy()
 	g.networkCheck.Destroy()
@@ -402,6 +445,10 @@ func (g *Game) MaskCards(playerCards []byte, gameToken uint64) ([]uint32, uint64
 }
 
 func (g *Game) NumCards(gameType GameType) uint32 {
+	return numCards(gameType)
+}
+
+func numCards(gameType GameType) uint32 {
 	noCards := 2
 	switch gameType {
 	case GameType_HOLDEM:
@@ -422,8 +469,24 @@ func (g *Game) NumCards(gameType GameType) uint32 {
 	return uint32(noCards)
 }
 
+// DealTournamentHand deals the hand in the background. Returns an error if the
+// table is closing.
 func (g *Game) DealTournamentHand(newHandInfo *NewHandInfo) error {
-	g.dealNewHand(newHandInfo)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.closing {
+		return fmt.Errorf("Table %d is closing. Cannot deal hand %d", g.tableNo, newHandInfo.HandNum)
+	}
+	g.tournamentHands.Add(1)
+	go func() {
+		defer g.tournamentHands.Done()
+		buyIns := g.waitTournamentBuyIns()
+		err := g.applyTournamentBuyIns(newHandInfo, buyIns)
+		if err != nil {
+			g.logger.Error().Err(err).Msgf("Could not report %d tournament buy-ins to the api server. The buy-ins are not applied", len(buyIns))
+		}
+		g.dealNewHand(newHandInfo)
+	}()
 	return nil
 }
 
@@ -464,6 +527,9 @@ func (g *Game) dealNewHand(newHandInfo *NewHandInfo) error {
 			if playerInSeat.SeatNo <= uint32(newHandInfo.MaxPlayers) {
 				g.PlayersInSeats[playerInSeat.SeatNo] = playerInSeat
 			}
+			if playerInSeat.EncryptionKey != "" && g.encryptionKeyCache != nil {
+				g.encryptionKeyCache.Add(playerInSeat.PlayerID, playerInSeat.EncryptionKey)
+			}
 		}
 		g.tournamentURL = newHandInfo.TournamentURL
 	}
@@ -581,8 +647,9 @@ func (g *Game) dealNewHand(newHandInfo *NewHandInfo) error {
 		CurrentState:  HandStatus_DEAL,
 		HandStartedAt: uint64(time.Now().Unix()),
 	}
+	g.continueFromPrevHand(handState)
 
-	err = handState.initialize(g.testGameConfig, newHandInfo, testHandSetup, buttonPos, sbPos, bbPos, g.PlayersInSeats, g.chipUnit)
+	err = handState.initialize(g.testGameConfig, newHandInfo, testHandSetup, buttonPos, sbPos, bbPos, g.PlayersInSeats, g.chipUnit, nil, g.rng)
 	if err != nil {
 		return errors.Wrapf(err, "Error while initializing hand state")
 	}
@@ -621,84 +688,13 @@ func (g *Game) dealNewHand(newHandInfo *NewHandInfo) error {
 			Msg(fmt.Sprintf("Table: %s", handState.PrintTable(g.scriptTestPlayers)))
 	}
 
-	playersActed := make(map[uint32]*PlayerActRound)
-	for seatNo, action := range handState.PlayersActed {
-		if action.Action == ACTION_EMPTY_SEAT {
-			continue
-		}
-		playersActed[uint32(seatNo)] = action
-	}
-	bettingState := handState.RoundState[uint32(handState.CurrentState)]
-	currentBettingRound := bettingState.Betting
-
-	handPlayerInSeats := make(map[uint32]*PlayerInSeatState)
-	for _, playerInSeat := range handState.PlayersInSeats {
-		copiedState := &PlayerInSeatState{
-			SeatNo:            playerInSeat.SeatNo,
-			Status:            playerInSeat.Status,
-			Stack:             playerInSeat.Stack,
-			PlayerId:          playerInSeat.PlayerId,
-			Name:              playerInSeat.Name,
-			BuyInExpTime:      playerInSeat.BuyInExpTime,
-			BreakExpTime:      playerInSeat.BreakExpTime,
-			Inhand:            playerInSeat.Inhand,
-			RunItTwice:        playerInSeat.RunItTwice,
-			MissedBlind:       playerInSeat.MissedBlind,
-			ButtonStraddle:    playerInSeat.ButtonStraddle,
-			MuckLosingHand:    playerInSeat.MuckLosingHand,
-			AutoStraddle:      playerInSeat.AutoStraddle,
-			ButtonStraddleBet: playerInSeat.ButtonStraddleBet,
-		}
-		handPlayerInSeats[playerInSeat.SeatNo] = copiedState
-		handPlayerInSeats[playerInSeat.SeatNo].Stack = playerInSeat.Stack - currentBettingRound.SeatBet[playerInSeat.SeatNo]
-	}
-
-	var nextSeatNo uint32
-	if handState.NextSeatAction != nil {
-		nextSeatNo = handState.NextSeatAction.SeatNo
-	}
-	potUpdates := float64(0)
-	pots := make([]float64, 0)
-
-	currentRoundState, ok := handState.RoundState[uint32(handState.CurrentState)]
-	if ok {
-		for _, bet := range currentRoundState.Betting.SeatBet {
-			potUpdates = potUpdates + bet
-		}
-	}
-
 	// send a new hand message to all players
-	newHand := NewHand{
-		HandNum:        handState.HandNum,
-		ButtonPos:      handState.ButtonPos,
-		SbPos:          handState.SmallBlindPos,
-		BbPos:          handState.BigBlindPos,
-		NextActionSeat: nextSeatNo,
-		NoCards:        g.NumCards(gameType),
-		GameType:       gameType,
-		SmallBlind:     handState.SmallBlind,
-		BigBlind:       handState.BigBlind,
-		BringIn:        handState.BringIn,
-		Straddle:       handState.Straddle,
-		Ante:           handState.Ante,
-		PlayersInSeats: handPlayerInSeats,
-		PlayersActed:   playersActed,
-		BombPot:        handState.BombPot,
-		BombPotBet:     handState.BombPotBet,
-		DoubleBoard:    handState.DoubleBoard,
-		PotUpdates:     potUpdates,
-		Pots:           pots,
-	}
-
 	handMessage := HandMessage{
 		HandNum:    handState.HandNum,
 		HandStatus: handState.CurrentState,
 		MessageId:  g.generateMsgID("NEW_HAND", handState.HandNum, handState.CurrentState, 0, "", handState.CurrentActionNum),
 		Messages: []*HandMessageItem{
-			{
-				MessageType: HandNewHand,
-				Content:     &HandMessageItem_NewHand{NewHand: &newHand},
-			},
+			handState.newHandMessage(),
 		},
 	}
 
@@ -775,63 +771,41 @@ func (g *Game) dealNewHand(newHandInfo *NewHandInfo) error {
 			Msg(fmt.Sprintf("Next action: %s", handState.NextSeatAction.PrettyPrint(handState, g.PlayersInSeats)))
 	}
 
-	allMsgItems := make([]*HandMessageItem, 0)
-	if handState.BombPot {
-		bombPotMessage := &HandMessageItem{
-			MessageType: HandBombPot,
-		}
-		allMsgItems = append(allMsgItems, bombPotMessage)
-		messages, err := g.gotoFlop(handState)
-		if err == nil {
-			allMsgItems = append(allMsgItems, messages...)
-		}
-	}
-	var handMsg HandMessage
-	var nextFlowState FlowState
-	if handState.allActionComplete() {
-		msgItems, err := g.allPlayersAllIn(handState)
+	step, allMsgItems, err := handState.startHand()
+	if err != nil && handState.NextSeatAction == nil {
+		bytes, err := protojson.Marshal(handState)
+		var errMsg string
 		if err != nil {
-			return err
-		}
-		nextFlowState = FlowState_MOVE_TO_NEXT_HAND
-		allMsgItems = append(allMsgItems, msgItems...)
-		handMsg = HandMessage{
-			HandNum:    handState.HandNum,
-			HandStatus: handState.CurrentState,
-			MessageId:  g.generateMsgID("NO_ACTION_THIS_HAND", handState.HandNum, handState.CurrentState, 0, "", handState.CurrentActionNum),
-			Messages:   allMsgItems,
-		}
-	} else {
-		if handState.NextSeatAction == nil {
-			bytes, err := protojson.Marshal(handState)
-			var errMsg string
-			if err != nil {
-				errMsg = "NextSeatAction is nil when dealing new hand"
-			} else {
-				errMsg = fmt.Sprintf("NextSeatAction is nil when dealing new hand. HandState: %s", string(bytes))
-			}
-			g.logger.Panic().
-				Uint32(logging.HandNumKey, handState.HandNum).
-				Uint32(logging.ButtonPosKey, handState.ButtonPos).
-				Uint32(logging.SbPosKey, handState.SmallBlindPos).
-				Uint32(logging.BbPosKey, handState.BigBlindPos).
-				Float64(logging.SbAmtKey, handState.SmallBlind).
-				Float64(logging.BbAmtKey, handState.BigBlind).
-				Msg(errMsg)
-		}
-
-		msgItems, err := g.moveToNextAction(handState)
-		if err != nil {
-			return err
-		}
-		allMsgItems = append(allMsgItems, msgItems...)
-		handMsg = HandMessage{
-			HandNum:    handState.HandNum,
-			HandStatus: handState.CurrentState,
-			MessageId:  g.generateMsgID("INITIAL_ACTION", handState.HandNum, handState.CurrentState, 0, "", handState.CurrentActionNum),
-			Messages:   allMsgItems,
+			errMsg = "NextSeatAction is nil when dealing new hand"
+		} else {
+			errMsg = fmt.Sprintf("NextSeatAction is nil when dealing new hand. HandState: %s", string(bytes))
 		}
-		nextFlowState = FlowState_WAIT_FOR_NEXT_ACTION
+		g.logger.Panic().
+			Uint32(logging.HandNumKey, handState.HandNum).
+			Uint32(logging.ButtonPosKey, handState.ButtonPos).
+			Uint32(logging.SbPosKey, handState.SmallBlindPos).
+			Uint32(logging.BbPosKey, handState.BigBlindPos).
+			Float64(logging.SbAmtKey, handState.SmallBlind).
+			Float64(logging.BbAmtKey, handState.BigBlind).
+			Msg(errMsg)
+	}
+	if err != nil {
+		return err
+	}
+	err = g.processHandMessages(handState, allMsgItems)
+	if err != nil {
+		return err
+	}
+	nextFlowState := flowStateAfter(step)
+	msgIDPrefix := "INITIAL_ACTION"
+	if step == stepAllPlayersAllIn {
+		msgIDPrefix = "NO_ACTION_THIS_HAND"
+	}
+	handMsg := HandMessage{
+		HandNum:    handState.HandNum,
+		HandStatus: handState.CurrentState,
+		MessageId:  g.generateMsgID(msgIDPrefix, handState.HandNum, handState.CurrentState, 0, "", handState.CurrentActionNum),
+		Messages:   allMsgItems,
 	}
 
 	g.broadcastHandMessage(&handMsg)
@@ -908,23 +882,71 @@ func (g *Game) saveHandState(handState *HandState, nextFlowState FlowState) erro
 	}
 
 	handState.FlowState = nextFlowState
+	if handState.Version == 0 {
+		// Newly dealt hand.
+		handState.Version = atomic.LoadUint64(&g.handStateVersion)
+	}
 
 	err := g.manager.handStatePersist.Save(
 		g.gameCode,
 		handState)
+	if err == StaleHandState {
+		g.logger.Error().
+			Uint32(logging.HandNumKey, handState.HandNum).
+			Msgf("Hand state version %d is stale. Another game server may be running this game.", handState.Version)
+		return err
+	}
+	if err == nil {
+		atomic.StoreUint64(&g.handStateVersion, handState.Version)
+		g.saveHandStateSnapshot(handState)
+	}
 	return err
 }
 
+// continueFromPrevHand carries the stored state of the previous hand over to
+// the new hand. The server seed of the new hand was committed with the previous
+// hand (or with this hand if it is dealt again). A game that has not saved a
+// hand since it started (after a restart or a takeover) continues from the
+// stored version, so its first save is not rejected as stale.
+func (g *Game) continueFromPrevHand(handState *HandState) {
+	prevHandState, err := g.manager.handStatePersist.Load(g.gameCode)
+	if err != nil || prevHandState == nil {
+		return
+	}
+	atomic.CompareAndSwapUint64(&g.handStateVersion, 0, prevHandState.Version)
+	if prevHandState.HandNum == handState.HandNum {
+		handState.ServerSeed = prevHandState.ServerSeed
+		handState.NextServerSeed = prevHandState.NextServerSeed
+	} else {
+		handState.ServerSeed = prevHandState.NextServerSeed
+	}
+}
+
 func (g *Game) removeHandState() error {
 	err := g.manager.handStatePersist.Remove(g.gameCode)
+	if err == nil {
+		atomic.StoreUint64(&g.handStateVersion, 0)
+	}
 	return err
 }
 
+// loadHandState loads the hand state and remembers its version for the next
+// new hand. Only the game goroutine calls it, the other readers use
+// peekHandState so that a concurrent load cannot roll the version back.
 func (g *Game) loadHandState() (*HandState, error) {
 	handState, err := g.manager.handStatePersist.Load(g.gameCode)
+	if err == nil {
+		atomic.StoreUint64(&g.handStateVersion, handState.Version)
+	}
 	return handState, err
 }
 
+// peekHandState loads the hand state without updating the version of the game.
+// The state can still be saved, the save checks the version of the loaded state.
+func (g *Game) peekHandState() (*HandState, error) {
+	return g.manager.handStatePersist.Load(g.gameCode)
+}
+
 func (g *Game) broadcastHandMessage(message *HandMessage) {
 	message.GameCode = g.gameCode
 	var outMsg *HandMessage = &HandMessage{}
@@ -1029,6 +1051,31 @@ func (g *Game) sendTournamentMessageToPlayer(message *HandMessage, tournamentID
 	}
 }
 
+// Announce broadcasts an announcement to the players at the table.
+func (g *Game) Announce(announcementType string, params []string) {
+	var handNum uint32
+	handState, err := g.peekHandState()
+	if err == nil && handState != nil {
+		handNum = handState.HandNum
+	}
+	announcement := &Announcement{
+		Type:   announcementType,
+		Params: params,
+	}
+	handMessage := HandMessage{
+		HandNum:    handNum,
+		HandStatus: HandStatus_DEAL,
+		MessageId:  g.generateMsgID("ANNOUNCEMENT", handNum, HandStatus_DEAL, 0, announcementType, 0),
+		Messages: []*HandMessageItem{
+			{
+				MessageType: HandAnnouncement,
+				Content:     &HandMessageItem_Announcement{Announcement: announcement},
+			},
+		},
+	}
+	g.broadcastHandMessage(&handMessage)
+}
+
 func (g *Game) HandlePlayerMovedTable(gameCode string, tournamentID uint32, oldTableNo uint32, newTableNo uint32, newSeatNo uint32, playerID uint64, gameInfo string) error {
 	playerMovedTable := PlayerMovedTable{
 		TournamentId:   tournamentID,
@@ -1044,7 +1091,7 @@ func (g *Game) HandlePlayerMovedTable(gameCode string, tournamentID uint32, oldT
 		Content:     &HandMessageItem_PlayerMovedTable{PlayerMovedTable: &playerMovedTable},
 	}
 
-	handState, err := g.loadHandState()
+	handState, err := g.peekHandState()
 	if err != nil || handState == nil ||
 		handState.HandNum == 0 ||
 		handState.CurrentState == HandStatus_HAND_CLOSED {
@@ -1088,7 +1135,7 @@ func (g *Game) HandlePlayerMovedTable(gameCode string, tournamentID uint32, oldT
 }
 
 func (g *Game) HandleQueryCurrentHand(playerID uint64, messageID string) error {
-	handState, err := g.loadHandState()
+	handState, err := g.peekHandState()
 	if err != nil || handState == nil ||
 		handState.HandNum == 0 ||
 		handState.CurrentState == HandStatus_HAND_CLOSED {
@@ -1460,7 +1507,7 @@ func (g *Game) GetRemainingActionTime() uint32 {
 
 func (g *Game) onClientConnLost(a networkcheck.Action) {
 	g.lostConnectionPlayers.Set(fmt.Sprintf("%d", a.PlayerID), true)
-	handState, _ := g.loadHandState()
+	handState, _ := g.peekHandState()
 	if handState != nil {
 		g.handleYourTurnIfNeeded(handState)
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	"voyager.com/server/internal"
 	"voyager.com/server/util"
 )
 
@@ -29,17 +30,38 @@ func CreateGameManager(isScriptTest bool, delays Delays) (*Manager, error) {
 	handSetupPersist := NewRedisHandsSetupTracker(fmt.Sprintf("%s:%d", redisHost, redisPort), redisUser, redisPW, redisDB, useSSL)
 
 	var handPersist PersistHandState
+	var handResultOutbox HandResultOutbox
 	var persistMethod = util.Env.GetPersistMethod()
-	if persistMethod == "redis" {
+	switch persistMethod {
+	case "redis":
 		handPersist, err = NewRedisHandStateTracker(fmt.Sprintf("%s:%d", redisHost, redisPort), redisUser, redisPW, redisDB, useSSL)
-	} else {
+		handResultOutbox = NewRedisHandResultOutbox(fmt.Sprintf("%s:%d", redisHost, redisPort), redisUser, redisPW, redisDB, useSSL)
+	case "bolt":
+		handPersist, err = NewBoltHandStateTracker(util.Env.GetBoltDBPath())
+		if err == nil {
+			handResultOutbox, err = NewFileHandResultOutbox(util.Env.GetResultOutboxDir())
+		}
+	case "postgres":
+		handPersist, err = NewPostgresHandStateTracker(internal.GetHandStateDBConnStr())
+		if err == nil {
+			handResultOutbox, err = NewFileHandResultOutbox(util.Env.GetResultOutboxDir())
+		}
+	default:
 		handPersist, err = NewMemoryHandStateTracker()
+		if err == nil {
+			handResultOutbox, err = NewFileHandResultOutbox(util.Env.GetResultOutboxDir())
+		}
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to create hand state tracker")
 	}
 
-	gm, err := NewGameManager(isScriptTest, apiServerURL, handPersist, handSetupPersist, delays)
+	handStateSnapshots, err := NewHandStateSnapshotsFromEnv()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to create hand state snapshots")
+	}
+
+	gm, err := NewGameManager(isScriptTest, apiServerURL, handPersist, handSetupPersist, handResultOutbox, handStateSnapshots, delays)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error in NewGameManager")
 	}
@@ -47,3 +69,17 @@ func CreateGameManager(isScriptTest bool, delays Delays) (*Manager, error) {
 	GameManager = gm
 	return GameManager, nil
 }
+
+// NewHandStateSnapshotsFromEnv creates the snapshot store for the configured
+// persist method. Returns nil when the snapshots are disabled.
+func NewHandStateSnapshotsFromEnv() (HandStateSnapshots, error) {
+	keepHands := util.Env.GetSnapshotHands()
+	if keepHands == 0 {
+		return nil, nil
+	}
+	if util.Env.GetPersistMethod() == "redis" {
+		redisURL := fmt.Sprintf("%s:%d", util.Env.GetRedisHost(), util.Env.GetRedisPort())
+		return NewRedisHandStateSnapshots(redisURL, util.Env.GetRedisUser(), util.Env.GetRedisPW(), util.Env.GetRedisDB(), util.Env.IsRedisSSL(), keepHands), nil
+	}
+	return NewFileHandStateSnapshots(util.Env.GetSnapshotDir(), keepHands)
+}
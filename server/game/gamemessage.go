@@ -100,9 +100,9 @@ func (g *Game) handleGameMessage(message *GameMessage) error {
 
 func (g *Game) onResume(message *GameMessage) error {
 	var err error
-	handState, err := g.loadHandState()
+	handState, err := g.peekHandState()
 	if err != nil {
-		if err != RedisKeyNotFound {
+		if err != HandStateNotFound {
 			return errors.Wrap(err, "Could not load hand state")
 		}
 
@@ -182,9 +182,9 @@ func (g *Game) onGetHandLog(message *GameMessage) error {
 		PlayerId:    message.PlayerId,
 	}
 
-	handState, err := g.loadHandState()
+	handState, err := g.peekHandState()
 	if err != nil {
-		if err == RedisKeyNotFound {
+		if err == HandStateNotFound {
 			go g.sendGameMessageToPlayer(gameMessage)
 			return nil
 		}
@@ -200,7 +200,7 @@ func (g *Game) onGetHandLog(message *GameMessage) error {
 }
 
 func (g *Game) onMoveToNextHand(message *GameMessage) (bool, error) {
-	handState, err := g.loadHandState()
+	handState, err := g.peekHandState()
 	if err != nil {
 		return false, err
 	}
@@ -341,7 +341,7 @@ func (g *Game) broadcastTableState() error {
 }
 
 func (g *Game) onPlayerLeftGame(message *GameMessage) error {
-	handState, err := g.loadHandState()
+	handState, err := g.peekHandState()
 	if err != nil {
 		return errors.Wrap(err, "Could not load hand state")
 	}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"sort"
+)
+
+// In hand-for-hand play every table of the tournament deals a hand and waits
+// for all the other tables to finish theirs before the next hand. The players
+// busted in the same hand-for-hand round are busted at the same time, so they
+// finish in the order of their stacks at the start of the hand: the bigger
+// starting stack finishes higher. Players with the same starting stack tie
+// and share the places.
+
+// Elimination is a player busted in a hand-for-hand round.
+type Elimination struct {
+	PlayerID      uint64  `json:"playerId"`
+	TableNo       uint32  `json:"tableNo"`
+	StartingStack float64 `json:"startingStack"`
+	// Place is the finishing place of the player (1 is the winner).
+	Place int `json:"place"`
+	// Tied is set when other players busted in the round with the same
+	// starting stack. The tied players have the best of their places.
+	Tied bool `json:"tied"`
+}
+
+// RankEliminations sets the places of the players busted in the same round.
+// remaining is the number of players left in the tournament after the round.
+// Returns the eliminations in the order of their places.
+func RankEliminations(eliminations []Elimination, remaining int) []Elimination {
+	ranked := append([]Elimination{}, eliminations...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].StartingStack != ranked[j].StartingStack {
+			return ranked[i].StartingStack > ranked[j].StartingStack
+		}
+		return ranked[i].PlayerID < ranked[j].PlayerID
+	})
+	for i := range ranked {
+		ranked[i].Place = remaining + i + 1
+		ranked[i].Tied = false
+		if i > 0 && ranked[i].StartingStack == ranked[i-1].StartingStack {
+			ranked[i].Place = ranked[i-1].Place
+			ranked[i].Tied = true
+			ranked[i-1].Tied = true
+		}
+	}
+	return ranked
+}
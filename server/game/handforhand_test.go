@@ -0,0 +1,25 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankEliminations(t *testing.T) {
+	ranked := RankEliminations([]Elimination{
+		{PlayerID: 7, TableNo: 1, StartingStack: 1200},
+		{PlayerID: 3, TableNo: 2, StartingStack: 5000},
+		{PlayerID: 9, TableNo: 3, StartingStack: 1200},
+		{PlayerID: 4, TableNo: 2, StartingStack: 800},
+	}, 18)
+
+	assert.Equal(t, []Elimination{
+		{PlayerID: 3, TableNo: 2, StartingStack: 5000, Place: 19},
+		{PlayerID: 7, TableNo: 1, StartingStack: 1200, Place: 20, Tied: true},
+		{PlayerID: 9, TableNo: 3, StartingStack: 1200, Place: 20, Tied: true},
+		{PlayerID: 4, TableNo: 2, StartingStack: 800, Place: 22},
+	}, ranked)
+
+	assert.Empty(t, RankEliminations(nil, 10))
+}
@@ -90,6 +90,16 @@ func (g *Game) handleHandMessage(message *HandMessage) error {
 				Uint64(logging.PlayerIDKey, message.PlayerId).
 				Msgf(errMsg)
 		}
+	case HandTournamentBuyIn:
+		err := g.onTournamentBuyIn(message)
+		if err != nil {
+			errMsg := "Could not process hand message"
+			g.logger.Error().
+				Err(err).
+				Str(logging.MsgTypeKey, msgItem.MessageType).
+				Uint64(logging.PlayerIDKey, message.PlayerId).
+				Msgf(errMsg)
+		}
 	}
 
 	return nil
@@ -171,7 +181,7 @@ func (g *Game) onResetCurrentTimer(playerMsg *HandMessage) error {
 func (g *Game) onQueryCurrentHand(playerMsg *HandMessage) error {
 	// get hand state
 	handState, err := g.loadHandState()
-	if err != nil && err != RedisKeyNotFound {
+	if err != nil && err != HandStateNotFound {
 		return errors.Wrap(err, "Unable to load hand state")
 	}
 
@@ -544,21 +554,12 @@ func validatePlayerAction(playerMsg *HandMessage, actionMsg *HandMessageItem, ha
 }
 
 func (g *Game) handleRITResponse(playerMsg *HandMessage, actionMsg *HandMessageItem, handState *HandState) error {
-	seatNo := actionMsg.GetPlayerActed().GetSeatNo()
 	msgItems, err := g.runItTwiceConfirmation(handState, playerMsg)
 	if err != nil {
 		return errors.Wrap(err, "Could not handle run-it-twice confirmation")
 	}
 	g.sendActionAck(handState, playerMsg, handState.CurrentActionNum)
 
-	player := handState.PlayersInSeats[seatNo]
-	if actionMsg.GetPlayerActed().GetTimedOut() {
-		handState.TimeoutStats[player.PlayerId].ConsecutiveActionTimeouts++
-	} else {
-		handState.TimeoutStats[player.PlayerId].ConsecutiveActionTimeouts = 0
-		handState.TimeoutStats[player.PlayerId].ActedAtLeastOnce = true
-	}
-
 	msg := HandMessage{
 		HandNum:    handState.HandNum,
 		HandStatus: handState.CurrentState,
@@ -613,79 +614,18 @@ func (g *Game) prepareNextAction(handState *HandState, actionResponseTime uint64
 		return err
 	}
 
-	allMsgItems := make([]*HandMessageItem, 0)
-	var msgItems []*HandMessageItem
-	seatNo := actionMsg.GetPlayerActed().GetSeatNo()
-	player := handState.PlayersInSeats[seatNo]
-
-	// Send player's current stack to be updated in the UI
-	handStage := handState.CurrentState
-
-	err = handState.actionReceived(actionMsg.GetPlayerActed(), actionResponseTime)
+	step, msgItems, err := handState.applyAction(actionMsg.GetPlayerActed(), actionResponseTime)
 	if err != nil {
-		return errors.Wrap(err, "Could not update hand state from action")
-	}
-
-	playerAction := handState.PlayersActed[seatNo]
-	bettingState := handState.RoundState[uint32(handStage)]
-	potUpdates := float64(0)
-	for _, pot := range handState.Pots {
-		potUpdates += pot.Pot
-	}
-
-	playerBalance := bettingState.PlayerBalance[seatNo]
-	actionMsg.GetPlayerActed().Stack = playerBalance
-	actionMsg.GetPlayerActed().PotUpdates = potUpdates
-	if playerAction.Action != ACTION_FOLD {
-		actionMsg.GetPlayerActed().Amount = playerAction.Amount
-	} else {
-		// the game folded this guy's hand
-		actionMsg.GetPlayerActed().Action = ACTION_FOLD
-		actionMsg.GetPlayerActed().Amount = 0
-	}
-	// broadcast this message to all the players (let everyone know this player acted)
-	allMsgItems = append(allMsgItems, actionMsg)
-
-	if actionMsg.GetPlayerActed().GetTimedOut() {
-		handState.TimeoutStats[player.PlayerId].ConsecutiveActionTimeouts++
-	} else {
-		handState.TimeoutStats[player.PlayerId].ConsecutiveActionTimeouts = 0
-
-		// When the consecutive timeout counts get reported to the api server,
-		// the api server needs to know if the player has acted at all this hand
-		// so that it can clear the count from the previous hand and start a new counter
-		// instead of adding to it.
-		handState.TimeoutStats[player.PlayerId].ActedAtLeastOnce = true
-	}
-
-	// This number is used to generate hand message IDs uniquely and deterministically across the server crashes.
-	handState.CurrentActionNum++
-
-	var nextFlowState FlowState
-	if handState.NoActiveSeats == 1 {
-		msgItems, err = g.onePlayerRemaining(handState)
-		nextFlowState = FlowState_MOVE_TO_NEXT_HAND
-	} else if g.runItTwice(handState, playerAction) {
-		msgItems, err = g.runItTwicePrompt(handState)
-		nextFlowState = FlowState_WAIT_FOR_NEXT_ACTION
-	} else if handState.isAllActivePlayersAllIn() || handState.allActionComplete() {
-		msgItems, err = g.allPlayersAllIn(handState)
-		nextFlowState = FlowState_MOVE_TO_NEXT_HAND
-	} else if handState.CurrentState == HandStatus_SHOW_DOWN {
-		msgItems, err = g.showdown(handState)
-		nextFlowState = FlowState_MOVE_TO_NEXT_HAND
-	} else if handState.LastState != handState.CurrentState {
-		msgItems, err = g.moveToNextRound(handState)
-		nextFlowState = FlowState_WAIT_FOR_NEXT_ACTION
-	} else {
-		msgItems, err = g.moveToNextAction(handState)
-		nextFlowState = FlowState_WAIT_FOR_NEXT_ACTION
+		return err
 	}
-
+	err = g.processHandMessages(handState, msgItems)
 	if err != nil {
 		return err
 	}
-	allMsgItems = append(allMsgItems, msgItems...)
+	nextFlowState := flowStateAfter(step)
+
+	// broadcast this message to all the players (let everyone know this player acted)
+	allMsgItems := append([]*HandMessageItem{actionMsg}, msgItems...)
 
 	// Create hand message with all of the message items.
 	serverMsg := HandMessage{
@@ -736,14 +676,7 @@ func (g *Game) logHandState(handState *HandState) {
 
 func (g *Game) handleHandEnded(handState *HandState, totalPauseTime uint32, allMsgItems []*HandMessageItem) {
 	// if the last message is hand ended (pause for the result animation)
-	handEnded := false
-	for _, message := range allMsgItems {
-		if message.MessageType == HandEnded {
-			handEnded = true
-		}
-	}
-
-	if handEnded {
+	if handEnded(allMsgItems) {
 		if totalPauseTime > 0 {
 			if !util.Env.ShouldDisableDelays() {
 				g.logger.Debug().
@@ -877,10 +810,10 @@ func (g *Game) sendActionAck(handState *HandState, playerMsg *HandMessage, curre
 		Msgf("Acknowledgment sent to player. Message Id: %s", playerMsg.GetMessageId())
 }
 
-func (g *Game) getPots(handState *HandState) ([]float64, []*SeatsInPots) {
+func (h *HandState) getPots() ([]float64, []*SeatsInPots) {
 	pots := make([]float64, 0)
 	seatsInPots := make([]*SeatsInPots, 0)
-	for _, pot := range handState.Pots {
+	for _, pot := range h.Pots {
 		if pot.Pot == 0 {
 			continue
 		}
@@ -890,26 +823,27 @@ func (g *Game) getPots(handState *HandState) ([]float64, []*SeatsInPots) {
 	return pots, seatsInPots
 }
 
-func (g *Game) getPlayerCardRanks(handState *HandState, numBoardCards int) map[uint32]string {
+func (h *HandState) getPlayerCardRanks(numBoardCards int) map[uint32]string {
 	playerCardRanks := make(map[uint32]string)
+	deck := h.deckDefinition()
 
-	for seatNo, playerID := range handState.ActiveSeats {
+	for seatNo, playerID := range h.ActiveSeats {
 		if playerID == 0 {
 			continue
 		}
-		playersCards := handState.PlayersCards[uint32(seatNo)]
+		playersCards := h.PlayersCards[uint32(seatNo)]
 
 		// this player's rank text for each board
 		var rankTexts []string
-		for _, board := range handState.Boards {
+		for _, board := range h.Boards {
 			boardCards := make([]byte, 0)
 			for _, card := range board.Cards[:numBoardCards] {
 				boardCards = append(boardCards, byte(card))
 			}
 
-			rank := g.getPlayerCardRank(handState.GameType, playersCards, boardCards)
+			rank := getPlayerCardRank(h.GameType, deck, playersCards, boardCards)
 			if rank != 0 {
-				rankTexts = append(rankTexts, poker.RankString(rank))
+				rankTexts = append(rankTexts, poker.RankString(deck.StandardRank(rank)))
 			}
 		}
 		playerCardRanks[uint32(seatNo)] = strings.Join(rankTexts, ",")
@@ -918,7 +852,76 @@ func (g *Game) getPlayerCardRanks(handState *HandState, numBoardCards int) map[u
 	return playerCardRanks
 }
 
-func (g *Game) getPlayerCardRank(gameType GameType, playerCards []byte, boardCards []byte) int32 {
+// getPlayerHandDescriptions returns the descriptions of the player hands on
+// each board in JSON.
+func (h *HandState) getPlayerHandDescriptions(numBoardCards int) map[uint32]string {
+	descriptions := make(map[uint32]string)
+	for seatNo, playerID := range h.ActiveSeats {
+		if playerID == 0 {
+			continue
+		}
+		playerCards := h.PlayersCards[uint32(seatNo)]
+
+		playerDescriptions := &PlayerHandDescriptions{}
+		for _, board := range h.Boards {
+			boardCards := make([]byte, 0)
+			for _, card := range board.Cards[:numBoardCards] {
+				boardCards = append(boardCards, byte(card))
+			}
+			description := describePlayerHand(h.GameType, h.deckDefinition(), playerCards, boardCards)
+			if description == nil {
+				continue
+			}
+			description.BoardNo = board.BoardNo
+			playerDescriptions.Boards = append(playerDescriptions.Boards, description)
+		}
+		data, err := protojson.Marshal(playerDescriptions)
+		if err != nil {
+			handLogger.Error().
+				Uint64(logging.GameIDKey, h.GetGameId()).
+				Uint32(logging.HandNumKey, h.GetHandNum()).
+				Msgf("Could not marshal the hand descriptions: %s", err)
+			continue
+		}
+		descriptions[uint32(seatNo)] = string(data)
+	}
+	return descriptions
+}
+
+// describePlayerHand describes the best hand of the player cards with the
+// board cards, and the low hand in the hi-lo games. The hands rank with the
+// rules of the deck. Returns nil if the game type is not supported.
+func describePlayerHand(gameType GameType, deck poker.DeckDefinition, playerCards []byte, boardCards []byte) *BoardHandDescription {
+	pokerBoardCards := poker.FromByteCards(boardCards)
+	pokerPlayerCards := poker.FromByteCards(playerCards)
+	switch gameType {
+	case GameType_HOLDEM:
+		rank, cards := deck.Evaluate(append(pokerPlayerCards, pokerBoardCards...))
+		return &BoardHandDescription{Hi: newHandDescription(poker.DescribeHand(deck.StandardRank(rank), cards))}
+	case GameType_PLO, GameType_FIVE_CARD_PLO, GameType_SIX_CARD_PLO:
+		result := deck.EvaluateOmahaHand(pokerPlayerCards, pokerBoardCards)
+		return &BoardHandDescription{Hi: newHandDescription(poker.DescribeHand(deck.StandardRank(result.HiRank), result.HiCards[:]))}
+	case GameType_PLO_HILO, GameType_FIVE_CARD_PLO_HILO, GameType_SIX_CARD_PLO_HILO:
+		result := deck.EvaluateOmahaHand(pokerPlayerCards, pokerBoardCards)
+		description := &BoardHandDescription{Hi: newHandDescription(poker.DescribeHand(deck.StandardRank(result.HiRank), result.HiCards[:]))}
+		if result.LowFound {
+			description.Lo = newHandDescription(poker.DescribeLow(result.LowCards[:]))
+		}
+		return description
+	}
+	return nil
+}
+
+func newHandDescription(description poker.HandDescription) *HandDescription {
+	return &HandDescription{
+		Type:    description.Type,
+		Params:  description.Params,
+		Kickers: description.Kickers,
+		Text:    description.Text(),
+	}
+}
+
+func getPlayerCardRank(gameType GameType, deck poker.DeckDefinition, playerCards []byte, boardCards []byte) int32 {
 	cards := make([]byte, len(boardCards)+len(playerCards))
 	copy(cards, boardCards)
 
@@ -934,251 +937,160 @@ func (g *Game) getPlayerCardRank(gameType GameType, playerCards []byte, boardCar
 	pokerPlayerCards := poker.FromByteCards(playerCardsInBytes)
 	var rank int32
 	if gameType == GameType_HOLDEM {
-		rank, _ = poker.Evaluate(pokerCards)
+		rank, _ = deck.Evaluate(pokerCards)
 	} else if gameType == GameType_PLO ||
 		gameType == GameType_PLO_HILO ||
 		gameType == GameType_FIVE_CARD_PLO_HILO ||
 		gameType == GameType_FIVE_CARD_PLO ||
 		gameType == GameType_SIX_CARD_PLO_HILO ||
 		gameType == GameType_SIX_CARD_PLO {
-		result := poker.EvaluateOmaha(pokerPlayerCards, pokerBoardCards)
+		result := deck.EvaluateOmahaHand(pokerPlayerCards, pokerBoardCards)
 		rank = result.HiRank
 	}
 
 	return rank
 }
 
-func (g *Game) gotoFlop(handState *HandState) ([]*HandMessageItem, error) {
-	g.logger.Debug().
-		Uint32(logging.HandNumKey, handState.GetHandNum()).
-		Msgf("Moving to %s", HandStatus_name[int32(handState.CurrentState)])
-
-	err := handState.setupFlop()
-	if err != nil {
-		return nil, err
-	}
-	pots, seatsInPots := g.getPots(handState)
-
-	potUpdates := float64(0)
-	for _, pot := range handState.Pots {
-		potUpdates = potUpdates + pot.Pot
-	}
-
+func (h *HandState) playerBalances() map[uint32]float64 {
 	balance := make(map[uint32]float64)
-	for seatNo, player := range handState.PlayersInSeats {
+	for seatNo, player := range h.PlayersInSeats {
 		if seatNo == 0 {
 			continue
 		}
 		balance[uint32(seatNo)] = player.Stack
 	}
+	return balance
+}
 
-	// update player stats
-	for _, playerID := range handState.ActiveSeats {
-		if playerID == 0 {
-			continue
-		}
-		handState.PlayerStats[playerID].InFlop = true
-	}
-	numBoardCards := 3
-	playerCardRanks := g.getPlayerCardRanks(handState, numBoardCards)
-	if util.Env.IsEncryptionEnabled() {
-		var err error
-		playerCardRanks, err = g.encryptPlayerCardRanks(playerCardRanks, handState.PlayersInSeats)
-		if err != nil {
-			return nil, err
-		}
-	}
-
+// boardsUpTo returns the first numBoardCards cards of each board.
+func (h *HandState) boardsUpTo(numBoardCards int) []*Board {
 	boards := make([]*Board, 0)
-	for _, board := range handState.Boards {
-		flopCards := make([]uint32, numBoardCards)
+	for _, board := range h.Boards {
+		cards := make([]uint32, numBoardCards)
 		for i, card := range board.Cards[:numBoardCards] {
-			flopCards[i] = uint32(card)
+			cards[i] = uint32(card)
 		}
-		board1 := &Board{
+		boards = append(boards, &Board{
 			BoardNo: board.BoardNo,
-			Cards:   flopCards,
-		}
-		boards = append(boards, board1)
+			Cards:   cards,
+		})
+	}
+	return boards
+}
+
+func (h *HandState) gotoFlop() (*HandMessageItem, error) {
+	err := h.setupFlop()
+	if err != nil {
+		return nil, err
 	}
+	pots, seatsInPots := h.getPots()
+
+	potUpdates := float64(0)
+	for _, pot := range h.Pots {
+		potUpdates = potUpdates + pot.Pot
+	}
+
+	numBoardCards := 3
 	flopCards := make([]uint32, numBoardCards)
-	for i, card := range handState.BoardCards[:numBoardCards] {
+	for i, card := range h.BoardCards[:numBoardCards] {
 		flopCards[i] = uint32(card)
 	}
 	cardsStr := poker.CardsToString(flopCards)
 	flop := &Flop{
-		Board:           flopCards,
-		Boards:          boards,
-		CardsStr:        cardsStr,
-		Pots:            pots,
-		SeatsPots:       seatsInPots,
-		PlayerBalance:   balance,
-		PlayerCardRanks: playerCardRanks,
-		PotUpdates:      potUpdates,
+		Board:                  flopCards,
+		Boards:                 h.boardsUpTo(numBoardCards),
+		CardsStr:               cardsStr,
+		Pots:                   pots,
+		SeatsPots:              seatsInPots,
+		PlayerBalance:          h.playerBalances(),
+		PlayerCardRanks:        h.getPlayerCardRanks(numBoardCards),
+		PotUpdates:             potUpdates,
+		PlayerHandDescriptions: h.getPlayerHandDescriptions(numBoardCards),
 	}
 	msgItem := &HandMessageItem{
 		MessageType: HandFlop,
 		Content:     &HandMessageItem_Flop{Flop: flop},
 	}
 
-	return []*HandMessageItem{msgItem}, nil
+	return msgItem, nil
 }
 
-func (g *Game) gotoTurn(handState *HandState) ([]*HandMessageItem, error) {
-	g.logger.Debug().
-		Uint32(logging.HandNumKey, handState.GetHandNum()).
-		Msgf("Moving to %s", HandStatus_name[int32(handState.CurrentState)])
-
-	err := handState.setupTurn()
+func (h *HandState) gotoTurn() (*HandMessageItem, error) {
+	err := h.setupTurn()
 	if err != nil {
 		return nil, err
 	}
 
-	pots, seatsInPots := g.getPots(handState)
+	pots, seatsInPots := h.getPots()
 	potUpdates := float64(0)
-	for _, pot := range handState.Pots {
+	for _, pot := range h.Pots {
 		potUpdates = potUpdates + pot.Pot
 	}
 
-	balance := make(map[uint32]float64)
-	for seatNo, player := range handState.PlayersInSeats {
-		if seatNo == 0 {
-			continue
-		}
-		balance[uint32(seatNo)] = player.Stack
-	}
-
-	// update player stats
-	for _, playerID := range handState.ActiveSeats {
-		if playerID == 0 {
-			continue
-		}
-		handState.PlayerStats[playerID].InTurn = true
-	}
-
 	numBoardCards := 4
-	playerCardRanks := g.getPlayerCardRanks(handState, numBoardCards)
-	if util.Env.IsEncryptionEnabled() {
-		var err error
-		playerCardRanks, err = g.encryptPlayerCardRanks(playerCardRanks, handState.PlayersInSeats)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	boardCards := make([]uint32, numBoardCards)
-	for i, card := range handState.BoardCards[:numBoardCards] {
+	for i, card := range h.BoardCards[:numBoardCards] {
 		boardCards[i] = uint32(card)
 	}
 
 	cardsStr := poker.CardsToString(boardCards)
-	boards := make([]*Board, 0)
-	for _, board := range handState.Boards {
-		turnCards := make([]uint32, numBoardCards)
-		for i, card := range board.Cards[:numBoardCards] {
-			turnCards[i] = uint32(card)
-		}
-		board1 := &Board{
-			BoardNo: board.BoardNo,
-			Cards:   turnCards,
-		}
-		boards = append(boards, board1)
-	}
 	turn := &Turn{
-		Board:           boardCards,
-		Boards:          boards,
-		TurnCard:        boardCards[numBoardCards-1],
-		CardsStr:        cardsStr,
-		Pots:            pots,
-		SeatsPots:       seatsInPots,
-		PlayerBalance:   balance,
-		PlayerCardRanks: playerCardRanks,
-		PotUpdates:      potUpdates,
+		Board:                  boardCards,
+		Boards:                 h.boardsUpTo(numBoardCards),
+		TurnCard:               boardCards[numBoardCards-1],
+		CardsStr:               cardsStr,
+		Pots:                   pots,
+		SeatsPots:              seatsInPots,
+		PlayerBalance:          h.playerBalances(),
+		PlayerCardRanks:        h.getPlayerCardRanks(numBoardCards),
+		PotUpdates:             potUpdates,
+		PlayerHandDescriptions: h.getPlayerHandDescriptions(numBoardCards),
 	}
 	msgItem := &HandMessageItem{
 		MessageType: HandTurn,
 		Content:     &HandMessageItem_Turn{Turn: turn},
 	}
 
-	return []*HandMessageItem{msgItem}, nil
+	return msgItem, nil
 }
 
-func (g *Game) gotoRiver(handState *HandState) ([]*HandMessageItem, error) {
-	g.logger.Debug().
-		Uint32(logging.HandNumKey, handState.GetHandNum()).
-		Msgf("Moving to %s", HandStatus_name[int32(handState.CurrentState)])
-
-	err := handState.setupRiver()
+func (h *HandState) gotoRiver() (*HandMessageItem, error) {
+	err := h.setupRiver()
 	if err != nil {
 		return nil, err
 	}
 
-	pots, seatsInPots := g.getPots(handState)
+	pots, seatsInPots := h.getPots()
 	potUpdates := float64(0)
-	for _, pot := range handState.Pots {
+	for _, pot := range h.Pots {
 		potUpdates = potUpdates + pot.Pot
 	}
 
-	balance := make(map[uint32]float64)
-	for seatNo, player := range handState.PlayersInSeats {
-		if seatNo == 0 {
-			continue
-		}
-		balance[uint32(seatNo)] = player.Stack
-	}
-
-	// update player stats
-	for _, playerID := range handState.ActiveSeats {
-		if playerID == 0 {
-			continue
-		}
-		handState.PlayerStats[playerID].InRiver = true
-	}
-
 	numBoardCards := 5
-	playerCardRanks := g.getPlayerCardRanks(handState, numBoardCards)
-	if util.Env.IsEncryptionEnabled() {
-		var err error
-		playerCardRanks, err = g.encryptPlayerCardRanks(playerCardRanks, handState.PlayersInSeats)
-		if err != nil {
-			return nil, err
-		}
-	}
-	cardsStr := poker.CardsToString(handState.BoardCards)
+	cardsStr := poker.CardsToString(h.BoardCards)
 	boardCards := make([]uint32, numBoardCards)
-	for i, card := range handState.BoardCards {
+	for i, card := range h.BoardCards {
 		boardCards[i] = uint32(card)
 	}
-	boards := make([]*Board, 0)
-	for _, board := range handState.Boards {
-		riverCards := make([]uint32, numBoardCards)
-		for i, card := range board.Cards[:numBoardCards] {
-			riverCards[i] = uint32(card)
-		}
-		board1 := &Board{
-			BoardNo: board.BoardNo,
-			Cards:   riverCards,
-		}
-		boards = append(boards, board1)
-	}
 
 	river := &River{
-		Board:           boardCards,
-		Boards:          boards,
-		RiverCard:       boardCards[numBoardCards-1],
-		CardsStr:        cardsStr,
-		Pots:            pots,
-		SeatsPots:       seatsInPots,
-		PlayerBalance:   balance,
-		PlayerCardRanks: playerCardRanks,
-		PotUpdates:      potUpdates,
+		Board:                  boardCards,
+		Boards:                 h.boardsUpTo(numBoardCards),
+		RiverCard:              boardCards[numBoardCards-1],
+		CardsStr:               cardsStr,
+		Pots:                   pots,
+		SeatsPots:              seatsInPots,
+		PlayerBalance:          h.playerBalances(),
+		PlayerCardRanks:        h.getPlayerCardRanks(numBoardCards),
+		PotUpdates:             potUpdates,
+		PlayerHandDescriptions: h.getPlayerHandDescriptions(numBoardCards),
 	}
 	msgItem := &HandMessageItem{
 		MessageType: HandRiver,
 		Content:     &HandMessageItem_River{River: river},
 	}
 
-	return []*HandMessageItem{msgItem}, nil
+	return msgItem, nil
 }
 
 func (g *Game) encryptPlayerCardRanks(playerCardRanks map[uint32]string, playersInSeats []*PlayerInSeatState) (map[uint32]string, error) {
@@ -1197,181 +1109,119 @@ func (g *Game) encryptPlayerCardRanks(playerCardRanks map[uint32]string, players
 	return encryptedRanks, nil
 }
 
-func (g *Game) handEnded(handState *HandState) ([]*HandMessageItem, error) {
-	handEnded := &HandMessageItem{
-		MessageType: HandEnded,
-	}
-
-	return []*HandMessageItem{handEnded}, nil
-}
-
-func (g *Game) sendResult2(hs *HandState, handResultClient *HandResultClient) ([]*HandMessageItem, error) {
-	//handResultClient.PlayerInfo = make(map[uint32]*PlayerHandInfo)
-	hs.CurrentState = HandStatus_RESULT
-
-	for seatNo, player := range hs.PlayersInSeats {
-		if seatNo == 0 || !player.Inhand || player.OpenSeat {
-			continue
-		}
-
-		before := float64(0.0)
-		after := float64(0.0)
-		for _, playerBalance := range hs.BalanceBeforeHand {
-			if playerBalance.SeatNo == uint32(seatNo) {
-				before = playerBalance.Balance
-				break
-			}
-		}
-		if balance, ok := handResultClient.PlayerInfo[uint32(seatNo)]; ok {
-			after = balance.Balance.After
-		} else {
-			after = player.Stack
-		}
-		rakePaid := float64(0.0)
-		if playerRake, ok := hs.RakePaid[player.PlayerId]; ok {
-			rakePaid = playerRake
-		}
-		if _, ok := handResultClient.PlayerInfo[uint32(seatNo)]; !ok {
-			handResultClient.PlayerInfo[uint32(seatNo)] = &PlayerHandInfo{
-				Id: player.PlayerId,
-				Balance: &HandPlayerBalance{
-					Before: before,
-					After:  after,
-				},
-				Received: player.PlayerReceived,
-				RakePaid: rakePaid,
+// processHandMessages does the game side of the hand messages produced by the
+// hand engine before they are broadcasted: encrypts the card ranks, starts the
+// action timers and saves the hand result.
+func (g *Game) processHandMessages(handState *HandState, msgItems []*HandMessageItem) error {
+	var err error
+	runItTwicePrompt := false
+	for _, msgItem := range msgItems {
+		var playerCardRanks, playerHandDescriptions *map[uint32]string
+		switch msgItem.MessageType {
+		case HandFlop:
+			playerCardRanks = &msgItem.GetFlop().PlayerCardRanks
+			playerHandDescriptions = &msgItem.GetFlop().PlayerHandDescriptions
+		case HandTurn:
+			playerCardRanks = &msgItem.GetTurn().PlayerCardRanks
+			playerHandDescriptions = &msgItem.GetTurn().PlayerHandDescriptions
+		case HandRiver:
+			playerCardRanks = &msgItem.GetRiver().PlayerCardRanks
+			playerHandDescriptions = &msgItem.GetRiver().PlayerHandDescriptions
+		case HandYourAction:
+			seatAction := msgItem.GetSeatAction()
+			if len(seatAction.AvailableActions) > 0 && seatAction.AvailableActions[0] == ACTION_RUN_IT_TWICE_PROMPT {
+				runItTwicePrompt = true
+			} else {
+				g.startActionTimer(handState, seatAction)
 			}
-		}
-	}
-	var highHandWinners []*HighHandWinner
-
-	// determine high hand winners
-	if hs.HighHandTracked {
-		highHandWinners = make([]*HighHandWinner, 0)
-		// walk through each player's rank
-		highRankFound := false
-		highRank := uint32(0)
-		for _, board := range handResultClient.Boards {
-			for _, playerRank := range board.PlayerRank {
-				if playerRank.HhRank == 0 ||
-					playerRank.HhRank > MIN_FULLHOUSE_RANK {
-					continue
-				}
-				if hs.HighHandRank == 0 {
-					highRankFound = true
-					highRank = playerRank.HhRank
-				}
-				if playerRank.HhRank <= hs.HighHandRank {
-					highRankFound = true
-					highRank = playerRank.HiRank
-				}
+		case HandResultMessage2:
+			err = g.saveHandResult(handState, msgItem.GetHandResultClient())
+			if err != nil {
+				return err
 			}
 		}
 
-		if highRankFound {
-			for _, board := range handResultClient.Boards {
-				for seatNo, playerRank := range board.PlayerRank {
-					if playerRank.HhRank == highRank {
-						player := hs.PlayersInSeats[seatNo]
-						winner := &HighHandWinner{
-							PlayerId:    player.PlayerId,
-							PlayerName:  player.Name,
-							SeatNo:      seatNo,
-							HhRank:      playerRank.HhRank,
-							HhCards:     playerRank.HhCards,
-							BoardNo:     board.BoardNo,
-							PlayerCards: poker.ByteCardsToUint32Cards(hs.PlayersCards[seatNo]),
-						}
-						highHandWinners = append(highHandWinners, winner)
-					}
+		if playerCardRanks != nil {
+			g.logger.Debug().
+				Uint32(logging.HandNumKey, handState.GetHandNum()).
+				Msgf("Moving to %s", msgItem.MessageType)
+			if util.Env.IsEncryptionEnabled() {
+				*playerCardRanks, err = g.encryptPlayerCardRanks(*playerCardRanks, handState.PlayersInSeats)
+				if err != nil {
+					return err
+				}
+				*playerHandDescriptions, err = g.encryptPlayerCardRanks(*playerHandDescriptions, handState.PlayersInSeats)
+				if err != nil {
+					return err
 				}
 			}
 		}
 	}
 
-	handResultClient.HandNum = hs.HandNum
-	handResultClient.HighHandWinners = highHandWinners
-	msgItem2 := &HandMessageItem{
-		MessageType: HandResultMessage2,
-		Content:     &HandMessageItem_HandResultClient{HandResultClient: handResultClient},
+	if runItTwicePrompt {
+		g.startRunItTwiceTimer(handState, msgItems)
 	}
-	msgItems := make([]*HandMessageItem, 0)
-	msgItems = append(msgItems, msgItem2)
-	return msgItems, nil
+	return nil
 }
 
-func (g *Game) moveToNextRound(handState *HandState) ([]*HandMessageItem, error) {
-	if handState.LastState == HandStatus_DEAL {
+func (h *HandState) moveToNextRound() ([]*HandMessageItem, error) {
+	if h.LastState == HandStatus_DEAL {
 		// How do we get here?
-		g.logger.Warn().
+		handLogger.Warn().
+			Uint64(logging.GameIDKey, h.GetGameId()).
+			Uint32(logging.HandNumKey, h.GetHandNum()).
 			Msg("handState.LastState == HandStatus_DEAL in moveToNextRound")
 		return []*HandMessageItem{}, nil
 	}
 
 	// remove folded players from the pots
-	handState.removeFoldedPlayersFromPots()
+	h.removeFoldedPlayersFromPots()
 
 	var allMsgItems []*HandMessageItem
-	var msgItems []*HandMessageItem
+	var msgItem *HandMessageItem
 	var err error
 
-	if handState.LastState == HandStatus_PREFLOP && handState.CurrentState == HandStatus_FLOP {
-		msgItems, err = g.gotoFlop(handState)
-	} else if handState.LastState == HandStatus_FLOP && handState.CurrentState == HandStatus_TURN {
-		msgItems, err = g.gotoTurn(handState)
-	} else if handState.LastState == HandStatus_TURN && handState.CurrentState == HandStatus_RIVER {
-		msgItems, err = g.gotoRiver(handState)
+	if h.LastState == HandStatus_PREFLOP && h.CurrentState == HandStatus_FLOP {
+		msgItem, err = h.gotoFlop()
+	} else if h.LastState == HandStatus_FLOP && h.CurrentState == HandStatus_TURN {
+		msgItem, err = h.gotoTurn()
+	} else if h.LastState == HandStatus_TURN && h.CurrentState == HandStatus_RIVER {
+		msgItem, err = h.gotoRiver()
 	}
 	if err != nil {
 		return nil, err
 	}
-	allMsgItems = append(allMsgItems, msgItems...)
+	if msgItem != nil {
+		allMsgItems = append(allMsgItems, msgItem)
+	}
 
-	msgItems, err = g.moveToNextAction(handState)
+	msgItems, err := h.moveToNextAction()
 	if err != nil {
 		return nil, err
 	}
-	allMsgItems = append(allMsgItems, msgItems...)
-
-	return allMsgItems, nil
+	return append(allMsgItems, msgItems...), nil
 }
 
-func (g *Game) moveToNextAction(handState *HandState) ([]*HandMessageItem, error) {
-	if handState.NextSeatAction == nil {
+func (h *HandState) moveToNextAction() ([]*HandMessageItem, error) {
+	if h.NextSeatAction == nil {
 		return nil, fmt.Errorf("moveToNextAction called when handState.NextSeatAction == nil")
 	}
-
 	var allMsgItems []*HandMessageItem
 
-	var canCheck bool
-	for _, action := range handState.NextSeatAction.AvailableActions {
-		if action == ACTION_CHECK {
-			canCheck = true
-			break
-		}
-	}
 	// tell the next player to act
 	yourActionMsg := &HandMessageItem{
 		MessageType: HandYourAction,
-		Content:     &HandMessageItem_SeatAction{SeatAction: handState.NextSeatAction},
+		Content:     &HandMessageItem_SeatAction{SeatAction: h.NextSeatAction},
 	}
-	player := handState.PlayersInSeats[handState.NextSeatAction.SeatNo]
-	// Additional time for network delay, client animation delay, etc.
-	// This doesn't need to be accurate. When the action times out,
-	// the client will submit a default action. This is just a fallback
-	// in case the client is unable to do that.
-	actionTimesoutAt := time.Now().Add(time.Duration(handState.ActionTime+g.timerCushionSec) * time.Second)
-	handState.NextSeatAction.ActionTimesoutAt = actionTimesoutAt.Unix()
-	g.resetTimer(handState.NextSeatAction.SeatNo, player.PlayerId, canCheck, actionTimesoutAt, handState.NextSeatAction.ActionId)
 	allMsgItems = append(allMsgItems, yourActionMsg)
 
 	pots := make([]float64, 0)
 	currentPot := float64(0)
-	for _, pot := range handState.Pots {
+	for _, pot := range h.Pots {
 		pots = append(pots, pot.Pot)
 		currentPot += pot.Pot
 	}
-	roundState := handState.RoundState[uint32(handState.CurrentState)]
+	roundState := h.RoundState[uint32(h.CurrentState)]
 	currentBettingRound := roundState.Betting
 	seatBets := currentBettingRound.SeatBet
 	for _, bet := range seatBets {
@@ -1380,11 +1230,11 @@ func (g *Game) moveToNextAction(handState *HandState) ([]*HandMessageItem, error
 
 	// action moves to the next player
 	actionChange := &ActionChange{
-		SeatNo:     handState.NextSeatAction.SeatNo,
+		SeatNo:     h.NextSeatAction.SeatNo,
 		Pots:       pots,
 		PotUpdates: currentPot,
-		SeatsPots:  handState.Pots,
-		BetAmount:  handState.getMaxBet(),
+		SeatsPots:  h.Pots,
+		BetAmount:  h.getMaxBet(),
 	}
 
 	nextActionMsg := &HandMessageItem{
@@ -1397,171 +1247,78 @@ func (g *Game) moveToNextAction(handState *HandState) ([]*HandMessageItem, error
 	return allMsgItems, nil
 }
 
-func (g *Game) allPlayersAllIn(handState *HandState) ([]*HandMessageItem, error) {
+func (g *Game) startActionTimer(handState *HandState, seatAction *NextSeatAction) {
+	var canCheck bool
+	for _, action := range seatAction.AvailableActions {
+		if action == ACTION_CHECK {
+			canCheck = true
+			break
+		}
+	}
+	player := handState.PlayersInSeats[seatAction.SeatNo]
+	// Additional time for network delay, client animation delay, etc.
+	// This doesn't need to be accurate. When the action times out,
+	// the client will submit a default action. This is just a fallback
+	// in case the client is unable to do that.
+	actionTimesoutAt := time.Now().Add(time.Duration(handState.ActionTime+g.timerCushionSec) * time.Second)
+	seatAction.ActionTimesoutAt = actionTimesoutAt.Unix()
+	g.resetTimer(seatAction.SeatNo, player.PlayerId, canCheck, actionTimesoutAt, seatAction.ActionId)
+}
+
+func (h *HandState) allPlayersAllIn() ([]*HandMessageItem, error) {
 	var allMsgItems []*HandMessageItem
-	var msgItems []*HandMessageItem
+	var msgItem *HandMessageItem
 	var err error
 
-	_, seatsInPots := g.getPots(handState)
+	_, seatsInPots := h.getPots()
 
 	// broadcast the players no more actions
 	noMoreActions := &NoMoreActions{
 		Pots: seatsInPots,
 	}
-	msgItem := &HandMessageItem{
+	allMsgItems = append(allMsgItems, &HandMessageItem{
 		MessageType: HandNoMoreActions,
 		Content:     &HandMessageItem_NoMoreActions{NoMoreActions: noMoreActions},
-	}
-
-	allMsgItems = append(allMsgItems, msgItem)
+	})
 
-	for handState.CurrentState != HandStatus_SHOW_DOWN {
-		switch handState.CurrentState {
+	for h.CurrentState != HandStatus_SHOW_DOWN {
+		switch h.CurrentState {
 		case HandStatus_FLOP:
-			msgItems, err = g.gotoFlop(handState)
-			handState.CurrentState = HandStatus_TURN
+			msgItem, err = h.gotoFlop()
+			h.CurrentState = HandStatus_TURN
 		case HandStatus_TURN:
-			msgItems, err = g.gotoTurn(handState)
-			handState.CurrentState = HandStatus_RIVER
+			msgItem, err = h.gotoTurn()
+			h.CurrentState = HandStatus_RIVER
 		case HandStatus_RIVER:
-			msgItems, err = g.gotoRiver(handState)
-			handState.CurrentState = HandStatus_SHOW_DOWN
+			msgItem, err = h.gotoRiver()
+			h.CurrentState = HandStatus_SHOW_DOWN
+		default:
+			return nil, fmt.Errorf("Unexpected hand status %s while running out the board", h.CurrentState)
 		}
 		if err != nil {
 			return nil, err
 		}
-		allMsgItems = append(allMsgItems, msgItems...)
+		allMsgItems = append(allMsgItems, msgItem)
 	}
 
-	msgItems, err = g.showdown(handState)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error from showdown")
-	}
-	allMsgItems = append(allMsgItems, msgItems...)
+	allMsgItems = append(allMsgItems, h.showdown()...)
 	return allMsgItems, nil
 }
 
-func (g *Game) showdown(handState *HandState) ([]*HandMessageItem, error) {
-	// update hand stats
-	handState.HandStats.EndedAtShowdown = true
-	// update player stats
-	for _, playerID := range handState.ActiveSeats {
-		if playerID == 0 {
-			continue
-		}
-		handState.PlayerStats[playerID].WentToShowdown = true
-	}
-
-	handState.removeFoldedPlayersFromPots()
-	handState.removeEmptyPots()
-	handState.HandCompletedAt = HandStatus_SHOW_DOWN
-
-	// track whether the player is active in this round or not
-	for seatNo, playerID := range handState.ActiveSeats {
-		if playerID == 0 {
-			continue
-		}
-		player := handState.PlayersInSeats[seatNo]
-		if player.Inhand {
-			player.Round = HandStatus_SHOW_DOWN
-		}
-	}
-
-	var allMsgItems []*HandMessageItem
-	var msgItems []*HandMessageItem
-	var err error
-	msgItems, err = g.generateAndSendResult(handState)
-	if err != nil {
-		return nil, err
-	}
-	allMsgItems = append(allMsgItems, msgItems...)
-
-	msgItems, err = g.handEnded(handState)
-	if err != nil {
-		return nil, err
-	}
-	allMsgItems = append(allMsgItems, msgItems...)
-
-	return allMsgItems, nil
+func (h *HandState) showdown() []*HandMessageItem {
+	h.prepareShowdown()
+	return h.handResultMessages()
 }
 
-func (g *Game) onePlayerRemaining(handState *HandState) ([]*HandMessageItem, error) {
-	switch handState.CurrentState {
-	case HandStatus_DEAL:
-		handState.HandStats.EndedAtPreflop = true
-	case HandStatus_FLOP:
-		handState.HandStats.EndedAtFlop = true
-	case HandStatus_TURN:
-		handState.HandStats.EndedAtTurn = true
-	case HandStatus_RIVER:
-		handState.HandStats.EndedAtRiver = true
-	}
-	// every one folded except one player, send the pot to the player
-	handState.everyOneFoldedWinners()
-	handState.CurrentState = HandStatus_RESULT
-
-	// scenario:
-	// player1 bets   10
-	// player2 calls  10
-	// player3 raises 40
-	// player1 folds
-	// player2 folds
-	// player3 raise 40 is not part of the pot contribution
-	// remove the player raise from pot contribution
-	for seatNo, _ := range handState.PotContribution {
-		if seatNo != handState.RaiseSeatNo {
-			continue
-		}
-		handState.PotContribution[seatNo] -= handState.CurrentRaiseDiff
-	}
-
-	var allMsgItems []*HandMessageItem
-	var msgItems []*HandMessageItem
-	var err error
-	msgItems, err = g.generateAndSendResult(handState)
-	if err != nil {
-		return nil, err
-	}
-	allMsgItems = append(allMsgItems, msgItems...)
-
-	msgItems, err = g.handEnded(handState)
-	if err != nil {
-		return nil, err
-	}
-	allMsgItems = append(allMsgItems, msgItems...)
-
-	return allMsgItems, nil
+func (h *HandState) onePlayerRemaining() []*HandMessageItem {
+	h.prepareOnePlayerRemaining()
+	return h.handResultMessages()
 }
 
-func (g *Game) generateAndSendResult(handState *HandState) ([]*HandMessageItem, error) {
-	hs := handState
-	for i := len(hs.Pots) - 1; i >= 0; i-- {
-		currentPot := hs.Pots[len(hs.Pots)-1]
-		if currentPot.Pot == 0 {
-			hs.Pots = hs.Pots[:len(hs.Pots)-1]
-			continue
-		}
-		// if current pot has only one player, return the money to the player
-		// if len(currentPot.Seats) == 1 {
-		// 	activePlayer := currentPot.Seats[0]
-		// 	player := hs.PlayersInSeats[activePlayer]
-		// 	player.Stack += currentPot.Pot
-		// 	// remove the pot
-		// 	hs.Pots = hs.Pots[:len(hs.Pots)-1]
-		// }
-	}
-
-	handResultProcessor := NewHandResultProcessor(handState, g.chipUnit, uint32(handState.MaxSeats), nil)
-
-	handResult2Client := handResultProcessor.determineWinners()
-	allMsgItems := make([]*HandMessageItem, 0)
-	handResult2Client.PlayerStats = handState.GetPlayerStats()
-	handResult2Client.TimeoutStats = handState.GetTimeoutStats()
-
-	msgItems, err := g.sendResult2(handState, handResult2Client)
-	if err != nil {
-		return nil, err
-	}
+// handResultMessages determines the winners and returns the result and the hand
+// ended messages.
+func (h *HandState) handResultMessages() []*HandMessageItem {
+	handResult2Client := h.determineResult(h.ChipUnit)
 
 	// determine total pause time
 	totalPauseTime := uint32(0)
@@ -1573,22 +1330,27 @@ func (g *Game) generateAndSendResult(handState *HandState) ([]*HandMessageItem,
 			}
 		}
 	}
-	hs.TotalResultPauseTime = totalPauseTime
+	h.TotalResultPauseTime = totalPauseTime
 
-	allMsgItems = append(allMsgItems, msgItems...)
-	// send the hand to the database to store first
-	// handResult := handResultProcessor.getResult(true /*db*/)
-	// handResult.NoCards = g.NumCards(handState.GameType)
-	// handResult.SmallBlind = handState.SmallBlind
-	// handResult.BigBlind = handState.BigBlind
-	// handResult.MaxPlayers = handState.MaxSeats
+	return []*HandMessageItem{
+		{
+			MessageType: HandResultMessage2,
+			Content:     &HandMessageItem_HandResultClient{HandResultClient: handResult2Client},
+		},
+		{
+			MessageType: HandEnded,
+		},
+	}
+}
 
+// saveHandResult checks the hand result and sends it to the api server.
+func (g *Game) saveHandResult(hs *HandState, handResult2Client *HandResultClient) error {
 	handResultServer := &HandResultServer{
 		GameId:        hs.GameId,
 		HandNum:       hs.HandNum,
 		GameType:      hs.GameType,
 		ButtonPos:     hs.ButtonPos,
-		NoCards:       g.NumCards(handState.GameType),
+		NoCards:       g.NumCards(hs.GameType),
 		HandLog:       hs.getLog(),
 		HandStats:     hs.GetHandStats(),
 		RunItTwice:    hs.RunItTwiceConfirmed,
@@ -1598,8 +1360,13 @@ func (g *Game) generateAndSendResult(handState *HandState) ([]*HandMessageItem,
 		Result:        handResult2Client,
 		CollectedAnte: hs.CollectedAnte,
 	}
+	g.recordTableStacks(hs, handResult2Client)
+	if hs.Tournament {
+		handResultServer.Bounties = g.payBounties(handResult2Client)
+		g.offerTournamentBuyIns(hs.HandNum)
+	}
 
-	err = g.analyzeResult(handResultServer)
+	err := g.analyzeResult(handResultServer)
 	if err != nil {
 		var msg string
 		b, e := protojson.Marshal(handResultServer)
@@ -1619,25 +1386,13 @@ func (g *Game) generateAndSendResult(handState *HandState) ([]*HandMessageItem,
 
 	sendResultToAPI := !g.isScriptTest
 	if sendResultToAPI {
-		if g.tournamentID != 0 {
-			_, err := g.saveTournamentHandResult2ToAPIServer(g.tournamentURL, handResultServer)
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not save hand result to api server")
-			}
-		} else {
-			_, err := g.saveHandResult2ToAPIServer(handResultServer)
-			if err != nil {
-				// if save hand failed, don't return error
-				// return nil, errors.Wrapf(err, "Could not save hand result to api server")
-				g.logger.Error().
-					Uint32(logging.HandNumKey, handState.GetHandNum()).
-					Msgf("Saving hand failed. Error: %s", err.Error())
-				return nil, nil
-			}
+		err = g.queueHandResult(handResultServer)
+		if err != nil {
+			return errors.Wrapf(err, "Could not queue hand result for the api server")
 		}
 	}
 
-	return allMsgItems, nil
+	return nil
 }
 
 func (g *Game) analyzeResult(handResult *HandResultServer) error {
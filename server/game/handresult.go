@@ -78,6 +78,12 @@ func (hr *HandResultProcessor) evaluateRank() {
 				HhCards:  poker.ByteCardsToUint32Cards(eval.hhCards),
 				HhRank:   uint32(eval.hhRank),
 			}
+			if len(eval.cards) == 5 {
+				board.PlayerRank[seatNo].HiDescription = newHandDescription(poker.DescribeHand(eval.rank, poker.FromByteCards(eval.cards)))
+			}
+			if lowFound {
+				board.PlayerRank[seatNo].LoDescription = newHandDescription(poker.DescribeLow(poker.FromByteCards(eval.locards)))
+			}
 		}
 	}
 }
@@ -184,7 +190,7 @@ func (hr *HandResultProcessor) determineWinners() *HandResultClient {
 			if !oneWinner {
 				// determined winning ranks in this board
 				hiRank, loRank = hr.determineHiLoRank(i, pot.Seats)
-				boardWinner.HiRankText = poker.RankString(hiRank)
+				boardWinner.HiRankText = poker.RankString(hs.deckDefinition().StandardRank(hiRank))
 			}
 
 			// determine winners
@@ -340,6 +346,11 @@ func (hr *HandResultProcessor) determineWinners() *HandResultClient {
 					hs.PlayerStats[playerID].WonChipsAtShowdown = true
 				}
 			}
+			if !winningPlayers[uint32(seatNo)] && len(hs.Boards) > 0 {
+				if rank, ok := hs.Boards[0].PlayerRank[uint32(seatNo)]; ok && isBadBeat(int32(rank.HiRank), hs.PlayerStats[playerID]) {
+					hs.PlayerStats[playerID].Badbeat = true
+				}
+			}
 		}
 	}
 	pauseTime := hs.ResultPauseTime
@@ -1,9 +1,11 @@
 package game
 
 import (
+	"encoding/hex"
 	"fmt"
 	"math"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -100,7 +102,9 @@ func (h *HandState) initialize(testGameConfig *TestGameConfig,
 	testHandSetup *TestHandSetup,
 	buttonPos uint32, sbPos uint32, bbPos uint32,
 	playersInSeats []SeatPlayer,
-	chipUnit ChipUnit) error {
+	chipUnit ChipUnit,
+	presetDeck []byte,
+	rng *rand.Rand) error {
 
 	h.Tournament = newHandInfo.Tournament
 	if h.Tournament {
@@ -228,6 +232,8 @@ func (h *HandState) initialize(testGameConfig *TestGameConfig,
 		h.BombPot = newHandInfo.BombPot
 		h.BombPotBet = newHandInfo.BombPotBet
 		h.DoubleBoard = newHandInfo.DoubleBoard
+		h.DeckType = newHandInfo.DeckType
+		h.NumDecks = newHandInfo.NumDecks
 	}
 
 	if testHandSetup != nil {
@@ -247,18 +253,19 @@ func (h *HandState) initialize(testGameConfig *TestGameConfig,
 
 	// if the players don't have money less than the blinds
 	// don't let them play
-	if sbPos != 0 && bbPos != 0 {
+	if sbPos != 0 && bbPos != 0 && h.activeSeatsCount() > 2 {
 		h.SmallBlindPos = sbPos
 		h.BigBlindPos = bbPos
 	} else {
 		// TODO: make sure small blind is still there
 		// if small blind left the game, we can have dead small
 		// to make it simple, we will make new players to always to post or wait for the big blind
-		sb, bb, err := h.getBlindPos()
+		// heads-up blinds are always computed from the button
+		button, sb, bb, err := h.getBlindPos()
 		if err != nil {
 			return errors.Wrap(err, "Error while getting blind positions")
 		}
-		h.SmallBlindPos, h.BigBlindPos = sb, bb
+		h.ButtonPos, h.SmallBlindPos, h.BigBlindPos = button, sb, bb
 	}
 
 	h.BalanceBeforeHand = make([]*PlayerBalance, 0)
@@ -279,23 +286,30 @@ func (h *HandState) initialize(testGameConfig *TestGameConfig,
 				Balance:  playerInSeat.Stack})
 	}
 
+	err := h.checkDeckSize()
+	if err != nil {
+		return err
+	}
+
 	var deck *poker.Deck
 	var b1Cards, b2Cards []poker.Card
 	var playerCardsMap map[uint32][]poker.Card
 	var numCardsUsed int
 
-	if testHandSetup == nil || testHandSetup.PlayerCards == nil {
+	if presetDeck != nil {
+		// The deck is given by the caller (hand engine).
+		h.Deck = presetDeck
+		playerCardsMap, b1Cards, b2Cards, numCardsUsed = h.pickScriptedCardsFromDeck(poker.DeckFromBytes(presetDeck), nil)
+	} else if testHandSetup == nil || testHandSetup.PlayerCards == nil {
 		// Real game or auto-play script.
-		playerCardsMap, b1Cards, b2Cards, deck, numCardsUsed = h.shuffleAndPickCards()
-		reshuffles := 0
-		for reshuffles < 10 && TooManyHighHands(newHandInfo, h.GameType, playerCardsMap, b1Cards, b2Cards) {
-			reshuffles++
-			handLogger.Debug().
-				Uint64(logging.GameIDKey, h.GetGameId()).
-				Uint32(logging.HandNumKey, h.GetHandNum()).
-				Msgf("Reshuffling for too many high hands. TotalHands=%d StraightFlushCount=%d FourKindCount=%d StraightFlushAllowed=%v FourKindAllowed=%v", newHandInfo.TotalHands, newHandInfo.StraightFlushCount, newHandInfo.FourKindCount, newHandInfo.StraightFlushAllowed, newHandInfo.FourKindAllowed)
-			playerCardsMap, b1Cards, b2Cards, deck, numCardsUsed = h.shuffleAndPickCards()
+		if rng == nil {
+			rng = poker.NewCryptoRand()
+		}
+		err := h.initShuffleSeeds(playersInSeats, rng)
+		if err != nil {
+			return err
 		}
+		playerCardsMap, b1Cards, b2Cards, deck, numCardsUsed = h.dealWithPolicy(newHandInfo)
 		h.Deck = deck.GetBytes()
 	} else {
 		// running script test, botrunner script, etc.
@@ -372,21 +386,103 @@ func (h *HandState) initialize(testGameConfig *TestGameConfig,
 }
 
 func TooManyHighHands(newHandInfo *NewHandInfo, gameType GameType, playerCards map[uint32][]poker.Card, b1Cards []poker.Card, b2Cards []poker.Card) bool {
-	if !newHandInfo.StraightFlushAllowed {
-		return AnyoneHasHighHand(playerCards, b1Cards, gameType, poker.MaxStraightFlush) ||
-			AnyoneHasHighHand(playerCards, b2Cards, gameType, poker.MaxStraightFlush)
+	return rareHandReshuffleReason(newHandInfo, gameType, playerCards, b1Cards, b2Cards) != ""
+}
+
+// initShuffleSeeds collects the client seeds of the players in the hand for
+// the provably fair shuffle. The server seed committed with the previous hand
+// (h.ServerSeed) is used, or a new one is generated for the first hand. The
+// server seed of the next hand is generated (unless the hand is dealt again)
+// to be committed in this hand.
+func (h *HandState) initShuffleSeeds(playersInSeats []SeatPlayer, rng *rand.Rand) error {
+	if h.ServerSeed == nil {
+		h.ServerSeed = poker.NewServerSeed(rng)
+	} else if len(h.ServerSeed) != poker.ServerSeedLen {
+		return fmt.Errorf("Server seed must be %d bytes. Got %d bytes", poker.ServerSeedLen, len(h.ServerSeed))
+	}
+	if h.NextServerSeed == nil {
+		h.NextServerSeed = poker.NewServerSeed(rng)
+	}
+	clientSeeds := make(map[uint32]string)
+	for _, playerInSeat := range playersInSeats {
+		if playerInSeat.PlayerID != 0 && playerInSeat.Inhand {
+			clientSeeds[playerInSeat.SeatNo] = playerInSeat.ClientSeed
+		}
 	}
+	h.SeedCommitment = poker.SeedCommitment(h.ServerSeed)
+	h.ClientSeeds = make([]string, 0, len(clientSeeds))
+	for seatNo := uint32(1); seatNo <= h.MaxSeats; seatNo++ {
+		if clientSeed, ok := clientSeeds[seatNo]; ok {
+			h.ClientSeeds = append(h.ClientSeeds, clientSeed)
+		}
+	}
+	h.ShuffleNonce = 0
+	return nil
+}
 
-	if !newHandInfo.FourKindAllowed {
-		return AnyoneHasHighHand(playerCards, b1Cards, gameType, poker.MaxFourOfAKind) ||
-			AnyoneHasHighHand(playerCards, b2Cards, gameType, poker.MaxFourOfAKind)
+func (h *HandState) shuffleSeeds() poker.ShuffleSeeds {
+	return poker.ShuffleSeeds{
+		ServerSeed:  h.ServerSeed,
+		ClientSeeds: h.ClientSeeds,
+		Nonce:       h.ShuffleNonce,
+		Deck:        h.deckDefinition(),
 	}
+}
 
-	return false
+// shuffleDeck returns the next deck derived from the shuffle seeds.
+func (h *HandState) shuffleDeck() *poker.Deck {
+	h.ShuffleNonce++
+	return poker.FairDeck(h.shuffleSeeds())
+}
+
+// shuffleCoin returns the coin flip of the current deck for the dealing
+// policy.
+func (h *HandState) shuffleCoin() bool {
+	return poker.FairCoin(h.shuffleSeeds())
+}
+
+// shuffleReveal returns the seeds of the deck for the hand result. Returns nil
+// if the deck didn't come from the provably fair shuffle.
+func (h *HandState) shuffleReveal() *ShuffleReveal {
+	if h.ShuffleNonce == 0 {
+		return nil
+	}
+	dealtSeats := make([]uint32, 0, len(h.PlayersCards))
+	for seatNo := range h.PlayersCards {
+		dealtSeats = append(dealtSeats, seatNo)
+	}
+	sort.Slice(dealtSeats, func(i, j int) bool { return dealtSeats[i] < dealtSeats[j] })
+	var runItTwiceStage HandStatus
+	if h.RunItTwiceConfirmed {
+		runItTwiceStage = h.RunItTwice.Stage
+	}
+	return &ShuffleReveal{
+		ServerSeed:           hex.EncodeToString(h.ServerSeed),
+		ClientSeeds:          h.ClientSeeds,
+		Nonce:                h.ShuffleNonce,
+		Commitment:           h.SeedCommitment,
+		CardsUsed:            h.DeckIndex,
+		DeckType:             h.DeckType,
+		NumDecks:             h.NumDecks,
+		Reshuffles:           h.Reshuffles,
+		DealingPolicy:        h.DealingPolicy,
+		StraightFlushAllowed: h.StraightFlushAllowed,
+		FourKindAllowed:      h.FourKindAllowed,
+		GameType:             h.GameType,
+		MaxSeats:             h.MaxSeats,
+		ButtonPos:            h.ButtonPos,
+		DealtSeats:           dealtSeats,
+		BurnCards:            h.BurnCards,
+		DoubleBoard:          h.DoubleBoard,
+		RunItTwiceStage:      runItTwiceStage,
+	}
 }
 
+// shuffleAndPickCards deals with the legacy dealing policy (without the
+// reshuffles for the straight flushes and four of a kinds that are not allowed).
+// The coin flips come from the shuffle seeds, so the deck dealt can be verified.
 func (h *HandState) shuffleAndPickCards() (map[uint32][]poker.Card, []poker.Card, []poker.Card, *poker.Deck, int) {
-	deck := poker.NewDeck().Shuffle()
+	deck := h.shuffleDeck()
 	tmpDeck := poker.CopyDeck(deck)
 	playerCardsMap, b1Cards, b2Cards, numCardsUsed := h.drawFromDeck(tmpDeck, nil)
 
@@ -394,15 +490,17 @@ func (h *HandState) shuffleAndPickCards() (map[uint32][]poker.Card, []poker.Card
 	if handNum <= 10 {
 		for AnyoneHasHighHand(playerCardsMap, b1Cards, h.GameType, poker.MaxFourOfAKind) ||
 			AnyoneHasHighHand(playerCardsMap, b2Cards, h.GameType, poker.MaxFourOfAKind) {
-			deck.Shuffle()
+			h.addReshuffle(ReshuffleEarlyHandFourOfAKind)
+			deck = h.shuffleDeck()
 			tmpDeck = poker.CopyDeck(deck)
 			playerCardsMap, b1Cards, b2Cards, numCardsUsed = h.drawFromDeck(tmpDeck, nil)
 		}
 	} else if handNum > 10 && handNum <= 20 {
 		if AnyoneHasHighHand(playerCardsMap, b1Cards, h.GameType, poker.MaxFourOfAKind) ||
 			AnyoneHasHighHand(playerCardsMap, b2Cards, h.GameType, poker.MaxFourOfAKind) {
-			if rand.Int()%2 == 0 {
-				deck.Shuffle()
+			if h.shuffleCoin() {
+				h.addReshuffle(ReshuffleFourOfAKind)
+				deck = h.shuffleDeck()
 				tmpDeck = poker.CopyDeck(deck)
 				playerCardsMap, b1Cards, b2Cards, numCardsUsed = h.drawFromDeck(tmpDeck, nil)
 			}
@@ -411,13 +509,18 @@ func (h *HandState) shuffleAndPickCards() (map[uint32][]poker.Card, []poker.Card
 
 	if !AnyoneHasHighHand(playerCardsMap, b1Cards, h.GameType, poker.MaxFullHouse) &&
 		!AnyoneHasHighHand(playerCardsMap, b2Cards, h.GameType, poker.MaxFullHouse) {
-		if rand.Int()%2 == 0 {
+		if h.shuffleCoin() {
 			maxReshuffleAllowed := 1
 			reshuffles := 0
 			for AnyoneHasHighHand(playerCardsMap, b2Cards, h.GameType, poker.MaxFullHouse) ||
 				(reshuffles < maxReshuffleAllowed && NeedReshuffle(playerCardsMap, b1Cards, b2Cards, h.GameType)) {
+				if AnyoneHasHighHand(playerCardsMap, b2Cards, h.GameType, poker.MaxFullHouse) {
+					h.addReshuffle(ReshuffleBoard2FullHouse)
+				} else {
+					h.addReshuffle(ReshuffleSameHoleCardsOrPairedBoard)
+				}
 				reshuffles++
-				deck.Shuffle()
+				deck = h.shuffleDeck()
 				tmpDeck = poker.CopyDeck(deck)
 				playerCardsMap, b1Cards, b2Cards, numCardsUsed = h.drawFromDeck(tmpDeck, nil)
 			}
@@ -839,17 +942,27 @@ func (h *HandState) hasEveryOneActed() bool {
 	return allActed
 }
 
-func (h *HandState) getBlindPos() (uint32, uint32, error) {
+// getBlindPos returns the button, small blind and big blind positions.
+func (h *HandState) getBlindPos() (uint32, uint32, uint32, error) {
 
 	buttonSeat := uint32(h.GetButtonPos())
 	smallBlindPos := h.getNextActivePlayer(buttonSeat)
 	bigBlindPos := h.getNextActivePlayer(smallBlindPos)
+	if h.activeSeatsCount() == 2 {
+		// heads-up: the button posts the small blind, acts first preflop and last
+		// after the flop. A dead button moves to the next player.
+		if h.ActiveSeats[buttonSeat] == 0 {
+			buttonSeat = h.getNextActivePlayer(buttonSeat)
+		}
+		smallBlindPos = buttonSeat
+		bigBlindPos = h.getNextActivePlayer(buttonSeat)
+	}
 
 	if smallBlindPos == 0 || bigBlindPos == 0 {
 		// TODO: handle not enough players condition
-		return 0, 0, fmt.Errorf("Small bind (%d) or big blind (%d) position is 0", smallBlindPos, bigBlindPos)
+		return 0, 0, 0, fmt.Errorf("Small bind (%d) or big blind (%d) position is 0", smallBlindPos, bigBlindPos)
 	}
-	return uint32(smallBlindPos), uint32(bigBlindPos), nil
+	return buttonSeat, uint32(smallBlindPos), uint32(bigBlindPos), nil
 }
 
 // WARNING: Keep this method idempotent (no mutate HandState). It could get retried in case of reshuffle.
@@ -1218,6 +1331,209 @@ func (h *HandState) actionReceived(action *HandAction, actionResponseTime uint64
 	return nil
 }
 
+// Steps that follow a player action. Game.prepareNextAction sends the messages
+// for each step, the hand replay applies only the hand state changes.
+type handStep int
+
+const (
+	stepNextAction handStep = iota
+	stepNextRound
+	stepOnePlayerRemaining
+	stepRunItTwicePrompt
+	stepAllPlayersAllIn
+	stepShowdown
+)
+
+// nextStep determines what happens after the action that was just received.
+func (h *HandState) nextStep(lastPlayerAction *PlayerActRound) handStep {
+	if h.NoActiveSeats == 1 {
+		return stepOnePlayerRemaining
+	} else if h.runItTwiceAvailable(lastPlayerAction) {
+		return stepRunItTwicePrompt
+	} else if h.isAllActivePlayersAllIn() || h.allActionComplete() {
+		return stepAllPlayersAllIn
+	} else if h.CurrentState == HandStatus_SHOW_DOWN {
+		return stepShowdown
+	} else if h.LastState != h.CurrentState {
+		return stepNextRound
+	}
+	return stepNextAction
+}
+
+func (h *HandState) prepareShowdown() {
+	// update hand stats
+	h.HandStats.EndedAtShowdown = true
+	// update player stats
+	for _, playerID := range h.ActiveSeats {
+		if playerID == 0 {
+			continue
+		}
+		h.PlayerStats[playerID].WentToShowdown = true
+	}
+
+	h.removeFoldedPlayersFromPots()
+	h.removeEmptyPots()
+	h.HandCompletedAt = HandStatus_SHOW_DOWN
+
+	// track whether the player is active in this round or not
+	for seatNo, playerID := range h.ActiveSeats {
+		if playerID == 0 {
+			continue
+		}
+		player := h.PlayersInSeats[seatNo]
+		if player.Inhand {
+			player.Round = HandStatus_SHOW_DOWN
+		}
+	}
+}
+
+func (h *HandState) prepareOnePlayerRemaining() {
+	switch h.CurrentState {
+	case HandStatus_DEAL:
+		h.HandStats.EndedAtPreflop = true
+	case HandStatus_FLOP:
+		h.HandStats.EndedAtFlop = true
+	case HandStatus_TURN:
+		h.HandStats.EndedAtTurn = true
+	case HandStatus_RIVER:
+		h.HandStats.EndedAtRiver = true
+	}
+	// every one folded except one player, send the pot to the player
+	h.everyOneFoldedWinners()
+	h.CurrentState = HandStatus_RESULT
+
+	// scenario:
+	// player1 bets   10
+	// player2 calls  10
+	// player3 raises 40
+	// player1 folds
+	// player2 folds
+	// player3 raise 40 is not part of the pot contribution
+	// remove the player raise from pot contribution
+	for seatNo, _ := range h.PotContribution {
+		if seatNo != h.RaiseSeatNo {
+			continue
+		}
+		h.PotContribution[seatNo] -= h.CurrentRaiseDiff
+	}
+}
+
+// determineResult determines the winners of the pots and moves the hand to the result state.
+func (h *HandState) determineResult(chipUnit ChipUnit) *HandResultClient {
+	for i := len(h.Pots) - 1; i >= 0; i-- {
+		currentPot := h.Pots[len(h.Pots)-1]
+		if currentPot.Pot == 0 {
+			h.Pots = h.Pots[:len(h.Pots)-1]
+			continue
+		}
+		// if current pot has only one player, return the money to the player
+		// if len(currentPot.Seats) == 1 {
+		// 	activePlayer := currentPot.Seats[0]
+		// 	player := h.PlayersInSeats[activePlayer]
+		// 	player.Stack += currentPot.Pot
+		// 	// remove the pot
+		// 	h.Pots = h.Pots[:len(h.Pots)-1]
+		// }
+	}
+
+	handResultProcessor := NewHandResultProcessor(h, chipUnit, uint32(h.MaxSeats), nil)
+
+	handResultClient := handResultProcessor.determineWinners()
+	handResultClient.PlayerStats = h.GetPlayerStats()
+	handResultClient.TimeoutStats = h.GetTimeoutStats()
+	h.completeResult(handResultClient)
+	return handResultClient
+}
+
+// completeResult fills the player balances and the high hand winners of the result.
+func (h *HandState) completeResult(handResultClient *HandResultClient) {
+	h.CurrentState = HandStatus_RESULT
+	handResultClient.Shuffle = h.shuffleReveal()
+
+	for seatNo, player := range h.PlayersInSeats {
+		if seatNo == 0 || !player.Inhand || player.OpenSeat {
+			continue
+		}
+
+		before := float64(0.0)
+		after := float64(0.0)
+		for _, playerBalance := range h.BalanceBeforeHand {
+			if playerBalance.SeatNo == uint32(seatNo) {
+				before = playerBalance.Balance
+				break
+			}
+		}
+		if balance, ok := handResultClient.PlayerInfo[uint32(seatNo)]; ok {
+			after = balance.Balance.After
+		} else {
+			after = player.Stack
+		}
+		rakePaid := float64(0.0)
+		if playerRake, ok := h.RakePaid[player.PlayerId]; ok {
+			rakePaid = playerRake
+		}
+		if _, ok := handResultClient.PlayerInfo[uint32(seatNo)]; !ok {
+			handResultClient.PlayerInfo[uint32(seatNo)] = &PlayerHandInfo{
+				Id: player.PlayerId,
+				Balance: &HandPlayerBalance{
+					Before: before,
+					After:  after,
+				},
+				Received: player.PlayerReceived,
+				RakePaid: rakePaid,
+			}
+		}
+	}
+	var highHandWinners []*HighHandWinner
+
+	// determine high hand winners
+	if h.HighHandTracked {
+		highHandWinners = make([]*HighHandWinner, 0)
+		// walk through each player's rank
+		highRankFound := false
+		highRank := uint32(0)
+		for _, board := range handResultClient.Boards {
+			for _, playerRank := range board.PlayerRank {
+				if playerRank.HhRank == 0 ||
+					playerRank.HhRank > MIN_FULLHOUSE_RANK {
+					continue
+				}
+				if h.HighHandRank == 0 {
+					highRankFound = true
+					highRank = playerRank.HhRank
+				}
+				if playerRank.HhRank <= h.HighHandRank {
+					highRankFound = true
+					highRank = playerRank.HiRank
+				}
+			}
+		}
+
+		if highRankFound {
+			for _, board := range handResultClient.Boards {
+				for seatNo, playerRank := range board.PlayerRank {
+					if playerRank.HhRank == highRank {
+						player := h.PlayersInSeats[seatNo]
+						winner := &HighHandWinner{
+							PlayerId:    player.PlayerId,
+							PlayerName:  player.Name,
+							SeatNo:      seatNo,
+							HhRank:      playerRank.HhRank,
+							HhCards:     playerRank.HhCards,
+							BoardNo:     board.BoardNo,
+							PlayerCards: poker.ByteCardsToUint32Cards(h.PlayersCards[seatNo]),
+						}
+						highHandWinners = append(highHandWinners, winner)
+					}
+				}
+			}
+		}
+	}
+
+	handResultClient.HandNum = h.HandNum
+	handResultClient.HighHandWinners = highHandWinners
+}
+
 func (h *HandState) getPlayerFromSeat(seatNo uint32) *PlayerInSeatState {
 	player := h.PlayersInSeats[seatNo]
 	if !player.Inhand {
@@ -1395,6 +1711,15 @@ func (h *HandState) setupFlop() error {
 	if err != nil {
 		return errors.Wrap(err, "Error while setting up next round (flop)")
 	}
+
+	// update player stats
+	for _, playerID := range h.ActiveSeats {
+		if playerID == 0 {
+			continue
+		}
+		h.PlayerStats[playerID].InFlop = true
+	}
+	h.analyzeStreet(HandStatus_FLOP, 3)
 	return nil
 }
 
@@ -1403,6 +1728,15 @@ func (h *HandState) setupTurn() error {
 	if err != nil {
 		return errors.Wrap(err, "Error while setting up next round (turn)")
 	}
+
+	// update player stats
+	for _, playerID := range h.ActiveSeats {
+		if playerID == 0 {
+			continue
+		}
+		h.PlayerStats[playerID].InTurn = true
+	}
+	h.analyzeStreet(HandStatus_TURN, 4)
 	return nil
 }
 
@@ -1411,6 +1745,15 @@ func (h *HandState) setupRiver() error {
 	if err != nil {
 		return errors.Wrap(err, "Error while setting up next round (river)")
 	}
+
+	// update player stats
+	for _, playerID := range h.ActiveSeats {
+		if playerID == 0 {
+			continue
+		}
+		h.PlayerStats[playerID].InRiver = true
+	}
+	h.analyzeStreet(HandStatus_RIVER, 5)
 	return nil
 }
 
@@ -1774,6 +2117,9 @@ func (h *HandState) getLog() *HandLog {
 	handResult.HandStartedAt = h.HandStartedAt
 	handResult.HandEndedAt = h.HandEndedAt
 	handResult.HandEndedAt = uint64(time.Now().Unix())
+	handResult.DealingPolicy = h.DealingPolicy
+	handResult.Reshuffles = h.Reshuffles
+	handResult.StreetAnalysis = h.StreetAnalysis
 	if h.HeadsupPlayers != nil {
 		handResult.HeadsupPlayers = make([]uint64, 0)
 		handResult.HeadsupPlayers = append(handResult.HeadsupPlayers, h.HeadsupPlayers...)
@@ -29,7 +29,8 @@ func (h *HoldemWinnerEvaluate) Evaluate2(seatCards []byte, board []byte) Evaluat
 	copy(allCards, board)
 	allCards = append(allCards, seatCards...)
 	cards := poker.FromByteCards(allCards)
-	rank, playerBestCards := poker.Evaluate(cards)
+	deck := h.handState.deckDefinition()
+	rank, playerBestCards := deck.Evaluate(cards)
 
 	// determine what player cards and board cards used to determine best cards
 	seatCardsInCard := poker.FromByteCards(seatCards)
@@ -55,7 +56,7 @@ func (h *HoldemWinnerEvaluate) Evaluate2(seatCards []byte, board []byte) Evaluat
 	// 2 cards from player, 3 card combo from board
 	playerCardsEval := poker.FromByteCards(seatCards)
 	boardCardsEval := poker.FromByteCards(board)
-	result := poker.EvaluateOmaha(playerCardsEval, boardCardsEval)
+	result := deck.EvaluateOmaha(playerCardsEval, boardCardsEval)
 
 	// the hh rank must be better than or equal board rank
 	// for example, player holds 5, 8
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+
+	"voyager.com/server/poker"
+	"voyager.com/server/util/random"
+)
+
+// The Independent Chip Model (Malmuth-Harville) values the stacks of the
+// players left in a tournament: a player finishes first with the probability
+// of the share of the chips, and each next place is decided the same way
+// among the players without a place. The equity of a player is the expected
+// prize. It is computed exactly over the sets of placed players for up to
+// ICMExactMaxPlayers players and estimated by simulating finishing orders for
+// larger fields.
+
+// ICMExactMaxPlayers is the largest field whose equities are computed exactly.
+const ICMExactMaxPlayers = 10
+
+const icmDefaultTrials = 200000
+
+// ICMResult is the ICM equity of each stack.
+type ICMResult struct {
+	Equities []float64
+	// Exact is false when the equities are estimated by simulation.
+	Exact  bool
+	Trials int
+}
+
+// ICMEquities returns the expected prize of each stack. trials is the number
+// of simulated finishing orders used beyond ICMExactMaxPlayers players (0 for
+// the default), and seed seeds the simulation (random if 0).
+func ICMEquities(stacks []float64, payouts []float64, trials int, seed uint64) (ICMResult, error) {
+	err := validateICM(stacks, payouts)
+	if err != nil {
+		return ICMResult{}, err
+	}
+	if len(stacks) <= ICMExactMaxPlayers {
+		return ICMResult{Equities: ICMExact(stacks, payouts), Exact: true}, nil
+	}
+	if trials <= 0 {
+		trials = icmDefaultTrials
+	}
+	if seed == 0 {
+		seed = uint64(random.NewSeed())
+	}
+	rng := poker.NewSeededRand(seed)
+	return ICMResult{Equities: ICMMonteCarlo(stacks, payouts, trials, rng), Trials: trials}, nil
+}
+
+func validateICM(stacks []float64, payouts []float64) error {
+	if len(stacks) == 0 {
+		return fmt.Errorf("ICM needs at least one stack")
+	}
+	total := 0.0
+	for i, stack := range stacks {
+		if stack < 0 {
+			return fmt.Errorf("Stack %d is negative (%v)", i+1, stack)
+		}
+		total += stack
+	}
+	if total == 0 {
+		return fmt.Errorf("ICM needs chips in play")
+	}
+	for i, payout := range payouts {
+		if payout < 0 {
+			return fmt.Errorf("Payout of place %d is negative (%v)", i+1, payout)
+		}
+	}
+	return nil
+}
+
+// ICMExact computes the equities over all the sets of placed players. The
+// cost grows with 2^n, use it for small fields.
+func ICMExact(stacks []float64, payouts []float64) []float64 {
+	n := len(stacks)
+	places := len(payouts)
+	if places > n {
+		places = n
+	}
+	total := 0.0
+	for _, stack := range stacks {
+		total += stack
+	}
+
+	equities := make([]float64, n)
+	// probability that the players of the set take the first places
+	prob := make(map[uint32]float64)
+	prob[0] = 1
+	level := []uint32{0}
+	for place := 0; place < places; place++ {
+		var next []uint32
+		for _, placed := range level {
+			p := prob[placed]
+			left := total
+			for i := 0; i < n; i++ {
+				if placed&(1<<uint(i)) != 0 {
+					left -= stacks[i]
+				}
+			}
+			for i := 0; i < n; i++ {
+				bit := uint32(1) << uint(i)
+				if placed&bit != 0 || stacks[i] == 0 {
+					continue
+				}
+				pi := p * stacks[i] / left
+				equities[i] += pi * payouts[place]
+				set := placed | bit
+				if _, ok := prob[set]; !ok {
+					next = append(next, set)
+				}
+				prob[set] += pi
+			}
+		}
+		level = next
+	}
+	// players without chips take the places left over in any order
+	busted := 0
+	for _, stack := range stacks {
+		if stack == 0 {
+			busted++
+		}
+	}
+	if busted > 0 {
+		live := n - busted
+		leftOver := 0.0
+		for place := live; place < places; place++ {
+			leftOver += payouts[place]
+		}
+		for i, stack := range stacks {
+			if stack == 0 {
+				equities[i] = leftOver / float64(busted)
+			}
+		}
+	}
+	return equities
+}
+
+// ICMMonteCarlo estimates the equities by drawing finishing orders.
+func ICMMonteCarlo(stacks []float64, payouts []float64, trials int, rng *rand.Rand) []float64 {
+	n := len(stacks)
+	places := len(payouts)
+	if places > n {
+		places = n
+	}
+	equities := make([]float64, n)
+	left := make([]int, n)
+	for trial := 0; trial < trials; trial++ {
+		for i := range left {
+			left[i] = i
+		}
+		remaining := 0.0
+		for _, stack := range stacks {
+			remaining += stack
+		}
+		for place := 0; place < places; place++ {
+			k := -1
+			if remaining > 0 {
+				r := rng.Float64() * remaining
+				for j, player := range left {
+					if stacks[player] == 0 {
+						continue
+					}
+					// the last player with chips if the rounding leaves r over
+					k = j
+					r -= stacks[player]
+					if r < 0 {
+						break
+					}
+				}
+			}
+			if k == -1 {
+				// only players without chips are left
+				k = rng.Intn(len(left))
+			}
+			player := left[k]
+			equities[player] += payouts[place]
+			remaining -= stacks[player]
+			left[k] = left[len(left)-1]
+			left = left[:len(left)-1]
+		}
+		left = left[:n]
+	}
+	for i := range equities {
+		equities[i] /= float64(trials)
+	}
+	return equities
+}
+
+// DealSplit is the share of a player in a final table deal.
+type DealSplit struct {
+	Stack    float64
+	ICM      float64
+	ChipChop float64
+}
+
+// ProposeDeal splits the prizes left among the players by ICM and by chip
+// count. In the chip chop every player gets the smallest prize in play and
+// the rest is split by the share of the chips.
+func ProposeDeal(stacks []float64, payouts []float64, trials int, seed uint64) ([]DealSplit, error) {
+	result, err := ICMEquities(stacks, payouts, trials, seed)
+	if err != nil {
+		return nil, err
+	}
+	n := len(stacks)
+	prizes := 0.0
+	for place := 0; place < len(payouts) && place < n; place++ {
+		prizes += payouts[place]
+	}
+	minPrize := 0.0
+	if n <= len(payouts) {
+		minPrize = payouts[n-1]
+	}
+	total := 0.0
+	for _, stack := range stacks {
+		total += stack
+	}
+
+	splits := make([]DealSplit, n)
+	for i, stack := range stacks {
+		splits[i] = DealSplit{
+			Stack:    stack,
+			ICM:      result.Equities[i],
+			ChipChop: minPrize + (prizes-minPrize*float64(n))*stack/total,
+		}
+	}
+	return splits, nil
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/poker"
+)
+
+func TestICMExact(t *testing.T) {
+	equities := ICMExact([]float64{5000, 3000, 2000}, []float64{50, 30, 20})
+	assert.InDelta(t, 38.392857, equities[0], 1e-6)
+	assert.InDelta(t, 32.75, equities[1], 1e-6)
+	assert.InDelta(t, 28.857143, equities[2], 1e-6)
+
+	// heads-up equity is the share of the chips
+	equities = ICMExact([]float64{3000, 1000}, []float64{70, 30})
+	assert.InDelta(t, 60, equities[0], 1e-9)
+	assert.InDelta(t, 40, equities[1], 1e-9)
+
+	// a player without chips takes the last place
+	equities = ICMExact([]float64{3000, 0, 1000}, []float64{50, 30, 20})
+	assert.InDelta(t, 20, equities[1], 1e-9)
+	assert.InDelta(t, 80, equities[0]+equities[2], 1e-9)
+}
+
+func TestICMMonteCarlo(t *testing.T) {
+	stacks := []float64{9000, 7000, 5000, 3000, 1000}
+	payouts := []float64{500, 300, 200}
+	exact := ICMExact(stacks, payouts)
+	estimated := ICMMonteCarlo(stacks, payouts, 200000, poker.NewSeededRand(7))
+	for i := range stacks {
+		assert.InDelta(t, exact[i], estimated[i], 2.5, "stack %d", i+1)
+	}
+}
+
+func TestICMEquities(t *testing.T) {
+	stacks := make([]float64, 12)
+	for i := range stacks {
+		stacks[i] = 1000
+	}
+	payouts := []float64{600, 360, 240}
+	result, err := ICMEquities(stacks, payouts, 50000, 11)
+	require.NoError(t, err)
+	assert.False(t, result.Exact)
+	total := 0.0
+	for _, equity := range result.Equities {
+		assert.InDelta(t, 100, equity, 5)
+		total += equity
+	}
+	assert.InDelta(t, 1200, total, 1e-6)
+
+	result, err = ICMEquities(stacks[:4], payouts, 0, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Exact)
+
+	_, err = ICMEquities([]float64{0, 0}, payouts, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestProposeDeal(t *testing.T) {
+	splits, err := ProposeDeal([]float64{5000, 3000, 2000}, []float64{50, 30, 20}, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, splits, 3)
+	assert.InDelta(t, 38.392857, splits[0].ICM, 1e-6)
+	// 20 each and 40 by the share of the chips
+	assert.InDelta(t, 40, splits[0].ChipChop, 1e-9)
+	assert.InDelta(t, 32, splits[1].ChipChop, 1e-9)
+	assert.InDelta(t, 28, splits[2].ChipChop, 1e-9)
+}
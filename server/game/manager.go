@@ -13,12 +13,14 @@ type Manager struct {
 	delays             Delays
 	handStatePersist   PersistHandState
 	handSetupPersist   *RedisHandsSetupTracker
+	handResultOutbox   HandResultOutbox
+	handStateSnapshots HandStateSnapshots
 	activeGames        map[string]*Game
 	crashHandler       func(uint64, string)
 	encryptionKeyCache *encryptionkey.Cache
 }
 
-func NewGameManager(isScriptTest bool, apiServerURL string, handPersist PersistHandState, handSetupPersist *RedisHandsSetupTracker, delays Delays) (*Manager, error) {
+func NewGameManager(isScriptTest bool, apiServerURL string, handPersist PersistHandState, handSetupPersist *RedisHandsSetupTracker, handResultOutbox HandResultOutbox, handStateSnapshots HandStateSnapshots, delays Delays) (*Manager, error) {
 
 	cache, err := encryptionkey.NewCache(100000, apiServerURL)
 	if err != nil || cache == nil {
@@ -31,6 +33,8 @@ func NewGameManager(isScriptTest bool, apiServerURL string, handPersist PersistH
 		delays:             delays,
 		handStatePersist:   handPersist,
 		handSetupPersist:   handSetupPersist,
+		handResultOutbox:   handResultOutbox,
+		handStateSnapshots: handStateSnapshots,
 		activeGames:        make(map[string]*Game),
 		encryptionKeyCache: cache,
 	}, nil
@@ -70,6 +70,7 @@ const (
 	HandResultMessage2   string = "RESULT2"
 	HandBombPot          string = "BOMBPOT"
 	HandPlayerMovedTable string = "PLAYER_MOVED_TABLE"
+	HandTournamentBuyIn  string = "TOURNAMENT_BUY_IN"
 )
 
 // sub message types used in TableUpdate message
@@ -86,4 +87,12 @@ const (
 // sub message types used in Announcment message
 const (
 	AnnouncementNewGameType string = "NewGameType"
+	// params: small blind, big blind, ante, level
+	AnnouncementBlindsUp string = "BlindsUp"
+	// params: break minutes
+	AnnouncementBreak string = "Break"
+	// params: table number
+	AnnouncementTableClosed string = "TableClosed"
+	// params: hand-for-hand round
+	AnnouncementHandForHand string = "HandForHand"
 )
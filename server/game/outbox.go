@@ -0,0 +1,226 @@
+package game
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"voyager.com/logging"
+)
+
+var outboxLogger = logging.GetZeroLogger("game::outbox", nil)
+
+// HandResultOutbox keeps the hand results that have not been accepted by the
+// api server yet. A result is added before the save attempt and removed only
+// after the api server acknowledges it, so a result survives api server outages
+// and game server restarts. Results are keyed by (game, hand number), adding the
+// same hand twice keeps the first copy.
+type HandResultOutbox interface {
+	Add(gameCode string, result *HandResultServer) error
+	// Pending returns the results of the game ordered by hand number.
+	Pending(gameCode string) ([]*HandResultServer, error)
+	Remove(gameCode string, handNum uint32) error
+	Count(gameCode string) (int, error)
+	// DeadLetter moves a result the api server rejected out of the pending
+	// results. The result is kept for manual recovery.
+	DeadLetter(gameCode string, result *HandResultServer) error
+	// SetTournamentSaveURL records where the results of a tournament table are
+	// saved, so the results can be replayed after the table is gone.
+	SetTournamentSaveURL(gameCode string, url string) error
+	// TournamentSaveURL returns the url recorded for the game, empty for cash games.
+	TournamentSaveURL(gameCode string) (string, error)
+	// GameCodes returns the games that have pending results.
+	GameCodes() ([]string, error)
+}
+
+// resultRejectedError is returned when the api server rejects a hand result
+// (a 4xx status or a response that cannot be parsed). Retrying does not help.
+type resultRejectedError struct {
+	error
+}
+
+func isResultRejected(err error) bool {
+	_, ok := err.(resultRejectedError)
+	return ok
+}
+
+// queueHandResult writes the hand result to the outbox and wakes up the outbox
+// worker that delivers it. The table keeps dealing until maxPendingResults
+// results are waiting, then this method blocks until the backlog drains or
+// the game ends.
+func (g *Game) queueHandResult(result *HandResultServer) error {
+	outbox := g.manager.handResultOutbox
+	if g.tournamentID != 0 {
+		err := outbox.SetTournamentSaveURL(g.gameCode, g.tournamentSaveHandURL(g.tournamentURL))
+		if err != nil {
+			return errors.Wrap(err, "Could not record the tournament save url in the outbox")
+		}
+	}
+	err := outbox.Add(g.gameCode, result)
+	if err != nil {
+		return errors.Wrap(err, "Could not add hand result to the outbox")
+	}
+	g.wakeHandResultOutboxWorker()
+
+	for {
+		pending, err := outbox.Count(g.gameCode)
+		if err != nil {
+			return errors.Wrap(err, "Could not get pending result count from the outbox")
+		}
+		if pending < g.maxPendingResults {
+			return nil
+		}
+		g.logger.Warn().
+			Uint32(logging.HandNumKey, result.HandNum).
+			Msgf("%d hand results are pending. Waiting for the api server before dealing the next hand.", pending)
+		select {
+		case <-g.endResultOutbox:
+			return nil
+		case <-time.After(time.Duration(g.retryDelayMillis) * time.Millisecond):
+		}
+	}
+}
+
+func (g *Game) wakeHandResultOutboxWorker() {
+	select {
+	case g.flushResultOutbox <- true:
+	default:
+		// the worker is already woken up
+	}
+}
+
+// flushHandResultOutbox sends the pending results to the api server in hand order.
+// It stops at the first failure so that the results are never delivered out of
+// order. A result the api server rejects is dead-lettered and skipped.
+func (g *Game) flushHandResultOutbox() error {
+	g.resultOutboxLock.Lock()
+	defer g.resultOutboxLock.Unlock()
+
+	outbox := g.manager.handResultOutbox
+	pending, err := outbox.Pending(g.gameCode)
+	if err != nil {
+		return errors.Wrap(err, "Could not read pending hand results")
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	tournamentSaveURL, err := outbox.TournamentSaveURL(g.gameCode)
+	if err != nil {
+		return errors.Wrap(err, "Could not read the tournament save url")
+	}
+
+	for _, result := range pending {
+		if tournamentSaveURL != "" {
+			_, err = g.postHandResultToAPIServer(tournamentSaveURL, result)
+		} else {
+			_, err = g.saveHandResult2ToAPIServer(result)
+		}
+		if err != nil && isResultRejected(err) {
+			g.logger.Error().
+				Err(err).
+				Uint32(logging.HandNumKey, result.HandNum).
+				Msg("Api server rejected the hand result. Moving it to the dead letters.")
+			err = outbox.DeadLetter(g.gameCode, result)
+			if err != nil {
+				return errors.Wrapf(err, "Could not dead-letter hand %d result", result.HandNum)
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Could not save hand %d result to api server", result.HandNum)
+		}
+
+		err = outbox.Remove(g.gameCode, result.HandNum)
+		if err != nil {
+			return errors.Wrapf(err, "Could not remove hand %d result from the outbox", result.HandNum)
+		}
+		g.logger.Debug().
+			Uint32(logging.HandNumKey, result.HandNum).
+			Msg("Hand result delivered from the outbox")
+	}
+	return nil
+}
+
+// runHandResultOutboxWorker delivers the results queued by the game and
+// replays the pending results left behind by earlier failures (or by a
+// previous game server process) until the game ends. The results still pending
+// when the game ends are flushed once more, what is left after that is replayed
+// by ReplayHandResultOutboxes when the game server starts.
+func (g *Game) runHandResultOutboxWorker() {
+	ticker := time.NewTicker(time.Duration(g.retryDelayMillis) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.endResultOutbox:
+			err := g.flushHandResultOutbox()
+			if err != nil {
+				g.logger.Error().Err(err).Msg("Could not flush hand result outbox at the end of the game. The results are replayed when the game server starts.")
+			}
+			return
+		case <-ticker.C:
+		case <-g.flushResultOutbox:
+		}
+		pending, err := g.manager.handResultOutbox.Count(g.gameCode)
+		if err != nil {
+			g.logger.Error().Err(err).Msg("Could not get pending result count from the outbox")
+			continue
+		}
+		if pending == 0 {
+			continue
+		}
+		err = g.flushHandResultOutbox()
+		if err != nil {
+			g.logger.Error().Err(err).Msgf("Could not flush hand result outbox. %d results pending", pending)
+		}
+	}
+}
+
+// PendingHandResults returns the number of hand results that are not saved in the api server yet.
+func (g *Game) PendingHandResults() int {
+	if g.manager.handResultOutbox == nil {
+		return 0
+	}
+	pending, err := g.manager.handResultOutbox.Count(g.gameCode)
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Could not get pending result count from the outbox")
+		return 0
+	}
+	return pending
+}
+
+// ReplayHandResultOutboxes delivers the pending results left behind by the
+// games that ended while the api server was down or by a game server that
+// crashed. Called once when the game server starts, before any game runs.
+func (gm *Manager) ReplayHandResultOutboxes() {
+	if gm.handResultOutbox == nil {
+		return
+	}
+	gameCodes, err := gm.handResultOutbox.GameCodes()
+	if err != nil {
+		outboxLogger.Error().Err(err).Msg("Could not read the games with pending hand results")
+		return
+	}
+	for _, gameCode := range gameCodes {
+		g := gm.newOutboxReplayGame(gameCode)
+		pending := g.PendingHandResults()
+		err = g.flushHandResultOutbox()
+		if err != nil {
+			g.logger.Error().Err(err).Msgf("Could not replay hand result outbox. %d results pending", pending)
+			continue
+		}
+		g.logger.Info().Msgf("Replayed %d pending hand results", pending)
+	}
+}
+
+// newOutboxReplayGame returns a game that only delivers the outbox results of
+// the game code.
+func (gm *Manager) newOutboxReplayGame(gameCode string) *Game {
+	logger := outboxLogger.With().Str(logging.GameCodeKey, gameCode).Logger()
+	return &Game{
+		logger:           &logger,
+		gameCode:         gameCode,
+		manager:          gm,
+		apiServerURL:     gm.apiServerURL,
+		maxRetries:       10,
+		retryDelayMillis: 2000,
+	}
+}
@@ -0,0 +1,210 @@
+package game
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// FileHandResultOutbox stores each pending hand result as a file under
+// <dir>/<gameCode>/<handNum>.pb and the rejected results under
+// <dir>/<gameCode>/dead-letter. Used for development when redis is not available.
+type FileHandResultOutbox struct {
+	dir  string
+	lock sync.Mutex
+}
+
+func NewFileHandResultOutbox(dir string) (*FileHandResultOutbox, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not create result outbox directory %s", dir)
+	}
+	return &FileHandResultOutbox{dir: dir}, nil
+}
+
+func (f *FileHandResultOutbox) Add(gameCode string, result *HandResultServer) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	gameDir := filepath.Join(f.dir, gameCode)
+	err := os.MkdirAll(gameDir, 0755)
+	if err != nil {
+		return err
+	}
+	path := f.getPath(gameCode, result.HandNum)
+	if _, err := os.Stat(path); err == nil {
+		// Already queued.
+		return nil
+	}
+
+	resultBytes, err := proto.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand result")
+	}
+
+	// Write to a temp file and rename so that a crash never leaves a partial result behind.
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, resultBytes, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (f *FileHandResultOutbox) Pending(gameCode string) ([]*HandResultServer, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	handNums, err := f.handNums(gameCode)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*HandResultServer, 0, len(handNums))
+	for _, handNum := range handNums {
+		resultBytes, err := ioutil.ReadFile(f.getPath(gameCode, uint32(handNum)))
+		if err != nil {
+			return nil, err
+		}
+		result := &HandResultServer{}
+		err = proto.Unmarshal(resultBytes, result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not proto-unmarshal hand %d result", handNum)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (f *FileHandResultOutbox) Remove(gameCode string, handNum uint32) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	err := os.Remove(f.getPath(gameCode, handNum))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeadLetter moves the result file to <dir>/<gameCode>/dead-letter.
+func (f *FileHandResultOutbox) DeadLetter(gameCode string, result *HandResultServer) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	deadLetterDir := filepath.Join(f.dir, gameCode, "dead-letter")
+	err := os.MkdirAll(deadLetterDir, 0755)
+	if err != nil {
+		return err
+	}
+	path := f.getPath(gameCode, result.HandNum)
+	deadLetterPath := filepath.Join(deadLetterDir, filepath.Base(path))
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		resultBytes, err := proto.Marshal(result)
+		if err != nil {
+			return errors.Wrap(err, "Could not proto-marshal hand result")
+		}
+		return ioutil.WriteFile(deadLetterPath, resultBytes, 0644)
+	}
+	return os.Rename(path, deadLetterPath)
+}
+
+func (f *FileHandResultOutbox) Count(gameCode string) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	handNums, err := f.handNums(gameCode)
+	return len(handNums), err
+}
+
+// SetTournamentSaveURL writes the url to <dir>/<gameCode>/tournament-save-url.
+func (f *FileHandResultOutbox) SetTournamentSaveURL(gameCode string, url string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	gameDir := filepath.Join(f.dir, gameCode)
+	err := os.MkdirAll(gameDir, 0755)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(gameDir, "tournament-save-url")
+	if current, err := ioutil.ReadFile(path); err == nil && string(current) == url {
+		return nil
+	}
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, []byte(url), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (f *FileHandResultOutbox) TournamentSaveURL(gameCode string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	url, err := ioutil.ReadFile(filepath.Join(f.dir, gameCode, "tournament-save-url"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(url), err
+}
+
+func (f *FileHandResultOutbox) GameCodes() ([]string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	files, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	gameCodes := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		handNums, err := f.handNums(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		if len(handNums) > 0 {
+			gameCodes = append(gameCodes, file.Name())
+		}
+	}
+	return gameCodes, nil
+}
+
+func (f *FileHandResultOutbox) handNums(gameCode string) ([]int, error) {
+	files, err := ioutil.ReadDir(filepath.Join(f.dir, gameCode))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []int{}, nil
+		}
+		return nil, err
+	}
+	handNums := make([]int, 0, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, ".pb") {
+			continue
+		}
+		handNum, err := strconv.Atoi(strings.TrimSuffix(name, ".pb"))
+		if err != nil {
+			continue
+		}
+		handNums = append(handNums, handNum)
+	}
+	sort.Ints(handNums)
+	return handNums, nil
+}
+
+func (f *FileHandResultOutbox) getPath(gameCode string, handNum uint32) string {
+	return filepath.Join(f.dir, gameCode, fmt.Sprintf("%d.pb", handNum))
+}
@@ -0,0 +1,151 @@
+package game
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// RedisHandResultOutbox stores the pending hand results of a game in a redis hash.
+// The hash field is the hand number, which deduplicates the results of the same hand.
+type RedisHandResultOutbox struct {
+	rdclient      *redis.Client
+	accessTimeout time.Duration
+}
+
+func NewRedisHandResultOutbox(redisURL string, redisUser string, redisPW string, redisDB int, useSSL bool) *RedisHandResultOutbox {
+	var tlsConfig *tls.Config
+	if useSSL {
+		tlsConfig = &tls.Config{}
+	}
+	rdclient := redis.NewClient(&redis.Options{
+		Addr:      redisURL,
+		Username:  redisUser,
+		Password:  redisPW,
+		DB:        redisDB,
+		TLSConfig: tlsConfig,
+	})
+	return &RedisHandResultOutbox{
+		rdclient:      rdclient,
+		accessTimeout: 5 * time.Second,
+	}
+}
+
+func (r *RedisHandResultOutbox) Add(gameCode string, result *HandResultServer) error {
+	resultBytes, err := proto.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand result")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	return r.rdclient.HSetNX(ctx, r.getKey(gameCode), fmt.Sprintf("%d", result.HandNum), resultBytes).Err()
+}
+
+func (r *RedisHandResultOutbox) Pending(gameCode string) ([]*HandResultServer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	entries, err := r.rdclient.HGetAll(ctx, r.getKey(gameCode)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	handNums := make([]int, 0, len(entries))
+	for handNumStr := range entries {
+		handNum, err := strconv.Atoi(handNumStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid hand number [%s] in result outbox", handNumStr)
+		}
+		handNums = append(handNums, handNum)
+	}
+	sort.Ints(handNums)
+
+	results := make([]*HandResultServer, 0, len(handNums))
+	for _, handNum := range handNums {
+		result := &HandResultServer{}
+		err = proto.Unmarshal([]byte(entries[fmt.Sprintf("%d", handNum)]), result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not proto-unmarshal hand %d result from redis", handNum)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (r *RedisHandResultOutbox) Remove(gameCode string, handNum uint32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	return r.rdclient.HDel(ctx, r.getKey(gameCode), fmt.Sprintf("%d", handNum)).Err()
+}
+
+// DeadLetter moves the result to the <gameCode>:RESULT_DEAD_LETTER hash.
+func (r *RedisHandResultOutbox) DeadLetter(gameCode string, result *HandResultServer) error {
+	resultBytes, err := proto.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand result")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	handNum := fmt.Sprintf("%d", result.HandNum)
+	pipe := r.rdclient.TxPipeline()
+	pipe.HSet(ctx, r.getDeadLetterKey(gameCode), handNum, resultBytes)
+	pipe.HDel(ctx, r.getKey(gameCode), handNum)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisHandResultOutbox) Count(gameCode string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	count, err := r.rdclient.HLen(ctx, r.getKey(gameCode)).Result()
+	return int(count), err
+}
+
+// SetTournamentSaveURL stores the url in <gameCode>:RESULT_OUTBOX_URL.
+func (r *RedisHandResultOutbox) SetTournamentSaveURL(gameCode string, url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	return r.rdclient.Set(ctx, r.getURLKey(gameCode), url, 0).Err()
+}
+
+func (r *RedisHandResultOutbox) TournamentSaveURL(gameCode string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	url, err := r.rdclient.Get(ctx, r.getURLKey(gameCode)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return url, err
+}
+
+// GameCodes scans the <gameCode>:RESULT_OUTBOX keys. Redis deletes the hash of
+// a game when its last result is removed.
+func (r *RedisHandResultOutbox) GameCodes() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	gameCodes := make([]string, 0)
+	iter := r.rdclient.Scan(ctx, 0, r.getKey("*"), 0).Iterator()
+	for iter.Next(ctx) {
+		gameCodes = append(gameCodes, strings.TrimSuffix(iter.Val(), ":RESULT_OUTBOX"))
+	}
+	return gameCodes, iter.Err()
+}
+
+func (r *RedisHandResultOutbox) getKey(gameCode string) string {
+	return fmt.Sprintf("%s:RESULT_OUTBOX", gameCode)
+}
+
+func (r *RedisHandResultOutbox) getURLKey(gameCode string) string {
+	return fmt.Sprintf("%s:RESULT_OUTBOX_URL", gameCode)
+}
+
+func (r *RedisHandResultOutbox) getDeadLetterKey(gameCode string) string {
+	return fmt.Sprintf("%s:RESULT_DEAD_LETTER", gameCode)
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/logging"
+)
+
+func testOutbox(t *testing.T, outbox HandResultOutbox) {
+	gameCode := fmt.Sprintf("outbox-test-%d", time.Now().UnixNano())
+	for _, handNum := range []uint32{3, 1, 2} {
+		require.NoError(t, outbox.Add(gameCode, &HandResultServer{HandNum: handNum, GameId: 1}))
+	}
+	// the same hand is kept once, the first copy wins
+	require.NoError(t, outbox.Add(gameCode, &HandResultServer{HandNum: 2, GameId: 2}))
+	count, err := outbox.Count(gameCode)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	pending, err := outbox.Pending(gameCode)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	for i, result := range pending {
+		assert.Equal(t, uint32(i+1), result.HandNum)
+		assert.Equal(t, uint64(1), result.GameId)
+	}
+
+	require.NoError(t, outbox.Remove(gameCode, 1))
+	require.NoError(t, outbox.Remove(gameCode, 1), "removing a delivered result again is not an error")
+	require.NoError(t, outbox.DeadLetter(gameCode, pending[1]))
+	pending, err = outbox.Pending(gameCode)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, uint32(3), pending[0].HandNum)
+
+	gameCodes, err := outbox.GameCodes()
+	require.NoError(t, err)
+	assert.Contains(t, gameCodes, gameCode)
+	url, err := outbox.TournamentSaveURL(gameCode)
+	require.NoError(t, err)
+	assert.Empty(t, url)
+	require.NoError(t, outbox.SetTournamentSaveURL(gameCode, "http://tournament/save-hand"))
+	url, err = outbox.TournamentSaveURL(gameCode)
+	require.NoError(t, err)
+	assert.Equal(t, "http://tournament/save-hand", url)
+
+	require.NoError(t, outbox.Remove(gameCode, 3))
+	count, err = outbox.Count(gameCode)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	gameCodes, err = outbox.GameCodes()
+	require.NoError(t, err)
+	assert.NotContains(t, gameCodes, gameCode)
+}
+
+func TestFileHandResultOutbox(t *testing.T) {
+	outbox, err := NewFileHandResultOutbox(t.TempDir())
+	require.NoError(t, err)
+	testOutbox(t, outbox)
+}
+
+func TestRedisHandResultOutbox(t *testing.T) {
+	redisHost := os.Getenv("REDIS_HOST")
+	if redisHost == "" {
+		t.Skip("REDIS_HOST is not set")
+	}
+	redisPort := os.Getenv("REDIS_PORT")
+	if redisPort == "" {
+		redisPort = "6379"
+	}
+	testOutbox(t, NewRedisHandResultOutbox(fmt.Sprintf("%s:%s", redisHost, redisPort), "", "", 0, false))
+}
+
+func newOutboxTestGame(t *testing.T, apiServerURL string, maxPending int) *Game {
+	outbox, err := NewFileHandResultOutbox(t.TempDir())
+	require.NoError(t, err)
+	logger := logging.GetZeroLogger("game::Game", nil)
+	return &Game{
+		logger:            logger,
+		gameCode:          "outbox-test",
+		gameID:            1,
+		manager:           &Manager{handResultOutbox: outbox},
+		apiServerURL:      apiServerURL,
+		retryDelayMillis:  10,
+		maxPendingResults: maxPending,
+		flushResultOutbox: make(chan bool, 1),
+		endResultOutbox:   make(chan bool),
+	}
+}
+
+func TestQueueHandResultBlocked(t *testing.T) {
+	g := newOutboxTestGame(t, "", 2)
+	outbox := g.manager.handResultOutbox
+	require.NoError(t, g.queueHandResult(&HandResultServer{HandNum: 1}))
+
+	// the second result fills the outbox, the table waits for the api server
+	queued := make(chan error)
+	go func() { queued <- g.queueHandResult(&HandResultServer{HandNum: 2}) }()
+	select {
+	case <-queued:
+		t.Fatal("queueHandResult returned with a full outbox")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, outbox.Remove(g.gameCode, 1))
+	select {
+	case err := <-queued:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("queueHandResult is not released when the outbox drains")
+	}
+
+	// the game ends while the table waits
+	go func() { queued <- g.queueHandResult(&HandResultServer{HandNum: 3}) }()
+	close(g.endResultOutbox)
+	select {
+	case err := <-queued:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("queueHandResult is not released when the game ends")
+	}
+}
+
+func TestFlushHandResultOutbox(t *testing.T) {
+	delivered := make([]string, 0)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/handNum/2") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		delivered = append(delivered, r.URL.Path)
+		w.Write([]byte("{}"))
+	}))
+	defer apiServer.Close()
+
+	g := newOutboxTestGame(t, apiServer.URL, 10)
+	outbox := g.manager.handResultOutbox
+	for handNum := uint32(1); handNum <= 3; handNum++ {
+		require.NoError(t, g.queueHandResult(&HandResultServer{GameId: 1, HandNum: handNum}))
+	}
+	// hand 2 is rejected and does not hold back hand 3
+	require.NoError(t, g.flushHandResultOutbox())
+	assert.Equal(t, []string{
+		"/internal/save-hand/gameId/1/handNum/1",
+		"/internal/save-hand/gameId/1/handNum/3",
+	}, delivered)
+	count, err := outbox.Count(g.gameCode)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// the api server is down: the results stay in the outbox
+	apiServer.Close()
+	require.NoError(t, g.queueHandResult(&HandResultServer{GameId: 1, HandNum: 4}))
+	assert.Error(t, g.flushHandResultOutbox())
+	count, err = outbox.Count(g.gameCode)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestOutboxFlushedWhenGameEnds(t *testing.T) {
+	delivered := make(chan string, 10)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r.URL.Path
+		w.Write([]byte("{}"))
+	}))
+	defer apiServer.Close()
+
+	// the worker is not woken up before the game ends
+	g := newOutboxTestGame(t, apiServer.URL, 10)
+	g.retryDelayMillis = 60000
+	require.NoError(t, g.manager.handResultOutbox.Add(g.gameCode, &HandResultServer{GameId: 1, HandNum: 1}))
+	done := make(chan bool)
+	go func() {
+		g.runHandResultOutboxWorker()
+		done <- true
+	}()
+	close(g.endResultOutbox)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the outbox worker did not stop")
+	}
+	require.Len(t, delivered, 1)
+	assert.Equal(t, "/internal/save-hand/gameId/1/handNum/1", <-delivered)
+	assert.Equal(t, 0, g.PendingHandResults())
+}
+
+func TestReplayHandResultOutboxes(t *testing.T) {
+	delivered := make([]string, 0)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = append(delivered, r.URL.Path)
+		w.Write([]byte("{}"))
+	}))
+	defer apiServer.Close()
+
+	// a cash game and a tournament table left results behind
+	outbox, err := NewFileHandResultOutbox(t.TempDir())
+	require.NoError(t, err)
+	tournamentTable := &Game{
+		logger:            logging.GetZeroLogger("game::Game", nil),
+		gameCode:          "tournament-table",
+		tournamentID:      7,
+		tableNo:           2,
+		tournamentURL:     apiServer.URL,
+		manager:           &Manager{handResultOutbox: outbox},
+		maxPendingResults: 10,
+		flushResultOutbox: make(chan bool, 1),
+	}
+	require.NoError(t, tournamentTable.queueHandResult(&HandResultServer{GameId: 2, HandNum: 5}))
+	require.NoError(t, outbox.Add("cash-game", &HandResultServer{GameId: 1, HandNum: 2}))
+	require.NoError(t, outbox.Add("cash-game", &HandResultServer{GameId: 1, HandNum: 1}))
+
+	gm := &Manager{apiServerURL: apiServer.URL, handResultOutbox: outbox}
+	gm.ReplayHandResultOutboxes()
+	assert.Equal(t, []string{
+		"/internal/save-hand/gameId/1/handNum/1",
+		"/internal/save-hand/gameId/1/handNum/2",
+		"/internal/save-hand/tournamentId/7/tableNo/2",
+	}, delivered)
+	gameCodes, err := outbox.GameCodes()
+	require.NoError(t, err)
+	assert.Empty(t, gameCodes)
+}
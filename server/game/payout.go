@@ -0,0 +1,223 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A payout structure has a payout table for each range of field sizes. The
+// table lists the percentages of the prize pool paid to the places, from the
+// winner down. The players busted in the same hand finish in the order of
+// their starting stacks (see RankEliminations); tied players split the prizes
+// of the places they share.
+
+// PayoutTier is the payout table of the tournaments with MinEntries to
+// MaxEntries entries (MaxEntries 0 is no limit).
+type PayoutTier struct {
+	MinEntries  int
+	MaxEntries  int
+	Percentages []float64
+}
+
+// PayoutStructure is the payout tables of the field sizes.
+type PayoutStructure struct {
+	Tiers []PayoutTier
+}
+
+// DefaultPayoutStructure returns the payout tables used when the tournament
+// doesn't have its own.
+func DefaultPayoutStructure() PayoutStructure {
+	return PayoutStructure{
+		Tiers: []PayoutTier{
+			{MinEntries: 2, MaxEntries: 4, Percentages: []float64{100}},
+			{MinEntries: 5, MaxEntries: 7, Percentages: []float64{65, 35}},
+			{MinEntries: 8, MaxEntries: 10, Percentages: []float64{50, 30, 20}},
+			{MinEntries: 11, MaxEntries: 20, Percentages: []float64{40, 25, 15, 12, 8}},
+			{MinEntries: 21, MaxEntries: 40, Percentages: []float64{30, 20, 13, 10, 8, 6, 5, 4, 4}},
+			{MinEntries: 41, MaxEntries: 0, Percentages: []float64{
+				25.5, 16, 11, 8.5, 7, 5.75, 4.75, 4, 3.5, 3, 2.5, 2.5, 2, 2, 2,
+			}},
+		},
+	}
+}
+
+// Validate checks that the tiers don't overlap and that the percentages of each
+// tier add up to 100 and don't increase.
+func (s PayoutStructure) Validate() error {
+	if len(s.Tiers) == 0 {
+		return fmt.Errorf("Payout structure does not have tiers")
+	}
+	tiers := append([]PayoutTier{}, s.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinEntries < tiers[j].MinEntries })
+	for i, tier := range tiers {
+		if tier.MaxEntries != 0 && tier.MaxEntries < tier.MinEntries {
+			return fmt.Errorf("Payout tier %d-%d is empty", tier.MinEntries, tier.MaxEntries)
+		}
+		if i > 0 && (tiers[i-1].MaxEntries == 0 || tiers[i-1].MaxEntries >= tier.MinEntries) {
+			return fmt.Errorf("Payout tier %d-%d overlaps tier %d-%d",
+				tier.MinEntries, tier.MaxEntries, tiers[i-1].MinEntries, tiers[i-1].MaxEntries)
+		}
+		if len(tier.Percentages) == 0 {
+			return fmt.Errorf("Payout tier %d-%d does not pay any place", tier.MinEntries, tier.MaxEntries)
+		}
+		if tier.MinEntries < len(tier.Percentages) {
+			return fmt.Errorf("Payout tier %d-%d pays %d places", tier.MinEntries, tier.MaxEntries, len(tier.Percentages))
+		}
+		total := 0.0
+		for j, p := range tier.Percentages {
+			if p <= 0 || (j > 0 && p > tier.Percentages[j-1]) {
+				return fmt.Errorf("Payout tier %d-%d place %d pays %v%%", tier.MinEntries, tier.MaxEntries, j+1, p)
+			}
+			total += p
+		}
+		if math.Abs(total-100) > 1e-6 {
+			return fmt.Errorf("Payout tier %d-%d pays %v%% of the prize pool", tier.MinEntries, tier.MaxEntries, total)
+		}
+	}
+	return nil
+}
+
+// Payouts returns the prizes of the places for the field size. The prizes
+// are rounded to cents and the rounding goes to the winner.
+func (s PayoutStructure) Payouts(entries int, prizePool float64) ([]float64, error) {
+	var tier *PayoutTier
+	for i := range s.Tiers {
+		t := &s.Tiers[i]
+		if entries >= t.MinEntries && (t.MaxEntries == 0 || entries <= t.MaxEntries) {
+			tier = t
+			break
+		}
+	}
+	if tier == nil {
+		return nil, fmt.Errorf("Payout structure does not have a tier for %d entries", entries)
+	}
+
+	payouts := make([]float64, len(tier.Percentages))
+	paid := 0.0
+	for i, p := range tier.Percentages {
+		payouts[i] = math.Floor(prizePool*p) / 100
+		paid += payouts[i]
+	}
+	payouts[0] = math.Round((payouts[0]+prizePool-paid)*100) / 100
+	return payouts, nil
+}
+
+// TournamentStandings keeps the finishing places of the players of a
+// tournament as they bust.
+type TournamentStandings struct {
+	entries   int
+	reEntries int
+	remaining int
+	// busted players in the order of their places (last place first)
+	eliminations []Elimination
+	winner       *Elimination
+}
+
+// NewTournamentStandings returns the standings of a tournament with the
+// number of entries.
+func NewTournamentStandings(entries int) *TournamentStandings {
+	return &TournamentStandings{
+		entries:   entries,
+		remaining: entries,
+	}
+}
+
+// SetReEntries sets the number of re-entries. A re-entry is a new entry, but
+// the busted entry of the player is not placed, so the number of players
+// remaining doesn't change.
+func (s *TournamentStandings) SetReEntries(reEntries int) {
+	s.reEntries = reEntries
+}
+
+// Entries returns the number of entries, the re-entries included.
+func (s *TournamentStandings) Entries() int {
+	return s.entries + s.reEntries
+}
+
+// Remaining returns the number of players left in the tournament.
+func (s *TournamentStandings) Remaining() int {
+	return s.remaining
+}
+
+// Eliminate gives the next places to the players busted at the same time and
+// returns their eliminations.
+func (s *TournamentStandings) Eliminate(busted []Elimination) []Elimination {
+	var players []Elimination
+	for _, e := range busted {
+		if !s.isEliminated(e.PlayerID) {
+			players = append(players, e)
+		}
+	}
+	if len(players) == 0 {
+		return nil
+	}
+	if len(players) > s.remaining {
+		players = players[:s.remaining]
+	}
+	s.remaining -= len(players)
+	ranked := RankEliminations(players, s.remaining)
+	// the worst place is eliminated first
+	for i := len(ranked) - 1; i >= 0; i-- {
+		s.eliminations = append(s.eliminations, ranked[i])
+	}
+	return ranked
+}
+
+// SetWinner records the winner of the tournament.
+func (s *TournamentStandings) SetWinner(playerID uint64, tableNo uint32) {
+	s.winner = &Elimination{PlayerID: playerID, TableNo: tableNo, Place: 1}
+	s.remaining = 0
+}
+
+func (s *TournamentStandings) isEliminated(playerID uint64) bool {
+	if s.winner != nil && s.winner.PlayerID == playerID {
+		return true
+	}
+	for _, e := range s.eliminations {
+		if e.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Results returns the players with a place, the winner first.
+func (s *TournamentStandings) Results() []Elimination {
+	results := make([]Elimination, 0, len(s.eliminations)+1)
+	if s.winner != nil {
+		results = append(results, *s.winner)
+	}
+	for i := len(s.eliminations) - 1; i >= 0; i-- {
+		results = append(results, s.eliminations[i])
+	}
+	return results
+}
+
+// Prizes returns the prizes of the players with a place. Players tied for a
+// place split the prizes of the places they take.
+func (s *TournamentStandings) Prizes(payouts []float64) map[uint64]float64 {
+	prizes := make(map[uint64]float64)
+	results := s.Results()
+	for i := 0; i < len(results); {
+		// the tied players take the places from their place on
+		j := i + 1
+		for j < len(results) && results[j].Place == results[i].Place {
+			j++
+		}
+		total := 0.0
+		for place := results[i].Place; place < results[i].Place+(j-i); place++ {
+			if place-1 < len(payouts) {
+				total += payouts[place-1]
+			}
+		}
+		if total > 0 {
+			share := math.Floor(total*100/float64(j-i)) / 100
+			for k := i; k < j; k++ {
+				prizes[results[k].PlayerID] = share
+			}
+		}
+		i = j
+	}
+	return prizes
+}
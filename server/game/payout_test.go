@@ -0,0 +1,75 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPayoutStructure(t *testing.T) {
+	s := DefaultPayoutStructure()
+	require.NoError(t, s.Validate())
+
+	payouts, err := s.Payouts(9, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{500, 300, 200}, payouts)
+
+	// the rounding goes to the winner
+	payouts, err = s.Payouts(6, 100.01)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{65.01, 35}, payouts)
+
+	payouts, err = s.Payouts(500, 10000)
+	require.NoError(t, err)
+	assert.Len(t, payouts, 15)
+
+	_, err = s.Payouts(1, 100)
+	assert.Error(t, err)
+}
+
+func TestPayoutStructureValidate(t *testing.T) {
+	assert.Error(t, PayoutStructure{}.Validate())
+	assert.Error(t, PayoutStructure{Tiers: []PayoutTier{
+		{MinEntries: 2, MaxEntries: 5, Percentages: []float64{60, 40}},
+		{MinEntries: 5, MaxEntries: 9, Percentages: []float64{60, 40}},
+	}}.Validate(), "overlapping tiers")
+	assert.Error(t, PayoutStructure{Tiers: []PayoutTier{
+		{MinEntries: 2, Percentages: []float64{40, 60}},
+	}}.Validate(), "increasing prizes")
+	assert.Error(t, PayoutStructure{Tiers: []PayoutTier{
+		{MinEntries: 2, Percentages: []float64{60, 30}},
+	}}.Validate(), "90% paid")
+}
+
+func TestTournamentStandings(t *testing.T) {
+	s := NewTournamentStandings(6)
+	s.Eliminate([]Elimination{{PlayerID: 6, StartingStack: 300}})
+	// two players bust in the same hand with the same stack
+	ranked := s.Eliminate([]Elimination{
+		{PlayerID: 4, StartingStack: 800},
+		{PlayerID: 5, StartingStack: 800},
+	})
+	assert.Equal(t, 4, ranked[0].Place)
+	assert.True(t, ranked[0].Tied)
+	// a player is placed once
+	assert.Empty(t, s.Eliminate([]Elimination{{PlayerID: 6}}))
+	s.Eliminate([]Elimination{{PlayerID: 3, StartingStack: 2000}})
+	// a re-entry adds an entry, the player is still in the tournament
+	s.SetReEntries(1)
+	assert.Equal(t, 7, s.Entries())
+	s.Eliminate([]Elimination{{PlayerID: 2, StartingStack: 5000}})
+	assert.Equal(t, 1, s.Remaining())
+	s.SetWinner(1, 1)
+
+	results := s.Results()
+	places := make([]int, len(results))
+	for i, r := range results {
+		places[i] = r.Place
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 4, 6}, places)
+
+	// the tied players split the 4th and 5th prizes
+	prizes := s.Prizes([]float64{400, 250, 150, 120, 80})
+	assert.Equal(t, map[uint64]float64{1: 400, 2: 250, 3: 150, 4: 100, 5: 100}, prizes)
+}
@@ -1,7 +1,36 @@
 package game
 
+// PersistHandState stores the current hand state of a game.
+//
+// Every Save increments HandState.Version. A save is accepted only when the
+// version of the given state matches the stored version (or there is no stored
+// state and the given version is 0), so a stale game server cannot overwrite the
+// state written by a newer one. The rejected save returns StaleHandState.
+// Load returns HandStateNotFound when the game has no stored state.
 type PersistHandState interface {
 	Load(gameCode string) (*HandState, error)
 	Save(gameCode string, state *HandState) error
 	Remove(gameCode string) error
 }
+
+const HandStateNotFound = PersistError("Record not found")
+const StaleHandState = PersistError("Hand state was updated by another writer")
+
+type PersistError string
+
+func (e PersistError) Error() string { return string(e) }
+
+// checkHandStateVersion validates the version of the state being saved against
+// the stored version. storedVersion is ignored when there is no stored state.
+func checkHandStateVersion(version uint64, exists bool, storedVersion uint64) error {
+	if !exists {
+		if version != 0 {
+			return StaleHandState
+		}
+		return nil
+	}
+	if version != storedVersion {
+		return StaleHandState
+	}
+	return nil
+}
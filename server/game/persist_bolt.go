@@ -0,0 +1,105 @@
+package game
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var handStateBucket = []byte("hand_state")
+
+// BoltHandStateTracker keeps the hand states in an embedded bolt database file.
+// Useful for a single game server that needs to survive restarts without redis.
+type BoltHandStateTracker struct {
+	db *bolt.DB
+}
+
+func NewBoltHandStateTracker(path string) (*BoltHandStateTracker, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to open bolt database at %s", path)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(handStateBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "Unable to create hand state bucket")
+	}
+	return &BoltHandStateTracker{db: db}, nil
+}
+
+func (b *BoltHandStateTracker) Load(gameCode string) (*HandState, error) {
+	return b.load(gameCode)
+}
+
+func (b *BoltHandStateTracker) load(key string) (*HandState, error) {
+	var handState *HandState
+	err := b.db.View(func(tx *bolt.Tx) error {
+		handStateBytes := tx.Bucket(handStateBucket).Get([]byte(key))
+		if handStateBytes == nil {
+			return HandStateNotFound
+		}
+		handState = &HandState{}
+		return proto.Unmarshal(handStateBytes, handState)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handState, nil
+}
+
+func (b *BoltHandStateTracker) Save(gameCode string, state *HandState) error {
+	return b.save(gameCode, state)
+}
+
+func (b *BoltHandStateTracker) save(key string, state *HandState) error {
+	version := state.Version
+	state.Version = version + 1
+	stateInBytes, err := proto.Marshal(state)
+	state.Version = version
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand state")
+	}
+
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(handStateBucket)
+		var storedVersion uint64
+		storedBytes := bucket.Get([]byte(key))
+		if storedBytes != nil {
+			stored := &HandState{}
+			err := proto.Unmarshal(storedBytes, stored)
+			if err != nil {
+				return errors.Wrap(err, "Could not proto-unmarshal stored hand state")
+			}
+			storedVersion = stored.Version
+		}
+		err := checkHandStateVersion(version, storedBytes != nil, storedVersion)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), stateInBytes)
+	})
+	if err != nil {
+		return err
+	}
+	state.Version = version + 1
+	return nil
+}
+
+func (b *BoltHandStateTracker) Remove(gameCode string) error {
+	return b.remove(gameCode)
+}
+
+func (b *BoltHandStateTracker) remove(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(handStateBucket).Delete([]byte(key))
+	})
+}
+
+func (b *BoltHandStateTracker) Close() error {
+	return b.db.Close()
+}
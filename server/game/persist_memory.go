@@ -1,13 +1,14 @@
 package game
 
 import (
-	"fmt"
+	"sync"
 
 	"github.com/golang/protobuf/proto"
 )
 
 type MemoryHandStateTracker struct {
 	activeHands map[string][]byte
+	lock        sync.Mutex
 }
 
 func NewMemoryHandStateTracker() (*MemoryHandStateTracker, error) {
@@ -17,6 +18,8 @@ func NewMemoryHandStateTracker() (*MemoryHandStateTracker, error) {
 }
 
 func (m *MemoryHandStateTracker) Load(gameCode string) (*HandState, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.load(gameCode)
 }
 
@@ -29,16 +32,34 @@ func (m *MemoryHandStateTracker) load(key string) (*HandState, error) {
 		}
 		return &handState, nil
 	}
-	return nil, fmt.Errorf("Hand state for Key: %s is not found", key)
+	return nil, HandStateNotFound
 }
 
 func (m *MemoryHandStateTracker) Save(gameCode string, state *HandState) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.save(gameCode, state)
 }
 
 func (m *MemoryHandStateTracker) save(key string, state *HandState) error {
+	var storedVersion uint64
+	stored, err := m.load(key)
+	exists := err != HandStateNotFound
+	if exists {
+		if err != nil {
+			return err
+		}
+		storedVersion = stored.Version
+	}
+	err = checkHandStateVersion(state.Version, exists, storedVersion)
+	if err != nil {
+		return err
+	}
+
+	state.Version++
 	stateInBytes, err := proto.Marshal(state)
 	if err != nil {
+		state.Version--
 		return err
 	}
 	m.activeHands[key] = stateInBytes
@@ -46,6 +67,8 @@ func (m *MemoryHandStateTracker) save(key string, state *HandState) error {
 }
 
 func (m *MemoryHandStateTracker) Remove(gameCode string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.remove(gameCode)
 }
 
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"database/sql"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	// postgres driver
+	_ "github.com/lib/pq"
+)
+
+const createHandStateTable = `CREATE TABLE IF NOT EXISTS hand_state (
+	game_code TEXT PRIMARY KEY,
+	version BIGINT NOT NULL,
+	state BYTEA NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+)`
+
+// PostgresHandStateTracker keeps the hand states in the hand_state table.
+// The version column mirrors HandState.Version so that the stale write check
+// is done in the same statement as the write.
+type PostgresHandStateTracker struct {
+	db *sqlx.DB
+}
+
+func NewPostgresHandStateTracker(connStr string) (*PostgresHandStateTracker, error) {
+	db, err := sqlx.Connect("postgres", connStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to connect to postgres")
+	}
+	_, err = db.Exec(createHandStateTable)
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "Unable to create hand_state table")
+	}
+	return &PostgresHandStateTracker{db: db}, nil
+}
+
+func (p *PostgresHandStateTracker) Load(gameCode string) (*HandState, error) {
+	return p.load(gameCode)
+}
+
+func (p *PostgresHandStateTracker) load(key string) (*HandState, error) {
+	var handStateBytes []byte
+	err := p.db.Get(&handStateBytes, "SELECT state FROM hand_state WHERE game_code = $1", key)
+	if err == sql.ErrNoRows {
+		return nil, HandStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	handState := &HandState{}
+	err = proto.Unmarshal(handStateBytes, handState)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not proto-unmarshal hand state from postgres")
+	}
+	return handState, nil
+}
+
+func (p *PostgresHandStateTracker) Save(gameCode string, state *HandState) error {
+	return p.save(gameCode, state)
+}
+
+func (p *PostgresHandStateTracker) save(key string, state *HandState) error {
+	version := state.Version
+	state.Version = version + 1
+	stateInBytes, err := proto.Marshal(state)
+	state.Version = version
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand state")
+	}
+
+	var result sql.Result
+	if version == 0 {
+		// First save of the game. Fails if any other writer got there first.
+		result, err = p.db.Exec(
+			"INSERT INTO hand_state (game_code, version, state) VALUES ($1, $2, $3) ON CONFLICT (game_code) DO NOTHING",
+			key, version+1, stateInBytes)
+	} else {
+		result, err = p.db.Exec(
+			"UPDATE hand_state SET version = $2, state = $3, updated_at = NOW() WHERE game_code = $1 AND version = $4",
+			key, version+1, stateInBytes, version)
+	}
+	if err != nil {
+		return err
+	}
+	numRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if numRows != 1 {
+		return StaleHandState
+	}
+	state.Version = version + 1
+	return nil
+}
+
+func (p *PostgresHandStateTracker) Remove(gameCode string) error {
+	return p.remove(gameCode)
+}
+
+func (p *PostgresHandStateTracker) remove(key string) error {
+	_, err := p.db.Exec("DELETE FROM hand_state WHERE game_code = $1", key)
+	return err
+}
+
+func (p *PostgresHandStateTracker) Close() error {
+	return p.db.Close()
+}
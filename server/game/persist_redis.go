@@ -20,12 +20,6 @@ type RedisHandStateTracker struct {
 	abortRetryAfter time.Duration
 }
 
-const RedisKeyNotFound = PersistRedisError("Record not found")
-
-type PersistRedisError string
-
-func (e PersistRedisError) Error() string { return string(e) }
-
 func NewRedisHandStateTracker(redisURL string, redisUser string, redisPW string, redisDB int, useSSL bool) (*RedisHandStateTracker, error) {
 	var tlsConfig *tls.Config
 	if useSSL {
@@ -76,7 +70,7 @@ func pingWithTimeout(client *redis.Client, timeout time.Duration) error {
 func (r *RedisHandStateTracker) Load(gameCode string) (*HandState, error) {
 	handStateStr, err := r.loadWithTimeout(gameCode)
 	if err == redis.Nil {
-		return nil, RedisKeyNotFound
+		return nil, HandStateNotFound
 	}
 
 	abortAt := time.Now().Add(r.abortRetryAfter)
@@ -91,7 +85,7 @@ func (r *RedisHandStateTracker) Load(gameCode string) (*HandState, error) {
 			redisLogger.Info().
 				Str(logging.GameCodeKey, gameCode).
 				Msg("Got nil hand state from Redis")
-			return nil, RedisKeyNotFound
+			return nil, HandStateNotFound
 		}
 		if err == nil {
 			redisLogger.Info().
@@ -123,41 +117,83 @@ func (r *RedisHandStateTracker) loadWithTimeout(key string) (string, error) {
 }
 
 func (r *RedisHandStateTracker) Save(gameCode string, state *HandState) error {
+	version := state.Version
+	state.Version = version + 1
 	stateInBytes, err := proto.Marshal(state)
+	state.Version = version
 	if err != nil {
 		return errors.Wrap(err, "Could not proto-marshal hand state")
 	}
 
-	err = r.saveWithTimeout(gameCode, stateInBytes)
+	err = r.saveWithTimeout(gameCode, version, stateInBytes)
 
 	abortAt := time.Now().Add(r.abortRetryAfter)
-	for retries := 1; err != nil && time.Now().Before(abortAt); retries++ {
+	for retries := 1; err != nil && err != StaleHandState && time.Now().Before(abortAt); retries++ {
 		redisLogger.Error().
 			Err(err).
 			Str(logging.GameCodeKey, gameCode).
 			Msgf("Could not save hand state to Redis. Retrying...%d", retries)
 		time.Sleep(r.retryDelay)
-		err = r.saveWithTimeout(gameCode, stateInBytes)
+		err = r.saveWithTimeout(gameCode, version, stateInBytes)
 		if err == nil {
 			redisLogger.Info().
 				Str(logging.GameCodeKey, gameCode).
 				Msg("Successfully saved hand state to Redis")
 		}
 	}
+	if err == StaleHandState {
+		redisLogger.Error().
+			Str(logging.GameCodeKey, gameCode).
+			Msgf("Rejected saving stale hand state (version %d)", version)
+		return err
+	}
 	if err != nil {
 		redisLogger.Error().
 			Err(err).
 			Str(logging.GameCodeKey, gameCode).
 			Msgf("Retry exhausted saving hand state")
+		return err
 	}
 
-	return err
+	state.Version = version + 1
+	return nil
 }
 
-func (r *RedisHandStateTracker) saveWithTimeout(key string, handStatebytes []byte) error {
+// saveWithTimeout writes the hand state only if the stored state is still at the
+// given version. The key is watched so that a concurrent writer fails the transaction.
+func (r *RedisHandStateTracker) saveWithTimeout(key string, version uint64, handStatebytes []byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
 	defer cancel()
-	return r.rdclient.Set(ctx, key, handStatebytes, 0).Err()
+	err := r.rdclient.Watch(ctx, func(tx *redis.Tx) error {
+		exists := true
+		var storedVersion uint64
+		storedBytes, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			exists = false
+		} else if err != nil {
+			return err
+		} else {
+			stored := &HandState{}
+			err = proto.Unmarshal(storedBytes, stored)
+			if err != nil {
+				return errors.Wrap(err, "Could not proto-unmarshal stored hand state from Redis")
+			}
+			storedVersion = stored.Version
+		}
+		err = checkHandStateVersion(version, exists, storedVersion)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, handStatebytes, 0)
+			return nil
+		})
+		return err
+	}, key)
+	if err == redis.TxFailedErr {
+		return StaleHandState
+	}
+	return err
 }
 
 func (r *RedisHandStateTracker) Remove(gameCode string) error {
@@ -0,0 +1,260 @@
+package game
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/logging"
+)
+
+// The same conformance tests run against every PersistHandState backend.
+// Redis and postgres tests run only when TEST_REDIS_ADDR (host:port) and
+// TEST_POSTGRES_CONN (postgres connection string) are set.
+
+func TestMemoryHandStatePersist(t *testing.T) {
+	testPersistHandState(t, func(t *testing.T) PersistHandState {
+		persist, err := NewMemoryHandStateTracker()
+		require.NoError(t, err)
+		return persist
+	})
+}
+
+func TestBoltHandStatePersist(t *testing.T) {
+	testPersistHandState(t, func(t *testing.T) PersistHandState {
+		dir, err := ioutil.TempDir("", "handstate")
+		require.NoError(t, err)
+		persist, err := NewBoltHandStateTracker(filepath.Join(dir, "handstate.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			persist.Close()
+			os.RemoveAll(dir)
+		})
+		return persist
+	})
+}
+
+func TestRedisHandStatePersist(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	testPersistHandState(t, func(t *testing.T) PersistHandState {
+		persist, err := NewRedisHandStateTracker(addr, "", "", 0, false)
+		require.NoError(t, err)
+		return persist
+	})
+}
+
+func TestPostgresHandStatePersist(t *testing.T) {
+	connStr := os.Getenv("TEST_POSTGRES_CONN")
+	if connStr == "" {
+		t.Skip("TEST_POSTGRES_CONN is not set")
+	}
+	testPersistHandState(t, func(t *testing.T) PersistHandState {
+		persist, err := NewPostgresHandStateTracker(connStr)
+		require.NoError(t, err)
+		t.Cleanup(func() { persist.Close() })
+		return persist
+	})
+}
+
+func testPersistHandState(t *testing.T, newPersist func(t *testing.T) PersistHandState) {
+	// Game codes are unique per run so that the shared backends don't see old records.
+	prefix := fmt.Sprintf("persist-test-%d", os.Getpid())
+
+	t.Run("SaveLoadRemove", func(t *testing.T) {
+		persist := newPersist(t)
+		gameCode := prefix + "-save"
+
+		state := &HandState{GameId: 1, HandNum: 10, CurrentState: HandStatus_PREFLOP}
+		require.NoError(t, persist.Save(gameCode, state))
+		assert.Equal(t, uint64(1), state.Version)
+
+		loaded, err := persist.Load(gameCode)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(10), loaded.HandNum)
+		assert.Equal(t, HandStatus_PREFLOP, loaded.CurrentState)
+		assert.Equal(t, uint64(1), loaded.Version)
+
+		loaded.CurrentState = HandStatus_FLOP
+		require.NoError(t, persist.Save(gameCode, loaded))
+		assert.Equal(t, uint64(2), loaded.Version)
+
+		loaded, err = persist.Load(gameCode)
+		require.NoError(t, err)
+		assert.Equal(t, HandStatus_FLOP, loaded.CurrentState)
+
+		require.NoError(t, persist.Remove(gameCode))
+		_, err = persist.Load(gameCode)
+		assert.Equal(t, HandStateNotFound, err)
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		persist := newPersist(t)
+		gameCode := prefix + "-missing"
+
+		_, err := persist.Load(gameCode)
+		assert.Equal(t, HandStateNotFound, err)
+		// Removing a missing game is not an error.
+		assert.NoError(t, persist.Remove(gameCode))
+	})
+
+	t.Run("StaleWrite", func(t *testing.T) {
+		persist := newPersist(t)
+		gameCode := prefix + "-stale"
+		defer persist.Remove(gameCode)
+
+		require.NoError(t, persist.Save(gameCode, &HandState{HandNum: 1}))
+		newer, err := persist.Load(gameCode)
+		require.NoError(t, err)
+		stale, err := persist.Load(gameCode)
+		require.NoError(t, err)
+
+		newer.HandNum = 2
+		require.NoError(t, persist.Save(gameCode, newer))
+
+		stale.HandNum = 99
+		assert.Equal(t, StaleHandState, persist.Save(gameCode, stale))
+		// A new hand state that never saw the stored version is stale too.
+		assert.Equal(t, StaleHandState, persist.Save(gameCode, &HandState{HandNum: 100}))
+
+		loaded, err := persist.Load(gameCode)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(2), loaded.HandNum)
+		assert.Equal(t, uint64(2), loaded.Version)
+	})
+
+	t.Run("ConcurrentGames", func(t *testing.T) {
+		persist := newPersist(t)
+		numGames := 8
+		numHands := 20
+
+		var wg sync.WaitGroup
+		errs := make(chan error, numGames)
+		for i := 0; i < numGames; i++ {
+			wg.Add(1)
+			go func(gameCode string) {
+				defer wg.Done()
+				defer persist.Remove(gameCode)
+				state := &HandState{}
+				for handNum := uint32(1); handNum <= uint32(numHands); handNum++ {
+					state.HandNum = handNum
+					err := persist.Save(gameCode, state)
+					if err != nil {
+						errs <- err
+						return
+					}
+					state, err = persist.Load(gameCode)
+					if err != nil {
+						errs <- err
+						return
+					}
+					if state.HandNum != handNum {
+						errs <- fmt.Errorf("game %s: loaded hand %d, expected %d", gameCode, state.HandNum, handNum)
+						return
+					}
+				}
+			}(fmt.Sprintf("%s-game-%d", prefix, i))
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ConcurrentWritersSameGame", func(t *testing.T) {
+		persist := newPersist(t)
+		gameCode := prefix + "-writers"
+		defer persist.Remove(gameCode)
+		require.NoError(t, persist.Save(gameCode, &HandState{}))
+
+		numWriters := 8
+		var wg sync.WaitGroup
+		results := make(chan error, numWriters)
+		states := make([]*HandState, numWriters)
+		for i := range states {
+			state, err := persist.Load(gameCode)
+			require.NoError(t, err)
+			states[i] = state
+		}
+		for i := range states {
+			wg.Add(1)
+			go func(state *HandState) {
+				defer wg.Done()
+				results <- persist.Save(gameCode, state)
+			}(states[i])
+		}
+		wg.Wait()
+		close(results)
+
+		succeeded := 0
+		for err := range results {
+			if err == nil {
+				succeeded++
+			} else {
+				assert.Equal(t, StaleHandState, err)
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
+
+func newPersistTestGame(persist PersistHandState) *Game {
+	return &Game{
+		gameCode: "restart-test",
+		logger:   logging.GetZeroLogger("game::Game", nil),
+		manager:  &Manager{handStatePersist: persist},
+	}
+}
+
+func TestGameRestartContinuesHandStateVersion(t *testing.T) {
+	persist, err := NewMemoryHandStateTracker()
+	require.NoError(t, err)
+	g := newPersistTestGame(persist)
+	for handNum := uint32(1); handNum <= 2; handNum++ {
+		handState := &HandState{HandNum: handNum, NextServerSeed: []byte{byte(handNum)}}
+		g.continueFromPrevHand(handState)
+		require.NoError(t, g.saveHandState(handState, FlowState_DEAL_HAND))
+		require.NoError(t, g.saveHandState(handState, FlowState_WAIT_FOR_NEXT_ACTION))
+	}
+
+	// the game server restarts, the stored state of hand 2 is kept
+	restarted := newPersistTestGame(persist)
+	handState := &HandState{HandNum: 3}
+	restarted.continueFromPrevHand(handState)
+	assert.Equal(t, []byte{2}, handState.ServerSeed)
+	require.NoError(t, restarted.saveHandState(handState, FlowState_DEAL_HAND))
+
+	// the game that did not restart is stale now
+	stale := &HandState{HandNum: 3}
+	g.continueFromPrevHand(stale)
+	assert.Equal(t, StaleHandState, g.saveHandState(stale, FlowState_DEAL_HAND))
+}
+
+func TestPeekHandStateKeepsVersion(t *testing.T) {
+	persist, err := NewMemoryHandStateTracker()
+	require.NoError(t, err)
+	g := newPersistTestGame(persist)
+	handState := &HandState{HandNum: 1}
+	g.continueFromPrevHand(handState)
+	require.NoError(t, g.saveHandState(handState, FlowState_DEAL_HAND))
+	stored, err := g.peekHandState()
+	require.NoError(t, err)
+
+	// another reader loads the state while the game saves the next action
+	require.NoError(t, g.saveHandState(handState, FlowState_WAIT_FOR_NEXT_ACTION))
+	_, err = g.peekHandState()
+	require.NoError(t, err)
+	assert.Equal(t, stored.Version+1, g.handStateVersion)
+
+	next := &HandState{HandNum: 2}
+	g.continueFromPrevHand(next)
+	assert.NoError(t, g.saveHandState(next, FlowState_DEAL_HAND))
+}
@@ -33,7 +33,7 @@ func NewPloWinnerEvaluate(handState *HandState, includeHighHand bool, lowWinner
 func (h *PloWinnerEvaluate) Evaluate2(seatCards []byte, board []byte) EvaluatedCards {
 	playerCardsEval := poker.FromByteCards(seatCards)
 	boardCardsEval := poker.FromByteCards(board)
-	result := poker.EvaluateOmaha(playerCardsEval, boardCardsEval)
+	result := h.handState.deckDefinition().EvaluateOmaha(playerCardsEval, boardCardsEval)
 
 	// determine what player cards and board cards used to determine best cards
 	seatCardsInCard := poker.FromByteCards(seatCards)
@@ -0,0 +1,476 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"voyager.com/server/poker"
+)
+
+// ReplayCase is a recorded hand to replay offline.
+//
+// HandState is the hand state saved right after the hand was dealt (the first
+// hand state snapshot of the hand). The recorded actions and the expected outcome
+// come from FinalHandState (the last snapshot of the hand) and/or HandResult
+// (the result that was sent to the api server). At least one of them is needed.
+//
+// The recorded actions are the actions after the game server normalized them
+// (e.g. a call for the whole stack is logged as all-in), which is what gets
+// re-fed to the hand state.
+type ReplayCase struct {
+	Name           string
+	HandState      *HandState
+	FinalHandState *HandState
+	HandResult     *HandResultServer
+}
+
+// ReplayReport is the outcome of replaying a hand. Diffs is empty when the
+// replayed hand matches the recorded hand.
+type ReplayReport struct {
+	Name    string   `json:"name"`
+	HandNum uint32   `json:"handNum"`
+	Actions int      `json:"actions"`
+	Skipped string   `json:"skipped,omitempty"`
+	Diffs   []string `json:"diffs,omitempty"`
+}
+
+func (r *ReplayReport) Diverged() bool {
+	return len(r.Diffs) > 0
+}
+
+func (r *ReplayReport) addDiff(format string, args ...interface{}) {
+	r.Diffs = append(r.Diffs, fmt.Sprintf(format, args...))
+}
+
+// ReplayHand re-feeds the recorded actions of the hand through the hand state
+// (no timers, messages or persistence) and compares the pots, winners and
+// balances with the recorded outcome. The run-it-twice responses are part of
+// the recorded actions, so a hand that ran twice replays both boards.
+func ReplayHand(c *ReplayCase) (*ReplayReport, error) {
+	if c.HandState == nil {
+		return nil, fmt.Errorf("Replay case %s does not have the initial hand state", c.Name)
+	}
+	recordedLogs := c.recordedActionLogs()
+	if recordedLogs == nil {
+		return nil, fmt.Errorf("Replay case %s does not have the recorded actions", c.Name)
+	}
+
+	report := &ReplayReport{
+		Name:    c.Name,
+		HandNum: c.HandState.HandNum,
+	}
+	h := proto.Clone(c.HandState).(*HandState)
+	checkReplayDeck(h, report)
+
+	actions := make([]*HandAction, 0)
+	for i, initialLog := range handActionLogs(h) {
+		recorded := recordedLogs[i].GetActions()
+		numInitial := len(initialLog.GetActions())
+		if len(recorded) < numInitial {
+			report.addDiff("%s: recorded %d actions, but the dealt hand already has %d", HandStatus(HandStatus_PREFLOP+HandStatus(i)), len(recorded), numInitial)
+			return report, nil
+		}
+		actions = append(actions, recorded[numInitial:]...)
+	}
+	if len(actions) == 0 {
+		report.Skipped = "no player actions to replay"
+		return report, nil
+	}
+
+	var result *HandResultClient
+	for i, recorded := range actions {
+		if result != nil {
+			report.addDiff("hand ended after %d actions, %d recorded actions are left", i, len(actions)-i)
+			break
+		}
+		if h.RunItTwicePrompt {
+			if !isRunItTwiceResponse(recorded.Action) {
+				report.addDiff("action %d: seat %d acted (%s %v), but the replay expects the run-it-twice responses", i+1, recorded.SeatNo, recorded.Action, recorded.Amount)
+				break
+			}
+		} else {
+			nextSeatAction := h.NextSeatAction
+			if nextSeatAction == nil || nextSeatAction.SeatNo != recorded.SeatNo {
+				report.addDiff("action %d: seat %d acted (%s %v), but the replay expects %s", i+1, recorded.SeatNo, recorded.Action, recorded.Amount, describeNextSeat(nextSeatAction))
+				break
+			}
+		}
+
+		next, action, actionResult, err := replayAction(h, recorded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error while replaying action %d of hand %d", i+1, h.HandNum)
+		}
+		h = next
+		report.Actions++
+
+		if action.Action != recorded.Action || !sameAmount(action.Amount, recorded.Amount) {
+			report.addDiff("action %d (seat %d): recorded %s %v, replayed %s %v", i+1, recorded.SeatNo, recorded.Action, recorded.Amount, action.Action, action.Amount)
+		}
+		if !sameAmount(action.Stack, recorded.Stack) {
+			report.addDiff("action %d (seat %d %s): stack recorded %v, replayed %v", i+1, recorded.SeatNo, recorded.Action, recorded.Stack, action.Stack)
+		}
+		result = actionResult
+	}
+	if result == nil {
+		report.addDiff("hand did not end after replaying %d actions", report.Actions)
+		return report, nil
+	}
+
+	if c.FinalHandState != nil {
+		compareReplayHandState(c.FinalHandState, h, report)
+	}
+	if c.HandResult != nil && c.HandResult.RunItTwice != h.RunItTwiceConfirmed {
+		report.addDiff("run it twice: recorded %v, replayed %v", c.HandResult.RunItTwice, h.RunItTwiceConfirmed)
+	}
+	if c.HandResult != nil && c.HandResult.Result != nil {
+		compareReplayResult(c.HandResult.Result, result, report)
+	}
+	return report, nil
+}
+
+// replayAction applies a player action (or a run-it-twice response) with the
+// hand engine. Returns the hand state after the action, the action as the
+// engine normalized it and the hand result when the action ends the hand.
+func replayAction(h *HandState, recorded *HandAction) (*HandState, *HandAction, *HandResultClient, error) {
+	next, msgItems, err := Apply(h, recorded)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	action := recorded
+	if !h.RunItTwicePrompt {
+		action = msgItems[0].GetPlayerActed()
+	}
+	var result *HandResultClient
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType == HandResultMessage2 {
+			result = msgItem.GetHandResultClient()
+		}
+	}
+	return next, action, result, nil
+}
+
+func isRunItTwiceResponse(action ACTION) bool {
+	return action == ACTION_RUN_IT_TWICE_YES || action == ACTION_RUN_IT_TWICE_NO
+}
+
+func (c *ReplayCase) recordedActionLogs() []*HandActionLog {
+	if c.FinalHandState != nil {
+		return handActionLogs(c.FinalHandState)
+	}
+	if c.HandResult != nil && c.HandResult.HandLog != nil {
+		log := c.HandResult.HandLog
+		return []*HandActionLog{log.PreflopActions, log.FlopActions, log.TurnActions, log.RiverActions}
+	}
+	return nil
+}
+
+func handActionLogs(h *HandState) []*HandActionLog {
+	return []*HandActionLog{h.PreflopActions, h.FlopActions, h.TurnActions, h.RiverActions}
+}
+
+func describeNextSeat(nextSeatAction *NextSeatAction) string {
+	if nextSeatAction == nil {
+		return "no more actions"
+	}
+	return fmt.Sprintf("seat %d to act", nextSeatAction.SeatNo)
+}
+
+// checkReplayDeck makes sure the player cards and the board(s) in the hand state
+// are the ones dealt from HandState.Deck.
+func checkReplayDeck(h *HandState, report *ReplayReport) {
+	if len(h.Deck) == 0 {
+		report.addDiff("deck: hand state does not have the deck")
+		return
+	}
+	deck := poker.DeckFromBytes(h.Deck)
+
+	numPlayerCards := 0
+	for _, cards := range h.PlayersCards {
+		numPlayerCards += len(cards)
+	}
+	dealt := make(map[byte]bool)
+	for _, card := range deck.Draw(numPlayerCards) {
+		dealt[card.GetByte()] = true
+	}
+	seats := make([]int, 0, len(h.PlayersCards))
+	for seatNo := range h.PlayersCards {
+		seats = append(seats, int(seatNo))
+	}
+	sort.Ints(seats)
+	for _, seatNo := range seats {
+		for _, card := range h.PlayersCards[uint32(seatNo)] {
+			if !dealt[card] {
+				report.addDiff("deck: seat %d card %s is not dealt from the deck", seatNo, poker.CardToString(uint32(card)))
+			}
+		}
+	}
+
+	for i, board := range h.Boards {
+		if i > 0 && !h.DoubleBoard {
+			// The second board of run-it-twice is drawn later.
+			break
+		}
+		boardCards, _ := h.board(deck)
+		expected := poker.ByteCardsToUint32Cards(poker.CardsToByteCards(boardCards))
+		if !sameCards(expected, board.Cards) {
+			report.addDiff("deck: board %d is %s, deck deals %s", board.BoardNo, poker.CardsToString(board.Cards), poker.CardsToString(expected))
+		}
+	}
+}
+
+func compareReplayHandState(recorded *HandState, replayed *HandState, report *ReplayReport) {
+	if recorded.HandCompletedAt != replayed.HandCompletedAt {
+		report.addDiff("hand completed at: recorded %s, replayed %s", recorded.HandCompletedAt, replayed.HandCompletedAt)
+	}
+
+	recordedPots := nonEmptyPots(recorded.Pots)
+	replayedPots := nonEmptyPots(replayed.Pots)
+	if len(recordedPots) != len(replayedPots) {
+		report.addDiff("pots: recorded %d pots %s, replayed %d pots %s", len(recordedPots), describePots(recordedPots), len(replayedPots), describePots(replayedPots))
+	} else {
+		for i := range recordedPots {
+			if !sameAmount(recordedPots[i].Pot, replayedPots[i].Pot) || !sameSeats(recordedPots[i].Seats, replayedPots[i].Seats) {
+				report.addDiff("pot %d: recorded %v %v, replayed %v %v", i, recordedPots[i].Pot, recordedPots[i].Seats, replayedPots[i].Pot, replayedPots[i].Seats)
+			}
+		}
+	}
+
+	for seatNo, player := range recorded.PlayersInSeats {
+		if seatNo == 0 || player == nil || seatNo >= len(replayed.PlayersInSeats) {
+			continue
+		}
+		if !sameAmount(player.Stack, replayed.PlayersInSeats[seatNo].Stack) {
+			report.addDiff("seat %d stack: recorded %v, replayed %v", seatNo, player.Stack, replayed.PlayersInSeats[seatNo].Stack)
+		}
+	}
+}
+
+func compareReplayResult(recorded *HandResultClient, replayed *HandResultClient, report *ReplayReport) {
+	if recorded.WonAt != replayed.WonAt {
+		report.addDiff("won at: recorded %s, replayed %s", recorded.WonAt, replayed.WonAt)
+	}
+
+	if len(recorded.PotWinners) != len(replayed.PotWinners) {
+		report.addDiff("pot winners: recorded %d pots, replayed %d pots", len(recorded.PotWinners), len(replayed.PotWinners))
+	} else {
+		for i, recordedPot := range recorded.PotWinners {
+			replayedPot := replayed.PotWinners[i]
+			if !sameAmount(recordedPot.Amount, replayedPot.Amount) || !sameSeats(recordedPot.SeatsInPots, replayedPot.SeatsInPots) {
+				report.addDiff("pot %d: recorded %v %v, replayed %v %v", recordedPot.PotNo, recordedPot.Amount, recordedPot.SeatsInPots, replayedPot.Amount, replayedPot.SeatsInPots)
+			}
+			if len(recordedPot.BoardWinners) != len(replayedPot.BoardWinners) {
+				report.addDiff("pot %d: recorded %d boards, replayed %d boards", recordedPot.PotNo, len(recordedPot.BoardWinners), len(replayedPot.BoardWinners))
+				continue
+			}
+			for j, recordedBoard := range recordedPot.BoardWinners {
+				replayedBoard := replayedPot.BoardWinners[j]
+				recordedHi, replayedHi := describeWinners(recordedBoard.HiWinners), describeWinners(replayedBoard.HiWinners)
+				if recordedHi != replayedHi {
+					report.addDiff("pot %d board %d hi winners: recorded %s, replayed %s", recordedPot.PotNo, recordedBoard.BoardNo, recordedHi, replayedHi)
+				}
+				recordedLo, replayedLo := describeWinners(recordedBoard.LowWinners), describeWinners(replayedBoard.LowWinners)
+				if recordedLo != replayedLo {
+					report.addDiff("pot %d board %d low winners: recorded %s, replayed %s", recordedPot.PotNo, recordedBoard.BoardNo, recordedLo, replayedLo)
+				}
+			}
+		}
+	}
+
+	seats := make([]int, 0, len(recorded.PlayerInfo))
+	for seatNo := range recorded.PlayerInfo {
+		seats = append(seats, int(seatNo))
+	}
+	for seatNo := range replayed.PlayerInfo {
+		if _, ok := recorded.PlayerInfo[seatNo]; !ok {
+			seats = append(seats, int(seatNo))
+		}
+	}
+	sort.Ints(seats)
+	for _, seatNo := range seats {
+		recordedInfo, recordedOk := recorded.PlayerInfo[uint32(seatNo)]
+		replayedInfo, replayedOk := replayed.PlayerInfo[uint32(seatNo)]
+		if !recordedOk || !replayedOk {
+			report.addDiff("seat %d: in recorded result %v, in replayed result %v", seatNo, recordedOk, replayedOk)
+			continue
+		}
+		if !sameAmount(recordedInfo.GetBalance().GetBefore(), replayedInfo.GetBalance().GetBefore()) ||
+			!sameAmount(recordedInfo.GetBalance().GetAfter(), replayedInfo.GetBalance().GetAfter()) {
+			report.addDiff("seat %d balance: recorded %v -> %v, replayed %v -> %v", seatNo,
+				recordedInfo.GetBalance().GetBefore(), recordedInfo.GetBalance().GetAfter(),
+				replayedInfo.GetBalance().GetBefore(), replayedInfo.GetBalance().GetAfter())
+		}
+		if !sameAmount(recordedInfo.Received, replayedInfo.Received) {
+			report.addDiff("seat %d received: recorded %v, replayed %v", seatNo, recordedInfo.Received, replayedInfo.Received)
+		}
+		if !sameAmount(recordedInfo.RakePaid, replayedInfo.RakePaid) {
+			report.addDiff("seat %d rake paid: recorded %v, replayed %v", seatNo, recordedInfo.RakePaid, replayedInfo.RakePaid)
+		}
+	}
+}
+
+func nonEmptyPots(pots []*SeatsInPots) []*SeatsInPots {
+	nonEmpty := make([]*SeatsInPots, 0, len(pots))
+	for _, pot := range pots {
+		if pot.Pot != 0 {
+			nonEmpty = append(nonEmpty, pot)
+		}
+	}
+	return nonEmpty
+}
+
+func describePots(pots []*SeatsInPots) string {
+	potStrs := make([]string, 0, len(pots))
+	for _, pot := range pots {
+		potStrs = append(potStrs, fmt.Sprintf("%v %v", pot.Pot, pot.Seats))
+	}
+	return "[" + strings.Join(potStrs, ", ") + "]"
+}
+
+func describeWinners(winners map[uint32]*Winner) string {
+	seats := make([]int, 0, len(winners))
+	for seatNo := range winners {
+		seats = append(seats, int(seatNo))
+	}
+	sort.Ints(seats)
+	winnerStrs := make([]string, 0, len(seats))
+	for _, seatNo := range seats {
+		winnerStrs = append(winnerStrs, fmt.Sprintf("seat %d: %v", seatNo, winners[uint32(seatNo)].Amount))
+	}
+	return "[" + strings.Join(winnerStrs, ", ") + "]"
+}
+
+func sameAmount(a float64, b float64) bool {
+	return math.Abs(a-b) < 0.0001
+}
+
+func sameSeats(a []uint32, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]uint32{}, a...)
+	sortedB := append([]uint32{}, b...)
+	sort.Slice(sortedA, func(i, j int) bool { return sortedA[i] < sortedA[j] })
+	sort.Slice(sortedB, func(i, j int) bool { return sortedB[i] < sortedB[j] })
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameCards(a []uint32, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadReplayCases reads the replay cases from a json file or from all the json
+// files in a directory. A file is either the snapshot list of a hand (the output
+// of the -dump-snapshots command), or an object with the proto json of
+// "handState" and optionally "finalHandState" and "handResult".
+func LoadReplayCases(path string) ([]*ReplayCase, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	cases := make([]*ReplayCase, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c, err := parseReplayCase(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not parse replay case %s", file)
+		}
+		c.Name = filepath.Base(file)
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+func parseReplayCase(data []byte) (*ReplayCase, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		snapshots := make([]*HandStateSnapshot, 0)
+		err := json.Unmarshal(data, &snapshots)
+		if err != nil {
+			return nil, err
+		}
+		return NewReplayCaseFromSnapshots(snapshots)
+	}
+
+	fields := make(map[string]json.RawMessage)
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	unmarshaller := protojson.UnmarshalOptions{DiscardUnknown: true}
+	c := &ReplayCase{}
+	if raw, ok := fields["handState"]; ok {
+		c.HandState = &HandState{}
+		err = unmarshaller.Unmarshal(raw, c.HandState)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid handState")
+		}
+	}
+	if raw, ok := fields["finalHandState"]; ok {
+		c.FinalHandState = &HandState{}
+		err = unmarshaller.Unmarshal(raw, c.FinalHandState)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid finalHandState")
+		}
+	}
+	if raw, ok := fields["handResult"]; ok {
+		c.HandResult = &HandResultServer{}
+		err = unmarshaller.Unmarshal(raw, c.HandResult)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid handResult")
+		}
+	}
+	return c, nil
+}
+
+// NewReplayCaseFromSnapshots uses the first snapshot of the hand as the dealt
+// hand and the last snapshot as the recorded outcome.
+func NewReplayCaseFromSnapshots(snapshots []*HandStateSnapshot) (*ReplayCase, error) {
+	if len(snapshots) < 2 {
+		return nil, fmt.Errorf("Need at least 2 snapshots of the hand to replay. Got %d", len(snapshots))
+	}
+	first := snapshots[0]
+	last := snapshots[len(snapshots)-1]
+	if first.State == nil || last.State == nil {
+		return nil, fmt.Errorf("Snapshots do not include the hand states")
+	}
+	return &ReplayCase{
+		Name:           fmt.Sprintf("hand %d", first.HandNum),
+		HandState:      first.State,
+		FinalHandState: last.State,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplayTestHand(t *testing.T, runItTwice bool) *HandState {
+	newHandInfo := &NewHandInfo{
+		GameType:   GameType_HOLDEM,
+		MaxPlayers: 3,
+		SmallBlind: 1,
+		BigBlind:   2,
+		ButtonPos:  1,
+		ActionTime: 30,
+		PlayersInSeats: []SeatPlayer{
+			{SeatNo: 0},
+			{SeatNo: 1, PlayerID: 101, Name: "a", Stack: 100, Status: PlayerStatus_PLAYING, Inhand: true, RunItTwice: runItTwice},
+			{SeatNo: 2, PlayerID: 102, Name: "b", Stack: 100, Status: PlayerStatus_PLAYING, Inhand: true, RunItTwice: runItTwice},
+			{SeatNo: 3, PlayerID: 103, Name: "c", Stack: 50, Status: PlayerStatus_PLAYING, Inhand: true, RunItTwice: runItTwice},
+		},
+	}
+	testHandSetup := &TestHandSetup{
+		Flop:  []string{"Ac", "Ad", "2c"},
+		Turn:  "Td",
+		River: "4s",
+		PlayerCards: []*GameSetupSeatCards{
+			{Cards: []string{"Kh", "Qd"}},
+			{Cards: []string{"3s", "7s"}},
+			{Cards: []string{"9h", "9c"}},
+		},
+	}
+	h := &HandState{GameId: 1, HandNum: 1}
+	err := h.initialize(nil, newHandInfo, testHandSetup, 1, 0, 0, nil, ChipUnit_DOLLAR, nil, nil)
+	require.NoError(t, err)
+	return h
+}
+
+// recordReplayTestHand plays the hand like the game server does and returns the
+// final hand state and the hand result.
+func recordReplayTestHand(t *testing.T, dealt *HandState, actions []*HandAction) (*HandState, *HandResultClient) {
+	h := proto.Clone(dealt).(*HandState)
+	var result *HandResultClient
+	for _, action := range actions {
+		require.Nil(t, result, "hand ended early")
+		if !h.RunItTwicePrompt {
+			require.Equal(t, h.NextSeatAction.SeatNo, action.SeatNo)
+		}
+		var err error
+		h, _, result, err = replayAction(h, action)
+		require.NoError(t, err)
+	}
+	require.NotNil(t, result)
+	return h, result
+}
+
+func TestReplayHand(t *testing.T) {
+	dealt := newReplayTestHand(t, false)
+	actions := []*HandAction{
+		{SeatNo: 1, Action: ACTION_CALL, Amount: 2},
+		{SeatNo: 2, Action: ACTION_CALL, Amount: 2},
+		{SeatNo: 3, Action: ACTION_CHECK},
+		{SeatNo: 2, Action: ACTION_BET, Amount: 4},
+		{SeatNo: 3, Action: ACTION_ALLIN, Amount: 48},
+		{SeatNo: 1, Action: ACTION_FOLD},
+		{SeatNo: 2, Action: ACTION_CALL, Amount: 48},
+	}
+	final, result := recordReplayTestHand(t, dealt, actions)
+
+	c := &ReplayCase{
+		Name:           "test",
+		HandState:      dealt,
+		FinalHandState: final,
+		HandResult:     &HandResultServer{Result: result},
+	}
+	report, err := ReplayHand(c)
+	require.NoError(t, err)
+	assert.Empty(t, report.Skipped)
+	assert.Empty(t, report.Diffs)
+	assert.Equal(t, 7, report.Actions)
+
+	// Tampered result.
+	tampered := proto.Clone(result).(*HandResultClient)
+	tampered.PlayerInfo[3].Balance.After += 10
+	c.HandResult = &HandResultServer{Result: tampered}
+	report, err = ReplayHand(c)
+	require.NoError(t, err)
+	require.True(t, report.Diverged())
+	assert.Contains(t, report.Diffs[0], "seat 3 balance")
+
+	// Tampered deck.
+	c.HandResult = &HandResultServer{Result: result}
+	c.HandState = proto.Clone(dealt).(*HandState)
+	c.HandState.Deck[0], c.HandState.Deck[len(c.HandState.Deck)-1] = c.HandState.Deck[len(c.HandState.Deck)-1], c.HandState.Deck[0]
+	report, err = ReplayHand(c)
+	require.NoError(t, err)
+	require.True(t, report.Diverged())
+	assert.Contains(t, report.Diffs[0], "deck")
+
+	// Recorded actions from the hand log in the result.
+	c.HandState = dealt
+	c.FinalHandState = nil
+	c.HandResult = &HandResultServer{
+		Result: result,
+		HandLog: &HandLog{
+			PreflopActions: final.PreflopActions,
+			FlopActions:    final.FlopActions,
+			TurnActions:    final.TurnActions,
+			RiverActions:   final.RiverActions,
+		},
+	}
+	report, err = ReplayHand(c)
+	require.NoError(t, err)
+	assert.Empty(t, report.Diffs)
+}
+
+func TestReplayRunItTwice(t *testing.T) {
+	dealt := newReplayTestHand(t, true)
+	actions := []*HandAction{
+		{SeatNo: 1, Action: ACTION_CALL, Amount: 2},
+		{SeatNo: 2, Action: ACTION_CALL, Amount: 2},
+		{SeatNo: 3, Action: ACTION_CHECK},
+		{SeatNo: 2, Action: ACTION_BET, Amount: 4},
+		{SeatNo: 3, Action: ACTION_ALLIN, Amount: 48},
+		{SeatNo: 1, Action: ACTION_FOLD},
+		{SeatNo: 2, Action: ACTION_CALL, Amount: 48},
+		{SeatNo: 2, Action: ACTION_RUN_IT_TWICE_YES},
+		{SeatNo: 3, Action: ACTION_RUN_IT_TWICE_YES},
+	}
+	final, result := recordReplayTestHand(t, dealt, actions)
+	require.True(t, final.RunItTwiceConfirmed)
+
+	c := &ReplayCase{
+		Name:           "test",
+		HandState:      dealt,
+		FinalHandState: final,
+		HandResult:     &HandResultServer{Result: result, RunItTwice: true},
+	}
+	report, err := ReplayHand(c)
+	require.NoError(t, err)
+	assert.Empty(t, report.Skipped)
+	assert.Empty(t, report.Diffs)
+	assert.Equal(t, 9, report.Actions)
+
+	// The second board winners differ from the recorded result.
+	tampered := proto.Clone(result).(*HandResultClient)
+	board2 := tampered.PotWinners[0].BoardWinners[1]
+	board2.HiWinners = map[uint32]*Winner{1: {SeatNo: 1, Amount: 1}}
+	c.HandResult = &HandResultServer{Result: tampered, RunItTwice: true}
+	report, err = ReplayHand(c)
+	require.NoError(t, err)
+	require.True(t, report.Diverged())
+	assert.Contains(t, report.Diffs[0], "board 2 hi winners")
+}
@@ -1,7 +1,6 @@
 package game
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
@@ -9,7 +8,7 @@ import (
 	"voyager.com/server/poker"
 )
 
-func (g *Game) runItTwice(h *HandState, lastPlayerAction *PlayerActRound) bool {
+func (h *HandState) runItTwiceAvailable(lastPlayerAction *PlayerActRound) bool {
 
 	if !(h.CurrentState == HandStatus_PREFLOP ||
 		h.CurrentState == HandStatus_FLOP ||
@@ -53,74 +52,63 @@ func (g *Game) runItTwice(h *HandState, lastPlayerAction *PlayerActRound) bool {
 	return false
 }
 
-func (g *Game) runItTwicePrompt(h *HandState) ([]*HandMessageItem, error) {
+func (h *HandState) runItTwicePrompt() []*HandMessageItem {
 
 	h.RunItTwicePrompt = true
 
-	player1ID := uint64(0)
 	player1Seat := uint32(0)
-	player2ID := uint64(0)
 	player2Seat := uint32(0)
 
 	for seat, playerID := range h.ActiveSeats {
 		if playerID == 0 {
 			continue
 		}
-		if player1ID == 0 {
-			player1ID = playerID
+		if player1Seat == 0 {
 			player1Seat = uint32(seat)
 		} else {
-			player2ID = playerID
 			player2Seat = uint32(seat)
 			break
 		}
 	}
 
-	// +1 second buffer to account for network delay to the client
-	timeoutAt := time.Now().Add(time.Duration(h.ActionTime+1) * time.Second)
-
 	// create run it twice
 	h.RunItTwice = &RunItTwice{
-		Stage:      h.LastState,
-		Seat1:      player1Seat,
-		Seat2:      player2Seat,
-		ExpiryTime: uint64(timeoutAt.Unix()),
+		Stage: h.LastState,
+		Seat1: player1Seat,
+		Seat2: player2Seat,
 	}
 
 	var msgItems []*HandMessageItem
-	// run a timer for the prompt
+	for _, seatNo := range []uint32{player1Seat, player2Seat} {
+		// prompt the player
+		seatAction := &NextSeatAction{
+			AvailableActions:    []ACTION{ACTION_RUN_IT_TWICE_PROMPT},
+			SeatNo:              seatNo,
+			SecondsTillTimesout: uint32(h.RunItTwiceTimeout),
+		}
+		msgItems = append(msgItems, &HandMessageItem{
+			MessageType: HandYourAction,
+			Content:     &HandMessageItem_SeatAction{SeatAction: seatAction},
+		})
+	}
+	return msgItems
+}
+
+// startRunItTwiceTimer sets the expiry time of the run-it-twice prompt and runs a timer for it.
+func (g *Game) startRunItTwiceTimer(h *HandState, msgItems []*HandMessageItem) {
+	// +1 second buffer to account for network delay to the client
+	timeoutAt := time.Now().Add(time.Duration(h.ActionTime+1) * time.Second)
 	timeoutAtUnix := timeoutAt.Unix()
 
-	// prompt player 1
-	seatAction := &NextSeatAction{
-		AvailableActions:    []ACTION{ACTION_RUN_IT_TWICE_PROMPT},
-		SeatNo:              player1Seat,
-		ActionTimesoutAt:    timeoutAtUnix,
-		SecondsTillTimesout: uint32(h.RunItTwiceTimeout),
-	}
-	player1MsgItem := &HandMessageItem{
-		MessageType: HandYourAction,
-		Content:     &HandMessageItem_SeatAction{SeatAction: seatAction},
-	}
-	msgItems = append(msgItems, player1MsgItem)
-
-	// prompt player 2
-	seatAction = &NextSeatAction{
-		AvailableActions:    []ACTION{ACTION_RUN_IT_TWICE_PROMPT},
-		SeatNo:              player2Seat,
-		ActionTimesoutAt:    timeoutAtUnix,
-		SecondsTillTimesout: uint32(h.RunItTwiceTimeout),
-	}
-	player2MsgItem := &HandMessageItem{
-		MessageType: HandYourAction,
-		Content:     &HandMessageItem_SeatAction{SeatAction: seatAction},
+	h.RunItTwice.ExpiryTime = uint64(timeoutAtUnix)
+	for _, msgItem := range msgItems {
+		if msgItem.MessageType == HandYourAction {
+			msgItem.GetSeatAction().ActionTimesoutAt = timeoutAtUnix
+		}
 	}
-	msgItems = append(msgItems, player2MsgItem)
-
-	//h.NextSeatAction.ActionTimesoutAt = timeoutAt.Unix()
-	g.runItTwiceTimer(player1Seat, player1ID, player2Seat, player2ID, timeoutAt)
 
-	return msgItems, nil
+	seat1, seat2 := h.RunItTwice.Seat1, h.RunItTwice.Seat2
+	g.runItTwiceTimer(seat1, h.ActiveSeats[seat1], seat2, h.ActiveSeats[seat2], timeoutAt)
 }
 
 // handle run-it-twice confirmation
@@ -135,7 +123,32 @@ func (g *Game) runItTwiceConfirmation(h *HandState, message *HandMessage) ([]*Ha
 		Uint32(logging.SeatNumKey, message.SeatNo).
 		Str("message", actionMsg.MessageType).
 		Msgf("Run it twice confirmation: %d", actionMsg.GetPlayerActed().Action)
-	action := actionMsg.GetPlayerActed().Action
+
+	runItTwice := h.RunItTwice
+	if runItTwice.Seat1 == message.SeatNo {
+		g.pausePlayTimer(message.SeatNo)
+	}
+	if runItTwice.Seat2 == message.SeatNo {
+		g.pausePlayTimer2(message.SeatNo)
+	}
+
+	// Not broadcasting this player's confirmation msg (or the default timeout msg) back to everyone
+	// because it is causing the board cards to show in the app UI without
+	// waiting for the other player's confirmation.
+	msgItems, err := h.runItTwiceResponse(actionMsg.GetPlayerActed())
+	if err != nil {
+		return nil, err
+	}
+	err = g.processHandMessages(h, msgItems)
+	if err != nil {
+		return nil, err
+	}
+	return msgItems, nil
+}
+
+// runItTwiceResponse records the run-it-twice response of a player. Once both
+// players responded, runs the remaining board once or twice and returns the result.
+func (h *HandState) runItTwiceResponse(action *HandAction) ([]*HandMessageItem, error) {
 	runItTwice := h.RunItTwice
 
 	var log *HandActionLog
@@ -150,151 +163,36 @@ func (g *Game) runItTwiceConfirmation(h *HandState, message *HandMessage) ([]*Ha
 		log = h.RiverActions
 	}
 
-	if runItTwice.Seat1 == message.SeatNo {
+	if runItTwice.Seat1 == action.SeatNo {
 		runItTwice.Seat1Responded = true
-		if action == ACTION_RUN_IT_TWICE_YES {
+		if action.Action == ACTION_RUN_IT_TWICE_YES {
 			runItTwice.Seat1Confirmed = true
 		}
-		log.Actions = append(log.Actions, actionMsg.GetPlayerActed())
-		// we need to acknowledge message
+		log.Actions = append(log.Actions, action)
 	}
 
-	if runItTwice.Seat2 == message.SeatNo {
+	if runItTwice.Seat2 == action.SeatNo {
 		runItTwice.Seat2Responded = true
-		if action == ACTION_RUN_IT_TWICE_YES {
+		if action.Action == ACTION_RUN_IT_TWICE_YES {
 			runItTwice.Seat2Confirmed = true
 		}
-		log.Actions = append(log.Actions, actionMsg.GetPlayerActed())
-
-		// we need to acknowledge message
-	}
-	if runItTwice.Seat1 == message.SeatNo {
-		g.pausePlayTimer(message.SeatNo)
-	}
-	if runItTwice.Seat2 == message.SeatNo {
-		g.pausePlayTimer2(message.SeatNo)
+		log.Actions = append(log.Actions, action)
 	}
+	h.updateTimeoutStats(action.SeatNo, action.TimedOut)
 
 	var allMsgItems []*HandMessageItem
-
-	// Broadcast this player's confirmation msg (or the default timeout msg) back to everyone.
-	// Commenting this out because it is causing the board cards to show in the app UI without
-	// waiting for the other player's confirmation.
-	// allMsgItems = append(allMsgItems, actionMsg)
-
-	msgItems, err := g.handleRunItTwice(h)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, msgItem := range msgItems {
-		allMsgItems = append(allMsgItems, msgItem)
-	}
-
-	return allMsgItems, nil
-}
-
-func (g *Game) handleRunItTwice(h *HandState) ([]*HandMessageItem, error) {
-	runItTwice := h.RunItTwice
-
-	boardCards := make([]uint32, 5)
-	for i, card := range h.BoardCards {
-		boardCards[i] = uint32(card)
-	}
-	fmt.Printf("Board1: %s\n", poker.CardsToString(boardCards))
-
-	var allMsgItems []*HandMessageItem
-
 	if !runItTwice.Seat1Responded || !runItTwice.Seat2Responded {
 		return allMsgItems, nil
 	}
 
 	if runItTwice.Seat1Confirmed && runItTwice.Seat2Confirmed {
 		// run two boards
-		g.logger.Info().
-			Uint32("handNum", h.HandNum).
+		handLogger.Info().
+			Uint64(logging.GameIDKey, h.GetGameId()).
+			Uint32(logging.HandNumKey, h.GetHandNum()).
 			Msgf("Both seats YES. Running two boards")
 		h.RunItTwiceConfirmed = true
-
-		deck := poker.DeckFromBytes(h.Deck)
-		fmt.Printf("Deck: %s\n", poker.CardsToString(deck.GetBytes()))
-
-		otherCards := deck.Draw(int(h.DeckIndex))
-		fmt.Printf("Other Cards: %s\n", poker.CardsToString(otherCards))
-
-		board2 := make([]byte, 0)
-		flop := false
-		turn := false
-		river := false
-
-		// get two boards and and run it twice
-		if h.RunItTwice.Stage == HandStatus_PREFLOP {
-			// all 5 cards
-			flop = true
-			turn = true
-			river = true
-		} else if h.RunItTwice.Stage == HandStatus_FLOP {
-			turn = true
-			river = true
-			// turn card and river card
-			board2 = append(board2, h.BoardCards[:3]...)
-		} else if h.RunItTwice.Stage == HandStatus_TURN {
-			river = true
-			// river card
-			// turn card and river card
-			board2 = append(board2, h.BoardCards[:4]...)
-		}
-
-		if flop {
-			// flop
-			cards := deck.Draw(3)
-			h.DeckIndex += 3
-			for _, card := range cards {
-				board2 = append(board2, card.GetByte())
-			}
-		}
-
-		if turn {
-			// turn
-			if h.BurnCards {
-				deck.Draw(1)
-				h.DeckIndex++
-			}
-			cards := deck.Draw(1)
-			h.DeckIndex++
-			fmt.Printf("Cards: %s\n", poker.CardsToString(cards))
-			for _, card := range cards {
-				board2 = append(board2, card.GetByte())
-			}
-		}
-
-		if river {
-			// river
-			if h.BurnCards {
-				deck.Draw(1)
-				h.DeckIndex++
-			}
-			cards := deck.Draw(1)
-			fmt.Printf("Cards: %s\n", poker.CardsToString(cards))
-			h.DeckIndex++
-			for _, card := range cards {
-				board2 = append(board2, card.GetByte())
-			}
-		}
-		h.NoOfBoards++
-		h.Boards = append(h.Boards, &Board{
-			BoardNo: h.NoOfBoards,
-			Cards:   poker.ByteCardsToUint32Cards(board2),
-		})
-		h.BoardCards_2 = board2
-		fmt.Printf("Board2: %s\n", poker.CardsToString(board2))
-
-		boardCards2 := make([]uint32, 5)
-		for i, card := range h.BoardCards_2 {
-			boardCards2[i] = uint32(card)
-		}
-
-		g.logger.Trace().Msgf("Board1: %s, Board2: %s", poker.CardsToString(boardCards), poker.CardsToString(boardCards2))
+		h.dealRunItTwiceBoard()
 
 		pots := make([]*SeatsInPots, 0)
 		for _, pot := range h.Pots {
@@ -305,8 +203,8 @@ func (g *Game) handleRunItTwice(h *HandState) ([]*HandMessageItem, error) {
 
 		// send the two boards to the app
 		runItTwiceMessage := &RunItTwiceBoards{
-			Board_1:   boardCards,
-			Board_2:   boardCards2,
+			Board_1:   poker.ByteCardsToUint32Cards(h.BoardCards),
+			Board_2:   poker.ByteCardsToUint32Cards(h.BoardCards_2),
 			Stage:     h.RunItTwice.Stage,
 			Seat1:     h.RunItTwice.Seat1,
 			Seat2:     h.RunItTwice.Seat2,
@@ -317,30 +215,97 @@ func (g *Game) handleRunItTwice(h *HandState) ([]*HandMessageItem, error) {
 			Content:     &HandMessageItem_RunItTwice{RunItTwice: runItTwiceMessage},
 		}
 		allMsgItems = append(allMsgItems, msgItem)
-
-		msgItems, err := g.showdown(h)
-		if err != nil {
-			return nil, errors.Wrap(err, "Error from showdown")
-		}
-		for _, m := range msgItems {
-			allMsgItems = append(allMsgItems, m)
-		}
+		allMsgItems = append(allMsgItems, h.showdown()...)
 	} else {
 		// one of the players didn't confirm
-		g.logger.Info().
-			Uint32("handNum", h.HandNum).
+		handLogger.Info().
+			Uint64(logging.GameIDKey, h.GetGameId()).
+			Uint32(logging.HandNumKey, h.GetHandNum()).
 			Msgf("Running one board")
 		h.RunItTwiceConfirmed = false
 
 		// run a single board
-		msgItems, err := g.allPlayersAllIn(h)
+		msgItems, err := h.allPlayersAllIn()
 		if err != nil {
 			return nil, errors.Wrap(err, "Error from allPlayersAllIn")
 		}
-		for _, m := range msgItems {
-			allMsgItems = append(allMsgItems, m)
-		}
+		allMsgItems = append(allMsgItems, msgItems...)
 	}
 
 	return allMsgItems, nil
 }
+
+// dealRunItTwiceBoard draws the second board from the remaining cards in the deck.
+// The second board shares the cards that were already open when the players went all in.
+func (h *HandState) dealRunItTwiceBoard() {
+	deck := poker.DeckFromBytes(h.Deck)
+	deck.Draw(int(h.DeckIndex))
+
+	board2 := make([]byte, 0)
+	flop := false
+	turn := false
+	river := false
+
+	// get two boards and and run it twice
+	if h.RunItTwice.Stage == HandStatus_PREFLOP {
+		// all 5 cards
+		flop = true
+		turn = true
+		river = true
+	} else if h.RunItTwice.Stage == HandStatus_FLOP {
+		turn = true
+		river = true
+		// turn card and river card
+		board2 = append(board2, h.BoardCards[:3]...)
+	} else if h.RunItTwice.Stage == HandStatus_TURN {
+		river = true
+		// river card
+		board2 = append(board2, h.BoardCards[:4]...)
+	}
+
+	if flop {
+		// flop
+		cards := deck.Draw(3)
+		h.DeckIndex += 3
+		for _, card := range cards {
+			board2 = append(board2, card.GetByte())
+		}
+	}
+
+	if turn {
+		// turn
+		if h.BurnCards {
+			deck.Draw(1)
+			h.DeckIndex++
+		}
+		cards := deck.Draw(1)
+		h.DeckIndex++
+		for _, card := range cards {
+			board2 = append(board2, card.GetByte())
+		}
+	}
+
+	if river {
+		// river
+		if h.BurnCards {
+			deck.Draw(1)
+			h.DeckIndex++
+		}
+		cards := deck.Draw(1)
+		h.DeckIndex++
+		for _, card := range cards {
+			board2 = append(board2, card.GetByte())
+		}
+	}
+	h.NoOfBoards++
+	h.Boards = append(h.Boards, &Board{
+		BoardNo: h.NoOfBoards,
+		Cards:   poker.ByteCardsToUint32Cards(board2),
+	})
+	h.BoardCards_2 = board2
+
+	handLogger.Debug().
+		Uint64(logging.GameIDKey, h.GetGameId()).
+		Uint32(logging.HandNumKey, h.GetHandNum()).
+		Msgf("Board1: %s, Board2: %s", poker.CardsToString(h.BoardCards), poker.CardsToString(board2))
+}
@@ -0,0 +1,356 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A sit-and-go is a single table tournament that starts as soon as its seats
+// are taken. The game server runs it without the tournament service: it seats
+// the players in the order they register, moves the button, raises the blinds
+// every HandsPerLevel hands (or by the blind clock when HandsPerLevel is 0)
+// and places the busted players in the standings until one player is left.
+
+// SitAndGoConfig is the structure of a sit-and-go.
+type SitAndGoConfig struct {
+	// Seats is the number of players. The sit-and-go starts when they register.
+	Seats         uint32
+	StartingChips float64
+	GameType      GameType
+	Levels        []BlindLevel
+	// HandsPerLevel raises the blinds by hand count. The durations of the
+	// levels are used when it is 0.
+	HandsPerLevel   uint32
+	ActionTime      uint32
+	ResultPauseTime uint32
+	PrizePool       float64
+	// Payouts is the payout structure (DefaultPayoutStructure if it doesn't
+	// have tiers).
+	Payouts PayoutStructure
+}
+
+// SitAndGoPlayer is a registered player.
+type SitAndGoPlayer struct {
+	PlayerID      uint64  `json:"playerId"`
+	PlayerUUID    string  `json:"playerUuid"`
+	Name          string  `json:"name"`
+	EncryptionKey string  `json:"-"`
+	SeatNo        uint32  `json:"seatNo"`
+	Stack         float64 `json:"stack"`
+}
+
+// SitAndGoStanding is the place and prize of a player.
+type SitAndGoStanding struct {
+	Elimination
+	Name  string  `json:"name"`
+	Prize float64 `json:"prize"`
+}
+
+// SitAndGoState is the state of a sit-and-go when it was read.
+type SitAndGoState struct {
+	TournamentID uint32             `json:"tournamentId"`
+	Seats        uint32             `json:"seats"`
+	Started      bool               `json:"started"`
+	Finished     bool               `json:"finished"`
+	HandNum      uint32             `json:"handNum"`
+	ButtonPos    uint32             `json:"buttonPos"`
+	SmallBlind   float64            `json:"smallBlind"`
+	BigBlind     float64            `json:"bigBlind"`
+	Ante         float64            `json:"ante"`
+	Players      []SitAndGoPlayer   `json:"players"`
+	Payouts      []float64          `json:"payouts"`
+	Standings    []SitAndGoStanding `json:"standings"`
+}
+
+type SitAndGo struct {
+	lock         sync.Mutex
+	tournamentID uint32
+	config       SitAndGoConfig
+	payouts      []float64
+	clock        *BlindClock
+	players      []*SitAndGoPlayer
+	standings    *TournamentStandings
+
+	started  bool
+	finished bool
+	handNum  uint32
+	// positions and seats of the last hand
+	positions ButtonPositions
+	seats     []uint32
+	// players dealt in the last hand and their starting stacks
+	startingStacks []Elimination
+}
+
+// NewSitAndGo returns a sit-and-go open for registration. now returns the
+// current time for the blind clock (time.Now if nil).
+func NewSitAndGo(tournamentID uint32, config SitAndGoConfig, now func() time.Time) (*SitAndGo, error) {
+	if config.Seats < 2 || config.Seats > 9 {
+		return nil, fmt.Errorf("Sit-and-go must have 2 to 9 seats (%d)", config.Seats)
+	}
+	if config.StartingChips <= 0 {
+		return nil, fmt.Errorf("Sit-and-go starting chips must be positive (%v)", config.StartingChips)
+	}
+	levels := config.Levels
+	if config.HandsPerLevel > 0 {
+		// the clock checks the levels, their durations are not used
+		levels = make([]BlindLevel, len(config.Levels))
+		for i, level := range config.Levels {
+			if level.Break {
+				return nil, fmt.Errorf("Level %d is a break. Levels by hand count cannot have breaks", i+1)
+			}
+			levels[i] = level
+			levels[i].Duration = time.Hour
+		}
+	}
+	clock, err := NewBlindClock(levels, now)
+	if err != nil {
+		return nil, err
+	}
+	structure := config.Payouts
+	if len(structure.Tiers) == 0 {
+		structure = DefaultPayoutStructure()
+	}
+	err = structure.Validate()
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := structure.Payouts(int(config.Seats), config.PrizePool)
+	if err != nil {
+		return nil, err
+	}
+	return &SitAndGo{
+		tournamentID: tournamentID,
+		config:       config,
+		payouts:      payouts,
+		clock:        clock,
+		standings:    NewTournamentStandings(int(config.Seats)),
+	}, nil
+}
+
+// TournamentID returns the ID of the sit-and-go.
+func (s *SitAndGo) TournamentID() uint32 {
+	return s.tournamentID
+}
+
+// Config returns the structure of the sit-and-go.
+func (s *SitAndGo) Config() SitAndGoConfig {
+	return s.config
+}
+
+// Register seats the player at the next seat. Returns true when the player
+// takes the last seat and the sit-and-go can start.
+func (s *SitAndGo) Register(player SitAndGoPlayer) (SitAndGoPlayer, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started || len(s.players) == int(s.config.Seats) {
+		return SitAndGoPlayer{}, false, fmt.Errorf("Sit-and-go %d is full", s.tournamentID)
+	}
+	for _, p := range s.players {
+		if p.PlayerID == player.PlayerID {
+			return SitAndGoPlayer{}, false, fmt.Errorf("Player %d is already registered in sit-and-go %d", player.PlayerID, s.tournamentID)
+		}
+	}
+	player.SeatNo = uint32(len(s.players) + 1)
+	player.Stack = s.config.StartingChips
+	s.players = append(s.players, &player)
+	return player, len(s.players) == int(s.config.Seats), nil
+}
+
+// Players returns the registered players in seat order.
+func (s *SitAndGo) Players() []SitAndGoPlayer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	players := make([]SitAndGoPlayer, 0, len(s.players))
+	for _, p := range s.players {
+		players = append(players, *p)
+	}
+	return players
+}
+
+// Start starts the blind clock. The button starts at a random seat given by
+// buttonSeat (1 to Seats).
+func (s *SitAndGo) Start(buttonSeat uint32) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started {
+		return fmt.Errorf("Sit-and-go %d is already started", s.tournamentID)
+	}
+	if len(s.players) < int(s.config.Seats) {
+		return fmt.Errorf("Sit-and-go %d has %d players out of %d", s.tournamentID, len(s.players), s.config.Seats)
+	}
+	s.started = true
+	// the first hand moves the button to buttonSeat
+	s.positions = ButtonPositions{ButtonPos: buttonSeat - 1}
+	s.clock.Start()
+	return nil
+}
+
+// Finished returns true when one player is left.
+func (s *SitAndGo) Finished() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.finished
+}
+
+// level returns the blinds of the next hand and the time left in the break if
+// the blind clock is on a break.
+func (s *SitAndGo) level() (BlindLevel, time.Duration) {
+	if s.config.HandsPerLevel > 0 {
+		index := int(s.handNum / s.config.HandsPerLevel)
+		if index >= len(s.config.Levels) {
+			index = len(s.config.Levels) - 1
+		}
+		return s.config.Levels[index], 0
+	}
+	state := s.clock.State()
+	if state.Level.Break {
+		return state.Blinds, state.Remaining
+	}
+	return state.Blinds, 0
+}
+
+// BreakRemaining returns the time left in the break of the blind clock (0 if
+// it is not on a break).
+func (s *SitAndGo) BreakRemaining() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, remaining := s.level()
+	return remaining
+}
+
+// NextHand moves the button to the next player with chips (see
+// NextButtonPositions) and returns the hand to deal.
+func (s *SitAndGo) NextHand() (*NewHandInfo, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.started {
+		return nil, fmt.Errorf("Sit-and-go %d is not started", s.tournamentID)
+	}
+	if s.finished {
+		return nil, fmt.Errorf("Sit-and-go %d is finished", s.tournamentID)
+	}
+	level, _ := s.level()
+
+	seats := make([]uint32, 0, len(s.players))
+	for _, p := range s.players {
+		if p.Stack > 0 {
+			seats = append(seats, p.SeatNo)
+		}
+	}
+	sort.Slice(seats, func(i, j int) bool { return seats[i] < seats[j] })
+	if s.handNum == 0 {
+		s.seats = seats
+	}
+	s.positions = NextButtonPositions(s.config.Seats, s.positions, s.seats, seats)
+	s.seats = seats
+	s.handNum++
+
+	hand := &NewHandInfo{
+		GameType:        s.config.GameType,
+		MaxPlayers:      s.config.Seats,
+		SmallBlind:      level.SmallBlind,
+		BigBlind:        level.BigBlind,
+		Ante:            level.Ante,
+		ButtonPos:       s.positions.ButtonPos,
+		SbPos:           s.positions.SbPos,
+		BbPos:           s.positions.BbPos,
+		HandNum:         s.handNum,
+		ActionTime:      s.config.ActionTime,
+		ResultPauseTime: s.config.ResultPauseTime,
+		Tournament:      true,
+	}
+	s.startingStacks = s.startingStacks[:0]
+	for _, p := range s.players {
+		if p.Stack <= 0 {
+			continue
+		}
+		hand.PlayersInSeats = append(hand.PlayersInSeats, SeatPlayer{
+			SeatNo:        p.SeatNo,
+			PlayerID:      p.PlayerID,
+			PlayerUUID:    p.PlayerUUID,
+			Name:          p.Name,
+			EncryptionKey: p.EncryptionKey,
+			Stack:         p.Stack,
+			Status:        PlayerStatus_PLAYING,
+			Inhand:        true,
+		})
+		s.startingStacks = append(s.startingStacks, Elimination{
+			PlayerID:      p.PlayerID,
+			TableNo:       1,
+			StartingStack: p.Stack,
+		})
+	}
+	return hand, nil
+}
+
+// HandEnded updates the stacks after the hand and places the players busted in
+// the hand. Returns the eliminations in the order of their places.
+func (s *SitAndGo) HandEnded(stacks []TableStack) []Elimination {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	byPlayer := make(map[uint64]float64)
+	for _, stack := range stacks {
+		byPlayer[stack.PlayerID] = stack.Stack
+	}
+	var busted []Elimination
+	for _, e := range s.startingStacks {
+		stack, ok := byPlayer[e.PlayerID]
+		if !ok {
+			continue
+		}
+		for _, p := range s.players {
+			if p.PlayerID == e.PlayerID {
+				p.Stack = stack
+			}
+		}
+		if stack <= 0 {
+			busted = append(busted, e)
+		}
+	}
+	ranked := s.standings.Eliminate(busted)
+	if s.standings.Remaining() == 1 {
+		for _, p := range s.players {
+			if p.Stack > 0 {
+				s.standings.SetWinner(p.PlayerID, 1)
+				s.finished = true
+				break
+			}
+		}
+	}
+	return ranked
+}
+
+// State returns the state of the sit-and-go.
+func (s *SitAndGo) State() SitAndGoState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	level, _ := s.level()
+	state := SitAndGoState{
+		TournamentID: s.tournamentID,
+		Seats:        s.config.Seats,
+		Started:      s.started,
+		Finished:     s.finished,
+		HandNum:      s.handNum,
+		ButtonPos:    s.positions.ButtonPos,
+		SmallBlind:   level.SmallBlind,
+		BigBlind:     level.BigBlind,
+		Ante:         level.Ante,
+		Payouts:      append([]float64{}, s.payouts...),
+	}
+	names := make(map[uint64]string)
+	for _, p := range s.players {
+		state.Players = append(state.Players, *p)
+		names[p.PlayerID] = p.Name
+	}
+	prizes := s.standings.Prizes(s.payouts)
+	for _, e := range s.standings.Results() {
+		state.Standings = append(state.Standings, SitAndGoStanding{
+			Elimination: e,
+			Name:        names[e.PlayerID],
+			Prize:       prizes[e.PlayerID],
+		})
+	}
+	return state
+}
@@ -0,0 +1,117 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSitAndGo(t *testing.T) *SitAndGo {
+	s, err := NewSitAndGo(1, SitAndGoConfig{
+		Seats:         3,
+		StartingChips: 1000,
+		Levels: []BlindLevel{
+			{Level: 1, SmallBlind: 10, BigBlind: 20},
+			{Level: 2, SmallBlind: 20, BigBlind: 40, Ante: 5},
+		},
+		HandsPerLevel: 2,
+		PrizePool:     300,
+	}, nil)
+	require.NoError(t, err)
+	for i := uint64(1); i <= 3; i++ {
+		player, full, err := s.Register(SitAndGoPlayer{PlayerID: i * 100, Name: "player"})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(i), player.SeatNo)
+		assert.Equal(t, i == 3, full)
+	}
+	return s
+}
+
+func TestSitAndGoConfig(t *testing.T) {
+	_, err := NewSitAndGo(1, SitAndGoConfig{Seats: 1, StartingChips: 1000}, nil)
+	assert.Error(t, err)
+	_, err = NewSitAndGo(1, SitAndGoConfig{
+		Seats:         2,
+		StartingChips: 1000,
+		Levels:        []BlindLevel{{SmallBlind: 10, BigBlind: 20}, {Break: true}},
+		HandsPerLevel: 5,
+	}, nil)
+	assert.Error(t, err, "breaks with levels by hand count")
+}
+
+func TestSitAndGoRegister(t *testing.T) {
+	s := newTestSitAndGo(t)
+	_, _, err := s.Register(SitAndGoPlayer{PlayerID: 400})
+	assert.Error(t, err, "full")
+
+	s, err = NewSitAndGo(2, SitAndGoConfig{
+		Seats:         2,
+		StartingChips: 1000,
+		Levels:        []BlindLevel{{SmallBlind: 10, BigBlind: 20}},
+		HandsPerLevel: 5,
+	}, nil)
+	require.NoError(t, err)
+	_, _, err = s.Register(SitAndGoPlayer{PlayerID: 100})
+	require.NoError(t, err)
+	_, _, err = s.Register(SitAndGoPlayer{PlayerID: 100})
+	assert.Error(t, err, "registered twice")
+	assert.Error(t, s.Start(1), "not full")
+}
+
+func TestSitAndGoPlay(t *testing.T) {
+	s := newTestSitAndGo(t)
+	require.NoError(t, s.Start(2))
+
+	hand, err := s.NextHand()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), hand.HandNum)
+	assert.Equal(t, uint32(2), hand.ButtonPos)
+	assert.Equal(t, 20.0, hand.BigBlind)
+	assert.Len(t, hand.PlayersInSeats, 3)
+
+	// player 300 busts
+	assert.Empty(t, s.HandEnded([]TableStack{
+		{SeatNo: 1, PlayerID: 100, Stack: 1300},
+		{SeatNo: 2, PlayerID: 200, Stack: 700},
+		{SeatNo: 3, PlayerID: 300, Stack: 1000},
+	}))
+	_, err = s.NextHand()
+	require.NoError(t, err)
+	busted := s.HandEnded([]TableStack{
+		{SeatNo: 1, PlayerID: 100, Stack: 2300},
+		{SeatNo: 2, PlayerID: 200, Stack: 700},
+		{SeatNo: 3, PlayerID: 300, Stack: 0},
+	})
+	require.Len(t, busted, 1)
+	assert.Equal(t, 3, busted[0].Place)
+	assert.False(t, s.Finished())
+
+	// the blinds go up after 2 hands. Heads-up the button stays at seat 2 so
+	// that seat 2 does not post the big blind twice in a row
+	hand, err = s.NextHand()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), hand.ButtonPos)
+	assert.Equal(t, uint32(2), hand.SbPos)
+	assert.Equal(t, uint32(1), hand.BbPos)
+	assert.Equal(t, 40.0, hand.BigBlind)
+	assert.Equal(t, 5.0, hand.Ante)
+	assert.Len(t, hand.PlayersInSeats, 2)
+
+	s.HandEnded([]TableStack{
+		{SeatNo: 1, PlayerID: 100, Stack: 3000},
+		{SeatNo: 2, PlayerID: 200, Stack: 0},
+	})
+	assert.True(t, s.Finished())
+	_, err = s.NextHand()
+	assert.Error(t, err)
+
+	state := s.State()
+	assert.Equal(t, []float64{300}, state.Payouts)
+	require.Len(t, state.Standings, 3)
+	assert.Equal(t, uint64(100), state.Standings[0].PlayerID)
+	assert.Equal(t, 300.0, state.Standings[0].Prize)
+	assert.Equal(t, uint64(200), state.Standings[1].PlayerID)
+	assert.Equal(t, 2, state.Standings[1].Place)
+	assert.Equal(t, uint64(300), state.Standings[2].PlayerID)
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"voyager.com/logging"
+)
+
+// HandStateSnapshots keeps a copy of the hand state on every save so that we can
+// see how a hand evolved after the fact. Snapshots are keyed by game code, hand
+// number and hand state version (which increases with every save). Only the
+// snapshots of the latest hands of a game are kept (see GetHandStateSnapshotHands).
+type HandStateSnapshots interface {
+	Add(gameCode string, state *HandState) error
+	// Hands returns the hand numbers that have snapshots in ascending order.
+	Hands(gameCode string) ([]uint32, error)
+	// Snapshots returns the snapshots of the hand in the order they were saved.
+	Snapshots(gameCode string, handNum uint32) ([]*HandStateSnapshot, error)
+}
+
+type HandStateSnapshot struct {
+	HandNum    uint32     `json:"handNum"`
+	ActionNum  uint32     `json:"actionNum"`
+	Version    uint64     `json:"version"`
+	HandStatus string     `json:"handStatus"`
+	FlowState  string     `json:"flowState"`
+	State      *HandState `json:"-"`
+}
+
+func newHandStateSnapshot(state *HandState) *HandStateSnapshot {
+	return &HandStateSnapshot{
+		HandNum:    state.HandNum,
+		ActionNum:  state.CurrentActionNum,
+		Version:    state.Version,
+		HandStatus: state.CurrentState.String(),
+		FlowState:  state.FlowState.String(),
+		State:      state,
+	}
+}
+
+// MarshalJSON includes the hand state in the proto json format when it is set.
+func (s *HandStateSnapshot) MarshalJSON() ([]byte, error) {
+	type snapshot HandStateSnapshot
+	out := struct {
+		*snapshot
+		State json.RawMessage `json:"state,omitempty"`
+	}{snapshot: (*snapshot)(s)}
+	if s.State != nil {
+		stateJSON, err := protojson.Marshal(s.State)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not convert hand state to json")
+		}
+		out.State = stateJSON
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads the snapshots dumped by MarshalJSON.
+func (s *HandStateSnapshot) UnmarshalJSON(data []byte) error {
+	type snapshot HandStateSnapshot
+	in := struct {
+		*snapshot
+		State json.RawMessage `json:"state,omitempty"`
+	}{snapshot: (*snapshot)(s)}
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return err
+	}
+	if len(in.State) == 0 {
+		return nil
+	}
+	s.State = &HandState{}
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(in.State, s.State)
+	if err != nil {
+		return errors.Wrap(err, "Could not convert json to hand state")
+	}
+	return nil
+}
+
+// handsToPrune returns the oldest hands beyond the retention limit.
+func handsToPrune(hands []uint32, keepHands int) []uint32 {
+	if len(hands) <= keepHands {
+		return nil
+	}
+	sort.Slice(hands, func(i, j int) bool { return hands[i] < hands[j] })
+	return hands[:len(hands)-keepHands]
+}
+
+func (g *Game) saveHandStateSnapshot(handState *HandState) {
+	if g.manager.handStateSnapshots == nil {
+		return
+	}
+	err := g.manager.handStateSnapshots.Add(g.gameCode, handState)
+	if err != nil {
+		// Snapshots are for debugging. Don't hold up the game.
+		g.logger.Error().
+			Err(err).
+			Uint32(logging.HandNumKey, handState.HandNum).
+			Msgf("Could not save hand state snapshot (version %d)", handState.Version)
+	}
+}
+
+// HandStateSnapshotHands returns the hand numbers of the game that have snapshots.
+func (gm *Manager) HandStateSnapshotHands(gameCode string) ([]uint32, error) {
+	if gm.handStateSnapshots == nil {
+		return []uint32{}, nil
+	}
+	return gm.handStateSnapshots.Hands(gameCode)
+}
+
+// HandStateSnapshots returns the snapshots of a hand in the order they were saved.
+func (gm *Manager) HandStateSnapshots(gameCode string, handNum uint32) ([]*HandStateSnapshot, error) {
+	if gm.handStateSnapshots == nil {
+		return []*HandStateSnapshot{}, nil
+	}
+	return gm.handStateSnapshots.Snapshots(gameCode, handNum)
+}
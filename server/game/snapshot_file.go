@@ -0,0 +1,139 @@
+package game
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// FileHandStateSnapshots stores the snapshots as files under
+// <dir>/<gameCode>/<handNum>/<version>.pb. Used when redis is not available.
+type FileHandStateSnapshots struct {
+	dir       string
+	keepHands int
+	lock      sync.Mutex
+}
+
+func NewFileHandStateSnapshots(dir string, keepHands int) (*FileHandStateSnapshots, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not create hand state snapshot directory %s", dir)
+	}
+	return &FileHandStateSnapshots{dir: dir, keepHands: keepHands}, nil
+}
+
+func (f *FileHandStateSnapshots) Add(gameCode string, state *HandState) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	handDir := filepath.Join(f.dir, gameCode, fmt.Sprintf("%d", state.HandNum))
+	err := os.MkdirAll(handDir, 0755)
+	if err != nil {
+		return err
+	}
+	stateBytes, err := proto.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand state")
+	}
+	path := filepath.Join(handDir, fmt.Sprintf("%d.pb", state.Version))
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, stateBytes, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return err
+	}
+
+	hands, err := f.hands(gameCode)
+	if err != nil {
+		return err
+	}
+	for _, handNum := range handsToPrune(hands, f.keepHands) {
+		err = os.RemoveAll(filepath.Join(f.dir, gameCode, fmt.Sprintf("%d", handNum)))
+		if err != nil {
+			return errors.Wrapf(err, "Could not remove snapshots of hand %d", handNum)
+		}
+	}
+	return nil
+}
+
+func (f *FileHandStateSnapshots) Hands(gameCode string) ([]uint32, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.hands(gameCode)
+}
+
+func (f *FileHandStateSnapshots) hands(gameCode string) ([]uint32, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(f.dir, gameCode))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []uint32{}, nil
+		}
+		return nil, err
+	}
+	hands := make([]uint32, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		handNum, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		hands = append(hands, uint32(handNum))
+	}
+	sort.Slice(hands, func(i, j int) bool { return hands[i] < hands[j] })
+	return hands, nil
+}
+
+func (f *FileHandStateSnapshots) Snapshots(gameCode string, handNum uint32) ([]*HandStateSnapshot, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	handDir := filepath.Join(f.dir, gameCode, fmt.Sprintf("%d", handNum))
+	files, err := ioutil.ReadDir(handDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*HandStateSnapshot{}, nil
+		}
+		return nil, err
+	}
+	versions := make([]uint64, 0, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, ".pb") {
+			continue
+		}
+		version, err := strconv.ParseUint(strings.TrimSuffix(name, ".pb"), 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	snapshots := make([]*HandStateSnapshot, 0, len(versions))
+	for _, version := range versions {
+		stateBytes, err := ioutil.ReadFile(filepath.Join(handDir, fmt.Sprintf("%d.pb", version)))
+		if err != nil {
+			return nil, err
+		}
+		state := &HandState{}
+		err = proto.Unmarshal(stateBytes, state)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not proto-unmarshal hand state snapshot %d", version)
+		}
+		snapshots = append(snapshots, newHandStateSnapshot(state))
+	}
+	return snapshots, nil
+}
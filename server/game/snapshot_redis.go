@@ -0,0 +1,141 @@
+package game
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// Snapshots of an inactive game are dropped after a day.
+const handStateSnapshotExpiration = 24 * time.Hour
+
+// RedisHandStateSnapshots stores the snapshots of a hand in a redis hash
+// (<gameCode>:SNAPSHOTS:<handNum>, field = version) and the hands of a game in
+// a sorted set (<gameCode>:SNAPSHOT_HANDS) used for listing and retention.
+type RedisHandStateSnapshots struct {
+	rdclient      *redis.Client
+	accessTimeout time.Duration
+	keepHands     int
+}
+
+func NewRedisHandStateSnapshots(redisURL string, redisUser string, redisPW string, redisDB int, useSSL bool, keepHands int) *RedisHandStateSnapshots {
+	var tlsConfig *tls.Config
+	if useSSL {
+		tlsConfig = &tls.Config{}
+	}
+	rdclient := redis.NewClient(&redis.Options{
+		Addr:      redisURL,
+		Username:  redisUser,
+		Password:  redisPW,
+		DB:        redisDB,
+		TLSConfig: tlsConfig,
+	})
+	return &RedisHandStateSnapshots{
+		rdclient:      rdclient,
+		accessTimeout: 5 * time.Second,
+		keepHands:     keepHands,
+	}
+}
+
+func (r *RedisHandStateSnapshots) Add(gameCode string, state *HandState) error {
+	stateBytes, err := proto.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "Could not proto-marshal hand state")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+
+	handKey := r.getHandKey(gameCode, state.HandNum)
+	handsKey := r.getHandsKey(gameCode)
+	_, err = r.rdclient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, handKey, fmt.Sprintf("%d", state.Version), stateBytes)
+		pipe.Expire(ctx, handKey, handStateSnapshotExpiration)
+		pipe.ZAdd(ctx, handsKey, &redis.Z{Score: float64(state.HandNum), Member: state.HandNum})
+		pipe.Expire(ctx, handsKey, handStateSnapshotExpiration)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	count, err := r.rdclient.ZCard(ctx, handsKey).Result()
+	if err != nil {
+		return err
+	}
+	if int(count) <= r.keepHands {
+		return nil
+	}
+	oldHands, err := r.rdclient.ZRange(ctx, handsKey, 0, count-int64(r.keepHands)-1).Result()
+	if err != nil {
+		return err
+	}
+	for _, handNumStr := range oldHands {
+		handNum, err := strconv.ParseUint(handNumStr, 10, 32)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid hand number [%s] in snapshot hands", handNumStr)
+		}
+		err = r.rdclient.Del(ctx, r.getHandKey(gameCode, uint32(handNum))).Err()
+		if err != nil {
+			return err
+		}
+		err = r.rdclient.ZRem(ctx, handsKey, handNumStr).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RedisHandStateSnapshots) Hands(gameCode string) ([]uint32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	members, err := r.rdclient.ZRange(ctx, r.getHandsKey(gameCode), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	hands := make([]uint32, 0, len(members))
+	for _, handNumStr := range members {
+		handNum, err := strconv.ParseUint(handNumStr, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid hand number [%s] in snapshot hands", handNumStr)
+		}
+		hands = append(hands, uint32(handNum))
+	}
+	return hands, nil
+}
+
+func (r *RedisHandStateSnapshots) Snapshots(gameCode string, handNum uint32) ([]*HandStateSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.accessTimeout)
+	defer cancel()
+	entries, err := r.rdclient.HGetAll(ctx, r.getHandKey(gameCode, handNum)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*HandStateSnapshot, 0, len(entries))
+	for versionStr, stateStr := range entries {
+		state := &HandState{}
+		err = proto.Unmarshal([]byte(stateStr), state)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not proto-unmarshal hand state snapshot %s from redis", versionStr)
+		}
+		snapshots = append(snapshots, newHandStateSnapshot(state))
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Version < snapshots[j].Version })
+	return snapshots, nil
+}
+
+func (r *RedisHandStateSnapshots) getHandKey(gameCode string, handNum uint32) string {
+	return fmt.Sprintf("%s:SNAPSHOTS:%d", gameCode, handNum)
+}
+
+func (r *RedisHandStateSnapshots) getHandsKey(gameCode string) string {
+	return fmt.Sprintf("%s:SNAPSHOT_HANDS", gameCode)
+}
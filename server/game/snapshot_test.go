@@ -0,0 +1,58 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileHandStateSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	snapshots, err := NewFileHandStateSnapshots(dir, 2)
+	require.NoError(t, err)
+
+	version := uint64(0)
+	for handNum := uint32(1); handNum <= 3; handNum++ {
+		for actionNum := uint32(1); actionNum <= 3; actionNum++ {
+			version++
+			state := &HandState{HandNum: handNum, CurrentActionNum: actionNum, Version: version, FlowState: FlowState_PREPARE_NEXT_ACTION}
+			require.NoError(t, snapshots.Add("ABC", state))
+		}
+	}
+
+	// Only the latest 2 hands are kept.
+	hands, err := snapshots.Hands("ABC")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{2, 3}, hands)
+
+	handSnapshots, err := snapshots.Snapshots("ABC", 3)
+	require.NoError(t, err)
+	require.Len(t, handSnapshots, 3)
+	for i, snapshot := range handSnapshots {
+		assert.Equal(t, uint32(i+1), snapshot.ActionNum)
+		assert.Equal(t, uint64(7+i), snapshot.Version)
+	}
+
+	handSnapshots, err = snapshots.Snapshots("ABC", 1)
+	require.NoError(t, err)
+	assert.Len(t, handSnapshots, 0)
+
+	snapshotJSON, err := json.Marshal(handSnapshots)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(snapshotJSON))
+
+	snapshot := newHandStateSnapshot(&HandState{HandNum: 5, Version: 9})
+	snapshotJSON, err = json.Marshal(snapshot)
+	require.NoError(t, err)
+	out := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(snapshotJSON, &out))
+	assert.Equal(t, float64(9), out["version"])
+	assert.NotNil(t, out["state"])
+}
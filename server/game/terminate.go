@@ -0,0 +1,121 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"voyager.com/logging"
+	"voyager.com/server/util"
+)
+
+// A tournament closes a table when it breaks the table or when the tournament
+// ends. The table stops dealing new hands, the hand in progress is played to
+// the end and the stacks after the last hand are returned to the tournament,
+// which seats the players at their new tables.
+
+const handEndCheckInterval = 100 * time.Millisecond
+
+// TableStack is the stack of a player at the table in chips.
+type TableStack struct {
+	SeatNo   uint32
+	PlayerID uint64
+	Stack    float64
+}
+
+// recordTableStacks keeps the stacks of the players after the hand.
+func (g *Game) recordTableStacks(hs *HandState, result *HandResultClient) {
+	stacks := make([]TableStack, 0, len(hs.PlayersInSeats))
+	for _, player := range hs.PlayersInSeats {
+		if player.PlayerId == 0 || player.OpenSeat {
+			continue
+		}
+		stack := player.Stack
+		if info, ok := result.GetPlayerInfo()[player.SeatNo]; ok && info.Balance != nil {
+			stack = info.Balance.After
+		}
+		stacks = append(stacks, TableStack{
+			SeatNo:   player.SeatNo,
+			PlayerID: player.PlayerId,
+			Stack:    util.CentsToChips(stack),
+		})
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].SeatNo < stacks[j].SeatNo })
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.tableStacks = stacks
+}
+
+// TableStacks returns the stacks of the players after the last hand.
+func (g *Game) TableStacks() []TableStack {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return append([]TableStack{}, g.tableStacks...)
+}
+
+// handInProgress returns whether a hand is dealt and its result is not saved yet.
+func (g *Game) handInProgress() bool {
+	handState, err := g.peekHandState()
+	if err != nil || handState == nil || handState.HandNum == 0 {
+		// no hand was dealt at this table
+		return false
+	}
+	switch handState.FlowState {
+	case FlowState_MOVE_TO_NEXT_HAND, FlowState_WAIT_FOR_PENDING_UPDATE:
+		return false
+	}
+	return true
+}
+
+// CloseTable stops dealing hands at the tournament table and waits up to
+// timeout for the hand in progress to end. Returns the stacks of the players
+// after the last hand. The table deals again if the hand doesn't end in time.
+func (g *Game) CloseTable(timeout time.Duration) ([]TableStack, error) {
+	g.lock.Lock()
+	g.closing = true
+	g.lock.Unlock()
+
+	// wait for the hands that are being dealt to be saved
+	g.tournamentHands.Wait()
+
+	deadline := time.Now().Add(timeout)
+	for g.handInProgress() {
+		if time.Now().After(deadline) {
+			g.reopenTable()
+			return nil, fmt.Errorf("Hand in progress at table %d did not end in %s", g.tableNo, timeout)
+		}
+		time.Sleep(handEndCheckInterval)
+	}
+
+	if g.handSetupPersist != nil {
+		err := g.handSetupPersist.Remove(g.gameCode)
+		if err != nil {
+			g.logger.Warn().Err(err).Msg("Could not remove the hand setup of the table")
+		}
+	}
+
+	// give the api server the pending results before the table goes away
+	err := g.flushHandResultOutbox()
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Could not flush hand result outbox before closing the table")
+	}
+	g.logger.Info().
+		Uint32(logging.HandNumKey, g.lastHandNum()).
+		Msgf("Tournament table %d is closed", g.tableNo)
+	return g.TableStacks(), nil
+}
+
+func (g *Game) reopenTable() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.closing = false
+}
+
+func (g *Game) lastHandNum() uint32 {
+	handState, err := g.peekHandState()
+	if err != nil || handState == nil {
+		return 0
+	}
+	return handState.HandNum
+}
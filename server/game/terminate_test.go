@@ -0,0 +1,93 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/logging"
+)
+
+func newTerminateTestGame(t *testing.T) *Game {
+	persist, err := NewMemoryHandStateTracker()
+	require.NoError(t, err)
+	outbox, err := NewFileHandResultOutbox(t.TempDir())
+	require.NoError(t, err)
+	return &Game{
+		gameCode: "tourney-1-3",
+		tableNo:  3,
+		logger:   logging.GetZeroLogger("game::Game", nil),
+		manager: &Manager{
+			handStatePersist: persist,
+			handResultOutbox: outbox,
+		},
+	}
+}
+
+func TestCloseTable(t *testing.T) {
+	g := newTerminateTestGame(t)
+	handState := &HandState{
+		HandNum:   4,
+		FlowState: FlowState_WAIT_FOR_NEXT_ACTION,
+		PlayersInSeats: []*PlayerInSeatState{
+			{OpenSeat: true},
+			{SeatNo: 1, PlayerId: 100, Stack: 500000},
+			{SeatNo: 2, OpenSeat: true},
+			{SeatNo: 3, PlayerId: 300, Stack: 250000},
+			{SeatNo: 4, PlayerId: 400, Stack: 120000},
+		},
+	}
+	require.NoError(t, g.manager.handStatePersist.Save(g.gameCode, handState))
+
+	closed := make(chan []TableStack, 1)
+	go func() {
+		stacks, err := g.CloseTable(5 * time.Second)
+		assert.NoError(t, err)
+		closed <- stacks
+	}()
+
+	// the table doesn't deal while it is closing
+	time.Sleep(2 * handEndCheckInterval)
+	assert.Error(t, g.DealTournamentHand(&NewHandInfo{HandNum: 5}))
+	select {
+	case <-closed:
+		t.Fatal("Table closed before the hand ended")
+	default:
+	}
+
+	// seat 4 sat out, seats 1 and 3 played the hand
+	g.recordTableStacks(handState, &HandResultClient{
+		PlayerInfo: map[uint32]*PlayerHandInfo{
+			1: {Id: 100, Balance: &HandPlayerBalance{Before: 500000, After: 650000}},
+			3: {Id: 300, Balance: &HandPlayerBalance{Before: 250000, After: 100000}},
+		},
+	})
+	handState.FlowState = FlowState_MOVE_TO_NEXT_HAND
+	require.NoError(t, g.manager.handStatePersist.Save(g.gameCode, handState))
+
+	select {
+	case stacks := <-closed:
+		assert.Equal(t, []TableStack{
+			{SeatNo: 1, PlayerID: 100, Stack: 6500},
+			{SeatNo: 3, PlayerID: 300, Stack: 1000},
+			{SeatNo: 4, PlayerID: 400, Stack: 1200},
+		}, stacks)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Table did not close after the hand ended")
+	}
+}
+
+func TestCloseTableTimeout(t *testing.T) {
+	g := newTerminateTestGame(t)
+	handState := &HandState{
+		HandNum:   1,
+		FlowState: FlowState_WAIT_FOR_NEXT_ACTION,
+	}
+	require.NoError(t, g.manager.handStatePersist.Save(g.gameCode, handState))
+
+	_, err := g.CloseTable(2 * handEndCheckInterval)
+	assert.Error(t, err)
+	// the table deals again after the failed close
+	assert.False(t, g.closing)
+}
@@ -87,7 +87,12 @@ func (g *Game) queueActionTimeoutMsg(msg timer.TimerMsg) {
 }
 
 func (g *Game) handlePlayTimeout(timeoutMsg timer.TimerMsg) error {
-	handState, err := g.loadHandState()
+	if timeoutMsg.TournamentBuyIn {
+		g.onTournamentBuyInTimeout(timeoutMsg)
+		return nil
+	}
+
+	handState, err := g.peekHandState()
 	if err != nil {
 		return err
 	}
@@ -58,6 +58,11 @@ type TestGameConfig struct {
 	AutoApprove        bool        `yaml:"auto-approve"`
 	RewardTrackingIds  []uint32    `json:"rewardTrackingIds"`
 	BringIn            float64     `json:"bringIn" yaml:"bring-in"`
+	// MoveButton moves the button after each hand like a table does (see
+	// NextButtonPositions) instead of giving it to the first player.
+	MoveButton bool `yaml:"move-button"`
+	// Tournament deals the hands as tournament hands.
+	Tournament bool `yaml:"tournament"`
 }
 
 type GamePlayer struct {
@@ -154,6 +159,7 @@ type HandSetup struct {
 	Board2      []string             `yaml:"board2"`
 	SeatCards   []TestSeatCards      `yaml:"seat-cards"`
 	NewPlayers  []PlayerSeat         `yaml:"new-players"`
+	LeftSeats   []uint32             `yaml:"left-seats"`
 	Verify      HandSetupVerfication `yaml:"verify"`
 	BombPot     bool                 `yaml:"bomb-pot"`
 	BombPotBet  uint32               `yaml:"bomb-pot-bet"`
@@ -327,6 +333,7 @@ type SeatPlayer struct {
 	AutoStraddle       bool
 	ButtonStraddle     bool
 	ButtontStraddleBet uint32 `json:"buttonStraddleBet"` // multiples of big blind
+	ClientSeed         string `json:"clientSeed"`        // player's seed for the provably fair shuffle
 }
 
 /*
@@ -375,6 +382,9 @@ type NewHandInfo struct {
 	FourKindCount        int
 	StraightFlushAllowed bool
 	FourKindAllowed      bool
+	DealingPolicy        DealingPolicy
+	DeckType             DeckType
+	NumDecks             uint32
 	Tournament           bool
 	TournamentURL        string
 }
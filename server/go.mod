@@ -24,6 +24,7 @@ require (
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.7.0
 	github.com/ugorji/go v1.2.0 // indirect
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e // indirect
 	google.golang.org/grpc v1.46.2
 	google.golang.org/protobuf v1.28.0
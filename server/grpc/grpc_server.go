@@ -10,6 +10,7 @@ import (
 	"voyager.com/logging"
 	"voyager.com/server/nats"
 	"voyager.com/server/rpc"
+	"voyager.com/server/tournamentrpc"
 )
 
 // var natsGameManager *nats.GameManager
@@ -20,6 +21,11 @@ type TableServer struct {
 	rpc.UnimplementedTableServiceServer
 }
 
+// TournamentServer serves the TournamentService (proto/tournament.proto).
+type TournamentServer struct {
+	tournamentrpc.UnimplementedTournamentServiceServer
+}
+
 var tableServer *grpc.Server
 var natsGameManager *nats.GameManager
 
@@ -31,6 +37,7 @@ func Start(gameManager *nats.GameManager, port int) {
 	}
 	tableServer = grpc.NewServer()
 	rpc.RegisterTableServiceServer(tableServer, &TableServer{})
+	tournamentrpc.RegisterTournamentServiceServer(tableServer, &TournamentServer{})
 	grpcLogger.Info().Msgf("starting grpc server on port %d", port)
 	if err := tableServer.Serve(lis); err != nil {
 		grpcLogger.Error().Msgf("failed to serve: %v", err)
@@ -77,12 +84,34 @@ func (s *TableServer) PlayerMovedTable(ctx context.Context, in *rpc.PlayerMovedT
 	}, nil
 }
 
+// TerimateTable is the misspelled TerminateTable of the TableService. It
+// terminates the table like TournamentService.TerminateTable without
+// returning the stacks.
 func (s *TableServer) TerimateTable(ctx context.Context, in *rpc.TerminateTableInfo) (*rpc.Result, error) {
-	return nil, nil
+	moves := make([]nats.PlayerMove, 0, len(in.Moves))
+	for _, move := range in.Moves {
+		moves = append(moves, nats.PlayerMove{
+			PlayerID:   move.PlayerId,
+			NewTableNo: move.NewTableNo,
+			NewSeatNo:  move.NewTableSeatNo,
+			GameInfo:   move.GameInfo,
+		})
+	}
+	_, err := terminateTable(in.GameCode, in.TournamentId, in.TableNo, moves)
+	if err != nil {
+		return &rpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &rpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
 }
 
 func (s *TableServer) RunHand(ctx context.Context, in *rpc.HandInfo) (*rpc.RunHandResult, error) {
-	err := natsGameManager.DealTournamentHand(in.GameCode, in)
+	_, err := natsGameManager.DealTournamentHand(in.GameCode, in)
 	if err != nil {
 		grpcLogger.Error().Msgf("Could not host table for tournament: %d, table: %d Error: %v", in.TournamentId, in.TableNo, err)
 		return &rpc.RunHandResult{
@@ -91,5 +120,9 @@ func (s *TableServer) RunHand(ctx context.Context, in *rpc.HandInfo) (*rpc.RunHa
 		}, err
 	}
 
-	return nil, nil
+	// the balancing moves are returned by TournamentService.GetTableMoves
+	return &rpc.RunHandResult{
+		Success: true,
+		Error:   "",
+	}, nil
 }
@@ -0,0 +1,335 @@
+package grpc
+
+import (
+	context "context"
+	"time"
+
+	"voyager.com/logging"
+	"voyager.com/server/game"
+	"voyager.com/server/nats"
+	"voyager.com/server/tournamentrpc"
+)
+
+func (s *TournamentServer) TerminateTable(ctx context.Context, in *tournamentrpc.TerminateTableInput) (*tournamentrpc.TerminateTableResult, error) {
+	moves := make([]nats.PlayerMove, 0, len(in.Moves))
+	for _, move := range in.Moves {
+		moves = append(moves, nats.PlayerMove{
+			PlayerID:   move.PlayerId,
+			NewTableNo: move.NewTableNo,
+			NewSeatNo:  move.NewTableSeatNo,
+			GameInfo:   move.GameInfo,
+		})
+	}
+	stacks, err := terminateTable(in.GameCode, in.TournamentId, in.TableNo, moves)
+	if err != nil {
+		return &tournamentrpc.TerminateTableResult{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	result := &tournamentrpc.TerminateTableResult{
+		Success: true,
+		Error:   "",
+		Stacks:  make([]*tournamentrpc.TableStack, 0, len(stacks)),
+	}
+	for _, stack := range stacks {
+		result.Stacks = append(result.Stacks, &tournamentrpc.TableStack{
+			SeatNo:   stack.SeatNo,
+			PlayerId: stack.PlayerID,
+			Stack:    stack.Stack,
+		})
+	}
+	return result, nil
+}
+
+// terminateTable terminates the tournament table for TerminateTable and
+// TerimateTable.
+func terminateTable(gameCode string, tournamentID uint32, tableNo uint32, moves []nats.PlayerMove) ([]game.TableStack, error) {
+	stacks, err := natsGameManager.TerminateTable(gameCode, tournamentID, tableNo, moves)
+	if err != nil {
+		grpcLogger.Error().Err(err).
+			Str(logging.GameCodeKey, gameCode).
+			Msgf("Could not terminate table for tournament: %d, table: %d", tournamentID, tableNo)
+		return nil, err
+	}
+	return stacks, nil
+}
+
+func (s *TournamentServer) GetTableMoves(ctx context.Context, in *tournamentrpc.TableInput) (*tournamentrpc.TableMovesResult, error) {
+	balance, err := natsGameManager.TableMoves(in.TournamentId, in.TableNo)
+	if err != nil {
+		return &tournamentrpc.TableMovesResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	result := &tournamentrpc.TableMovesResult{
+		Success:     true,
+		Error:       "",
+		HandNum:     balance.HandNum,
+		Moves:       make([]*tournamentrpc.TableMove, 0, len(balance.Moves)),
+		TableBroken: balance.Broken,
+	}
+	for _, move := range balance.Moves {
+		result.Moves = append(result.Moves, &tournamentrpc.TableMove{
+			PlayerId:    move.PlayerID,
+			FromTableNo: move.FromTableNo,
+			FromSeatNo:  move.FromSeatNo,
+			ToTableNo:   move.ToTableNo,
+			ToSeatNo:    move.ToSeatNo,
+		})
+	}
+	return result, nil
+}
+
+func (s *TournamentServer) SetTableBalancing(ctx context.Context, in *tournamentrpc.TableBalancingInput) (*tournamentrpc.Result, error) {
+	natsGameManager.SetTableBalancing(in.TournamentId, in.Enabled)
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) SetTournamentStructure(ctx context.Context, in *tournamentrpc.TournamentStructure) (*tournamentrpc.Result, error) {
+	levels := make([]game.BlindLevel, 0, len(in.Levels))
+	for _, level := range in.Levels {
+		levels = append(levels, game.BlindLevel{
+			Level:      level.Level,
+			SmallBlind: level.Sb,
+			BigBlind:   level.Bb,
+			Ante:       level.Ante,
+			Duration:   time.Duration(level.DurationSecs) * time.Second,
+			Break:      level.IsBreak,
+		})
+	}
+	err := natsGameManager.SetTournamentStructure(in.TournamentId, levels)
+	if err != nil {
+		grpcLogger.Error().Err(err).Msgf("Could not set the structure of tournament: %d", in.TournamentId)
+		return &tournamentrpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) PauseTournamentClock(ctx context.Context, in *tournamentrpc.TournamentInput) (*tournamentrpc.Result, error) {
+	err := natsGameManager.PauseTournamentClock(in.TournamentId)
+	if err != nil {
+		grpcLogger.Error().Err(err).Msgf("Could not pause the clock of tournament: %d", in.TournamentId)
+		return &tournamentrpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) ResumeTournamentClock(ctx context.Context, in *tournamentrpc.TournamentInput) (*tournamentrpc.Result, error) {
+	err := natsGameManager.ResumeTournamentClock(in.TournamentId)
+	if err != nil {
+		grpcLogger.Error().Err(err).Msgf("Could not resume the clock of tournament: %d", in.TournamentId)
+		return &tournamentrpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) GetTournamentClock(ctx context.Context, in *tournamentrpc.TournamentInput) (*tournamentrpc.TournamentClockState, error) {
+	state, err := natsGameManager.TournamentClockState(in.TournamentId)
+	if err != nil {
+		return &tournamentrpc.TournamentClockState{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.TournamentClockState{
+		Success:    true,
+		LevelIndex: uint32(state.LevelIndex),
+		Level: &tournamentrpc.BlindLevel{
+			Level:        state.Level.Level,
+			Sb:           state.Level.SmallBlind,
+			Bb:           state.Level.BigBlind,
+			Ante:         state.Level.Ante,
+			DurationSecs: uint32(state.Level.Duration / time.Second),
+			IsBreak:      state.Level.Break,
+		},
+		RemainingSecs: uint32(state.Remaining / time.Second),
+		Paused:        state.Paused,
+	}, nil
+}
+
+func (s *TournamentServer) SetHandForHand(ctx context.Context, in *tournamentrpc.HandForHandInput) (*tournamentrpc.Result, error) {
+	err := natsGameManager.SetHandForHand(in.TournamentId, in.Enabled, int(in.PlayersRemaining))
+	if err != nil {
+		return &tournamentrpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) GetHandForHand(ctx context.Context, in *tournamentrpc.HandForHandInput) (*tournamentrpc.HandForHandState, error) {
+	state := natsGameManager.HandForHandState(in.TournamentId)
+	result := &tournamentrpc.HandForHandState{
+		Success:       true,
+		Error:         "",
+		Enabled:       state.Enabled,
+		Round:         state.Round,
+		WaitingTables: state.WaitingTables,
+	}
+	for _, e := range state.Eliminations {
+		result.Eliminations = append(result.Eliminations, &tournamentrpc.Elimination{
+			PlayerId:      e.PlayerID,
+			TableNo:       e.TableNo,
+			StartingStack: e.StartingStack,
+			Place:         uint32(e.Place),
+			Tied:          e.Tied,
+		})
+	}
+	return result, nil
+}
+
+func (s *TournamentServer) SetTournamentBuyIns(ctx context.Context, in *tournamentrpc.TournamentBuyInsInput) (*tournamentrpc.Result, error) {
+	config := game.TournamentBuyInConfig{
+		StartingChips: in.StartingChips,
+		RebuyChips:    in.RebuyChips,
+		MaxRebuys:     int(in.MaxRebuys),
+		AddOnChips:    in.AddOnChips,
+		ReEntryChips:  in.ReEntryChips,
+		MaxReEntries:  int(in.MaxReEntries),
+		PromptTimeout: time.Duration(in.PromptTimeoutSecs) * time.Second,
+	}
+	natsGameManager.SetTournamentBuyIns(in.TournamentId, config, int(in.RebuyLevels), int(in.ReEntryLevels))
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) SetTournamentBuyInPeriods(ctx context.Context, in *tournamentrpc.BuyInPeriodsInput) (*tournamentrpc.Result, error) {
+	err := natsGameManager.SetTournamentBuyInPeriods(in.TournamentId, in.RebuyOpen, in.AddOnOpen, in.ReEntryOpen)
+	if err != nil {
+		grpcLogger.Error().Err(err).Msgf("Could not set the buy-in periods of tournament: %d", in.TournamentId)
+		return &tournamentrpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) SetTournamentBounties(ctx context.Context, in *tournamentrpc.TournamentBountiesInput) (*tournamentrpc.Result, error) {
+	config := game.TournamentBountyConfig{
+		Bounty:      in.Bounty,
+		Progressive: in.Progressive,
+	}
+	err := natsGameManager.SetTournamentBounties(in.TournamentId, config)
+	if err != nil {
+		grpcLogger.Error().Err(err).Msgf("Could not set the bounties of tournament: %d", in.TournamentId)
+		return &tournamentrpc.Result{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.Result{
+		Success: true,
+		Error:   "",
+	}, nil
+}
+
+func (s *TournamentServer) SetTournamentPayouts(ctx context.Context, in *tournamentrpc.TournamentPayoutsInput) (*tournamentrpc.PayoutResult, error) {
+	var structure game.PayoutStructure
+	for _, tier := range in.Tiers {
+		structure.Tiers = append(structure.Tiers, game.PayoutTier{
+			MinEntries:  int(tier.MinEntries),
+			MaxEntries:  int(tier.MaxEntries),
+			Percentages: tier.Percentages,
+		})
+	}
+	payouts, err := natsGameManager.SetTournamentPayouts(in.TournamentId, int(in.Entries), in.PrizePool, structure)
+	if err != nil {
+		grpcLogger.Error().Err(err).Msgf("Could not set the payouts of tournament: %d", in.TournamentId)
+		return &tournamentrpc.PayoutResult{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	return &tournamentrpc.PayoutResult{
+		Success: true,
+		Error:   "",
+		Payouts: payouts,
+	}, nil
+}
+
+func (s *TournamentServer) GetTournamentStandings(ctx context.Context, in *tournamentrpc.TournamentInput) (*tournamentrpc.StandingsResult, error) {
+	payouts, standings, err := natsGameManager.TournamentStandings(in.TournamentId)
+	if err != nil {
+		return &tournamentrpc.StandingsResult{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	entries, remaining, err := natsGameManager.TournamentEntries(in.TournamentId)
+	if err != nil {
+		return &tournamentrpc.StandingsResult{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	result := &tournamentrpc.StandingsResult{
+		Success:   true,
+		Error:     "",
+		Payouts:   payouts,
+		Entries:   uint32(entries),
+		Remaining: uint32(remaining),
+	}
+	for _, standing := range standings {
+		result.Standings = append(result.Standings, &tournamentrpc.Standing{
+			PlayerId: standing.PlayerID,
+			TableNo:  standing.TableNo,
+			Place:    uint32(standing.Place),
+			Tied:     standing.Tied,
+			Prize:    standing.Prize,
+		})
+	}
+	return result, nil
+}
+
+func (s *TournamentServer) CalculateICM(ctx context.Context, in *tournamentrpc.ICMInput) (*tournamentrpc.ICMResult, error) {
+	splits, err := game.ProposeDeal(in.Stacks, in.Payouts, int(in.Trials), in.Seed)
+	if err != nil {
+		return &tournamentrpc.ICMResult{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	result := &tournamentrpc.ICMResult{
+		Success: true,
+		Error:   "",
+		Exact:   len(in.Stacks) <= game.ICMExactMaxPlayers,
+	}
+	for _, split := range splits {
+		result.Equities = append(result.Equities, split.ICM)
+		result.ChipChop = append(result.ChipChop, split.ChipChop)
+	}
+	return result, nil
+}
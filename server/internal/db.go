@@ -17,3 +17,15 @@ func GetCrashDBConnStr() string {
 		util.Env.GetPostgresSSLMode(),
 	)
 }
+
+func GetHandStateDBConnStr() string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		util.Env.GetPostgresHost(),
+		util.Env.GetPostgresPort(),
+		util.Env.GetPostgresUser(),
+		util.Env.GetPostgresPW(),
+		util.Env.GetPostgresHandStateDB(),
+		util.Env.GetPostgresSSLMode(),
+	)
+}
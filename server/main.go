@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"os"
+	"regexp"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -34,6 +37,25 @@ var delayConfigFile *string
 var testName *string
 var testDeal *bool
 var numDeals *uint
+var dealSeed *uint64
+var testRNG *bool
+var rngReportFile *string
+var dealingPolicy *string
+var testDealingPolicy *bool
+var testBalancing *bool
+var numTournaments *uint
+var tournamentPlayers *uint
+var snapshotGameCode *string
+var snapshotHandNum *uint
+var replayPath *string
+var replayGameCode *string
+var equityRanges *string
+var equityBoard *string
+var equityDead *string
+var equityTrials *uint
+var equityHiLo *bool
+var verifyHandPath *string
+var verifyCommitment *string
 var exit bool
 var mainLogger = logging.GetZeroLogger("main::main", nil)
 var rpcPort = 9000
@@ -47,6 +69,25 @@ func init() {
 	testName = flag.String("testname", "", "runs a specific test")
 	testDeal = flag.Bool("test-deal", false, "deals and counts ranks")
 	numDeals = flag.Uint("num-deals", 100000, "number of test deals when -test-deal is set")
+	dealSeed = flag.Uint64("deal-seed", 0, "seed of the test deals when -test-deal, -test-rng or -test-balancing is set (random if 0)")
+	testRNG = flag.Bool("test-rng", false, "deals hands and runs the statistical tests of the RNG audit")
+	rngReportFile = flag.String("rng-report", "", "writes the RNG audit report as JSON to the file when -test-rng or -test-dealing-policy is set")
+	dealingPolicy = flag.String("dealing-policy", "legacy", "dealing policy of the RNG audit when -test-rng is set (legacy, pure-random, capped-rare-hands)")
+	testBalancing = flag.Bool("test-balancing", false, "plays bot tournaments and checks the table balancing")
+	numTournaments = flag.Uint("num-tournaments", 1000, "number of tournaments when -test-balancing is set")
+	tournamentPlayers = flag.Uint("tournament-players", 100, "number of players of a tournament when -test-balancing is set")
+	testDealingPolicy = flag.Bool("test-dealing-policy", false, "deals hands with every dealing policy and compares the hand frequencies with a fair deal")
+	snapshotGameCode = flag.String("dump-snapshots", "", "dumps the hand state snapshots of the game as JSON and exits")
+	snapshotHandNum = flag.Uint("hand-num", 0, "hand number to dump when -dump-snapshots or -replay-game is set (lists the hands if 0)")
+	replayPath = flag.String("replay", "", "replays the hands in a json file or a directory of json files and compares with the recorded outcome")
+	replayGameCode = flag.String("replay-game", "", "replays the hands of the game from the hand state snapshots")
+	equityRanges = flag.String("equity", "", "calculates the equity of the ranges separated by vs (e.g. \"AKs, TT+ vs QQ+, AKo\") and exits")
+	equityBoard = flag.String("board", "", "board cards when -equity is set (e.g. Ah7d2c)")
+	equityDead = flag.String("dead", "", "dead cards when -equity is set")
+	equityTrials = flag.Uint("trials", 0, "Monte Carlo trials when -equity is set (enumerates the small spots if 0)")
+	equityHiLo = flag.Bool("hilo", false, "splits the pot with the 8 or better low when -equity is set (Omaha)")
+	verifyHandPath = flag.String("verify-hand", "", "verifies the shuffle of the hand result in a json file against the published server seed commitment")
+	verifyCommitment = flag.String("commitment", "", "server seed commitment published with the previous hand (next seed commitment of the new hand message) when -verify-hand is set")
 }
 
 func main() {
@@ -65,8 +106,34 @@ func run() error {
 	fmt.Printf("Setting log level to %s\n", logLevel)
 	zerolog.SetGlobalLevel(logLevel)
 	flag.Parse()
+	handEvaluator, err := poker.ParseHandEvaluator(util.Env.GetHandEvaluator())
+	if err != nil {
+		return err
+	}
+	poker.SetHandEvaluator(handEvaluator)
 	if *testDeal {
-		return simulation.Run(int(*numDeals))
+		return simulation.Run(int(*numDeals), *dealSeed)
+	}
+	if *testRNG {
+		return rngAudit(int(*numDeals), *dealSeed, *dealingPolicy, *rngReportFile)
+	}
+	if *testDealingPolicy {
+		return compareDealingPolicies(int(*numDeals), *dealSeed, *rngReportFile)
+	}
+	if *testBalancing {
+		return simulateTableBalancing(int(*numTournaments), int(*tournamentPlayers), *dealSeed)
+	}
+	if *snapshotGameCode != "" {
+		return dumpSnapshots(*snapshotGameCode, uint32(*snapshotHandNum))
+	}
+	if *replayPath != "" || *replayGameCode != "" {
+		return replayHands(*replayPath, *replayGameCode, uint32(*snapshotHandNum))
+	}
+	if *equityRanges != "" {
+		return equity(*equityRanges, *equityBoard, *equityDead, int(*equityTrials), *equityHiLo)
+	}
+	if *verifyHandPath != "" {
+		return verifyHand(*verifyHandPath, *verifyCommitment)
 	}
 
 	delays, err := game.ParseDelayConfig(*delayConfigFile)
@@ -89,6 +156,7 @@ func run() error {
 		return testScripts()
 	}
 
+	gameManager.ReplayHandResultOutboxes()
 	runWithNats(gameManager)
 	return nil
 }
@@ -190,6 +258,236 @@ func testScripts() error {
 	return nil
 }
 
+func dumpSnapshots(gameCode string, handNum uint32) error {
+	snapshots, err := game.NewHandStateSnapshotsFromEnv()
+	if err != nil {
+		return errors.Wrap(err, "Error while creating hand state snapshots")
+	}
+	if snapshots == nil {
+		return fmt.Errorf("Hand state snapshots are disabled")
+	}
+
+	var out interface{}
+	if handNum == 0 {
+		out, err = snapshots.Hands(gameCode)
+	} else {
+		out, err = snapshots.Snapshots(gameCode, handNum)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Error while reading hand state snapshots of game %s", gameCode)
+	}
+	outJSON, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(outJSON))
+	return nil
+}
+
+func replayHands(path string, gameCode string, handNum uint32) error {
+	var cases []*game.ReplayCase
+	var err error
+	if path != "" {
+		cases, err = game.LoadReplayCases(path)
+	} else {
+		cases, err = loadReplayCasesFromSnapshots(gameCode, handNum)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error while loading the hands to replay")
+	}
+
+	diverged := 0
+	skipped := 0
+	for _, c := range cases {
+		report, err := game.ReplayHand(c)
+		if err != nil {
+			return errors.Wrapf(err, "Error while replaying %s", c.Name)
+		}
+		if report.Skipped != "" {
+			skipped++
+			fmt.Printf("SKIPPED  %s (hand %d): %s\n", report.Name, report.HandNum, report.Skipped)
+			continue
+		}
+		if !report.Diverged() {
+			fmt.Printf("OK       %s (hand %d): %d actions\n", report.Name, report.HandNum, report.Actions)
+			continue
+		}
+		diverged++
+		fmt.Printf("DIVERGED %s (hand %d): %d actions\n", report.Name, report.HandNum, report.Actions)
+		for _, diff := range report.Diffs {
+			fmt.Printf("    %s\n", diff)
+		}
+	}
+	fmt.Printf("Replayed %d hands. OK: %d, Diverged: %d, Skipped: %d\n", len(cases), len(cases)-diverged-skipped, diverged, skipped)
+	if diverged > 0 {
+		return fmt.Errorf("%d hands diverged from the recorded outcome", diverged)
+	}
+	return nil
+}
+
+func rngAudit(numDeals int, seed uint64, policyName string, reportFile string) error {
+	policy, err := game.ParseDealingPolicy(policyName)
+	if err != nil {
+		return err
+	}
+	report, err := simulation.RunRNGAudit(simulation.AuditConfig{
+		NumDeals:      numDeals,
+		NumPlayers:    9,
+		Seed:          seed,
+		DealingPolicy: policy,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(report.Text())
+	if reportFile != "" {
+		data, err := report.JSON()
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(reportFile, data, 0644)
+		if err != nil {
+			return errors.Wrap(err, "Error while writing the RNG audit report")
+		}
+	}
+	if !report.Pass() {
+		return fmt.Errorf("RNG audit failed")
+	}
+	return nil
+}
+
+func compareDealingPolicies(numDeals int, seed uint64, reportFile string) error {
+	comparison, err := simulation.CompareDealingPolicies(simulation.AuditConfig{
+		NumDeals:   numDeals,
+		NumPlayers: 9,
+		Seed:       seed,
+	}, []game.DealingPolicy{
+		game.DealingPolicy_DEALING_PURE_RANDOM,
+		game.DealingPolicy_DEALING_CAPPED_RARE_HANDS,
+		game.DealingPolicy_DEALING_LEGACY,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(comparison.Text())
+	if reportFile != "" {
+		data, err := comparison.JSON()
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(reportFile, data, 0644)
+		if err != nil {
+			return errors.Wrap(err, "Error while writing the dealing policy report")
+		}
+	}
+	return nil
+}
+
+func simulateTableBalancing(numTournaments int, numPlayers int, seed uint64) error {
+	report, err := simulation.SimulateTableBalancing(simulation.BalanceConfig{
+		NumTournaments: numTournaments,
+		NumPlayers:     numPlayers,
+		TableSize:      9,
+		Seed:           seed,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(report.Text())
+	if !report.Pass() {
+		return fmt.Errorf("Table balancing simulation failed")
+	}
+	return nil
+}
+
+func equity(rangesNotation string, boardCards string, deadCards string, trials int, hiLo bool) error {
+	board, err := poker.ParseCards(boardCards)
+	if err != nil {
+		return err
+	}
+	dead, err := poker.ParseCards(deadCards)
+	if err != nil {
+		return err
+	}
+	var ranges []*poker.Range
+	for _, notation := range regexp.MustCompile(`(?i)\s+vs\s+`).Split(rangesNotation, -1) {
+		r, err := poker.ParseRange(notation, append(append([]poker.Card{}, board...), dead...))
+		if err != nil {
+			return err
+		}
+		ranges = append(ranges, r)
+	}
+	config := poker.EquityConfig{Trials: trials, HiLo: hiLo}
+	if trials > 0 {
+		config.Method = poker.EquityMonteCarlo
+	}
+	result, err := poker.CalculateEquity(ranges, board, dead, config)
+	if err != nil {
+		return err
+	}
+
+	method := "Monte Carlo"
+	if result.Exact {
+		method = "exact"
+	}
+	fmt.Printf("Board: %s, %d showdowns (%s)\n\n", poker.CardsToString(board), result.Showdowns, method)
+	fmt.Printf("%-30s %8s %8s %8s %8s\n", "Range", "Combos", "Equity", "Win", "Tie")
+	for i, r := range ranges {
+		equity := result.Ranges[i]
+		fmt.Printf("%-30s %8d %7.2f%% %7.2f%% %7.2f%%\n", r.Notation, len(r.Combos), equity.Equity*100, equity.Win*100, equity.Tie*100)
+	}
+	return nil
+}
+
+func verifyHand(path string, commitment string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := game.ParseHandResult(data)
+	if err != nil {
+		return errors.Wrapf(err, "Could not parse hand result %s", path)
+	}
+	err = game.VerifyHandShuffle(result, commitment)
+	if err != nil {
+		return errors.Wrapf(err, "Hand %d failed the shuffle verification", result.HandNum)
+	}
+	fmt.Printf("OK hand %d: the server seed matches the commitment %s and the cards are dealt from the deck\n", result.HandNum, result.Shuffle.Commitment)
+	return nil
+}
+
+func loadReplayCasesFromSnapshots(gameCode string, handNum uint32) ([]*game.ReplayCase, error) {
+	snapshots, err := game.NewHandStateSnapshotsFromEnv()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while creating hand state snapshots")
+	}
+	if snapshots == nil {
+		return nil, fmt.Errorf("Hand state snapshots are disabled")
+	}
+
+	handNums := []uint32{handNum}
+	if handNum == 0 {
+		handNums, err = snapshots.Hands(gameCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cases := make([]*game.ReplayCase, 0, len(handNums))
+	for _, handNum := range handNums {
+		handSnapshots, err := snapshots.Snapshots(gameCode, handNum)
+		if err != nil {
+			return nil, err
+		}
+		c, err := game.NewReplayCaseFromSnapshots(handSnapshots)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot replay hand %d", handNum)
+		}
+		c.Name = fmt.Sprintf("%s/%d", gameCode, handNum)
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
 func testStuff() {
 	player1 := poker.CardsInAscii{"Kh", "Qd"}
 	player2 := poker.CardsInAscii{"3s", "7s"}
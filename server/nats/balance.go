@@ -0,0 +1,336 @@
+package nats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"voyager.com/logging"
+	"voyager.com/server/game"
+)
+
+// When table balancing is on, the game server keeps the seating of the tables
+// of the tournament it hosts and balances them with game.PlanTableBalance. A
+// table applies the moves of its players when the tournament asks for its next
+// hand: the moved players are sent to their new tables and left out of the
+// hand. Until the tournament seats a moved player at the new table, the server
+// seats the player there itself with the stack from the last hand. A table
+// whose players are all moved is broken and doesn't deal.
+
+type seatedPlayer struct {
+	player game.SeatPlayer
+	// movedIn is set when the server moved the player to the table and the
+	// tournament has not seated the player there yet.
+	movedIn bool
+}
+
+type seatingTable struct {
+	tableNo      uint32
+	gameCode     string
+	maxSeats     uint32
+	bigBlindSeat uint32
+	seats        map[uint32]*seatedPlayer
+}
+
+type tournamentSeating struct {
+	lock   sync.Mutex
+	tables map[uint32]*seatingTable
+	// table of each player
+	players map[uint64]uint32
+	// balance of the last hand of each table (also the broken tables)
+	lastBalances map[uint32]TableBalance
+}
+
+// TableBalance is the result of balancing a table before its hand.
+type TableBalance struct {
+	// HandNum is the hand the table was balanced for.
+	HandNum uint32
+	Moves   []game.TableMove
+	// Broken is set when all the players of the table are moved.
+	Broken bool
+}
+
+// movedTableInfo is the game info of the PlayerMovedTable message sent for a
+// balancing move.
+type movedTableInfo struct {
+	GameCode string `json:"gameCode"`
+	TableNo  uint32 `json:"tableNo"`
+}
+
+// SetTableBalancing turns the table balancing of the tournament on or off.
+func (gm *GameManager) SetTableBalancing(tournamentID uint32, enabled bool) {
+	key := tournamentKey(tournamentID)
+	if !enabled {
+		gm.tournamentSeatings.Remove(key)
+		natsGMLogger.Info().Msgf("Tournament %d table balancing is off", tournamentID)
+		return
+	}
+	gm.tournamentSeatings.SetIfAbsent(key, &tournamentSeating{
+		tables:       make(map[uint32]*seatingTable),
+		players:      make(map[uint64]uint32),
+		lastBalances: make(map[uint32]TableBalance),
+	})
+	natsGMLogger.Info().Msgf("Tournament %d table balancing is on", tournamentID)
+}
+
+// TableMoves returns the balance of the last hand of the table.
+func (gm *GameManager) TableMoves(tournamentID uint32, tableNo uint32) (TableBalance, error) {
+	v, exists := gm.tournamentSeatings.Get(tournamentKey(tournamentID))
+	if !exists {
+		return TableBalance{}, fmt.Errorf("Tournament %d does not balance the tables", tournamentID)
+	}
+	s := v.(*tournamentSeating)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	balance, exists := s.lastBalances[tableNo]
+	if !exists {
+		return TableBalance{}, fmt.Errorf("Tournament %d table %d has not dealt a hand", tournamentID, tableNo)
+	}
+	return balance, nil
+}
+
+// removeSeatingTable removes the table from the seating of the tournament.
+func (gm *GameManager) removeSeatingTable(tournamentID uint32, tableNo uint32) {
+	v, exists := gm.tournamentSeatings.Get(tournamentKey(tournamentID))
+	if !exists {
+		return
+	}
+	s := v.(*tournamentSeating)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	table, exists := s.tables[tableNo]
+	if !exists {
+		return
+	}
+	for _, p := range table.seats {
+		if s.players[p.player.PlayerID] == tableNo {
+			delete(s.players, p.player.PlayerID)
+		}
+	}
+	delete(s.tables, tableNo)
+}
+
+// balanceTable updates the seating with the players of the new hand and
+// applies the moves of the players of the table. The moved players are
+// removed from the hand.
+func (gm *GameManager) balanceTable(tournamentID uint32, natsGame *NatsGame, hand *game.NewHandInfo) (TableBalance, error) {
+	v, exists := gm.tournamentSeatings.Get(tournamentKey(tournamentID))
+	if !exists {
+		return TableBalance{HandNum: hand.HandNum}, nil
+	}
+	s := v.(*tournamentSeating)
+	balance, destinations := s.balance(natsGame.tableNo, natsGame.gameCode, hand, natsGame.serverGame.TableStacks())
+
+	for _, move := range balance.Moves {
+		gameInfo, err := json.Marshal(movedTableInfo{
+			GameCode: destinations[move.ToTableNo],
+			TableNo:  move.ToTableNo,
+		})
+		if err != nil {
+			return balance, err
+		}
+		err = natsGame.HandlePlayerMovedTable(natsGame.gameCode, tournamentID, move.FromTableNo, move.ToTableNo, move.ToSeatNo, move.PlayerID, string(gameInfo))
+		if err != nil {
+			natsGMLogger.Error().Err(err).
+				Str(logging.GameCodeKey, natsGame.gameCode).
+				Uint64(logging.PlayerIDKey, move.PlayerID).
+				Msgf("Could not send the move to table %d", move.ToTableNo)
+		}
+	}
+	if len(balance.Moves) > 0 {
+		natsGMLogger.Info().
+			Str(logging.GameCodeKey, natsGame.gameCode).
+			Msgf("Tournament %d table %d moved %d players. Broken: %v", tournamentID, natsGame.tableNo, len(balance.Moves), balance.Broken)
+	}
+	return balance, nil
+}
+
+// balance updates the seating of the table with the stacks of its last hand
+// and the players of the new hand, and moves the players of the table. It
+// returns the game codes of the tables the players are moved to.
+func (s *tournamentSeating) balance(tableNo uint32, gameCode string, hand *game.NewHandInfo, stacks []game.TableStack) (TableBalance, map[uint32]string) {
+	balance := TableBalance{HandNum: hand.HandNum}
+	destinations := make(map[uint32]string)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	table, exists := s.tables[tableNo]
+	if !exists {
+		table = &seatingTable{
+			tableNo:  tableNo,
+			gameCode: gameCode,
+			seats:    make(map[uint32]*seatedPlayer),
+		}
+		s.tables[tableNo] = table
+	}
+	table.maxSeats = hand.MaxPlayers
+	table.bigBlindSeat = hand.BbPos
+	s.updateStacks(table, stacks)
+	s.seatHandPlayers(table, hand.PlayersInSeats)
+
+	plan := game.PlanTableBalance(s.balanceTables())
+	balance.Moves = plan.MovesFrom(table.tableNo)
+	for _, move := range balance.Moves {
+		s.move(move)
+		destinations[move.ToTableNo] = s.tables[move.ToTableNo].gameCode
+	}
+	hand.PlayersInSeats = table.handPlayers()
+	if len(table.seats) == 0 {
+		balance.Broken = true
+		delete(s.tables, table.tableNo)
+	} else {
+		hand.BbPos = table.nextOccupiedSeat(hand.BbPos)
+		// the other tables plan with the big blind of the hand after this one
+		table.bigBlindSeat = table.nextOccupiedSeat(hand.BbPos%table.maxSeats + 1)
+	}
+	s.lastBalances[table.tableNo] = balance
+	return balance, destinations
+}
+
+// updateStacks sets the stacks of the players moved to the table from the
+// last hand at the table. The players who busted are removed.
+func (s *tournamentSeating) updateStacks(table *seatingTable, stacks []game.TableStack) {
+	for _, stack := range stacks {
+		p, ok := table.seats[stack.SeatNo]
+		if !ok || !p.movedIn || p.player.PlayerID != stack.PlayerID {
+			continue
+		}
+		if stack.Stack <= 0 {
+			delete(table.seats, stack.SeatNo)
+			delete(s.players, stack.PlayerID)
+			continue
+		}
+		p.player.Stack = stack.Stack
+	}
+}
+
+// seatHandPlayers seats the players the tournament sent for the hand. The
+// players the server moved to another table stay there, and the players the
+// server moved to this table keep their seats.
+func (s *tournamentSeating) seatHandPlayers(table *seatingTable, players []game.SeatPlayer) {
+	previous := table.seats
+	table.seats = make(map[uint32]*seatedPlayer)
+	seated := make(map[uint64]bool)
+	for _, player := range players {
+		if player.PlayerID == 0 {
+			continue
+		}
+		if other, seatNo, ok := s.findPlayer(player.PlayerID); ok && other != table {
+			if other.seats[seatNo].movedIn {
+				// the server moved the player to the other table
+				continue
+			}
+			// the tournament moved the player to this table
+			delete(other.seats, seatNo)
+		}
+		table.seats[player.SeatNo] = &seatedPlayer{player: player}
+		s.players[player.PlayerID] = table.tableNo
+		seated[player.PlayerID] = true
+	}
+	for _, p := range previous {
+		playerID := p.player.PlayerID
+		if seated[playerID] {
+			continue
+		}
+		if !p.movedIn {
+			// the tournament removed the player from the table
+			if s.players[playerID] == table.tableNo {
+				delete(s.players, playerID)
+			}
+			continue
+		}
+		if _, taken := table.seats[p.player.SeatNo]; taken {
+			seatNo := table.emptySeat()
+			if seatNo == 0 {
+				natsGMLogger.Error().
+					Str(logging.GameCodeKey, table.gameCode).
+					Uint64(logging.PlayerIDKey, playerID).
+					Msgf("No seat left at table %d for the moved player", table.tableNo)
+				delete(s.players, playerID)
+				continue
+			}
+			p.player.SeatNo = seatNo
+		}
+		table.seats[p.player.SeatNo] = p
+	}
+}
+
+// findPlayer returns the table and the seat of the player.
+func (s *tournamentSeating) findPlayer(playerID uint64) (*seatingTable, uint32, bool) {
+	tableNo, ok := s.players[playerID]
+	if !ok {
+		return nil, 0, false
+	}
+	table, ok := s.tables[tableNo]
+	if !ok {
+		return nil, 0, false
+	}
+	for seatNo, p := range table.seats {
+		if p.player.PlayerID == playerID {
+			return table, seatNo, true
+		}
+	}
+	return nil, 0, false
+}
+
+func (s *tournamentSeating) balanceTables() []game.BalanceTable {
+	tables := make([]game.BalanceTable, 0, len(s.tables))
+	for _, table := range s.tables {
+		bt := game.BalanceTable{
+			TableNo:      table.tableNo,
+			MaxSeats:     table.maxSeats,
+			BigBlindSeat: table.bigBlindSeat,
+		}
+		for seatNo, p := range table.seats {
+			bt.Players = append(bt.Players, game.BalanceSeat{SeatNo: seatNo, PlayerID: p.player.PlayerID})
+		}
+		tables = append(tables, bt)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].TableNo < tables[j].TableNo })
+	return tables
+}
+
+func (s *tournamentSeating) move(move game.TableMove) {
+	from := s.tables[move.FromTableNo]
+	to := s.tables[move.ToTableNo]
+	p := from.seats[move.FromSeatNo]
+	delete(from.seats, move.FromSeatNo)
+	p.movedIn = true
+	p.player.SeatNo = move.ToSeatNo
+	to.seats[move.ToSeatNo] = p
+	s.players[move.PlayerID] = move.ToTableNo
+}
+
+// handPlayers returns the players of the table in seat order.
+func (t *seatingTable) handPlayers() []game.SeatPlayer {
+	players := make([]game.SeatPlayer, 0, len(t.seats))
+	for _, p := range t.seats {
+		players = append(players, p.player)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].SeatNo < players[j].SeatNo })
+	return players
+}
+
+// nextOccupiedSeat returns the seat or the next occupied seat after it.
+func (t *seatingTable) nextOccupiedSeat(seatNo uint32) uint32 {
+	if t.maxSeats == 0 || seatNo == 0 {
+		return seatNo
+	}
+	for i := uint32(0); i < t.maxSeats; i++ {
+		next := (seatNo+i-1)%t.maxSeats + 1
+		if _, ok := t.seats[next]; ok {
+			return next
+		}
+	}
+	return seatNo
+}
+
+func (t *seatingTable) emptySeat() uint32 {
+	for seatNo := uint32(1); seatNo <= t.maxSeats; seatNo++ {
+		if _, ok := t.seats[seatNo]; !ok {
+			return seatNo
+		}
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package nats
+
+import (
+	"testing"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/game"
+)
+
+// seatingHand returns a new hand of a 6-max table. The players are seated in
+// the order of the seats, player 0 leaves the seat empty.
+func seatingHand(handNum uint32, bbPos uint32, playerIDs ...uint64) *game.NewHandInfo {
+	hand := &game.NewHandInfo{
+		HandNum:    handNum,
+		MaxPlayers: 6,
+		BbPos:      bbPos,
+	}
+	for i, playerID := range playerIDs {
+		if playerID == 0 {
+			continue
+		}
+		hand.PlayersInSeats = append(hand.PlayersInSeats, game.SeatPlayer{
+			SeatNo:   uint32(i + 1),
+			PlayerID: playerID,
+			Stack:    100,
+		})
+	}
+	return hand
+}
+
+func handPlayerIDs(hand *game.NewHandInfo) []uint64 {
+	playerIDs := make([]uint64, 0, len(hand.PlayersInSeats))
+	for _, player := range hand.PlayersInSeats {
+		playerIDs = append(playerIDs, player.PlayerID)
+	}
+	return playerIDs
+}
+
+func newTestSeating() *tournamentSeating {
+	return &tournamentSeating{
+		tables:       make(map[uint32]*seatingTable),
+		players:      make(map[uint64]uint32),
+		lastBalances: make(map[uint32]TableBalance),
+	}
+}
+
+func TestBalanceMovesPlayers(t *testing.T) {
+	s := newTestSeating()
+	balance, _ := s.balance(2, "table-2", seatingHand(1, 2, 21, 22), nil)
+	assert.Empty(t, balance.Moves)
+
+	hand := seatingHand(1, 2, 11, 12, 13, 14, 15, 16)
+	balance, destinations := s.balance(1, "table-1", hand, nil)
+	require.Len(t, balance.Moves, 2)
+	assert.False(t, balance.Broken)
+	assert.Equal(t, uint32(1), balance.HandNum)
+	assert.Equal(t, "table-2", destinations[2])
+	moved := make(map[uint64]bool)
+	for _, move := range balance.Moves {
+		assert.Equal(t, uint32(1), move.FromTableNo)
+		assert.Equal(t, uint32(2), move.ToTableNo)
+		moved[move.PlayerID] = true
+		assert.Equal(t, uint32(2), s.players[move.PlayerID])
+		assert.True(t, s.tables[2].seats[move.ToSeatNo].movedIn)
+	}
+	assert.Len(t, hand.PlayersInSeats, 4)
+	for _, player := range hand.PlayersInSeats {
+		assert.False(t, moved[player.PlayerID], "moved player %d is dealt at the old table", player.PlayerID)
+	}
+	_, occupied := s.tables[1].seats[hand.BbPos]
+	assert.True(t, occupied, "the big blind is not an empty seat")
+}
+
+func TestBalanceReseatMovedPlayer(t *testing.T) {
+	s := newTestSeating()
+	s.balance(2, "table-2", seatingHand(1, 2, 21, 22), nil)
+	balance, _ := s.balance(1, "table-1", seatingHand(1, 2, 11, 12, 13, 14, 15, 16), nil)
+	require.Len(t, balance.Moves, 2)
+	move := balance.Moves[0]
+
+	// the tournament has not seen the move yet and sends the player to the
+	// old table again
+	hand := seatingHand(2, 3, 11, 12, 13, 14, 15, 16)
+	balance, _ = s.balance(1, "table-1", hand, nil)
+	assert.Empty(t, balance.Moves)
+	assert.NotContains(t, handPlayerIDs(hand), move.PlayerID)
+	assert.Equal(t, uint32(2), s.players[move.PlayerID])
+
+	// the new table deals the moved player with the stack of the last hand
+	// at the new table
+	stacks := []game.TableStack{{SeatNo: move.ToSeatNo, PlayerID: move.PlayerID, Stack: 250}}
+	hand = seatingHand(2, 3, 21, 22)
+	s.balance(2, "table-2", hand, stacks)
+	require.Contains(t, handPlayerIDs(hand), move.PlayerID)
+	for _, player := range hand.PlayersInSeats {
+		if player.PlayerID == move.PlayerID {
+			assert.Equal(t, move.ToSeatNo, player.SeatNo)
+			assert.Equal(t, float64(250), player.Stack)
+		}
+	}
+
+	// the tournament seats the player at the new table
+	playerIDs := []uint64{21, 22, 0, 0, 0, 0}
+	playerIDs[move.ToSeatNo-1] = move.PlayerID
+	s.balance(2, "table-2", seatingHand(3, 1, playerIDs...), nil)
+	assert.False(t, s.tables[2].seats[move.ToSeatNo].movedIn)
+}
+
+func TestBalanceMovedPlayerSeatTaken(t *testing.T) {
+	s := newTestSeating()
+	s.balance(2, "table-2", seatingHand(1, 2, 21, 22), nil)
+	balance, _ := s.balance(1, "table-1", seatingHand(1, 2, 11, 12, 13, 14, 15, 16), nil)
+	require.Len(t, balance.Moves, 2)
+	move := balance.Moves[0]
+
+	// the tournament seats a new player in the seat the server moved the
+	// player to
+	playerIDs := []uint64{21, 22, 0, 0, 0, 0}
+	playerIDs[move.ToSeatNo-1] = 31
+	hand := seatingHand(2, 1, playerIDs...)
+	s.balance(2, "table-2", hand, nil)
+	seats := make(map[uint64]uint32)
+	for _, player := range hand.PlayersInSeats {
+		seats[player.PlayerID] = player.SeatNo
+	}
+	require.Contains(t, seats, move.PlayerID)
+	assert.Equal(t, move.ToSeatNo, seats[31])
+	assert.NotEqual(t, move.ToSeatNo, seats[move.PlayerID])
+	assert.Len(t, seats, len(hand.PlayersInSeats), "two players share a seat")
+}
+
+func TestBalanceBustedMovedPlayer(t *testing.T) {
+	s := newTestSeating()
+	s.balance(2, "table-2", seatingHand(1, 2, 21, 22), nil)
+	balance, _ := s.balance(1, "table-1", seatingHand(1, 2, 11, 12, 13, 14, 15, 16), nil)
+	require.Len(t, balance.Moves, 2)
+	move := balance.Moves[0]
+
+	stacks := []game.TableStack{{SeatNo: move.ToSeatNo, PlayerID: move.PlayerID, Stack: 0}}
+	hand := seatingHand(2, 3, 21, 22)
+	s.balance(2, "table-2", hand, stacks)
+	assert.NotContains(t, handPlayerIDs(hand), move.PlayerID)
+	assert.NotContains(t, s.players, move.PlayerID)
+}
+
+func TestBalanceBrokenTable(t *testing.T) {
+	gm := &GameManager{tournamentSeatings: cmap.New()}
+	gm.SetTableBalancing(1, true)
+	v, _ := gm.tournamentSeatings.Get(tournamentKey(1))
+	s := v.(*tournamentSeating)
+
+	s.balance(2, "table-2", seatingHand(1, 2, 21, 22, 23, 24), nil)
+	hand := seatingHand(1, 2, 11, 12)
+	balance, _ := s.balance(1, "table-1", hand, nil)
+	assert.True(t, balance.Broken)
+	assert.Len(t, balance.Moves, 2)
+	assert.Empty(t, hand.PlayersInSeats)
+	assert.NotContains(t, s.tables, uint32(1))
+	assert.Len(t, s.tables[2].seats, 6)
+	assert.Equal(t, uint32(2), s.players[11])
+	assert.Equal(t, uint32(2), s.players[12])
+
+	// the moves of the broken table are kept for the tournament
+	moves, err := gm.TableMoves(1, 1)
+	require.NoError(t, err)
+	assert.True(t, moves.Broken)
+	assert.Equal(t, balance.Moves, moves.Moves)
+	_, err = gm.TableMoves(1, 3)
+	assert.Error(t, err)
+
+	gm.SetTableBalancing(1, false)
+	_, err = gm.TableMoves(1, 1)
+	assert.Error(t, err)
+}
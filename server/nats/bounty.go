@@ -0,0 +1,37 @@
+package nats
+
+import (
+	"voyager.com/server/game"
+)
+
+// The game server keeps the bounties of the knockout tournaments it hosts
+// tables for. The tables pay the bounties of the players busted in a hand and
+// report the transfers with the hand result. The bounty state is local to this
+// server: a progressive bounty won at a table of this server is not known to
+// the tables of the tournament hosted by other servers, so a PKO tournament
+// with growing heads must keep its tables on one server.
+
+// SetTournamentBounties sets the bounty structure of the tournament.
+func (gm *GameManager) SetTournamentBounties(tournamentID uint32, config game.TournamentBountyConfig) error {
+	bounties, err := game.NewTournamentBounties(tournamentID, config)
+	if err != nil {
+		return err
+	}
+	gm.tournamentBounties.Set(tournamentKey(tournamentID), bounties)
+	for _, natsGame := range gm.tournamentTables(tournamentID) {
+		natsGame.serverGame.SetTournamentBounties(bounties)
+	}
+	natsGMLogger.Info().
+		Msgf("Tournament %d bounty: %v progressive: %v", tournamentID, config.Bounty, config.Progressive)
+	return nil
+}
+
+// attachTournamentBounties shares the bounty state of the tournament with the
+// table.
+func (gm *GameManager) attachTournamentBounties(tournamentID uint32, natsGame *NatsGame) {
+	v, exists := gm.tournamentBounties.Get(tournamentKey(tournamentID))
+	if !exists {
+		return
+	}
+	natsGame.serverGame.SetTournamentBounties(v.(*game.TournamentBounties))
+}
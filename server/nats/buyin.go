@@ -0,0 +1,95 @@
+package nats
+
+import (
+	"fmt"
+
+	"voyager.com/server/game"
+)
+
+// The game server keeps the rebuy, add-on and re-entry state of the
+// tournaments it hosts tables for. The periods follow the blind clock: rebuys
+// are open for the first rebuy levels, the add-on is offered at the level
+// after them, and re-entry is open for the first re-entry levels. Without a
+// blind clock the tournament opens and closes the periods itself.
+
+type tournamentBuyIns struct {
+	buyIns        *game.TournamentBuyIns
+	rebuyLevels   int
+	reEntryLevels int
+}
+
+// SetTournamentBuyIns sets the buy-in structure of the tournament. rebuyLevels
+// and reEntryLevels are the number of blind levels the rebuy and re-entry
+// periods last.
+func (gm *GameManager) SetTournamentBuyIns(tournamentID uint32, config game.TournamentBuyInConfig, rebuyLevels int, reEntryLevels int) {
+	tb := &tournamentBuyIns{
+		buyIns:        game.NewTournamentBuyIns(tournamentID, config),
+		rebuyLevels:   rebuyLevels,
+		reEntryLevels: reEntryLevels,
+	}
+	gm.tournamentBuyIns.Set(tournamentKey(tournamentID), tb)
+	if state, err := gm.TournamentClockState(tournamentID); err == nil {
+		gm.updateBuyInPeriods(tournamentID, state.LevelIndex)
+	} else {
+		tb.buyIns.SetPeriods(rebuyLevels > 0, false, reEntryLevels > 0)
+	}
+	natsGMLogger.Info().
+		Msgf("Tournament %d buy-ins: rebuy %v (%d levels) add-on %v re-entry %v (%d levels)",
+			tournamentID, config.RebuyChips, rebuyLevels, config.AddOnChips, config.ReEntryChips, reEntryLevels)
+}
+
+// SetTournamentBuyInPeriods opens or closes the buy-in periods of the
+// tournament. The players are offered the add-on when its period opens.
+func (gm *GameManager) SetTournamentBuyInPeriods(tournamentID uint32, rebuyOpen bool, addOnOpen bool, reEntryOpen bool) error {
+	tb, err := gm.getTournamentBuyIns(tournamentID)
+	if err != nil {
+		return err
+	}
+	gm.setBuyInPeriods(tournamentID, tb, rebuyOpen, addOnOpen, reEntryOpen)
+	return nil
+}
+
+func (gm *GameManager) getTournamentBuyIns(tournamentID uint32) (*tournamentBuyIns, error) {
+	v, exists := gm.tournamentBuyIns.Get(tournamentKey(tournamentID))
+	if !exists {
+		return nil, fmt.Errorf("Tournament %d does not have buy-ins", tournamentID)
+	}
+	return v.(*tournamentBuyIns), nil
+}
+
+// updateBuyInPeriods sets the buy-in periods at the level of the blind clock.
+func (gm *GameManager) updateBuyInPeriods(tournamentID uint32, levelIndex int) {
+	tb, err := gm.getTournamentBuyIns(tournamentID)
+	if err != nil {
+		return
+	}
+	rebuyOpen := levelIndex < tb.rebuyLevels
+	addOnOpen := tb.rebuyLevels > 0 && levelIndex == tb.rebuyLevels
+	reEntryOpen := levelIndex < tb.reEntryLevels
+	gm.setBuyInPeriods(tournamentID, tb, rebuyOpen, addOnOpen, reEntryOpen)
+}
+
+func (gm *GameManager) setBuyInPeriods(tournamentID uint32, tb *tournamentBuyIns, rebuyOpen bool, addOnOpen bool, reEntryOpen bool) {
+	_, wasAddOnOpen, _ := tb.buyIns.Periods()
+	tb.buyIns.SetPeriods(rebuyOpen, addOnOpen, reEntryOpen)
+	natsGMLogger.Info().
+		Msgf("Tournament %d buy-in periods. Rebuy: %v add-on: %v re-entry: %v", tournamentID, rebuyOpen, addOnOpen, reEntryOpen)
+	if !rebuyOpen && !reEntryOpen {
+		gm.placeDeferredBusted(tournamentID)
+	}
+	if addOnOpen && !wasAddOnOpen {
+		for _, natsGame := range gm.tournamentTables(tournamentID) {
+			natsGame.serverGame.SetTournamentBuyIns(tb.buyIns)
+			natsGame.serverGame.OfferTournamentBuyIns()
+		}
+	}
+}
+
+// attachTournamentBuyIns shares the buy-in state of the tournament with the table.
+func (gm *GameManager) attachTournamentBuyIns(tournamentID uint32, natsGame *NatsGame) {
+	tb, err := gm.getTournamentBuyIns(tournamentID)
+	if err != nil {
+		return
+	}
+	natsGame.serverGame.SetTournamentBuyIns(tb.buyIns)
+}
@@ -25,11 +25,26 @@ type GameManager struct {
 	gameIDToCode cmap.ConcurrentMap
 	gameCodeToID cmap.ConcurrentMap
 	nc           *natsgo.Conn
+	// blind clocks of the tournaments (tournament ID -> *tournamentClock)
+	tournamentClocks cmap.ConcurrentMap
+	// seating of the tournaments that the server balances (tournament ID -> *tournamentSeating)
+	tournamentSeatings cmap.ConcurrentMap
+	// tournaments playing hand-for-hand (tournament ID -> *handForHand)
+	handForHands cmap.ConcurrentMap
+	// rebuys, add-ons and re-entries of the tournaments (tournament ID -> *tournamentBuyIns)
+	tournamentBuyIns cmap.ConcurrentMap
+	// payouts and standings of the tournaments (tournament ID -> *tournamentStandings)
+	tournamentStandings cmap.ConcurrentMap
+	// bounties of the knockout tournaments (tournament ID -> *game.TournamentBounties)
+	tournamentBounties cmap.ConcurrentMap
+	// sit-and-go tournaments hosted by this server (tournament ID -> *sitAndGo)
+	sitAndGos cmap.ConcurrentMap
 }
 
 type GameListItem struct {
-	GameID   uint64 `json:"gameId"`
-	GameCode string `json:"gameCode"`
+	GameID         uint64 `json:"gameId"`
+	GameCode       string `json:"gameCode"`
+	PendingResults int    `json:"pendingResults"`
 }
 
 const (
@@ -54,6 +69,15 @@ func NewGameManager(nc *natsgo.Conn) (*GameManager, error) {
 		activeGames:  cmap.New(),
 		gameIDToCode: cmap.New(),
 		gameCodeToID: cmap.New(),
+
+		tournamentClocks:   cmap.New(),
+		tournamentSeatings: cmap.New(),
+		handForHands:       cmap.New(),
+		tournamentBuyIns:   cmap.New(),
+
+		tournamentStandings: cmap.New(),
+		tournamentBounties:  cmap.New(),
+		sitAndGos:           cmap.New(),
 	}, nil
 }
 
@@ -129,8 +153,9 @@ func (gm *GameManager) GetGames() ([]GameListItem, error) {
 		}
 		gameCode := game.gameCode
 		games = append(games, GameListItem{
-			GameID:   gameID,
-			GameCode: gameCode,
+			GameID:         gameID,
+			GameCode:       gameCode,
+			PendingResults: game.serverGame.PendingHandResults(),
 		})
 	}
 	return games, nil
@@ -221,7 +246,13 @@ func (gm *GameManager) GetCurrentHandLog(gameID uint64) (*map[string]interface{}
 	return handLog, true
 }
 
-func (gm *GameManager) DealTournamentHand(gameCode string, in *rpc.HandInfo) error {
+// DealTournamentHand deals the next hand at the tournament table. With table
+// balancing, the moves of the players of the table are applied first and the
+// hand is not dealt if the table is broken. In hand-for-hand play, the hand
+// waits for the other tables. The rebuys, add-ons and re-entries accepted
+// after the last hand are added to the seats when the hand is dealt. The
+// players busted at the table are placed in the tournament standings.
+func (gm *GameManager) DealTournamentHand(gameCode string, in *rpc.HandInfo) (TableBalance, error) {
 	// first check whether the game is hosted by this game server
 	v, _ := gm.gameCodeToID.Get(gameCode)
 	gameIDStr := v.(string)
@@ -233,7 +264,7 @@ func (gm *GameManager) DealTournamentHand(gameCode string, in *rpc.HandInfo) err
 		// lookup using game code
 		var errors map[string]interface{}
 		errors["errors"] = fmt.Sprintf("Cannot find game %s", gameCode)
-		return fmt.Errorf("Cannot find game %s", gameCode)
+		return TableBalance{}, fmt.Errorf("Cannot find game %s", gameCode)
 	}
 
 	/*
@@ -271,6 +302,9 @@ func (gm *GameManager) DealTournamentHand(gameCode string, in *rpc.HandInfo) err
 		FourKindCount        int
 		StraightFlushAllowed bool
 		FourKindAllowed      bool
+		DealingPolicy        DealingPolicy
+		DeckType             DeckType
+		NumDecks             uint32
 		Tournament           bool
 	*/
 	var hand game.NewHandInfo
@@ -302,6 +336,23 @@ func (gm *GameManager) DealTournamentHand(gameCode string, in *rpc.HandInfo) err
 		hand.PlayersInSeats = append(hand.PlayersInSeats, sp)
 	}
 	hand.TournamentURL = in.TournamentUrl
-	go natsGame.serverGame.DealTournamentHand(&hand)
-	return nil
+	gm.attachTournamentBuyIns(in.TournamentId, natsGame)
+	gm.attachTournamentBounties(in.TournamentId, natsGame)
+	err := gm.applyTournamentLevel(in.TournamentId, &hand)
+	if err != nil {
+		return TableBalance{}, err
+	}
+	balance, err := gm.balanceTable(in.TournamentId, natsGame, &hand)
+	if err != nil {
+		return balance, err
+	}
+	if balance.Broken {
+		gm.handForHandTableRemoved(in.TournamentId, in.TableNo)
+		return balance, nil
+	}
+	gm.recordTableEliminations(in.TournamentId, natsGame, &hand)
+	if gm.queueHandForHand(in.TournamentId, natsGame, &hand) {
+		return balance, nil
+	}
+	return balance, natsGame.serverGame.DealTournamentHand(&hand)
 }
@@ -0,0 +1,250 @@
+package nats
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"voyager.com/server/game"
+)
+
+// In hand-for-hand play the hands the tournament asks for are not dealt right
+// away. The tables of the tournament hosted by this server wait for each other
+// and the hands of a round are dealt together when the last table asks for its
+// hand. When the round starts, the players busted in the previous round are
+// ranked by their starting stacks, in the tournament standings when it has
+// payouts. Otherwise they are ranked with game.RankEliminations below the
+// players remaining in the tournament, which the tournament sets when it turns
+// hand-for-hand play on (the tables of the tournament may be hosted by other
+// servers). A round is dealt after handForHandTimeout
+// even if some tables didn't ask for a hand, so a table that can't deal
+// doesn't stop the tournament.
+
+const handForHandTimeout = 2 * time.Minute
+
+// handForHandTable is the table dealing the hands (*game.Game).
+type handForHandTable interface {
+	TableStacks() []game.TableStack
+	Announce(announcementType string, params []string)
+	DealTournamentHand(newHandInfo *game.NewHandInfo) error
+}
+
+type pendingHand struct {
+	table handForHandTable
+	hand  *game.NewHandInfo
+}
+
+type handForHand struct {
+	lock         sync.Mutex
+	tournamentID uint32
+	round        uint32
+	timeout      time.Duration
+	// players remaining in the tournament, used when it has no standings
+	remaining int
+	pending   map[uint32]*pendingHand
+	// tables that are broken or terminated
+	excluded map[uint32]bool
+	// players dealt in the round and their starting stacks
+	startingStacks []game.Elimination
+	eliminations   []game.Elimination
+	timer          *time.Timer
+}
+
+// HandForHandState is the state of the hand-for-hand play of a tournament.
+type HandForHandState struct {
+	Enabled bool   `json:"enabled"`
+	Round   uint32 `json:"round"`
+	// WaitingTables are the tables waiting for the other tables.
+	WaitingTables []uint32 `json:"waitingTables"`
+	// Eliminations are the players busted in the last round.
+	Eliminations []game.Elimination `json:"eliminations"`
+}
+
+// SetHandForHand turns the hand-for-hand play of the tournament on or off.
+// remaining is the number of players remaining in the tournament. It is
+// required when the tournament has no standings and updates the count when
+// hand-for-hand play is already on. The waiting hands are dealt when it is
+// turned off.
+func (gm *GameManager) SetHandForHand(tournamentID uint32, enabled bool, remaining int) error {
+	key := tournamentKey(tournamentID)
+	if enabled {
+		if _, err := gm.getTournamentStandings(tournamentID); err != nil && remaining <= 0 {
+			return fmt.Errorf("Tournament %d does not have standings. The number of players remaining is required", tournamentID)
+		}
+		gm.handForHands.SetIfAbsent(key, &handForHand{
+			tournamentID: tournamentID,
+			round:        1,
+			timeout:      handForHandTimeout,
+			pending:      make(map[uint32]*pendingHand),
+			excluded:     make(map[uint32]bool),
+		})
+		if remaining > 0 {
+			v, _ := gm.handForHands.Get(key)
+			h := v.(*handForHand)
+			h.lock.Lock()
+			h.remaining = remaining
+			h.lock.Unlock()
+		}
+		natsGMLogger.Info().Msgf("Tournament %d hand-for-hand play is on. Players remaining: %d", tournamentID, remaining)
+		return nil
+	}
+	v, exists := gm.handForHands.Pop(key)
+	if !exists {
+		return nil
+	}
+	h := v.(*handForHand)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.pending) > 0 {
+		gm.startHandForHandRound(h)
+	}
+	natsGMLogger.Info().Msgf("Tournament %d hand-for-hand play is off", tournamentID)
+	return nil
+}
+
+// HandForHandState returns the state of the hand-for-hand play of the tournament.
+func (gm *GameManager) HandForHandState(tournamentID uint32) HandForHandState {
+	v, exists := gm.handForHands.Get(tournamentKey(tournamentID))
+	if !exists {
+		return HandForHandState{}
+	}
+	h := v.(*handForHand)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	state := HandForHandState{
+		Enabled:      true,
+		Round:        h.round,
+		Eliminations: append([]game.Elimination{}, h.eliminations...),
+	}
+	for tableNo := range h.pending {
+		state.WaitingTables = append(state.WaitingTables, tableNo)
+	}
+	sort.Slice(state.WaitingTables, func(i, j int) bool { return state.WaitingTables[i] < state.WaitingTables[j] })
+	return state
+}
+
+// queueHandForHand queues the hand of the table until the round starts.
+// Returns false if the tournament is not playing hand-for-hand.
+func (gm *GameManager) queueHandForHand(tournamentID uint32, natsGame *NatsGame, hand *game.NewHandInfo) bool {
+	return gm.queueTableHandForHand(tournamentID, natsGame.tableNo, natsGame.serverGame, hand)
+}
+
+func (gm *GameManager) queueTableHandForHand(tournamentID uint32, tableNo uint32, table handForHandTable, hand *game.NewHandInfo) bool {
+	v, exists := gm.handForHands.Get(tournamentKey(tournamentID))
+	if !exists {
+		return false
+	}
+	h := v.(*handForHand)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.pending) == 0 {
+		round := h.round
+		h.timer = time.AfterFunc(h.timeout, func() {
+			h.lock.Lock()
+			defer h.lock.Unlock()
+			if h.round != round || len(h.pending) == 0 {
+				return
+			}
+			natsGMLogger.Warn().
+				Msgf("Tournament %d hand-for-hand round %d starts without all the tables after %s", tournamentID, round, h.timeout)
+			gm.startHandForHandRound(h)
+		})
+	}
+	h.pending[tableNo] = &pendingHand{
+		table: table,
+		hand:  hand,
+	}
+	gm.checkHandForHandRound(h)
+	return true
+}
+
+// handForHandTableRemoved stops waiting for the broken or terminated table.
+func (gm *GameManager) handForHandTableRemoved(tournamentID uint32, tableNo uint32) {
+	v, exists := gm.handForHands.Get(tournamentKey(tournamentID))
+	if !exists {
+		return
+	}
+	h := v.(*handForHand)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.excluded[tableNo] = true
+	delete(h.pending, tableNo)
+	if len(h.pending) > 0 {
+		gm.checkHandForHandRound(h)
+	}
+}
+
+// checkHandForHandRound starts the round when all the tables are waiting.
+func (gm *GameManager) checkHandForHandRound(h *handForHand) {
+	for _, natsGame := range gm.tournamentTables(h.tournamentID) {
+		if h.excluded[natsGame.tableNo] {
+			continue
+		}
+		if _, waiting := h.pending[natsGame.tableNo]; !waiting {
+			return
+		}
+	}
+	gm.startHandForHandRound(h)
+}
+
+// startHandForHandRound ranks the players busted in the last round and deals
+// the waiting hands.
+func (gm *GameManager) startHandForHandRound(h *handForHand) {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+
+	stacks := make(map[uint64]float64)
+	tableNos := make([]uint32, 0, len(h.pending))
+	for tableNo, p := range h.pending {
+		tableNos = append(tableNos, tableNo)
+		for _, stack := range p.table.TableStacks() {
+			stacks[stack.PlayerID] = stack.Stack
+		}
+	}
+	sort.Slice(tableNos, func(i, j int) bool { return tableNos[i] < tableNos[j] })
+	var busted []game.Elimination
+	for _, player := range h.startingStacks {
+		if stack, ok := stacks[player.PlayerID]; ok && stack <= 0 {
+			busted = append(busted, player)
+		}
+	}
+	if ranked, ok := gm.placeHandForHandEliminations(h.tournamentID, busted); ok {
+		h.eliminations = ranked
+	} else {
+		h.remaining -= len(busted)
+		if h.remaining < 0 {
+			h.remaining = 0
+		}
+		h.eliminations = game.RankEliminations(busted, h.remaining)
+	}
+	for _, e := range h.eliminations {
+		natsGMLogger.Info().
+			Msgf("Tournament %d hand-for-hand round %d: player %d busted at table %d with %v chips. Place: %d tied: %v",
+				h.tournamentID, h.round-1, e.PlayerID, e.TableNo, e.StartingStack, e.Place, e.Tied)
+	}
+
+	h.startingStacks = h.startingStacks[:0]
+	for _, tableNo := range tableNos {
+		p := h.pending[tableNo]
+		for _, player := range p.hand.PlayersInSeats {
+			h.startingStacks = append(h.startingStacks, game.Elimination{
+				PlayerID:      player.PlayerID,
+				TableNo:       tableNo,
+				StartingStack: player.Stack,
+			})
+		}
+		p.table.Announce(game.AnnouncementHandForHand, []string{fmt.Sprintf("%d", h.round)})
+		err := p.table.DealTournamentHand(p.hand)
+		if err != nil {
+			natsGMLogger.Error().Err(err).
+				Msgf("Could not deal tournament %d table %d hand-for-hand round %d", h.tournamentID, tableNo, h.round)
+		}
+	}
+	natsGMLogger.Info().
+		Msgf("Tournament %d hand-for-hand round %d dealt at %d tables", h.tournamentID, h.round, len(tableNos))
+	h.round++
+	h.pending = make(map[uint32]*pendingHand)
+}
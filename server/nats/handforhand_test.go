@@ -0,0 +1,147 @@
+package nats
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/game"
+)
+
+type testHandForHandTable struct {
+	lock   sync.Mutex
+	stacks []game.TableStack
+	dealt  chan *game.NewHandInfo
+}
+
+func newTestHandForHandTable() *testHandForHandTable {
+	return &testHandForHandTable{dealt: make(chan *game.NewHandInfo, 10)}
+}
+
+func (t *testHandForHandTable) TableStacks() []game.TableStack {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]game.TableStack{}, t.stacks...)
+}
+
+func (t *testHandForHandTable) Announce(announcementType string, params []string) {}
+
+func (t *testHandForHandTable) DealTournamentHand(newHandInfo *game.NewHandInfo) error {
+	t.dealt <- newHandInfo
+	return nil
+}
+
+func (t *testHandForHandTable) setStacks(stacks ...game.TableStack) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.stacks = stacks
+}
+
+func (t *testHandForHandTable) dealtHands() int {
+	return len(t.dealt)
+}
+
+func newHandForHandTestManager(tournamentID uint32, tableNos ...uint32) *GameManager {
+	gm := &GameManager{
+		activeGames:         cmap.New(),
+		handForHands:        cmap.New(),
+		tournamentBuyIns:    cmap.New(),
+		tournamentStandings: cmap.New(),
+	}
+	for _, tableNo := range tableNos {
+		gm.activeGames.Set(fmt.Sprintf("table-%d", tableNo), &NatsGame{
+			tournamentID: uint64(tournamentID),
+			tableNo:      tableNo,
+		})
+	}
+	return gm
+}
+
+func TestHandForHandRound(t *testing.T) {
+	gm := newHandForHandTestManager(1, 1, 2)
+	require.Error(t, gm.SetHandForHand(1, true, 0), "the players remaining are required without standings")
+	require.NoError(t, gm.SetHandForHand(1, true, 20))
+	table1 := newTestHandForHandTable()
+	table2 := newTestHandForHandTable()
+
+	// table 1 waits for table 2
+	assert.True(t, gm.queueTableHandForHand(1, 1, table1, seatingHand(1, 2, 11, 12, 13)))
+	assert.Equal(t, 0, table1.dealtHands())
+	state := gm.HandForHandState(1)
+	assert.Equal(t, uint32(1), state.Round)
+	assert.Equal(t, []uint32{1}, state.WaitingTables)
+
+	assert.True(t, gm.queueTableHandForHand(1, 2, table2, seatingHand(1, 2, 21, 22)))
+	assert.Equal(t, 1, table1.dealtHands())
+	assert.Equal(t, 1, table2.dealtHands())
+	state = gm.HandForHandState(1)
+	assert.Equal(t, uint32(2), state.Round)
+	assert.Empty(t, state.WaitingTables)
+
+	// two players bust in round 1, the bigger starting stack places better
+	table1.setStacks(game.TableStack{SeatNo: 1, PlayerID: 11, Stack: 0})
+	table2.setStacks(game.TableStack{SeatNo: 1, PlayerID: 21, Stack: 0})
+	hand := seatingHand(2, 3, 0, 12, 13)
+	gm.queueTableHandForHand(1, 1, table1, hand)
+	gm.queueTableHandForHand(1, 2, table2, seatingHand(2, 3, 0, 22))
+	state = gm.HandForHandState(1)
+	require.Len(t, state.Eliminations, 2)
+	for _, e := range state.Eliminations {
+		// the places are below the players remaining in the tournament, not
+		// only the players of the tables of this server
+		assert.Equal(t, 19, e.Place)
+		assert.True(t, e.Tied)
+	}
+
+	// a tournament without hand-for-hand play deals right away
+	assert.False(t, gm.queueTableHandForHand(2, 1, table1, hand))
+}
+
+func TestHandForHandTableRemoved(t *testing.T) {
+	gm := newHandForHandTestManager(1, 1, 2)
+	require.NoError(t, gm.SetHandForHand(1, true, 10))
+	table1 := newTestHandForHandTable()
+	gm.queueTableHandForHand(1, 1, table1, seatingHand(1, 2, 11, 12))
+	assert.Equal(t, 0, table1.dealtHands())
+
+	// table 2 is broken, the round doesn't wait for it
+	gm.handForHandTableRemoved(1, 2)
+	assert.Equal(t, 1, table1.dealtHands())
+	gm.queueTableHandForHand(1, 1, table1, seatingHand(2, 1, 11, 12))
+	assert.Equal(t, 2, table1.dealtHands())
+	assert.Equal(t, uint32(3), gm.HandForHandState(1).Round)
+}
+
+func TestHandForHandTimeout(t *testing.T) {
+	gm := newHandForHandTestManager(1, 1, 2)
+	require.NoError(t, gm.SetHandForHand(1, true, 10))
+	v, _ := gm.handForHands.Get(tournamentKey(1))
+	v.(*handForHand).timeout = 20 * time.Millisecond
+
+	table1 := newTestHandForHandTable()
+	gm.queueTableHandForHand(1, 1, table1, seatingHand(1, 2, 11, 12))
+	select {
+	case hand := <-table1.dealt:
+		assert.Equal(t, uint32(1), hand.HandNum)
+	case <-time.After(time.Second):
+		t.Fatal("the round did not start after the timeout")
+	}
+	assert.Equal(t, uint32(2), gm.HandForHandState(1).Round)
+}
+
+func TestHandForHandOff(t *testing.T) {
+	gm := newHandForHandTestManager(1, 1, 2)
+	require.NoError(t, gm.SetHandForHand(1, true, 10))
+	table1 := newTestHandForHandTable()
+	gm.queueTableHandForHand(1, 1, table1, seatingHand(1, 2, 11, 12))
+	assert.Equal(t, 0, table1.dealtHands())
+
+	// the waiting hands are dealt when hand-for-hand play is turned off
+	require.NoError(t, gm.SetHandForHand(1, false, 0))
+	assert.Equal(t, 1, table1.dealtHands())
+	assert.False(t, gm.HandForHandState(1).Enabled)
+}
@@ -0,0 +1,266 @@
+package nats
+
+import (
+	"fmt"
+	"sync"
+
+	"voyager.com/server/game"
+)
+
+// The game server keeps the standings of the tournaments with a payout
+// structure. The players busted at a table are placed when the table asks for
+// its next hand, ranked by their starting stacks of the last hand. In
+// hand-for-hand play the players busted in a round are placed together when
+// the next round starts. Busted players are not placed while they can still
+// rebuy or re-enter: they are kept in the order they busted and placed when
+// the rebuy and re-entry periods close, unless they bought back in. A
+// re-entry counts as a new entry.
+
+type tournamentStandings struct {
+	lock      sync.Mutex
+	payouts   []float64
+	standings *game.TournamentStandings
+	// players dealt in the last hand of each table and their starting stacks
+	startingStacks map[uint32][]game.Elimination
+	// players busted while they can buy back in, grouped by the hand (or
+	// hand-for-hand round) they busted in, the first bust first
+	deferred [][]game.Elimination
+}
+
+// TournamentStanding is the place and prize of a player.
+type TournamentStanding struct {
+	game.Elimination
+	Prize float64 `json:"prize"`
+}
+
+// SetTournamentPayouts sets the prizes of the tournament from the payout
+// structure for the field size and starts its standings.
+func (gm *GameManager) SetTournamentPayouts(tournamentID uint32, entries int, prizePool float64, structure game.PayoutStructure) ([]float64, error) {
+	if len(structure.Tiers) == 0 {
+		structure = game.DefaultPayoutStructure()
+	}
+	err := structure.Validate()
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := structure.Payouts(entries, prizePool)
+	if err != nil {
+		return nil, err
+	}
+	gm.tournamentStandings.Set(tournamentKey(tournamentID), &tournamentStandings{
+		payouts:        payouts,
+		standings:      game.NewTournamentStandings(entries),
+		startingStacks: make(map[uint32][]game.Elimination),
+	})
+	natsGMLogger.Info().
+		Msgf("Tournament %d payouts: %d entries prize pool: %v payouts: %v", tournamentID, entries, prizePool, payouts)
+	return payouts, nil
+}
+
+// TournamentStandings returns the prizes of the tournament and the players
+// with a place, the winner first.
+func (gm *GameManager) TournamentStandings(tournamentID uint32) ([]float64, []TournamentStanding, error) {
+	ts, err := gm.getTournamentStandings(tournamentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	prizes := ts.standings.Prizes(ts.payouts)
+	var standings []TournamentStanding
+	for _, e := range ts.standings.Results() {
+		standings = append(standings, TournamentStanding{
+			Elimination: e,
+			Prize:       prizes[e.PlayerID],
+		})
+	}
+	return append([]float64{}, ts.payouts...), standings, nil
+}
+
+// TournamentEntries returns the number of entries of the tournament (the
+// re-entries included) and the number of players remaining.
+func (gm *GameManager) TournamentEntries(tournamentID uint32) (int, int, error) {
+	ts, err := gm.getTournamentStandings(tournamentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	gm.updateReEntries(tournamentID, ts)
+	return ts.standings.Entries(), ts.standings.Remaining(), nil
+}
+
+func (gm *GameManager) getTournamentStandings(tournamentID uint32) (*tournamentStandings, error) {
+	v, exists := gm.tournamentStandings.Get(tournamentKey(tournamentID))
+	if !exists {
+		return nil, fmt.Errorf("Tournament %d does not have payouts", tournamentID)
+	}
+	return v.(*tournamentStandings), nil
+}
+
+// canPlaceBusted returns false while the busted players of the tournament can
+// buy back in.
+func (gm *GameManager) canPlaceBusted(tournamentID uint32) bool {
+	tb, err := gm.getTournamentBuyIns(tournamentID)
+	if err != nil {
+		return true
+	}
+	rebuyOpen, _, reEntryOpen := tb.buyIns.Periods()
+	return !rebuyOpen && !reEntryOpen
+}
+
+// recordTableEliminations places the players busted in the last hand of the
+// table and keeps the starting stacks of the next hand. The busted players of
+// the tournaments playing hand-for-hand are placed when the round starts.
+func (gm *GameManager) recordTableEliminations(tournamentID uint32, natsGame *NatsGame, hand *game.NewHandInfo) {
+	gm.recordEliminations(tournamentID, natsGame.tableNo, natsGame.serverGame.TableStacks(), hand)
+}
+
+// recordEliminations records the eliminations of the table with the stacks of
+// its last hand.
+func (gm *GameManager) recordEliminations(tournamentID uint32, tableNo uint32, tableStacks []game.TableStack, hand *game.NewHandInfo) {
+	ts, err := gm.getTournamentStandings(tournamentID)
+	if err != nil {
+		return
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	gm.updateReEntries(tournamentID, ts)
+
+	_, handForHand := gm.handForHands.Get(tournamentKey(tournamentID))
+	if !handForHand {
+		stacks := make(map[uint64]float64)
+		for _, stack := range tableStacks {
+			stacks[stack.PlayerID] = stack.Stack
+		}
+		var busted []game.Elimination
+		for _, player := range ts.startingStacks[tableNo] {
+			if stack, ok := stacks[player.PlayerID]; ok && stack <= 0 {
+				busted = append(busted, player)
+			}
+		}
+		gm.placeOrDeferBusted(tournamentID, ts, busted)
+	}
+
+	startingStacks := make([]game.Elimination, 0, len(hand.PlayersInSeats))
+	for _, player := range hand.PlayersInSeats {
+		startingStacks = append(startingStacks, game.Elimination{
+			PlayerID:      player.PlayerID,
+			TableNo:       tableNo,
+			StartingStack: player.Stack,
+		})
+		if player.Stack > 0 {
+			// the player bought back in
+			ts.removeDeferred(player.PlayerID)
+		}
+	}
+	ts.startingStacks[tableNo] = startingStacks
+	if ts.standings.Remaining() == 1 && len(startingStacks) == 1 {
+		ts.standings.SetWinner(startingStacks[0].PlayerID, tableNo)
+		natsGMLogger.Info().
+			Msgf("Tournament %d winner: player %d at table %d", tournamentID, startingStacks[0].PlayerID, tableNo)
+	}
+}
+
+// placeHandForHandEliminations places the players busted in a hand-for-hand
+// round. Returns false if the tournament does not have standings.
+func (gm *GameManager) placeHandForHandEliminations(tournamentID uint32, busted []game.Elimination) ([]game.Elimination, bool) {
+	ts, err := gm.getTournamentStandings(tournamentID)
+	if err != nil {
+		return nil, false
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	gm.updateReEntries(tournamentID, ts)
+	return gm.placeOrDeferBusted(tournamentID, ts, busted), true
+}
+
+// placeDeferredBusted places the players who busted while they could buy back
+// in and did not. It is called when the rebuy and re-entry periods close.
+func (gm *GameManager) placeDeferredBusted(tournamentID uint32) {
+	ts, err := gm.getTournamentStandings(tournamentID)
+	if err != nil {
+		return
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	if !gm.canPlaceBusted(tournamentID) {
+		return
+	}
+	gm.updateReEntries(tournamentID, ts)
+	gm.placeDeferred(tournamentID, ts)
+}
+
+// placeOrDeferBusted places the busted players, or keeps them until the
+// periods close while they can buy back in.
+func (gm *GameManager) placeOrDeferBusted(tournamentID uint32, ts *tournamentStandings, busted []game.Elimination) []game.Elimination {
+	if !gm.canPlaceBusted(tournamentID) {
+		if len(busted) > 0 {
+			ts.deferred = append(ts.deferred, busted)
+		}
+		return nil
+	}
+	gm.placeDeferred(tournamentID, ts)
+	return gm.placeBusted(tournamentID, ts, busted)
+}
+
+func (gm *GameManager) placeDeferred(tournamentID uint32, ts *tournamentStandings) {
+	if len(ts.deferred) == 0 {
+		return
+	}
+	// a player who bought back in at the end of the periods may not have
+	// been dealt since
+	alive := make(map[uint64]bool)
+	for _, natsGame := range gm.tournamentTables(tournamentID) {
+		for _, stack := range natsGame.serverGame.TableStacks() {
+			if stack.Stack > 0 {
+				alive[stack.PlayerID] = true
+			}
+		}
+	}
+	for _, group := range ts.deferred {
+		var busted []game.Elimination
+		for _, e := range group {
+			if !alive[e.PlayerID] {
+				busted = append(busted, e)
+			}
+		}
+		gm.placeBusted(tournamentID, ts, busted)
+	}
+	ts.deferred = nil
+}
+
+// removeDeferred forgets the bust of the player who bought back in.
+func (ts *tournamentStandings) removeDeferred(playerID uint64) {
+	for i, group := range ts.deferred {
+		kept := group[:0]
+		for _, e := range group {
+			if e.PlayerID != playerID {
+				kept = append(kept, e)
+			}
+		}
+		ts.deferred[i] = kept
+	}
+}
+
+// updateReEntries counts the re-entries of the tournament as entries.
+func (gm *GameManager) updateReEntries(tournamentID uint32, ts *tournamentStandings) {
+	tb, err := gm.getTournamentBuyIns(tournamentID)
+	if err != nil {
+		return
+	}
+	ts.standings.SetReEntries(tb.buyIns.ReEntries())
+}
+
+func (gm *GameManager) placeBusted(tournamentID uint32, ts *tournamentStandings, busted []game.Elimination) []game.Elimination {
+	if len(busted) == 0 {
+		return nil
+	}
+	ranked := ts.standings.Eliminate(busted)
+	for _, e := range ranked {
+		natsGMLogger.Info().
+			Msgf("Tournament %d player %d busted at table %d with %v chips. Place: %d tied: %v",
+				tournamentID, e.PlayerID, e.TableNo, e.StartingStack, e.Place, e.Tied)
+	}
+	return ranked
+}
@@ -0,0 +1,102 @@
+package nats
+
+import (
+	"testing"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/game"
+)
+
+func newPayoutTestManager() *GameManager {
+	return &GameManager{
+		activeGames:         cmap.New(),
+		tournamentClocks:    cmap.New(),
+		handForHands:        cmap.New(),
+		tournamentBuyIns:    cmap.New(),
+		tournamentStandings: cmap.New(),
+	}
+}
+
+func TestStandingsBustDuringRebuyPeriod(t *testing.T) {
+	gm := newPayoutTestManager()
+	_, err := gm.SetTournamentPayouts(1, 4, 1000, game.PayoutStructure{})
+	require.NoError(t, err)
+	gm.SetTournamentBuyIns(1, game.TournamentBuyInConfig{StartingChips: 1000, RebuyChips: 1000}, 1, 0)
+
+	gm.recordEliminations(1, 1, nil, seatingHand(1, 2, 1, 2, 3, 4))
+	// players 3 and 4 bust while they can rebuy
+	gm.recordEliminations(1, 1, []game.TableStack{
+		{SeatNo: 1, PlayerID: 1, Stack: 2000},
+		{SeatNo: 2, PlayerID: 2, Stack: 2000},
+		{SeatNo: 3, PlayerID: 3, Stack: 0},
+		{SeatNo: 4, PlayerID: 4, Stack: 0},
+	}, seatingHand(2, 3, 1, 2))
+	_, standings, err := gm.TournamentStandings(1)
+	require.NoError(t, err)
+	assert.Empty(t, standings)
+	_, remaining, err := gm.TournamentEntries(1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, remaining)
+
+	// player 3 rebuys and is dealt again
+	gm.recordEliminations(1, 1, []game.TableStack{
+		{SeatNo: 1, PlayerID: 1, Stack: 2000},
+		{SeatNo: 2, PlayerID: 2, Stack: 2000},
+	}, seatingHand(3, 1, 1, 2, 3))
+
+	// player 4 did not rebuy and is placed when the rebuy period closes
+	require.NoError(t, gm.SetTournamentBuyInPeriods(1, false, false, false))
+	_, standings, err = gm.TournamentStandings(1)
+	require.NoError(t, err)
+	require.Len(t, standings, 1)
+	assert.Equal(t, uint64(4), standings[0].PlayerID)
+	assert.Equal(t, 4, standings[0].Place)
+
+	// the busted players are placed right away after the period
+	gm.recordEliminations(1, 1, []game.TableStack{
+		{SeatNo: 1, PlayerID: 1, Stack: 3000},
+		{SeatNo: 2, PlayerID: 2, Stack: 2000},
+		{SeatNo: 3, PlayerID: 3, Stack: 0},
+	}, seatingHand(4, 2, 1, 2))
+	gm.recordEliminations(1, 1, []game.TableStack{
+		{SeatNo: 1, PlayerID: 1, Stack: 5000},
+		{SeatNo: 2, PlayerID: 2, Stack: 0},
+	}, seatingHand(5, 1, 1))
+
+	payouts, standings, err := gm.TournamentStandings(1)
+	require.NoError(t, err)
+	places := make(map[uint64]int)
+	for _, standing := range standings {
+		places[standing.PlayerID] = standing.Place
+	}
+	assert.Equal(t, map[uint64]int{1: 1, 2: 2, 3: 3, 4: 4}, places)
+	assert.Equal(t, payouts[0], standings[0].Prize)
+	_, remaining, err = gm.TournamentEntries(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, remaining)
+}
+
+func TestStandingsHandForHandBustDuringReEntryPeriod(t *testing.T) {
+	gm := newPayoutTestManager()
+	_, err := gm.SetTournamentPayouts(1, 6, 1000, game.PayoutStructure{})
+	require.NoError(t, err)
+	gm.SetTournamentBuyIns(1, game.TournamentBuyInConfig{ReEntryChips: 1000}, 0, 1)
+
+	ranked, ok := gm.placeHandForHandEliminations(1, []game.Elimination{{PlayerID: 5, StartingStack: 500}})
+	assert.True(t, ok)
+	assert.Empty(t, ranked)
+	ranked, _ = gm.placeHandForHandEliminations(1, []game.Elimination{{PlayerID: 6, StartingStack: 300}})
+	assert.Empty(t, ranked)
+
+	// the first bust takes the worse place
+	require.NoError(t, gm.SetTournamentBuyInPeriods(1, false, false, false))
+	_, standings, err := gm.TournamentStandings(1)
+	require.NoError(t, err)
+	places := make(map[uint64]int)
+	for _, standing := range standings {
+		places[standing.PlayerID] = standing.Place
+	}
+	assert.Equal(t, map[uint64]int{5: 6, 6: 5}, places)
+}
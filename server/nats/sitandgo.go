@@ -0,0 +1,231 @@
+package nats
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"voyager.com/logging"
+	"voyager.com/server/game"
+	"voyager.com/server/util"
+)
+
+// The game server hosts the sit-and-go tournaments by itself. The table is
+// created when the last player registers and the first hand is dealt after
+// sitAndGoStartDelay so the players can join the table channels. The hand
+// results of the table are sent to this server (util.Env.GetGameServerURL), in
+// place of the tournament service, and the next hand is dealt after the result
+// pause until one player is left.
+
+const (
+	sitAndGoStartDelay = 3 * time.Second
+	sitAndGoTableNo    = 1
+)
+
+type sitAndGo struct {
+	lock     sync.Mutex
+	sng      *game.SitAndGo
+	gameCode string
+	natsGame *NatsGame
+	// blinds of the last hand dealt
+	lastLevel game.BlindLevel
+}
+
+// SitAndGoTableInfo is what a registered player needs to join the table.
+type SitAndGoTableInfo struct {
+	TournamentID            uint32                `json:"tournamentId"`
+	GameID                  uint64                `json:"gameId"`
+	GameCode                string                `json:"gameCode"`
+	TableNo                 uint32                `json:"tableNo"`
+	SeatNo                  uint32                `json:"seatNo"`
+	Full                    bool                  `json:"full"`
+	Players                 []game.SitAndGoPlayer `json:"players"`
+	GameToPlayerChannel     string                `json:"gameToPlayerChannel"`
+	HandToAllChannel        string                `json:"handToAllChannel"`
+	HandToPlayerChannel     string                `json:"handToPlayerChannel"`
+	HandToPlayerTextChannel string                `json:"handToPlayerTextChannel"`
+	PlayerToHandChannel     string                `json:"playerToHandChannel"`
+	ClientAliveChannel      string                `json:"clientAliveChannel"`
+	TournamentPlayerChannel string                `json:"tournamentPlayerChannel"`
+}
+
+// CreateSitAndGo opens a sit-and-go for registration.
+func (gm *GameManager) CreateSitAndGo(tournamentID uint32, config game.SitAndGoConfig) error {
+	sng, err := game.NewSitAndGo(tournamentID, config, nil)
+	if err != nil {
+		return err
+	}
+	s := &sitAndGo{
+		sng:      sng,
+		gameCode: fmt.Sprintf("sng-%d", tournamentID),
+	}
+	if !gm.sitAndGos.SetIfAbsent(tournamentKey(tournamentID), s) {
+		return fmt.Errorf("Sit-and-go %d already exists", tournamentID)
+	}
+	natsGMLogger.Info().
+		Msgf("Sit-and-go %d is open for %d players. Starting chips: %v", tournamentID, config.Seats, config.StartingChips)
+	return nil
+}
+
+func (gm *GameManager) getSitAndGo(tournamentID uint32) (*sitAndGo, error) {
+	v, exists := gm.sitAndGos.Get(tournamentKey(tournamentID))
+	if !exists {
+		return nil, fmt.Errorf("Sit-and-go %d does not exist", tournamentID)
+	}
+	return v.(*sitAndGo), nil
+}
+
+// RegisterSitAndGo seats the player in the sit-and-go. The sit-and-go starts
+// when the player takes the last seat.
+func (gm *GameManager) RegisterSitAndGo(tournamentID uint32, player game.SitAndGoPlayer) (SitAndGoTableInfo, error) {
+	s, err := gm.getSitAndGo(tournamentID)
+	if err != nil {
+		return SitAndGoTableInfo{}, err
+	}
+	seated, full, err := s.sng.Register(player)
+	if err != nil {
+		return SitAndGoTableInfo{}, err
+	}
+	natsGMLogger.Info().
+		Msgf("Sit-and-go %d: player %d (%s) registered at seat %d", tournamentID, seated.PlayerID, seated.Name, seated.SeatNo)
+	if full {
+		time.AfterFunc(sitAndGoStartDelay, func() {
+			gm.startSitAndGo(s)
+		})
+	}
+	return SitAndGoTableInfo{
+		TournamentID:            tournamentID,
+		GameID:                  uint64(tournamentID)<<32 | sitAndGoTableNo,
+		GameCode:                s.gameCode,
+		TableNo:                 sitAndGoTableNo,
+		SeatNo:                  seated.SeatNo,
+		Full:                    full,
+		Players:                 s.sng.Players(),
+		GameToPlayerChannel:     GetGame2AllPlayerSubject(s.gameCode),
+		HandToAllChannel:        GetHand2AllPlayerSubject(s.gameCode),
+		HandToPlayerChannel:     GetHand2PlayerSubject(s.gameCode, seated.PlayerID),
+		HandToPlayerTextChannel: GetHand2PlayerTextSubject(s.gameCode, seated.PlayerID),
+		PlayerToHandChannel:     GetPlayer2HandSubject(s.gameCode),
+		ClientAliveChannel:      GetClientAliveSubject(s.gameCode),
+		TournamentPlayerChannel: GetTournamentPlayerSubject(tournamentID, seated.PlayerID),
+	}, nil
+}
+
+// SitAndGoState returns the state of the sit-and-go.
+func (gm *GameManager) SitAndGoState(tournamentID uint32) (game.SitAndGoState, error) {
+	s, err := gm.getSitAndGo(tournamentID)
+	if err != nil {
+		return game.SitAndGoState{}, err
+	}
+	return s.sng.State(), nil
+}
+
+func (gm *GameManager) startSitAndGo(s *sitAndGo) {
+	tournamentID := s.sng.TournamentID()
+	natsGame, err := gm.NewTournamentGame(s.gameCode, uint64(tournamentID), sitAndGoTableNo)
+	if err != nil {
+		natsGMLogger.Error().Err(err).Msgf("Could not create the table of sit-and-go %d", tournamentID)
+		return
+	}
+	s.lock.Lock()
+	s.natsGame = natsGame
+	s.lock.Unlock()
+
+	buttonSeat := uint32(rand.Intn(int(s.sng.Config().Seats))) + 1
+	err = s.sng.Start(buttonSeat)
+	if err != nil {
+		natsGMLogger.Error().Err(err).Msgf("Could not start sit-and-go %d", tournamentID)
+		return
+	}
+	natsGMLogger.Info().
+		Str(logging.GameCodeKey, s.gameCode).
+		Msgf("Sit-and-go %d started", tournamentID)
+	gm.dealSitAndGoHand(s)
+}
+
+// dealSitAndGoHand deals the next hand, or waits for the end of the break.
+func (gm *GameManager) dealSitAndGoHand(s *sitAndGo) {
+	tournamentID := s.sng.TournamentID()
+	if remaining := s.sng.BreakRemaining(); remaining > 0 {
+		natsGMLogger.Info().Msgf("Sit-and-go %d is on a break for %s", tournamentID, remaining)
+		time.AfterFunc(remaining, func() {
+			gm.dealSitAndGoHand(s)
+		})
+		return
+	}
+	hand, err := s.sng.NextHand()
+	if err != nil {
+		natsGMLogger.Error().Err(err).Msgf("Could not get the next hand of sit-and-go %d", tournamentID)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	hand.GameID = s.natsGame.gameID
+	hand.GameCode = s.gameCode
+	hand.TournamentURL = util.Env.GetGameServerURL()
+	if hand.BigBlind != s.lastLevel.BigBlind || hand.Ante != s.lastLevel.Ante {
+		s.natsGame.serverGame.Announce(game.AnnouncementBlindsUp, []string{
+			fmt.Sprintf("%v", hand.SmallBlind),
+			fmt.Sprintf("%v", hand.BigBlind),
+			fmt.Sprintf("%v", hand.Ante),
+		})
+	}
+	s.lastLevel = game.BlindLevel{SmallBlind: hand.SmallBlind, BigBlind: hand.BigBlind, Ante: hand.Ante}
+	err = s.natsGame.serverGame.DealTournamentHand(hand)
+	if err != nil {
+		natsGMLogger.Error().Err(err).
+			Str(logging.GameCodeKey, s.gameCode).
+			Msgf("Could not deal sit-and-go %d hand %d", tournamentID, hand.HandNum)
+	}
+}
+
+// SitAndGoHandResult places the players busted in the hand and deals the next
+// hand after the result pause. The table is closed when one player is left.
+func (gm *GameManager) SitAndGoHandResult(tournamentID uint32, tableNo uint32, handNum uint32) error {
+	s, err := gm.getSitAndGo(tournamentID)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	natsGame := s.natsGame
+	s.lock.Unlock()
+	if natsGame == nil || tableNo != sitAndGoTableNo {
+		return fmt.Errorf("Sit-and-go %d does not have table %d", tournamentID, tableNo)
+	}
+
+	for _, e := range s.sng.HandEnded(natsGame.serverGame.TableStacks()) {
+		natsGMLogger.Info().
+			Msgf("Sit-and-go %d hand %d: player %d busted with %v chips. Place: %d tied: %v",
+				tournamentID, handNum, e.PlayerID, e.StartingStack, e.Place, e.Tied)
+	}
+	if s.sng.Finished() {
+		go gm.endSitAndGo(s)
+		return nil
+	}
+	pause := time.Duration(s.sng.Config().ResultPauseTime) * time.Second
+	time.AfterFunc(pause, func() {
+		gm.dealSitAndGoHand(s)
+	})
+	return nil
+}
+
+func (gm *GameManager) endSitAndGo(s *sitAndGo) {
+	tournamentID := s.sng.TournamentID()
+	state := s.sng.State()
+	for _, standing := range state.Standings {
+		natsGMLogger.Info().
+			Msgf("Sit-and-go %d place %d: player %d (%s) prize: %v",
+				tournamentID, standing.Place, standing.PlayerID, standing.Name, standing.Prize)
+	}
+	// the players are told before the table stops sending messages
+	s.natsGame.serverGame.Announce(game.AnnouncementTableClosed, []string{fmt.Sprintf("%d", sitAndGoTableNo)})
+	_, err := s.natsGame.serverGame.CloseTable(tableCloseTimeout)
+	if err != nil {
+		natsGMLogger.Error().Err(err).Msgf("Could not close the table of sit-and-go %d", tournamentID)
+	}
+	gm.EndNatsGame(s.natsGame.gameID)
+	gm.sitAndGos.Remove(tournamentKey(tournamentID))
+	natsGMLogger.Info().Msgf("Sit-and-go %d ended after %d hands", tournamentID, state.HandNum)
+}
@@ -24,6 +24,10 @@ func GetHand2PlayerSubject(gameCode string, playerID uint64) string {
 	return fmt.Sprintf("hand.%s.player.%d", gameCode, playerID)
 }
 
+func GetHand2PlayerTextSubject(gameCode string, playerID uint64) string {
+	return fmt.Sprintf("hand.%s.player.%d.text", gameCode, playerID)
+}
+
 func GetClientAliveSubject(gameCode string) string {
 	return fmt.Sprintf("clientalive.%s", gameCode)
 }
@@ -0,0 +1,226 @@
+package nats
+
+import (
+	"fmt"
+	"time"
+
+	"voyager.com/logging"
+	"voyager.com/server/game"
+)
+
+// The game server keeps the blind clock of each tournament it hosts tables
+// for. The clock is checked every second. When the level changes, the tables
+// of the tournament are told the blinds of the next hand (or the break), and
+// the tournament hands are dealt with the blinds of the current level instead
+// of the blinds in the RunHand request.
+
+const tournamentClockInterval = time.Second
+
+type tournamentClock struct {
+	tournamentID uint32
+	clock        *game.BlindClock
+	chStop       chan bool
+}
+
+func tournamentKey(tournamentID uint32) string {
+	return fmt.Sprintf("%d", tournamentID)
+}
+
+// SetTournamentStructure sets the blind levels of the tournament and starts
+// the blind clock at the first level. The clock of a previous structure is
+// stopped.
+func (gm *GameManager) SetTournamentStructure(tournamentID uint32, levels []game.BlindLevel) error {
+	clock, err := game.NewBlindClock(levels, nil)
+	if err != nil {
+		return err
+	}
+	gm.StopTournamentClock(tournamentID)
+
+	tc := &tournamentClock{
+		tournamentID: tournamentID,
+		clock:        clock,
+		chStop:       make(chan bool, 1),
+	}
+	clock.Start()
+	gm.tournamentClocks.Set(tournamentKey(tournamentID), tc)
+	natsGMLogger.Info().
+		Msgf("Tournament %d blind clock started with %d levels", tournamentID, len(levels))
+	go gm.runTournamentClock(tc)
+	return nil
+}
+
+// StopTournamentClock stops and removes the blind clock of the tournament.
+func (gm *GameManager) StopTournamentClock(tournamentID uint32) {
+	v, exists := gm.tournamentClocks.Pop(tournamentKey(tournamentID))
+	if !exists {
+		return
+	}
+	v.(*tournamentClock).chStop <- true
+}
+
+func (gm *GameManager) getTournamentClock(tournamentID uint32) (*game.BlindClock, error) {
+	v, exists := gm.tournamentClocks.Get(tournamentKey(tournamentID))
+	if !exists {
+		return nil, fmt.Errorf("Tournament %d does not have a blind clock", tournamentID)
+	}
+	return v.(*tournamentClock).clock, nil
+}
+
+// PauseTournamentClock pauses the blind clock of the tournament.
+func (gm *GameManager) PauseTournamentClock(tournamentID uint32) error {
+	clock, err := gm.getTournamentClock(tournamentID)
+	if err != nil {
+		return err
+	}
+	return clock.Pause()
+}
+
+// ResumeTournamentClock resumes the blind clock of the tournament.
+func (gm *GameManager) ResumeTournamentClock(tournamentID uint32) error {
+	clock, err := gm.getTournamentClock(tournamentID)
+	if err != nil {
+		return err
+	}
+	return clock.Resume()
+}
+
+// TournamentClockState returns the current level of the tournament.
+func (gm *GameManager) TournamentClockState(tournamentID uint32) (game.BlindClockState, error) {
+	clock, err := gm.getTournamentClock(tournamentID)
+	if err != nil {
+		return game.BlindClockState{}, err
+	}
+	return clock.State(), nil
+}
+
+func (gm *GameManager) runTournamentClock(tc *tournamentClock) {
+	ticker := time.NewTicker(tournamentClockInterval)
+	defer ticker.Stop()
+	levelIndex := tc.clock.State().LevelIndex
+	for {
+		select {
+		case <-tc.chStop:
+			return
+		case <-ticker.C:
+			state := tc.clock.State()
+			if state.LevelIndex == levelIndex {
+				continue
+			}
+			levelIndex = state.LevelIndex
+			gm.announceLevel(tc.tournamentID, state)
+			gm.updateBuyInPeriods(tc.tournamentID, levelIndex)
+		}
+	}
+}
+
+// announceLevel tells the tables of the tournament about the new level.
+func (gm *GameManager) announceLevel(tournamentID uint32, state game.BlindClockState) {
+	var announcementType string
+	var params []string
+	level := state.Level
+	if level.Break {
+		announcementType = game.AnnouncementBreak
+		params = []string{fmt.Sprintf("%d", int(level.Duration.Minutes()))}
+	} else {
+		announcementType = game.AnnouncementBlindsUp
+		params = []string{
+			fmt.Sprintf("%v", level.SmallBlind),
+			fmt.Sprintf("%v", level.BigBlind),
+			fmt.Sprintf("%v", level.Ante),
+			fmt.Sprintf("%d", level.Level),
+		}
+	}
+	natsGMLogger.Info().
+		Msgf("Tournament %d level %d: %s %v", tournamentID, state.LevelIndex+1, announcementType, params)
+	for _, natsGame := range gm.tournamentTables(tournamentID) {
+		natsGame.serverGame.Announce(announcementType, params)
+	}
+}
+
+func (gm *GameManager) tournamentTables(tournamentID uint32) []*NatsGame {
+	var tables []*NatsGame
+	for item := range gm.activeGames.IterBuffered() {
+		natsGame := item.Val.(*NatsGame)
+		if natsGame.tournamentID == uint64(tournamentID) {
+			tables = append(tables, natsGame)
+		}
+	}
+	return tables
+}
+
+// applyTournamentLevel sets the blinds of the current level of the tournament
+// in the new hand. Returns an error during a break. The hand keeps its blinds
+// if the tournament doesn't have a blind clock.
+func (gm *GameManager) applyTournamentLevel(tournamentID uint32, hand *game.NewHandInfo) error {
+	clock, err := gm.getTournamentClock(tournamentID)
+	if err != nil {
+		return nil
+	}
+	state := clock.State()
+	if state.Level.Break {
+		return fmt.Errorf("Tournament %d is on a break for %s", tournamentID, state.Remaining.Round(time.Second))
+	}
+	hand.SmallBlind = state.Blinds.SmallBlind
+	hand.BigBlind = state.Blinds.BigBlind
+	hand.Ante = state.Blinds.Ante
+	natsGMLogger.Debug().
+		Str(logging.GameCodeKey, hand.GameCode).
+		Msgf("Tournament %d hand %d at level %d: %v/%v ante %v", tournamentID, hand.HandNum, state.LevelIndex+1, hand.SmallBlind, hand.BigBlind, hand.Ante)
+	return nil
+}
+
+// tableCloseTimeout is how long a table being terminated waits for the hand
+// in progress to end.
+const tableCloseTimeout = 5 * time.Minute
+
+// PlayerMove is the new seat of a player of a table being terminated.
+type PlayerMove struct {
+	PlayerID   uint64
+	NewTableNo uint32
+	NewSeatNo  uint32
+	GameInfo   string
+}
+
+// TerminateTable closes the tournament table. The hand in progress is played
+// to the end, the moved players are sent to their new tables and the players
+// left at the table are told that the table is closed. Returns the stacks of
+// the players after the last hand.
+func (gm *GameManager) TerminateTable(gameCode string, tournamentID uint32, tableNo uint32, moves []PlayerMove) ([]game.TableStack, error) {
+	v, exists := gm.gameCodeToID.Get(gameCode)
+	if !exists {
+		return nil, fmt.Errorf("Game %s does not exist", gameCode)
+	}
+	gameIDStr := v.(string)
+	v, exists = gm.activeGames.Get(gameIDStr)
+	if !exists {
+		return nil, fmt.Errorf("Game %s does not exist", gameCode)
+	}
+	natsGame := v.(*NatsGame)
+	if natsGame.tournamentID != uint64(tournamentID) || natsGame.tableNo != tableNo {
+		return nil, fmt.Errorf("Game %s is not table %d of tournament %d", gameCode, tableNo, tournamentID)
+	}
+
+	stacks, err := natsGame.serverGame.CloseTable(tableCloseTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, move := range moves {
+		err = natsGame.HandlePlayerMovedTable(gameCode, tournamentID, tableNo, move.NewTableNo, move.NewSeatNo, move.PlayerID, move.GameInfo)
+		if err != nil {
+			natsGMLogger.Error().Err(err).
+				Str(logging.GameCodeKey, gameCode).
+				Uint64(logging.PlayerIDKey, move.PlayerID).
+				Msgf("Could not move player to table %d", move.NewTableNo)
+		}
+	}
+	natsGame.serverGame.Announce(game.AnnouncementTableClosed, []string{fmt.Sprintf("%d", tableNo)})
+
+	gm.removeSeatingTable(tournamentID, tableNo)
+	gm.EndNatsGame(natsGame.gameID)
+	gm.handForHandTableRemoved(tournamentID, tableNo)
+	natsGMLogger.Info().
+		Str(logging.GameCodeKey, gameCode).
+		Msgf("Tournament %d table %d terminated with %d players", tournamentID, tableNo, len(stacks))
+	return stacks, nil
+}
@@ -7,6 +7,16 @@ import (
 
 type Card int32
 
+// The joker has the rank after the ace and no suit. It is the byte 0xD0, so
+// the standard cards keep their byte encoding. Jokers are wild: the evaluators
+// play them as the card that makes the best hand.
+const (
+	JokerRank   = 13
+	JokerByte   = uint8(JokerRank << 4)
+	JokerString = "XX"
+	Joker       = Card(JokerRank << 8)
+)
+
 var (
 	intRanks [13]int32
 	strRanks = "23456789TJQKA"
@@ -45,6 +55,9 @@ func init() {
 }
 
 func NewCard(s string) Card {
+	if s == JokerString {
+		return Joker
+	}
 	rankInt := charRankToIntRank[s[0]]
 	suitInt := charSuitToIntSuit[s[1]]
 	rankPrime := primes[rankInt]
@@ -57,6 +70,9 @@ func NewCard(s string) Card {
 }
 
 func NewCardFromByte(cardByte uint8) Card {
+	if cardByte>>4 == JokerRank {
+		return Joker
+	}
 	rankInt := int32(cardByte >> 4)
 	suitInt := int32(cardByte & 0xF)
 	rankPrime := primes[rankInt]
@@ -78,6 +94,9 @@ func (c *Card) UnmarshalJSON(b []byte) error {
 }
 
 func (c Card) String() string {
+	if c.IsJoker() {
+		return JokerString
+	}
 	return string(strRanks[c.Rank()]) + string(intSuitToCharSuit[c.Suit()])
 }
 
@@ -89,6 +108,10 @@ func (c Card) Suit() int32 {
 	return (int32(c) >> 12) & 0xF
 }
 
+func (c Card) IsJoker() bool {
+	return c.Rank() == JokerRank
+}
+
 func (c Card) BitRank() int32 {
 	return (int32(c) >> 16) & 0x1FFF
 }
@@ -130,12 +153,18 @@ func CardToString(card interface{}) string {
 	switch card.(type) {
 	case Card:
 		c := card.(Card)
+		if c.IsJoker() {
+			return JokerString
+		}
 		val := c.GetByte()
 		suit := int(val & 0xF)
 		rank := int((val >> 4) & 0xF)
 		return fmt.Sprintf("%s%s", string(strRanks[rank]), string(prettySuits[suit]))
 	case uint32:
 		c := card.(uint32)
+		if c>>4 == JokerRank {
+			return JokerString
+		}
 		suit := int(c & 0xF)
 		rank := int((c >> 4) & 0xF)
 		return fmt.Sprintf("%s%s", string(strRanks[rank]), string(prettySuits[suit]))
@@ -1,33 +1,179 @@
 package poker
 
 import (
+	crypto_rand "crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"math/rand"
 
 	"github.com/db47h/rand64/v3/xoshiro"
-	"voyager.com/server/util/random"
 )
 
 var (
-	fullDeck *Deck
-	rndSrc   xoshiro.Rng256SS
-	rng      *rand.Rand
+	fullDeck    *Deck
+	defaultRand *rand.Rand
 )
 
 func init() {
 	fullDeck = &Deck{cards: initializeFullCards()}
-	rndSrc = xoshiro.Rng256SS{}
-	rndSrc.Seed(random.NewSeed())
-	rng = rand.New(&rndSrc)
+	defaultRand = NewCryptoRand()
+}
+
+// cryptoSource is a rand.Source over crypto/rand. It has no state, so it can
+// be used from any goroutine.
+type cryptoSource struct{}
+
+func (cryptoSource) Seed(int64) {}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() & (1<<63 - 1))
+}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	_, err := crypto_rand.Read(b[:])
+	if err != nil {
+		panic(fmt.Sprintf("Unable to read from crypto/rand: %v", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// NewCryptoRand returns a random number generator over crypto/rand. The
+// methods that only take numbers from the source (Int63, Uint64, Intn,
+// Shuffle, ...) are safe to use from multiple goroutines. Read is not, it
+// keeps the unread bytes in the generator.
+func NewCryptoRand() *rand.Rand {
+	return rand.New(cryptoSource{})
+}
+
+// NewSeededRand returns a deterministic random number generator for tests and
+// simulations. It is not safe to use from multiple goroutines.
+func NewSeededRand(seed uint64) *rand.Rand {
+	src := &xoshiro.Rng256SS{}
+	src.Seed(int64(seed))
+	return rand.New(src)
+}
+
+// DeckDefinition is the set of cards of a deck. The zero value is the
+// standard 52 card deck.
+type DeckDefinition struct {
+	// MinRank is the lowest rank of the deck: 0 (two) for the standard deck,
+	// 4 (six) for the short deck.
+	MinRank int32
+	// Jokers is the number of jokers added to the deck (at most 2).
+	Jokers int
+	// NumDecks is the number of decks shuffled together. The cards repeat
+	// with more than one deck. 1 if 0.
+	NumDecks int
+}
+
+const (
+	maxJokers   = 2
+	maxNumDecks = 4
+)
+
+var (
+	StandardDeck = DeckDefinition{}
+	ShortDeck    = DeckDefinition{MinRank: 4}
+	JokerDeck    = DeckDefinition{Jokers: 2}
+)
+
+// Validate checks that the deck can be dealt.
+func (d DeckDefinition) Validate() error {
+	if d.MinRank < 0 || d.MinRank > 8 {
+		return fmt.Errorf("Invalid lowest rank %d. It must be between 0 (two) and 8 (ten)", d.MinRank)
+	}
+	if d.Jokers < 0 || d.Jokers > maxJokers {
+		return fmt.Errorf("Invalid number of jokers %d. Max: %d", d.Jokers, maxJokers)
+	}
+	if d.Jokers > 0 && d.MinRank > 0 {
+		// the jokers play as any of the 52 cards
+		return fmt.Errorf("Jokers are not supported in a deck without the low ranks")
+	}
+	if d.NumDecks < 0 || d.NumDecks > maxNumDecks {
+		return fmt.Errorf("Invalid number of decks %d. Max: %d", d.NumDecks, maxNumDecks)
+	}
+	return nil
+}
+
+func (d DeckDefinition) numDecks() int {
+	if d.NumDecks == 0 {
+		return 1
+	}
+	return d.NumDecks
+}
+
+// IsStandard returns whether the deck is a single standard 52 card deck.
+func (d DeckDefinition) IsStandard() bool {
+	return d.MinRank == 0 && d.Jokers == 0 && d.numDecks() == 1
+}
+
+// Size returns the number of cards in the deck.
+func (d DeckDefinition) Size() int {
+	return (13-int(d.MinRank))*4*d.numDecks() + d.Jokers
+}
+
+// Cards returns the cards of the deck before the shuffle: each deck in the
+// order 2s 2h 2d 2c 3s ... Ac (from the lowest rank) and the jokers last.
+func (d DeckDefinition) Cards() []Card {
+	cards := make([]Card, 0, d.Size())
+	for i := 0; i < d.numDecks(); i++ {
+		for rank := d.MinRank; rank < 13; rank++ {
+			for _, suit := range "shdc" {
+				cards = append(cards, NewCard(string(strRanks[rank])+string(suit)))
+			}
+		}
+	}
+	for i := 0; i < d.Jokers; i++ {
+		cards = append(cards, Joker)
+	}
+	return cards
+}
+
+// ValidateDeck checks that the deck bytes are the cards of the deck definition
+// in any order.
+func (d DeckDefinition) ValidateDeck(deck []byte) error {
+	full := d.Cards()
+	if len(deck) != len(full) {
+		return fmt.Errorf("Deck must have %d cards. Got %d", len(full), len(deck))
+	}
+	counts := make(map[byte]int)
+	for _, card := range full {
+		counts[card.GetByte()]++
+	}
+	for _, card := range deck {
+		if counts[card] == 0 {
+			return fmt.Errorf("Invalid or duplicate card %d in the deck", card)
+		}
+		counts[card]--
+	}
+	return nil
 }
 
 type Deck struct {
 	cards               []Card
 	scriptedCardsBySeat map[uint32]CardsInAscii
+	rng                 *rand.Rand
+	definition          DeckDefinition
 }
 
+// NewDeck returns a deck shuffled with crypto/rand.
 func NewDeck() *Deck {
-	deck := &Deck{}
+	return NewDeckWithRand(defaultRand)
+}
+
+// NewDeckWithRand returns a deck that is shuffled with the given random number
+// generator.
+func NewDeckWithRand(rng *rand.Rand) *Deck {
+	deck := &Deck{rng: rng}
+	deck.Shuffle()
+	return deck
+}
+
+// NewDeckFromDefinition returns a deck of the cards of the definition shuffled
+// with the given random number generator (crypto/rand if nil).
+func NewDeckFromDefinition(definition DeckDefinition, rng *rand.Rand) *Deck {
+	deck := &Deck{rng: rng, definition: definition}
 	deck.Shuffle()
 	return deck
 }
@@ -53,15 +199,27 @@ func NewDeckFromBytes(cards []byte, deckIndex int) *Deck {
 }
 
 func CopyDeck(original *Deck) *Deck {
-	deck := &Deck{}
+	deck := &Deck{rng: original.rng, definition: original.definition}
 	deck.cards = make([]Card, len(original.cards))
 	copy(deck.cards, original.cards)
 	return deck
 }
 
+func (deck *Deck) random() *rand.Rand {
+	if deck.rng == nil {
+		return defaultRand
+	}
+	return deck.rng
+}
+
 func (deck *Deck) Shuffle() *Deck {
-	deck.cards = make([]Card, len(fullDeck.cards))
-	copy(deck.cards, fullDeck.cards)
+	if deck.definition.IsStandard() {
+		deck.cards = make([]Card, len(fullDeck.cards))
+		copy(deck.cards, fullDeck.cards)
+	} else {
+		deck.cards = deck.definition.Cards()
+	}
+	rng := deck.random()
 	rng.Shuffle(len(deck.cards), func(i, j int) { deck.cards[i], deck.cards[j] = deck.cards[j], deck.cards[i] })
 	rng.Shuffle(len(deck.cards), func(i, j int) { deck.cards[i], deck.cards[j] = deck.cards[j], deck.cards[i] })
 	deck.box()
@@ -70,6 +228,7 @@ func (deck *Deck) Shuffle() *Deck {
 }
 
 func (deck *Deck) ShuffleWithoutReset() *Deck {
+	rng := deck.random()
 	rng.Shuffle(len(deck.cards), func(i, j int) { deck.cards[i], deck.cards[j] = deck.cards[j], deck.cards[i] })
 	rng.Shuffle(len(deck.cards), func(i, j int) { deck.cards[i], deck.cards[j] = deck.cards[j], deck.cards[i] })
 	deck.box()
@@ -131,8 +290,9 @@ func (deck *Deck) Empty() bool {
 func initializeFullCards() []Card {
 	var cards []Card
 
+	// same order in every run, so that a seeded shuffle deals the same cards
 	for _, rank := range strRanks {
-		for suit := range charSuitToIntSuit {
+		for _, suit := range "shdc" {
 			cards = append(cards, NewCard(string(rank)+string(suit)))
 		}
 	}
@@ -1,6 +1,7 @@
 package poker
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,35 @@ func TestNewDeck(t *testing.T) {
 	assert.False(t, same)
 }
 
+func TestNewDeckWithRand(t *testing.T) {
+	deck1 := NewDeckWithRand(NewSeededRand(42))
+	deck2 := NewDeckWithRand(NewSeededRand(42))
+	assert.Equal(t, deck1.GetBytes(), deck2.GetBytes())
+
+	// a copy keeps shuffling with the same generator
+	copy1 := CopyDeck(deck1)
+	copy2 := CopyDeck(deck2)
+	assert.Equal(t, copy1.Shuffle().GetBytes(), copy2.Shuffle().GetBytes())
+
+	deck3 := NewDeckWithRand(NewSeededRand(43))
+	assert.NotEqual(t, deck1.GetBytes(), deck3.GetBytes())
+}
+
+func TestShuffleConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				deck := NewDeck()
+				assert.ElementsMatch(t, NewDeckNoShuffle().GetBytes(), deck.GetBytes())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func TestDraw(t *testing.T) {
 	deck := NewDeck()
 
@@ -40,3 +70,31 @@ func TestEmpty(t *testing.T) {
 	deck.Draw(1)
 	assert.True(t, deck.Empty())
 }
+
+func TestDeckDefinition(t *testing.T) {
+	assert.True(t, StandardDeck.IsStandard())
+	assert.Equal(t, NewDeckNoShuffle().GetBytes(), CardsToByteCards(StandardDeck.Cards()))
+	assert.Equal(t, 36, ShortDeck.Size())
+	assert.Equal(t, "6s", ShortDeck.Cards()[0].String())
+	assert.Equal(t, 54, JokerDeck.Size())
+	assert.Equal(t, JokerByte, JokerDeck.Cards()[53].GetByte())
+	twoDecks := DeckDefinition{NumDecks: 2}
+	assert.Equal(t, 104, twoDecks.Size())
+
+	assert.Error(t, DeckDefinition{Jokers: 3}.Validate())
+	assert.Error(t, DeckDefinition{MinRank: 9}.Validate())
+	assert.Error(t, DeckDefinition{MinRank: 4, Jokers: 1}.Validate())
+	assert.NoError(t, twoDecks.Validate())
+
+	deck := NewDeckFromDefinition(twoDecks, NewSeededRand(1))
+	assert.Equal(t, 104, deck.Size())
+	assert.NoError(t, twoDecks.ValidateDeck(deck.GetBytes()))
+	assert.NoError(t, twoDecks.ValidateDeck(CopyDeck(deck).Shuffle().GetBytes()))
+	assert.Error(t, StandardDeck.ValidateDeck(deck.GetBytes()[:52]))
+
+	// the joker survives the byte encoding
+	deck = DeckFromBytes(NewDeckFromDefinition(JokerDeck, NewSeededRand(1)).GetBytes())
+	assert.NoError(t, JokerDeck.ValidateDeck(deck.GetBytes()))
+	assert.Equal(t, JokerString, NewCardFromByte(JokerByte).String())
+	assert.Equal(t, Joker, NewCard(JokerString))
+}
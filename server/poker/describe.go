@@ -0,0 +1,198 @@
+package poker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A hand description tells which hand the five cards make, like "Two Pair,
+// Kings and Sevens, Ace kicker" or "8-6 low". The structured form has the i18n
+// key of the hand (Type), the ranks that make the hand (Params) and the
+// kickers, so that the clients can render it in their language. The ranks are
+// the rank characters 2..9, T, J, Q, K and A.
+
+// Hand description types (i18n keys).
+const (
+	DescRoyalFlush    = "ROYAL_FLUSH"
+	DescStraightFlush = "STRAIGHT_FLUSH"
+	DescFourOfAKind   = "FOUR_OF_A_KIND"
+	DescFullHouse     = "FULL_HOUSE"
+	DescFlush         = "FLUSH"
+	DescStraight      = "STRAIGHT"
+	DescThreeOfAKind  = "THREE_OF_A_KIND"
+	DescTwoPair       = "TWO_PAIR"
+	DescPair          = "PAIR"
+	DescHighCard      = "HIGH_CARD"
+	DescLow           = "LOW"
+)
+
+// HandDescription is the structured description of a hand.
+type HandDescription struct {
+	Type    string   `json:"type"`
+	Params  []string `json:"params"`
+	Kickers []string `json:"kickers,omitempty"`
+}
+
+var rankNames = [13]string{"Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Jack", "Queen", "King", "Ace"}
+var rankPluralNames = [13]string{"Twos", "Threes", "Fours", "Fives", "Sixes", "Sevens", "Eights", "Nines", "Tens", "Jacks", "Queens", "Kings", "Aces"}
+
+// DescribeHand describes the high hand of the five cards. rank is the rank of
+// the cards from Evaluate or the Omaha evaluator.
+func DescribeHand(rank int32, cards []Card) HandDescription {
+	// group the cards by rank, the largest groups and the highest ranks first
+	var counts [13]int
+	for _, card := range cards {
+		counts[card.Rank()]++
+	}
+	ranks := make([]int, 0, 5)
+	for r := 12; r >= 0; r-- {
+		if counts[r] > 0 {
+			ranks = append(ranks, r)
+		}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		return counts[ranks[i]] > counts[ranks[j]]
+	})
+
+	var desc HandDescription
+	numPrimary := 1
+	switch RankClass(rank) {
+	case StraightFlush, Straight:
+		desc.Type = DescStraight
+		if RankClass(rank) == StraightFlush {
+			desc.Type = DescStraightFlush
+		}
+		high := ranks[0]
+		if counts[12] > 0 && counts[11] == 0 {
+			// the ace plays low in the wheel (A-6-7-8-9 in the short deck)
+			high = ranks[1]
+		}
+		if desc.Type == DescStraightFlush && high == 12 {
+			desc.Type = DescRoyalFlush
+		}
+		desc.Params = []string{rankChar(high)}
+		return desc
+	case FourOfAKind:
+		desc.Type = DescFourOfAKind
+	case FullHouse:
+		desc.Type = DescFullHouse
+		numPrimary = 2
+	case Flush:
+		desc.Type = DescFlush
+	case ThreeOfAKind:
+		desc.Type = DescThreeOfAKind
+	case TwoPair:
+		desc.Type = DescTwoPair
+		numPrimary = 2
+	case Pair:
+		desc.Type = DescPair
+	default:
+		desc.Type = DescHighCard
+	}
+	if numPrimary > len(ranks) {
+		numPrimary = len(ranks)
+	}
+	for i, r := range ranks {
+		if i < numPrimary {
+			desc.Params = append(desc.Params, rankChar(r))
+		} else {
+			desc.Kickers = append(desc.Kickers, rankChar(r))
+		}
+	}
+	return desc
+}
+
+// DescribeLow describes the 8 or better low hand of the five cards.
+func DescribeLow(cards []Card) HandDescription {
+	values := make([]int, len(cards))
+	for i, card := range cards {
+		values[i] = lowValue(card)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(values)))
+	desc := HandDescription{Type: DescLow}
+	for _, value := range values {
+		r := value - 2
+		if value == 1 {
+			r = 12
+		}
+		desc.Params = append(desc.Params, rankChar(r))
+	}
+	return desc
+}
+
+func lowValue(card Card) int {
+	if card.Rank() == 12 {
+		return 1
+	}
+	return int(card.Rank()) + 2
+}
+
+func rankChar(r int) string {
+	return string(strRanks[r])
+}
+
+func rankIndex(s string) int {
+	return strings.Index(strRanks, s)
+}
+
+// Text returns the description in English.
+func (d HandDescription) Text() string {
+	name := func(i int) string {
+		if i >= len(d.Params) {
+			return ""
+		}
+		return rankNames[rankIndex(d.Params[i])]
+	}
+	plural := func(i int) string {
+		if i >= len(d.Params) {
+			return ""
+		}
+		return rankPluralNames[rankIndex(d.Params[i])]
+	}
+
+	var text string
+	switch d.Type {
+	case DescRoyalFlush:
+		return "Royal Flush"
+	case DescStraightFlush:
+		return fmt.Sprintf("Straight Flush, %s high", name(0))
+	case DescStraight:
+		return fmt.Sprintf("Straight, %s high", name(0))
+	case DescFourOfAKind:
+		text = fmt.Sprintf("Four of a Kind, %s", plural(0))
+	case DescFullHouse:
+		return fmt.Sprintf("Full House, %s full of %s", plural(0), plural(1))
+	case DescFlush:
+		return fmt.Sprintf("Flush, %s high", name(0))
+	case DescThreeOfAKind:
+		text = fmt.Sprintf("Three of a Kind, %s", plural(0))
+	case DescTwoPair:
+		text = fmt.Sprintf("Two Pair, %s and %s", plural(0), plural(1))
+	case DescPair:
+		text = fmt.Sprintf("Pair of %s", plural(0))
+	case DescHighCard:
+		text = fmt.Sprintf("High Card, %s", name(0))
+	case DescLow:
+		lowRanks := make([]string, 0, 2)
+		for i := 0; i < len(d.Params) && i < 2; i++ {
+			lowRanks = append(lowRanks, d.Params[i])
+		}
+		return fmt.Sprintf("%s low", strings.Join(lowRanks, "-"))
+	default:
+		return ""
+	}
+
+	if len(d.Kickers) > 0 {
+		kickers := make([]string, len(d.Kickers))
+		for i, kicker := range d.Kickers {
+			kickers[i] = rankNames[rankIndex(kicker)]
+		}
+		if len(kickers) == 1 {
+			text += fmt.Sprintf(", %s kicker", kickers[0])
+		} else {
+			text += fmt.Sprintf(", %s kickers", strings.Join(kickers, "-"))
+		}
+	}
+	return text
+}
@@ -0,0 +1,57 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeHand(t *testing.T) {
+	tests := []struct {
+		cards   string
+		typ     string
+		params  []string
+		kickers []string
+		text    string
+	}{
+		{"AhKhQhJhTh9c2d", DescRoyalFlush, []string{"A"}, nil, "Royal Flush"},
+		{"5d4d3d2dAd9c2h", DescStraightFlush, []string{"5"}, nil, "Straight Flush, Five high"},
+		{"9s9h9d9cKh2c3d", DescFourOfAKind, []string{"9"}, []string{"K"}, "Four of a Kind, Nines, King kicker"},
+		{"KsKhKd7c7h2c3d", DescFullHouse, []string{"K", "7"}, nil, "Full House, Kings full of Sevens"},
+		{"AhJh9h7h3h2c2d", DescFlush, []string{"A"}, []string{"J", "9", "7", "3"}, "Flush, Ace high"},
+		{"As2d3c4h5s9dKc", DescStraight, []string{"5"}, nil, "Straight, Five high"},
+		{"7s7h7dAcKh2c3d", DescThreeOfAKind, []string{"7"}, []string{"A", "K"}, "Three of a Kind, Sevens, Ace-King kickers"},
+		{"KsKh7d7cAh2c3d", DescTwoPair, []string{"K", "7"}, []string{"A"}, "Two Pair, Kings and Sevens, Ace kicker"},
+		{"KsKh9d7cAh2c3d", DescPair, []string{"K"}, []string{"A", "9", "7"}, "Pair of Kings, Ace-Nine-Seven kickers"},
+		{"AsJh9d7c5h2c3d", DescHighCard, []string{"A"}, []string{"J", "9", "7", "5"}, "High Card, Ace, Jack-Nine-Seven-Five kickers"},
+	}
+	for _, test := range tests {
+		cards, err := ParseCards(test.cards)
+		require.NoError(t, err)
+		rank, best := Evaluate(cards)
+		desc := DescribeHand(rank, best)
+		assert.Equal(t, test.typ, desc.Type, test.cards)
+		assert.Equal(t, test.params, desc.Params, test.cards)
+		assert.Equal(t, test.kickers, desc.Kickers, test.cards)
+		assert.Equal(t, test.text, desc.Text(), test.cards)
+	}
+}
+
+func TestDescribeOmaha(t *testing.T) {
+	playerCards, err := ParseCards("Ad2h8s6c")
+	require.NoError(t, err)
+	board, err := ParseCards("4c3d7hKcQd")
+	require.NoError(t, err)
+	result := EvaluateOmahaHand(playerCards, board)
+	require.True(t, result.LowFound)
+
+	hi := DescribeHand(result.HiRank, result.HiCards[:])
+	assert.Equal(t, DescHighCard, hi.Type)
+	assert.Equal(t, "High Card, Ace, King-Queen-Eight-Seven kickers", hi.Text())
+
+	low := DescribeLow(result.LowCards[:])
+	assert.Equal(t, DescLow, low.Type)
+	assert.Equal(t, []string{"7", "4", "3", "2", "A"}, low.Params)
+	assert.Equal(t, "7-4 low", low.Text())
+}
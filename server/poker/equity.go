@@ -0,0 +1,324 @@
+package poker
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+)
+
+// The equity of the ranges is the average share of the pot each range wins at
+// the showdown, over the combos of the ranges that don't share a card and the
+// boards that complete the partial board. Every valid assignment of combos has
+// the same weight.
+//
+// The exact enumeration goes through every assignment of combos and every
+// board. The Monte Carlo simulation picks random combos (rejecting the
+// assignments with a shared card) and a random board for each trial.
+
+type EquityMethod int
+
+const (
+	// EquityAuto enumerates when the number of showdowns is at most
+	// MaxExactShowdowns and simulates otherwise.
+	EquityAuto EquityMethod = iota
+	EquityExact
+	EquityMonteCarlo
+)
+
+const (
+	MaxExactShowdowns   = 2000000
+	DefaultEquityTrials = 100000
+	// maxComboRejections is the number of times in a row a Monte Carlo trial
+	// can pick combos that share a card before giving up.
+	maxComboRejections = 10000
+)
+
+// EquityConfig configures CalculateEquity.
+type EquityConfig struct {
+	Method EquityMethod
+	// Trials is the number of Monte Carlo trials. DefaultEquityTrials if 0.
+	Trials int
+	// HiLo splits the pot with the 8 or better low hand (Omaha only).
+	HiLo bool
+	// Rand is the random number generator of the Monte Carlo simulation.
+	// crypto/rand is used when not set.
+	Rand *rand.Rand
+}
+
+// RangeEquity is the result of a range.
+type RangeEquity struct {
+	// Equity is the average share of the pot.
+	Equity float64
+	// Win is the fraction of the showdowns the range wins the whole pot alone.
+	Win float64
+	// Tie is the fraction of the showdowns the range wins a part of the pot.
+	Tie float64
+}
+
+// EquityResult is the result of CalculateEquity.
+type EquityResult struct {
+	Ranges []RangeEquity
+	// Showdowns is the number of showdowns enumerated or simulated.
+	Showdowns int
+	Exact     bool
+}
+
+type equityCalculator struct {
+	ranges   [][][]Card
+	board    []Card
+	omaha    bool
+	hiLo     bool
+	numCards int
+
+	// showdown state
+	holes      [][]Card
+	fullBoard  []Card
+	hand       []Card
+	hiRanks    []int32
+	loRanks    []int32
+	shares     []float64
+	equities   []float64
+	wins       []float64
+	ties       []float64
+	numResults int
+}
+
+// CalculateEquity returns the equity of the ranges against each other on the
+// partial board (0 to 5 cards). The combos with a board or a dead card are
+// ignored. The ranges must have the same number of cards: 2 for Hold'em or 4
+// to 6 for Omaha.
+func CalculateEquity(ranges []*Range, board []Card, dead []Card, config EquityConfig) (*EquityResult, error) {
+	if len(ranges) < 2 {
+		return nil, fmt.Errorf("Equity needs at least 2 ranges")
+	}
+	if len(board) > 5 {
+		return nil, fmt.Errorf("Board can't have more than 5 cards. Got %d", len(board))
+	}
+	boardMask := cardMask(board)
+	deadMask := cardMask(dead)
+	if boardMask&deadMask != 0 || len(board)+len(dead) != bits.OnesCount64(boardMask|deadMask) {
+		return nil, fmt.Errorf("Board and dead cards have duplicates")
+	}
+
+	c := &equityCalculator{
+		board:    board,
+		numCards: ranges[0].NumCards(),
+	}
+	c.omaha = c.numCards > 2
+	if c.numCards < 2 || c.numCards == 3 || c.numCards > maxOmahaPlayerCards {
+		return nil, fmt.Errorf("Invalid number of hole cards %d", c.numCards)
+	}
+	if config.HiLo && !c.omaha {
+		return nil, fmt.Errorf("Hi-lo equity is only supported for Omaha")
+	}
+	c.hiLo = config.HiLo
+	numCombos := 1.0
+	for _, r := range ranges {
+		if r.NumCards() != c.numCards {
+			return nil, fmt.Errorf("Range %s has %d cards. Expected %d", r.Notation, r.NumCards(), c.numCards)
+		}
+		var combos [][]Card
+		for _, combo := range r.Combos {
+			if cardMask(combo)&(boardMask|deadMask) == 0 {
+				combos = append(combos, combo)
+			}
+		}
+		if len(combos) == 0 {
+			return nil, fmt.Errorf("Range %s has no combos left with the board and the dead cards", r.Notation)
+		}
+		c.ranges = append(c.ranges, combos)
+		numCombos *= float64(len(combos))
+	}
+	c.holes = make([][]Card, len(ranges))
+	c.fullBoard = make([]Card, 5)
+	copy(c.fullBoard, board)
+	c.hand = make([]Card, 7)
+	c.hiRanks = make([]int32, len(ranges))
+	c.loRanks = make([]int32, len(ranges))
+	c.shares = make([]float64, len(ranges))
+	c.equities = make([]float64, len(ranges))
+	c.wins = make([]float64, len(ranges))
+	c.ties = make([]float64, len(ranges))
+
+	numLive := 52 - len(board) - len(dead) - len(ranges)*c.numCards
+	if numLive < 5-len(board) {
+		return nil, fmt.Errorf("Not enough cards left for the board")
+	}
+	showdowns := numCombos * binomial(numLive, 5-len(board))
+	exact := config.Method == EquityExact || (config.Method == EquityAuto && showdowns <= MaxExactShowdowns)
+	if exact {
+		c.enumerate(0, boardMask|deadMask)
+	} else {
+		trials := config.Trials
+		if trials == 0 {
+			trials = DefaultEquityTrials
+		}
+		rng := config.Rand
+		if rng == nil {
+			rng = NewCryptoRand()
+		}
+		err := c.simulate(trials, boardMask|deadMask, rng)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.numResults == 0 {
+		return nil, fmt.Errorf("Ranges have no combos without a shared card")
+	}
+
+	result := &EquityResult{
+		Ranges:    make([]RangeEquity, len(ranges)),
+		Showdowns: c.numResults,
+		Exact:     exact,
+	}
+	n := float64(c.numResults)
+	for i := range ranges {
+		result.Ranges[i] = RangeEquity{
+			Equity: c.equities[i] / n,
+			Win:    c.wins[i] / n,
+			Tie:    c.ties[i] / n,
+		}
+	}
+	return result, nil
+}
+
+// enumerate assigns the combos of the ranges from index i and then goes
+// through every board.
+func (c *equityCalculator) enumerate(i int, used uint64) {
+	if i < len(c.ranges) {
+		for _, combo := range c.ranges[i] {
+			mask := cardMask(combo)
+			if mask&used != 0 {
+				continue
+			}
+			c.holes[i] = combo
+			c.enumerate(i+1, used|mask)
+		}
+		return
+	}
+
+	live := liveCards(used)
+	missing := 5 - len(c.board)
+	forEachCombination(len(live), missing, func(indexes []int) {
+		for j, index := range indexes {
+			c.fullBoard[len(c.board)+j] = live[index]
+		}
+		c.showdown()
+	})
+}
+
+func (c *equityCalculator) simulate(trials int, used uint64, rng *rand.Rand) error {
+	for trial := 0; trial < trials; trial++ {
+		mask := used
+		for rejections := 0; ; rejections++ {
+			if rejections == maxComboRejections {
+				return fmt.Errorf("Ranges have no combos without a shared card")
+			}
+			mask = used
+			ok := true
+			for i, combos := range c.ranges {
+				combo := combos[rng.Intn(len(combos))]
+				comboMask := cardMask(combo)
+				if comboMask&mask != 0 {
+					ok = false
+					break
+				}
+				c.holes[i] = combo
+				mask |= comboMask
+			}
+			if ok {
+				break
+			}
+		}
+
+		live := liveCards(mask)
+		// partial Fisher-Yates for the missing board cards
+		for j := len(c.board); j < 5; j++ {
+			k := rng.Intn(len(live))
+			c.fullBoard[j] = live[k]
+			live[k] = live[len(live)-1]
+			live = live[:len(live)-1]
+		}
+		c.showdown()
+	}
+	return nil
+}
+
+// showdown evaluates the hands on the full board and adds the pot shares.
+func (c *equityCalculator) showdown() {
+	bestHi, bestLo := int32(MaxHighCard+1), int32(0x7FFFFFF)
+	hiRanks, loRanks := c.hiRanks, c.loRanks
+	for i, hole := range c.holes {
+		if c.omaha {
+			hand := EvaluateOmahaHand(hole, c.fullBoard)
+			hiRanks[i] = hand.HiRank
+			loRanks[i] = 0x7FFFFFF
+			if c.hiLo && hand.LowFound {
+				loRanks[i] = hand.LowRank
+			}
+		} else {
+			c.hand = append(append(c.hand[:0], hole...), c.fullBoard...)
+			hiRanks[i], _ = Evaluate(c.hand)
+		}
+		if hiRanks[i] < bestHi {
+			bestHi = hiRanks[i]
+		}
+		if c.hiLo && loRanks[i] < bestLo {
+			bestLo = loRanks[i]
+		}
+	}
+
+	hiPot := 1.0
+	if c.hiLo && bestLo != 0x7FFFFFF {
+		hiPot = 0.5
+	}
+	for i := range c.shares {
+		c.shares[i] = 0
+	}
+	splitPot(c.shares, hiRanks, bestHi, hiPot)
+	if hiPot < 1 {
+		splitPot(c.shares, loRanks, bestLo, 1-hiPot)
+	}
+	for i, share := range c.shares {
+		c.equities[i] += share
+		if share == 1 {
+			c.wins[i]++
+		} else if share > 0 {
+			c.ties[i]++
+		}
+	}
+	c.numResults++
+}
+
+func splitPot(shares []float64, ranks []int32, best int32, pot float64) {
+	winners := 0
+	for _, rank := range ranks {
+		if rank == best {
+			winners++
+		}
+	}
+	for i, rank := range ranks {
+		if rank == best {
+			shares[i] += pot / float64(winners)
+		}
+	}
+}
+
+// liveCards returns the cards that are not in the mask.
+func liveCards(used uint64) []Card {
+	live := make([]Card, 0, 52)
+	for _, card := range allCards {
+		if cardMask([]Card{card})&used == 0 {
+			live = append(live, card)
+		}
+	}
+	return live
+}
+
+func binomial(n int, k int) float64 {
+	result := 1.0
+	for i := 0; i < k; i++ {
+		result = result * float64(n-i) / float64(i+1)
+	}
+	return result
+}
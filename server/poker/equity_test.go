@@ -0,0 +1,95 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseRangesForTest(t *testing.T, notations ...string) []*Range {
+	ranges := make([]*Range, len(notations))
+	for i, notation := range notations {
+		r, err := ParseRange(notation, nil)
+		require.NoError(t, err)
+		ranges[i] = r
+	}
+	return ranges
+}
+
+func parseCardsForTest(t *testing.T, s string) []Card {
+	cards, err := ParseCards(s)
+	require.NoError(t, err)
+	return cards
+}
+
+func TestEquityExact(t *testing.T) {
+	// the kings need one of the two kings on the river
+	ranges := parseRangesForTest(t, "AsAh", "KsKh")
+	result, err := CalculateEquity(ranges, parseCardsForTest(t, "2c3d8h9c"), nil, EquityConfig{})
+	require.NoError(t, err)
+	assert.True(t, result.Exact)
+	assert.Equal(t, 44, result.Showdowns)
+	assert.InDelta(t, 42.0/44, result.Ranges[0].Equity, 1e-9)
+	assert.InDelta(t, 2.0/44, result.Ranges[1].Equity, 1e-9)
+	assert.InDelta(t, 42.0/44, result.Ranges[0].Win, 1e-9)
+
+	// a dead king leaves one out
+	result, err = CalculateEquity(ranges, parseCardsForTest(t, "2c3d8h9c"), parseCardsForTest(t, "Kd"), EquityConfig{})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0/43, result.Ranges[1].Equity, 1e-9)
+
+	// the same hand splits
+	ranges = parseRangesForTest(t, "AK", "AK")
+	result, err = CalculateEquity(ranges, parseCardsForTest(t, "2c3d8h9c"), nil, EquityConfig{})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, result.Ranges[0].Equity, 0.01)
+	assert.InDelta(t, result.Ranges[0].Equity, result.Ranges[1].Equity, 1e-9)
+	assert.InDelta(t, 1, result.Ranges[0].Equity+result.Ranges[1].Equity, 1e-9)
+}
+
+func TestEquityMonteCarlo(t *testing.T) {
+	ranges := parseRangesForTest(t, "AA", "KK")
+	result, err := CalculateEquity(ranges, nil, nil, EquityConfig{Trials: 50000, Rand: NewSeededRand(1)})
+	require.NoError(t, err)
+	assert.False(t, result.Exact)
+	assert.Equal(t, 50000, result.Showdowns)
+	assert.InDelta(t, 0.82, result.Ranges[0].Equity, 0.01)
+}
+
+func TestEquityOmaha(t *testing.T) {
+	// the flush wins the high and the wheel wins the low
+	ranges := parseRangesForTest(t, "Ad2h5s9s", "AcJc9h9d")
+	board := parseCardsForTest(t, "2c3d4hKcQc")
+	result, err := CalculateEquity(ranges, board, nil, EquityConfig{HiLo: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Showdowns)
+	assert.InDelta(t, 0.5, result.Ranges[0].Equity, 1e-9)
+	assert.InDelta(t, 0.5, result.Ranges[1].Equity, 1e-9)
+	assert.InDelta(t, 1, result.Ranges[0].Tie, 1e-9)
+
+	// high only
+	result, err = CalculateEquity(ranges, board, nil, EquityConfig{})
+	require.NoError(t, err)
+	assert.InDelta(t, 1, result.Ranges[1].Equity, 1e-9)
+
+	// the filter range on the flop
+	ranges = parseRangesForTest(t, "AA**ds", "KK**")
+	result, err = CalculateEquity(ranges, board[:3], nil, EquityConfig{Trials: 5000, Rand: NewSeededRand(2)})
+	require.NoError(t, err)
+	assert.False(t, result.Exact)
+	assert.InDelta(t, 1, result.Ranges[0].Equity+result.Ranges[1].Equity, 1e-9)
+}
+
+func TestEquityErrors(t *testing.T) {
+	_, err := CalculateEquity(parseRangesForTest(t, "AA"), nil, nil, EquityConfig{})
+	assert.Error(t, err)
+	_, err = CalculateEquity(parseRangesForTest(t, "AhAs", "AhKs"), nil, nil, EquityConfig{})
+	assert.Error(t, err)
+	_, err = CalculateEquity(parseRangesForTest(t, "AA", "KKQQ"), nil, nil, EquityConfig{})
+	assert.Error(t, err)
+	_, err = CalculateEquity(parseRangesForTest(t, "AA", "KK"), nil, nil, EquityConfig{HiLo: true})
+	assert.Error(t, err)
+	_, err = CalculateEquity(parseRangesForTest(t, "AhAs", "KK"), parseCardsForTest(t, "Ah2c3d"), nil, EquityConfig{})
+	assert.Error(t, err)
+}
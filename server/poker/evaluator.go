@@ -2,6 +2,7 @@ package poker
 
 import (
 	"fmt"
+	"math/bits"
 )
 
 var table *lookupTable
@@ -40,13 +41,28 @@ func RankString(rank int32) string {
 	return rankClassToString[RankClass(rank)]
 }
 
+// Evaluate returns the rank and the best five cards of 5, 6 or 7 cards. The 6
+// and 7 card hands are looked up directly unless the combination evaluator is
+// selected with SetHandEvaluator. Jokers are wild.
 func Evaluate(cards []Card) (int32, []Card) {
+	if hasSpecialCards(cards, nil) {
+		if len(cards) < 5 || len(cards) > 7 {
+			panic("Only support 5, 6 and 7 cards.")
+		}
+		return evaluateSpecial(cards)
+	}
 	switch len(cards) {
 	case 5:
 		return five(cards...)
 	case 6:
+		if GetHandEvaluator() == LookupEvaluator {
+			return lookupEvaluate(cards)
+		}
 		return six(cards...)
 	case 7:
+		if GetHandEvaluator() == LookupEvaluator {
+			return lookupEvaluate(cards)
+		}
 		return seven(cards...)
 	default:
 		panic("Only support 5, 6 and 7 cards.")
@@ -56,12 +72,18 @@ func Evaluate(cards []Card) (int32, []Card) {
 func five(cards ...Card) (int32, []Card) {
 	if cards[0]&cards[1]&cards[2]&cards[3]&cards[4]&0xF000 != 0 {
 		handOR := (cards[0] | cards[1] | cards[2] | cards[3] | cards[4]) >> 16
-		prime := primeProductFromRankBits(int32(handOR))
-		return table.flushLookup[prime], cards
+		// a repeated card (more than one deck) is not a flush
+		if bits.OnesCount32(uint32(handOR)) == 5 {
+			return table.flushRanks[handOR], cards
+		}
 	}
 
 	prime := primeProductFromHand(cards)
-	return table.unsuitedLookup[prime], cards
+	rank, ok := table.unsuitedLookup[prime]
+	if !ok {
+		rank = fiveOfAKindRank(cards[0].Rank())
+	}
+	return rank, cards
 }
 
 func six(cards ...Card) (int32, []Card) {
@@ -98,93 +120,3 @@ func seven(cards ...Card) (int32, []Card) {
 
 	return minimum, bestCards
 }
-
-type OmahaResult struct {
-	HiRank   int32
-	HiCards  []Card
-	LowFound bool
-	LowRank  int32
-	LowCards []Card
-}
-
-type HighHand struct {
-	HiRank  int32
-	HiCards []Card
-}
-
-func EvaluateOmaha(playerCards []Card, boardCards []Card) OmahaResult {
-	minimum := int32(MaxHighCard)
-	lowScore := int32(0x7FFFFFF)
-
-	playerPairs := make([][]Card, 0)
-	for pair := range combinations(playerCards, 2) {
-		playerPairs = append(playerPairs, pair)
-	}
-	boardPairs := make([][]Card, 0)
-	for pair := range combinations(boardCards, 3) {
-		boardPairs = append(boardPairs, pair)
-	}
-	bestCards := make([]Card, 5)
-	lowCards := make([]Card, 5)
-	lowFound := false
-	for _, playerPair := range playerPairs {
-		for _, boardPair := range boardPairs {
-			cards := make([]Card, 0)
-			cards = append(cards, playerPair...)
-			cards = append(cards, boardPair...)
-			// str := CardsToString(cards)
-			score, _ := five(cards...)
-			// rankText := RankString(score)
-			// fmt.Printf("%s score: %d rank: %s\n", str, score, rankText)
-			if score < minimum {
-				minimum = score
-				copy(bestCards, cards)
-			}
-
-			isLow, score := table.getLowRank(cards)
-			if isLow && score < lowScore {
-				copy(lowCards, cards)
-				lowFound = true
-				lowScore = score
-			}
-		}
-	}
-	return OmahaResult{
-		HiRank:   minimum,
-		HiCards:  bestCards,
-		LowFound: lowFound,
-		LowRank:  lowScore,
-		LowCards: lowCards,
-	}
-}
-
-func EvaluateHighHand(playerCards []Card, boardCards []Card) HighHand {
-	minimum := int32(MaxHighCard)
-
-	playerPairs := make([][]Card, 0)
-	for pair := range combinations(playerCards, 2) {
-		playerPairs = append(playerPairs, pair)
-	}
-	boardPairs := make([][]Card, 0)
-	for pair := range combinations(boardCards, 3) {
-		boardPairs = append(boardPairs, pair)
-	}
-	bestCards := make([]Card, 5)
-	for _, playerPair := range playerPairs {
-		for _, boardPair := range boardPairs {
-			cards := make([]Card, 0)
-			cards = append(cards, playerPair...)
-			cards = append(cards, boardPair...)
-			score, _ := five(cards...)
-			if score < minimum {
-				minimum = score
-				copy(bestCards, cards)
-			}
-		}
-	}
-
-	return HighHand{
-		HiRank:  minimum,
-		HiCards: bestCards,
-	}
-}
@@ -0,0 +1,154 @@
+package poker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// Provably fair shuffle.
+//
+// The deck of a hand is derived from a random server seed, the client seeds
+// of the players in the hand (in seat order) and a nonce that is incremented
+// every time the deck is shuffled again for the same hand. The server seed of
+// a hand is generated during the previous hand, and its commitment (SHA-256 of
+// the server seed) is published with the previous hand, before the client
+// seeds of the hand are collected. The seeds are revealed in the hand result,
+// so anyone can check the server seed against the commitment and derive the
+// deck again.
+//
+// The nonce sequence is fixed by the seeds: the nonces start at 1, and a deck
+// is only thrown away when the dealing policy says so for the cards of that
+// deck. The coin flips of the dealing policy come from the seeds too (FairCoin),
+// so the deck dealt is the only one the policy allows.
+//
+// The deck is derived as follows.
+//   key = SHA-256(server seed || for each client seed: 0x00 || client seed || nonce as 4 byte big endian)
+//   block i of the random stream = SHA-256(key || i as 8 byte big endian)
+// Each block gives four 8 byte big endian numbers. Starting from the deck in
+// the order 2s 2h 2d 2c 3s ... Ac, for i from 51 down to 1, a number n below
+// the largest multiple of i+1 that fits in 64 bits is taken from the stream
+// (larger numbers are skipped) and card i is swapped with card n mod (i+1).
+// The other decks start from the order of DeckDefinition.Cards and i goes
+// from the deck size - 1 down to 1.
+// The coin flip of a nonce is the lowest bit of the first number of block
+// 2^64-1 of the random stream, which the shuffle never reaches.
+
+const ServerSeedLen = 32
+
+// ShuffleSeeds are the inputs of the provably fair shuffle.
+type ShuffleSeeds struct {
+	ServerSeed  []byte
+	ClientSeeds []string
+	Nonce       uint32
+	// Deck is the definition of the deck (the standard deck if not set).
+	Deck DeckDefinition
+}
+
+// NewServerSeed returns a server seed from the random number generator
+// (crypto/rand in the game server). The seed is read with Uint64, which keeps
+// no state in the generator of NewCryptoRand, so the generator can be shared.
+func NewServerSeed(rng *rand.Rand) []byte {
+	seed := make([]byte, ServerSeedLen)
+	for i := 0; i < ServerSeedLen; i += 8 {
+		binary.BigEndian.PutUint64(seed[i:], rng.Uint64())
+	}
+	return seed
+}
+
+// seedStream is the random stream of the shuffle seeds.
+type seedStream struct {
+	key     [sha256.Size]byte
+	counter uint64
+	block   [sha256.Size]byte
+	next    int
+}
+
+func newSeedStream(seeds ShuffleSeeds) *seedStream {
+	h := sha256.New()
+	h.Write(seeds.ServerSeed)
+	for _, clientSeed := range seeds.ClientSeeds {
+		h.Write([]byte{0})
+		h.Write([]byte(clientSeed))
+	}
+	var nonce [4]byte
+	binary.BigEndian.PutUint32(nonce[:], seeds.Nonce)
+	h.Write(nonce[:])
+
+	s := &seedStream{next: sha256.Size}
+	copy(s.key[:], h.Sum(nil))
+	return s
+}
+
+func (s *seedStream) Uint64() uint64 {
+	if s.next == sha256.Size {
+		var input [sha256.Size + 8]byte
+		copy(input[:], s.key[:])
+		binary.BigEndian.PutUint64(input[sha256.Size:], s.counter)
+		s.block = sha256.Sum256(input[:])
+		s.counter++
+		s.next = 0
+	}
+	v := binary.BigEndian.Uint64(s.block[s.next:])
+	s.next += 8
+	return v
+}
+
+// Intn returns a uniform number in [0, n).
+func (s *seedStream) Intn(n int) int {
+	bound := uint64(n)
+	limit := ^uint64(0) - (^uint64(0)%bound+1)%bound
+	for {
+		v := s.Uint64()
+		if v <= limit {
+			return int(v % bound)
+		}
+	}
+}
+
+// FairDeck returns the deck derived from the shuffle seeds.
+func FairDeck(seeds ShuffleSeeds) *Deck {
+	deck := &Deck{cards: seeds.Deck.Cards(), definition: seeds.Deck}
+	stream := newSeedStream(seeds)
+	for i := len(deck.cards) - 1; i > 0; i-- {
+		j := stream.Intn(i + 1)
+		deck.cards[i], deck.cards[j] = deck.cards[j], deck.cards[i]
+	}
+	return deck
+}
+
+// FairCoin returns the coin flip derived from the shuffle seeds.
+func FairCoin(seeds ShuffleSeeds) bool {
+	stream := newSeedStream(seeds)
+	stream.counter = ^uint64(0)
+	return stream.Uint64()&1 == 1
+}
+
+// SeedCommitment returns the hex encoded SHA-256 of the server seed.
+func SeedCommitment(serverSeed []byte) string {
+	sum := sha256.Sum256(serverSeed)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyShuffle checks the server seed against the commitment that was
+// published before the client seeds were collected, and derives the deck from
+// the shuffle seeds. It returns the deck in bytes.
+func VerifyShuffle(seeds ShuffleSeeds, commitment string) ([]byte, error) {
+	if len(seeds.ServerSeed) != ServerSeedLen {
+		return nil, fmt.Errorf("Server seed must be %d bytes. Got %d bytes", ServerSeedLen, len(seeds.ServerSeed))
+	}
+	expected, err := hex.DecodeString(commitment)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid commitment")
+	}
+	actual := sha256.Sum256(seeds.ServerSeed)
+	if !bytes.Equal(expected, actual[:]) {
+		return nil, fmt.Errorf("Server seed %s does not match the commitment %s", hex.EncodeToString(seeds.ServerSeed), commitment)
+	}
+	return FairDeck(seeds).GetBytes(), nil
+}
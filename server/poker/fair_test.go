@@ -0,0 +1,112 @@
+package poker
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFairDeck(t *testing.T) {
+	serverSeed := NewServerSeed(NewCryptoRand())
+	require.Len(t, serverSeed, ServerSeedLen)
+
+	seeds := ShuffleSeeds{ServerSeed: serverSeed, ClientSeeds: []string{"alice", "bob"}, Nonce: 1}
+	deck := FairDeck(seeds).GetBytes()
+	assert.Equal(t, deck, FairDeck(seeds).GetBytes())
+	assert.ElementsMatch(t, NewDeckNoShuffle().GetBytes(), deck)
+
+	// every seed changes the deck
+	other := seeds
+	other.Nonce = 2
+	assert.NotEqual(t, deck, FairDeck(other).GetBytes())
+	other = seeds
+	other.ClientSeeds = []string{"bob", "alice"}
+	assert.NotEqual(t, deck, FairDeck(other).GetBytes())
+	other = seeds
+	other.ClientSeeds = []string{"alic", "ebob"}
+	assert.NotEqual(t, deck, FairDeck(other).GetBytes())
+}
+
+func TestNewServerSeedShared(t *testing.T) {
+	// one crypto generator can be shared by the goroutines
+	rng := NewCryptoRand()
+	seeds := make(chan string, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(seeds); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seeds <- string(NewServerSeed(rng))
+		}()
+	}
+	wg.Wait()
+	close(seeds)
+	unique := make(map[string]bool)
+	for seed := range seeds {
+		assert.Len(t, seed, ServerSeedLen)
+		unique[seed] = true
+	}
+	assert.Len(t, unique, cap(seeds))
+}
+
+func TestFairDeckKnownSeed(t *testing.T) {
+	// The derivation is published for the players to verify the hands, so the
+	// deck of known seeds must not change.
+	seeds := ShuffleSeeds{ServerSeed: bytes.Repeat([]byte{1}, ServerSeedLen), ClientSeeds: []string{"seed"}, Nonce: 1}
+	deck := FairDeck(seeds).GetBytes()
+	assert.Equal(t, "[ T♠  T❤  Q♠  8♦  T♣ ]", CardsToString(deck[:5]))
+	assert.Equal(t, "72cd6e8422c407fb6d098690f1130b7ded7ec2f7f5e1d30bd9d521f015363793", SeedCommitment(seeds.ServerSeed))
+}
+
+func TestFairCoin(t *testing.T) {
+	seeds := ShuffleSeeds{ServerSeed: make([]byte, ServerSeedLen), ClientSeeds: []string{"seed"}}
+	heads := 0
+	for nonce := uint32(1); nonce <= 2000; nonce++ {
+		seeds.Nonce = nonce
+		flip := FairCoin(seeds)
+		assert.Equal(t, flip, FairCoin(seeds))
+		if flip {
+			heads++
+		}
+	}
+	assert.InDelta(t, 1000, heads, 100)
+}
+
+func TestSeedStreamIntn(t *testing.T) {
+	stream := newSeedStream(ShuffleSeeds{ServerSeed: make([]byte, ServerSeedLen)})
+	counts := make([]int, 3)
+	for i := 0; i < 30000; i++ {
+		n := stream.Intn(3)
+		require.True(t, n >= 0 && n < 3)
+		counts[n]++
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 10000, count, 500)
+	}
+}
+
+func TestVerifyShuffle(t *testing.T) {
+	serverSeed := NewServerSeed(NewCryptoRand())
+	seeds := ShuffleSeeds{ServerSeed: serverSeed, ClientSeeds: []string{"alice", "", "carol"}, Nonce: 3}
+	deck := FairDeck(seeds).GetBytes()
+	commitment := SeedCommitment(serverSeed)
+
+	verified, err := VerifyShuffle(seeds, commitment)
+	require.NoError(t, err)
+	assert.Equal(t, deck, verified)
+
+	seeds.ServerSeed = NewServerSeed(NewCryptoRand())
+	_, err = VerifyShuffle(seeds, commitment)
+	assert.Error(t, err)
+
+	seeds.ServerSeed = serverSeed[1:]
+	_, err = VerifyShuffle(seeds, commitment)
+	assert.Error(t, err)
+
+	seeds.ServerSeed = serverSeed
+	_, err = VerifyShuffle(seeds, "not hex")
+	assert.Error(t, err)
+}
@@ -1,5 +1,7 @@
 package poker
 
+import "math/bits"
+
 const (
 	MaxStraightFlush = 10
 	MaxFourOfAKind   = 166
@@ -50,6 +52,10 @@ type lookupTable struct {
 	flushLookup    map[int32]int32
 	unsuitedLookup map[int32]int32
 	lowLookup      map[int32][]Card
+
+	// flushRanks is the flush lookup indexed by the rank bits of the hand
+	// instead of the prime product.
+	flushRanks [1 << 13]int32
 }
 
 func newLookupTable() *lookupTable {
@@ -106,6 +112,7 @@ func (table *lookupTable) flushes() {
 	for _, sf := range straightFlushes {
 		primeProduct := primeProductFromRankBits(sf)
 		table.flushLookup[primeProduct] = rank
+		table.flushRanks[sf] = rank
 		rank++
 	}
 
@@ -113,6 +120,7 @@ func (table *lookupTable) flushes() {
 	for _, f := range flushes {
 		primeProduct := primeProductFromRankBits(f)
 		table.flushLookup[primeProduct] = rank
+		table.flushRanks[f] = rank
 		rank++
 	}
 
@@ -275,6 +283,20 @@ func lexographicallyNextBitSequence(bits int32) int32 {
 	return t | ((((t & -t) / (bits & -bits)) >> 1) - 1)
 }
 
+// lowRankBits is the bit of each card rank in the low rank (ace is the lowest
+// bit). The cards above 9 don't count towards the low.
+var lowRankBits = [13]int32{
+	1 << 1, 1 << 2, 1 << 3, 1 << 4, 1 << 5, 1 << 6, 1 << 7, 1 << 8, // 2 to 9
+	0, 0, 0, 0, // T to K
+	1, // A
+}
+
+// isLowRank returns true if the low rank bits are five different cards from
+// ace to 8.
+func isLowRank(lowBits int32) bool {
+	return lowBits < 1<<8 && bits.OnesCount32(uint32(lowBits)) == 5
+}
+
 func _getLowRank(cards []Card) int32 {
 	lowBits := 0
 	for _, card := range cards {
@@ -296,7 +318,7 @@ func (table *lookupTable) buildLows() {
 		NewCard("Ac"), NewCard("2c"), NewCard("3c"), NewCard("4c"),
 		NewCard("5c"), NewCard("6c"), NewCard("7c"), NewCard("8c"),
 	}
-	for combo := range combinations(lowCards, 5) {
+	for _, combo := range combinations(lowCards, 5) {
 		lowRank := _getLowRank(combo)
 		table.lowLookup[lowRank] = combo
 	}
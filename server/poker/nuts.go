@@ -0,0 +1,207 @@
+package poker
+
+import (
+	"math/bits"
+)
+
+// The nuts are the best hand any holding can make with the board. Only the
+// board cards are known, so the cards in the other players' hands count as
+// possible holdings. The Hold'em nuts use any two cards with the board, the
+// Omaha nuts use exactly two hole cards and three board cards.
+
+// Straight draw types.
+const (
+	GutshotDraw   = "GUTSHOT"
+	OpenEndedDraw = "OPEN_ENDED"
+	WrapDraw      = "WRAP"
+)
+
+// Nuts is the best possible high hand and 8 or better low hand of a board.
+type Nuts struct {
+	HiRank   int32
+	HiCards  []Card
+	LowFound bool
+	LowRank  int32
+	LowCards []Card
+}
+
+// Draws are the draws of a hand that is not made yet.
+type Draws struct {
+	FlushDraw    bool
+	NutFlushDraw bool
+	// StraightOuts is the number of cards that complete a straight.
+	StraightOuts int
+	// StraightDraw is GutshotDraw, OpenEndedDraw (8 outs) or WrapDraw (more
+	// than 8 outs, Omaha only). Empty if there is no straight draw.
+	StraightDraw string
+}
+
+// FindNuts returns the nuts of the board (3 to 5 cards). omaha selects the
+// Omaha rules (exactly two hole cards) and lowGame the 8 or better low.
+func FindNuts(board []Card, omaha bool, lowGame bool) Nuts {
+	nuts := Nuts{HiRank: MaxHighCard + 1, LowRank: 0x7FFFFFF}
+	live := liveCards(cardMask(board))
+	hand := make([]Card, 0, 7)
+	hole := make([]Card, 2)
+	forEachCombination(len(live), 2, func(indexes []int) {
+		hole[0], hole[1] = live[indexes[0]], live[indexes[1]]
+		if omaha {
+			result := evaluateOmaha(hole, board, lowGame)
+			if result.HiRank < nuts.HiRank {
+				nuts.HiRank = result.HiRank
+				nuts.HiCards = append([]Card{}, result.HiCards[:]...)
+			}
+			if result.LowFound && result.LowRank < nuts.LowRank {
+				nuts.LowFound = true
+				nuts.LowRank = result.LowRank
+				nuts.LowCards = append([]Card{}, result.LowCards[:]...)
+			}
+			return
+		}
+		hand = append(append(hand[:0], hole...), board...)
+		rank, cards := Evaluate(hand)
+		if rank < nuts.HiRank {
+			nuts.HiRank = rank
+			nuts.HiCards = append([]Card{}, cards...)
+		}
+	})
+	return nuts
+}
+
+// FindDraws returns the flush and straight draws of the hole cards on the
+// flop or the turn. The made flushes and straights are not draws.
+func FindDraws(holeCards []Card, board []Card, omaha bool) Draws {
+	var draws Draws
+	if len(board) >= 5 {
+		return draws
+	}
+
+	// flush draws
+	for _, suit := range []int32{1, 2, 4, 8} {
+		holeSuited, boardSuited := 0, 0
+		var suitedRanks uint32
+		for _, card := range holeCards {
+			if card.Suit() == suit {
+				holeSuited++
+			}
+		}
+		for _, card := range board {
+			if card.Suit() == suit {
+				boardSuited++
+				suitedRanks |= 1 << uint(card.Rank())
+			}
+		}
+		flushDraw := false
+		if omaha {
+			flushDraw = holeSuited >= 2 && boardSuited == 2
+		} else {
+			flushDraw = holeSuited >= 1 && holeSuited+boardSuited == 4
+		}
+		if !flushDraw {
+			continue
+		}
+		draws.FlushDraw = true
+		// the nut flush draw has the highest card of the suit that is not
+		// on the board
+		for r := 12; r >= 0; r-- {
+			if suitedRanks&(1<<uint(r)) != 0 {
+				continue
+			}
+			for _, card := range holeCards {
+				if card.Suit() == suit && int(card.Rank()) == r {
+					draws.NutFlushDraw = true
+				}
+			}
+			break
+		}
+	}
+
+	// straight draws: the ranks that complete a straight with one more card
+	if makesStraight(holeCards, board, omaha, 0) {
+		return draws
+	}
+	var visible [13]int
+	for _, card := range append(append([]Card{}, holeCards...), board...) {
+		visible[card.Rank()]++
+	}
+	outRanks := 0
+	for r := 0; r < 13; r++ {
+		if visible[r] == 4 {
+			continue
+		}
+		if makesStraight(holeCards, board, omaha, 1<<uint(r)) {
+			outRanks++
+			draws.StraightOuts += 4 - visible[r]
+		}
+	}
+	switch {
+	case draws.StraightOuts == 0:
+	case omaha && draws.StraightOuts > 8:
+		draws.StraightDraw = WrapDraw
+	case outRanks >= 2:
+		draws.StraightDraw = OpenEndedDraw
+	default:
+		draws.StraightDraw = GutshotDraw
+	}
+	return draws
+}
+
+// makesStraight returns whether the hole cards make a straight with the board
+// and the extra board rank bits.
+func makesStraight(holeCards []Card, board []Card, omaha bool, extra uint32) bool {
+	boardRanks := make([]uint32, 0, len(board)+1)
+	for _, card := range board {
+		boardRanks = append(boardRanks, 1<<uint(card.Rank()))
+	}
+	if extra != 0 {
+		boardRanks = append(boardRanks, extra)
+	}
+	if !omaha {
+		// the straight on the board alone is not the player's straight
+		var onBoard uint32
+		for _, r := range boardRanks {
+			onBoard |= r
+		}
+		return hasStraight(rankBits(holeCards)|onBoard) && !hasStraight(onBoard)
+	}
+	if len(boardRanks) < 3 {
+		return false
+	}
+	for i := 0; i < len(holeCards); i++ {
+		for j := i + 1; j < len(holeCards); j++ {
+			hole := uint32(1)<<uint(holeCards[i].Rank()) | uint32(1)<<uint(holeCards[j].Rank())
+			for _, t := range boardTriples[len(boardRanks)] {
+				ranks := hole | boardRanks[t[0]] | boardRanks[t[1]] | boardRanks[t[2]]
+				if bits.OnesCount32(ranks) == 5 && hasStraight(ranks) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func rankBits(cards []Card) uint32 {
+	var ranks uint32
+	for _, card := range cards {
+		ranks |= 1 << uint(card.Rank())
+	}
+	return ranks
+}
+
+// hasStraight returns whether the rank bits have five ranks in a row.
+func hasStraight(ranks uint32) bool {
+	// the ace plays low in the wheel
+	if ranks&(1<<12) != 0 {
+		ranks = ranks<<1 | 1
+	} else {
+		ranks <<= 1
+	}
+	for high := 13; high >= 4; high-- {
+		run := uint32(0x1F) << uint(high-4)
+		if ranks&run == run {
+			return true
+		}
+	}
+	return false
+}
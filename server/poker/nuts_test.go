@@ -0,0 +1,71 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNuts(t *testing.T) {
+	board, err := ParseCards("AhKhQh2c3d")
+	require.NoError(t, err)
+	nuts := FindNuts(board, false, false)
+	assert.Equal(t, int32(1), nuts.HiRank)
+	assert.False(t, nuts.LowFound)
+
+	board, err = ParseCards("2c7d9hJsKc")
+	require.NoError(t, err)
+	nuts = FindNuts(board, false, false)
+	assert.Equal(t, "Straight, King high", DescribeHand(nuts.HiRank, nuts.HiCards).Text())
+
+	// Omaha needs two hole cards for the royal flush
+	board, err = ParseCards("AsKsQs2d3c")
+	require.NoError(t, err)
+	nuts = FindNuts(board, true, false)
+	assert.Equal(t, int32(1), nuts.HiRank)
+
+	board, err = ParseCards("2c3d8hKsQd")
+	require.NoError(t, err)
+	nuts = FindNuts(board, true, true)
+	require.True(t, nuts.LowFound)
+	assert.Equal(t, "8-4 low", DescribeLow(nuts.LowCards).Text())
+
+	// no low with two high cards on the flop
+	board, err = ParseCards("2cKsQd")
+	require.NoError(t, err)
+	nuts = FindNuts(board, true, true)
+	assert.False(t, nuts.LowFound)
+}
+
+func TestFindDraws(t *testing.T) {
+	tests := []struct {
+		hole         string
+		board        string
+		omaha        bool
+		flushDraw    bool
+		nutFlushDraw bool
+		outs         int
+		straightDraw string
+	}{
+		{"9h8h", "7h6c2h", false, true, false, 8, OpenEndedDraw},
+		{"Ah5h", "Kh7h2c", false, true, true, 0, ""},
+		{"9c8d", "Jh7s2c", false, false, false, 4, GutshotDraw},
+		{"9c8d", "Th7s6c", false, false, false, 0, ""},
+		{"JhTc7d6s", "9c8h2d", true, false, false, 20, WrapDraw},
+		{"AhKh7d6s", "9h8h2d", true, true, true, 8, OpenEndedDraw},
+		{"9h8h", "7h6c2h5d", false, true, false, 0, ""},
+		{"9h8h", "7h6c2h5dKs", false, false, false, 0, ""},
+	}
+	for _, test := range tests {
+		hole, err := ParseCards(test.hole)
+		require.NoError(t, err)
+		board, err := ParseCards(test.board)
+		require.NoError(t, err)
+		draws := FindDraws(hole, board, test.omaha)
+		assert.Equal(t, test.flushDraw, draws.FlushDraw, test.hole+test.board)
+		assert.Equal(t, test.nutFlushDraw, draws.NutFlushDraw, test.hole+test.board)
+		assert.Equal(t, test.outs, draws.StraightOuts, test.hole+test.board)
+		assert.Equal(t, test.straightDraw, draws.StraightDraw, test.hole+test.board)
+	}
+}
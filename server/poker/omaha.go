@@ -0,0 +1,172 @@
+package poker
+
+import (
+	"fmt"
+)
+
+// The Omaha evaluator goes through every combination of two player cards and
+// three board cards. The combinations come from index tables built at init and
+// the rank bits, suits and prime products of the player card pairs and the
+// board card triples are combined instead of evaluating each five card hand
+// from scratch. Evaluating a hand doesn't allocate.
+
+const (
+	maxOmahaPlayerCards = 6
+	maxBoardCards       = 5
+)
+
+var (
+	// omahaPairs[n] has the index pairs for n player cards.
+	omahaPairs [maxOmahaPlayerCards + 1][][]uint8
+	// boardTriples[n] has the index triples for n board cards.
+	boardTriples [maxBoardCards + 1][][]uint8
+)
+
+func init() {
+	for n := 2; n <= maxOmahaPlayerCards; n++ {
+		omahaPairs[n] = indexCombinations(n, 2)
+	}
+	for n := 3; n <= maxBoardCards; n++ {
+		boardTriples[n] = indexCombinations(n, 3)
+	}
+}
+
+type OmahaResult struct {
+	HiRank   int32
+	HiCards  []Card
+	LowFound bool
+	LowRank  int32
+	LowCards []Card
+}
+
+type HighHand struct {
+	HiRank  int32
+	HiCards []Card
+}
+
+// OmahaHand is the result of EvaluateOmahaHand. Same as OmahaResult, but the
+// cards are kept in arrays so that the result doesn't need to be allocated.
+type OmahaHand struct {
+	HiRank   int32
+	HiCards  [5]Card
+	LowFound bool
+	LowRank  int32
+	LowCards [5]Card
+}
+
+// partialHand is the part of a five card hand that comes from the player cards
+// or the board cards.
+type partialHand struct {
+	suits int32 // suit bits that all the cards have
+	ranks int32 // rank bits of the cards
+	prime int32 // product of the rank primes
+	low   int32 // low rank bits of the cards
+}
+
+// EvaluateOmahaHand returns the best high hand and the best 8 or better low
+// hand using exactly two of the player cards and three of the board cards.
+func EvaluateOmahaHand(playerCards []Card, boardCards []Card) OmahaHand {
+	return evaluateOmaha(playerCards, boardCards, true)
+}
+
+func EvaluateOmaha(playerCards []Card, boardCards []Card) OmahaResult {
+	return omahaResult(evaluateOmaha(playerCards, boardCards, true))
+}
+
+func omahaResult(hand OmahaHand) OmahaResult {
+	hiCards := make([]Card, 5)
+	copy(hiCards, hand.HiCards[:])
+	lowCards := make([]Card, 5)
+	copy(lowCards, hand.LowCards[:])
+	return OmahaResult{
+		HiRank:   hand.HiRank,
+		HiCards:  hiCards,
+		LowFound: hand.LowFound,
+		LowRank:  hand.LowRank,
+		LowCards: lowCards,
+	}
+}
+
+func EvaluateHighHand(playerCards []Card, boardCards []Card) HighHand {
+	hand := evaluateOmaha(playerCards, boardCards, false)
+	hiCards := make([]Card, 5)
+	copy(hiCards, hand.HiCards[:])
+	return HighHand{
+		HiRank:  hand.HiRank,
+		HiCards: hiCards,
+	}
+}
+
+func evaluateOmaha(playerCards []Card, boardCards []Card, evaluateLow bool) OmahaHand {
+	if len(playerCards) > maxOmahaPlayerCards || len(boardCards) > maxBoardCards ||
+		omahaPairs[len(playerCards)] == nil || boardTriples[len(boardCards)] == nil {
+		panic(fmt.Sprintf("Omaha needs 2 to %d player cards and 3 to %d board cards. Got %d player cards and %d board cards",
+			maxOmahaPlayerCards, maxBoardCards, len(playerCards), len(boardCards)))
+	}
+	if hasSpecialCards(playerCards, boardCards) {
+		return evaluateOmahaSpecial(playerCards, boardCards, evaluateLow)
+	}
+	pairs := omahaPairs[len(playerCards)]
+	triples := boardTriples[len(boardCards)]
+
+	var boardHands [10]partialHand
+	for i, t := range triples {
+		c0, c1, c2 := boardCards[t[0]], boardCards[t[1]], boardCards[t[2]]
+		boardHands[i] = partialHand{
+			suits: int32(c0&c1&c2) & 0xF000,
+			ranks: int32(c0|c1|c2) >> 16,
+			prime: c0.Prime() * c1.Prime() * c2.Prime(),
+			low:   lowRankBits[c0.Rank()] | lowRankBits[c1.Rank()] | lowRankBits[c2.Rank()],
+		}
+	}
+
+	result := OmahaHand{
+		HiRank:  MaxHighCard,
+		LowRank: 0x7FFFFFF,
+	}
+	hiPair, hiTriple := -1, -1
+	lowPair, lowTriple := -1, -1
+	for i, p := range pairs {
+		c0, c1 := playerCards[p[0]], playerCards[p[1]]
+		suits := int32(c0&c1) & 0xF000
+		ranks := int32(c0|c1) >> 16
+		prime := c0.Prime() * c1.Prime()
+		low := lowRankBits[c0.Rank()] | lowRankBits[c1.Rank()]
+
+		for j := range triples {
+			board := &boardHands[j]
+			var rank int32
+			if suits&board.suits != 0 {
+				rank = table.flushRanks[ranks|board.ranks]
+			} else {
+				rank = table.unsuitedLookup[prime*board.prime]
+			}
+			if rank < result.HiRank || hiPair < 0 {
+				result.HiRank = rank
+				hiPair, hiTriple = i, j
+			}
+
+			if evaluateLow {
+				lowRank := low | board.low
+				if lowRank < result.LowRank && isLowRank(lowRank) {
+					result.LowFound = true
+					result.LowRank = lowRank
+					lowPair, lowTriple = i, j
+				}
+			}
+		}
+	}
+
+	result.HiCards = omahaCards(playerCards, boardCards, pairs[hiPair], triples[hiTriple])
+	if result.LowFound {
+		result.LowCards = omahaCards(playerCards, boardCards, pairs[lowPair], triples[lowTriple])
+	}
+	return result
+}
+
+func omahaCards(playerCards []Card, boardCards []Card, pair []uint8, triple []uint8) [5]Card {
+	return [5]Card{
+		playerCards[pair[0]], playerCards[pair[1]],
+		boardCards[triple[0]], boardCards[triple[1]], boardCards[triple[2]],
+	}
+}
@@ -0,0 +1,190 @@
+package poker
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The default keeps the regular test run short. The nightly build runs the
+// differential check over millions of hands with `make test-omaha-diff`.
+var omahaDiffHands = flag.Int("omaha-diff-hands", 100000, "number of random hands to compare with the reference Omaha evaluator")
+
+// referenceEvaluateOmaha is the original Omaha evaluator that evaluates every
+// five card combination separately. The index table evaluator is tested
+// against it.
+func referenceEvaluateOmaha(playerCards []Card, boardCards []Card) OmahaResult {
+	minimum := int32(MaxHighCard)
+	lowScore := int32(0x7FFFFFF)
+
+	playerPairs := make([][]Card, 0)
+	for pair := range referenceCombinations(playerCards, 2) {
+		playerPairs = append(playerPairs, pair)
+	}
+	boardPairs := make([][]Card, 0)
+	for pair := range referenceCombinations(boardCards, 3) {
+		boardPairs = append(boardPairs, pair)
+	}
+	bestCards := make([]Card, 5)
+	lowCards := make([]Card, 5)
+	lowFound := false
+	for _, playerPair := range playerPairs {
+		for _, boardPair := range boardPairs {
+			cards := make([]Card, 0)
+			cards = append(cards, playerPair...)
+			cards = append(cards, boardPair...)
+			score, _ := five(cards...)
+			if score < minimum {
+				minimum = score
+				copy(bestCards, cards)
+			}
+
+			isLow, score := table.getLowRank(cards)
+			if isLow && score < lowScore {
+				copy(lowCards, cards)
+				lowFound = true
+				lowScore = score
+			}
+		}
+	}
+	return OmahaResult{
+		HiRank:   minimum,
+		HiCards:  bestCards,
+		LowFound: lowFound,
+		LowRank:  lowScore,
+		LowCards: lowCards,
+	}
+}
+
+// referenceCombinations is the original channel based combination generator
+// used by the reference evaluator.
+func referenceCombinations(iterable []Card, r int) chan []Card {
+	ch := make(chan []Card)
+	go func() {
+		for _, comb := range indexCombinations(len(iterable), r) {
+			result := make([]Card, r)
+			for i, val := range comb {
+				result[i] = iterable[val]
+			}
+			ch <- result
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+func fullDeckCards() []Card {
+	cards := make([]Card, 0, 52)
+	for _, rank := range strRanks {
+		for _, suit := range "shdc" {
+			cards = append(cards, NewCard(fmt.Sprintf("%c%c", rank, suit)))
+		}
+	}
+	return cards
+}
+
+// randomCards deals n cards from the deck by moving them to the front.
+func randomCards(r *rand.Rand, deck []Card, n int) []Card {
+	for i := 0; i < n; i++ {
+		j := i + r.Intn(len(deck)-i)
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+	return deck[:n]
+}
+
+func TestIndexCombinations(t *testing.T) {
+	assert.Equal(t, [][]uint8{{0, 1}, {0, 2}, {1, 2}}, indexCombinations(3, 2))
+	assert.Len(t, indexCombinations(6, 2), 15)
+	assert.Len(t, indexCombinations(5, 3), 10)
+	assert.Len(t, indexCombinations(8, 5), 56)
+}
+
+func TestEvaluateOmaha(t *testing.T) {
+	playerCards := []Card{NewCard("As"), NewCard("2s"), NewCard("Kd"), NewCard("Kh")}
+	boardCards := []Card{NewCard("Ks"), NewCard("3s"), NewCard("5s"), NewCard("8d"), NewCard("Qc")}
+	result := EvaluateOmaha(playerCards, boardCards)
+	assert.Equal(t, "Flush", RankString(result.HiRank))
+	assert.Equal(t, []Card{NewCard("As"), NewCard("2s"), NewCard("Ks"), NewCard("3s"), NewCard("5s")}, result.HiCards)
+	assert.True(t, result.LowFound)
+	assert.Equal(t, []Card{NewCard("As"), NewCard("2s"), NewCard("3s"), NewCard("5s"), NewCard("8d")}, result.LowCards)
+
+	// only one of the player's spades counts, so no flush
+	playerCards = []Card{NewCard("As"), NewCard("Kd"), NewCard("Kh"), NewCard("Qh")}
+	result = EvaluateOmaha(playerCards, boardCards)
+	assert.Equal(t, "Three of a Kind", RankString(result.HiRank))
+	assert.False(t, result.LowFound)
+
+	highHand := EvaluateHighHand(playerCards, boardCards)
+	assert.Equal(t, result.HiRank, highHand.HiRank)
+	assert.Equal(t, result.HiCards, highHand.HiCards)
+}
+
+func TestEvaluateOmahaDifferential(t *testing.T) {
+	hands := *omahaDiffHands
+	if testing.Short() {
+		hands = 10000
+	}
+	r := rand.New(rand.NewSource(1))
+	deck := fullDeckCards()
+	for i := 0; i < hands; i++ {
+		numPlayerCards := 4 + i%3
+		numBoardCards := 3 + (i/3)%3
+		cards := randomCards(r, deck, numPlayerCards+numBoardCards)
+		playerCards := cards[:numPlayerCards]
+		boardCards := cards[numPlayerCards:]
+
+		expected := referenceEvaluateOmaha(playerCards, boardCards)
+		actual := EvaluateOmaha(playerCards, boardCards)
+		require.Equal(t, expected.HiRank, actual.HiRank, "%s %s", CardsToString(playerCards), CardsToString(boardCards))
+		if expected.HiRank != MaxHighCard {
+			// The reference evaluator doesn't keep the cards of the worst possible hand.
+			require.Equal(t, expected.HiCards, actual.HiCards, "%s %s", CardsToString(playerCards), CardsToString(boardCards))
+		}
+		require.Equal(t, expected.LowFound, actual.LowFound, "%s %s", CardsToString(playerCards), CardsToString(boardCards))
+		require.Equal(t, expected.LowRank, actual.LowRank, "%s %s", CardsToString(playerCards), CardsToString(boardCards))
+		require.Equal(t, expected.LowCards, actual.LowCards, "%s %s", CardsToString(playerCards), CardsToString(boardCards))
+	}
+}
+
+func TestEvaluateOmahaHandAllocs(t *testing.T) {
+	playerCards := []Card{NewCard("As"), NewCard("2s"), NewCard("Kd"), NewCard("Kh"), NewCard("7c"), NewCard("6c")}
+	boardCards := []Card{NewCard("Ks"), NewCard("3s"), NewCard("5s"), NewCard("8d"), NewCard("Qc")}
+	allocs := testing.AllocsPerRun(100, func() {
+		EvaluateOmahaHand(playerCards, boardCards)
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func benchmarkOmaha(b *testing.B, numPlayerCards int, evaluate func(playerCards []Card, boardCards []Card)) {
+	r := rand.New(rand.NewSource(1))
+	deck := fullDeckCards()
+	hands := make([][]Card, 1000)
+	for i := range hands {
+		hands[i] = append([]Card{}, randomCards(r, deck, numPlayerCards+5)...)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hand := hands[i%len(hands)]
+		evaluate(hand[:numPlayerCards], hand[numPlayerCards:])
+	}
+}
+
+func BenchmarkEvaluateOmaha(b *testing.B) {
+	for _, numPlayerCards := range []int{4, 5, 6} {
+		b.Run(fmt.Sprintf("%d-cards", numPlayerCards), func(b *testing.B) {
+			benchmarkOmaha(b, numPlayerCards, func(playerCards []Card, boardCards []Card) {
+				EvaluateOmahaHand(playerCards, boardCards)
+			})
+		})
+		b.Run(fmt.Sprintf("%d-cards-reference", numPlayerCards), func(b *testing.B) {
+			benchmarkOmaha(b, numPlayerCards, func(playerCards []Card, boardCards []Card) {
+				referenceEvaluateOmaha(playerCards, boardCards)
+			})
+		})
+	}
+}
@@ -114,7 +114,7 @@ func (h PokerHand) EvaulateHoldem() HandResult {
 		allCards := make([]Card, 0)
 		allCards = append(allCards, playerHand.Cards...)
 		allCards = append(allCards, h.board...)
-		rank, bestCards := seven(allCards...)
+		rank, bestCards := Evaluate(allCards)
 		playerResult := PlayerResult{
 			PlayerId:  playerHand.PlayerId,
 			Rank:      rank,
@@ -0,0 +1,366 @@
+package poker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A range is a comma separated list of hole card groups in the usual notation.
+//
+// Hold'em (two cards):
+//   AhKh        a single combo
+//   TT          a pocket pair (6 combos)
+//   TT+         TT, JJ, QQ, KK and AA
+//   TT-77       the pocket pairs from TT down to 77
+//   AK          suited and offsuit (16 combos)
+//   AKs, AKo    suited (4 combos) or offsuit (12 combos) only
+//   ATs+        the kicker goes up to one below the high card: ATs, AJs, AQs, AKs
+//   A5s-A2s     the same high card with the kickers from 5 down to 2
+//
+// Omaha (four to six cards) filters, one position per card:
+//   A           a card of the rank
+//   As          the card
+//   * or x      any card
+// followed by an optional suit filter:
+//   ds          double suited (two suits with at least two cards each)
+//   ss          single suited (exactly one suit with at least two cards)
+//   r           rainbow (no two cards of the same suit)
+// For example AA**ds is every double suited hand with at least two aces.
+
+// Range is a set of hole card combos.
+type Range struct {
+	Notation string
+	Combos   [][]Card
+}
+
+// NumCards returns the number of hole cards of the combos.
+func (r *Range) NumCards() int {
+	if len(r.Combos) == 0 {
+		return 0
+	}
+	return len(r.Combos[0])
+}
+
+var suitChars = "shdc"
+
+// cardIndex returns the index of the card in 0..51 (52 for the joker).
+func cardIndex(c Card) int {
+	suitIndex := 0
+	switch c.Suit() {
+	case 2:
+		suitIndex = 1
+	case 4:
+		suitIndex = 2
+	case 8:
+		suitIndex = 3
+	}
+	return int(c.Rank())*4 + suitIndex
+}
+
+// cardMask returns the bit mask of the cards.
+func cardMask(cards []Card) uint64 {
+	var mask uint64
+	for _, c := range cards {
+		mask |= 1 << uint(cardIndex(c))
+	}
+	return mask
+}
+
+// allCards are the 52 cards in the order of cardIndex.
+var allCards = func() []Card {
+	cards := make([]Card, 0, 52)
+	for rank := 0; rank < 13; rank++ {
+		for i := 0; i < 4; i++ {
+			cards = append(cards, NewCardFromByte(uint8(rank<<4|1<<i)))
+		}
+	}
+	return cards
+}()
+
+func parseRank(c byte) (int, bool) {
+	rank := strings.IndexByte(strRanks, strings.ToUpper(string(c))[0])
+	return rank, rank >= 0
+}
+
+func isSuit(c byte) bool {
+	return strings.IndexByte(suitChars, c) >= 0
+}
+
+// ParseCards parses cards like "AhKd", "Ah Kd" or "Ah,Kd".
+func ParseCards(s string) ([]Card, error) {
+	s = strings.NewReplacer(" ", "", ",", "").Replace(s)
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("Invalid cards: %s", s)
+	}
+	cards := make([]Card, 0, len(s)/2)
+	var mask uint64
+	for i := 0; i < len(s); i += 2 {
+		rank, ok := parseRank(s[i])
+		if !ok || !isSuit(s[i+1]) {
+			return nil, fmt.Errorf("Invalid card %s in %s", s[i:i+2], s)
+		}
+		card := NewCard(string([]byte{strRanks[rank], s[i+1]}))
+		if mask&cardMask([]Card{card}) != 0 {
+			return nil, fmt.Errorf("Duplicate card %s in %s", card, s)
+		}
+		mask |= cardMask([]Card{card})
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// ParseRange parses the range notation and expands it into the combos that
+// don't have any of the dead cards.
+func ParseRange(notation string, dead []Card) (*Range, error) {
+	deadMask := cardMask(dead)
+	r := &Range{Notation: notation}
+	seen := make(map[uint64]bool)
+	for _, item := range strings.Split(notation, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		combos, err := parseRangeItem(item, deadMask)
+		if err != nil {
+			return nil, err
+		}
+		for _, combo := range combos {
+			if r.NumCards() != 0 && len(combo) != r.NumCards() {
+				return nil, fmt.Errorf("Range %s mixes hands of %d and %d cards", notation, r.NumCards(), len(combo))
+			}
+			mask := cardMask(combo)
+			if seen[mask] {
+				continue
+			}
+			seen[mask] = true
+			r.Combos = append(r.Combos, combo)
+		}
+	}
+	if len(r.Combos) == 0 {
+		return nil, fmt.Errorf("Range %s has no combos", notation)
+	}
+	return r, nil
+}
+
+func parseRangeItem(item string, deadMask uint64) ([][]Card, error) {
+	if cards, err := ParseCards(item); err == nil {
+		if len(cards) == 3 || len(cards) < 2 || len(cards) > maxOmahaPlayerCards {
+			return nil, fmt.Errorf("Invalid number of cards in %s", item)
+		}
+		if cardMask(cards)&deadMask != 0 {
+			return nil, nil
+		}
+		return [][]Card{sortCombo(cards)}, nil
+	}
+
+	// Hold'em: pairs, suited/offsuit hands, + and - ranges
+	first, second, suffix, err := parseHoldemHand(item)
+	if err != nil {
+		return parseOmahaFilter(item, deadMask)
+	}
+	if strings.Contains(item, "-") {
+		parts := strings.SplitN(item, "-", 2)
+		first2, second2, suffix2, err := parseHoldemHand(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if suffix2 != suffix {
+			return nil, fmt.Errorf("Invalid range %s", item)
+		}
+		var combos [][]Card
+		if first == second {
+			if first2 != second2 {
+				return nil, fmt.Errorf("Invalid range %s", item)
+			}
+			low, high := first2, first
+			if low > high {
+				low, high = high, low
+			}
+			for rank := low; rank <= high; rank++ {
+				combos = append(combos, holdemCombos(rank, rank, suffix, deadMask)...)
+			}
+			return combos, nil
+		}
+		if first2 != first || second2 == first2 {
+			return nil, fmt.Errorf("Invalid range %s. The high cards must be the same", item)
+		}
+		low, high := second2, second
+		if low > high {
+			low, high = high, low
+		}
+		for kicker := low; kicker <= high; kicker++ {
+			combos = append(combos, holdemCombos(first, kicker, suffix, deadMask)...)
+		}
+		return combos, nil
+	}
+	if strings.HasSuffix(item, "+") {
+		var combos [][]Card
+		if first == second {
+			for rank := first; rank < 13; rank++ {
+				combos = append(combos, holdemCombos(rank, rank, suffix, deadMask)...)
+			}
+			return combos, nil
+		}
+		for kicker := second; kicker < first; kicker++ {
+			combos = append(combos, holdemCombos(first, kicker, suffix, deadMask)...)
+		}
+		return combos, nil
+	}
+	return holdemCombos(first, second, suffix, deadMask), nil
+}
+
+// parseHoldemHand parses a hand like AKs, AKo, AK or TT at the start of the
+// item. The first rank is the higher one.
+func parseHoldemHand(item string) (int, int, string, error) {
+	item = strings.TrimSuffix(strings.SplitN(item, "-", 2)[0], "+")
+	if len(item) < 2 || len(item) > 3 {
+		return 0, 0, "", fmt.Errorf("Invalid hand %s", item)
+	}
+	first, ok1 := parseRank(item[0])
+	second, ok2 := parseRank(item[1])
+	suffix := item[2:]
+	if !ok1 || !ok2 || (suffix != "" && suffix != "s" && suffix != "o") {
+		return 0, 0, "", fmt.Errorf("Invalid hand %s", item)
+	}
+	if first == second && suffix != "" {
+		return 0, 0, "", fmt.Errorf("Invalid hand %s. A pair can't be suited or offsuit", item)
+	}
+	if first < second {
+		first, second = second, first
+	}
+	return first, second, suffix, nil
+}
+
+// holdemCombos returns the combos of the two ranks. suffix is s (suited), o
+// (offsuit) or empty (both).
+func holdemCombos(first int, second int, suffix string, deadMask uint64) [][]Card {
+	var combos [][]Card
+	for s1 := 0; s1 < 4; s1++ {
+		for s2 := 0; s2 < 4; s2++ {
+			if first == second && s2 <= s1 {
+				continue
+			}
+			if (suffix == "s" && s1 != s2) || (suffix == "o" && s1 == s2) {
+				continue
+			}
+			combo := []Card{
+				NewCard(string([]byte{strRanks[first], suitChars[s1]})),
+				NewCard(string([]byte{strRanks[second], suitChars[s2]})),
+			}
+			if cardMask(combo)&deadMask != 0 {
+				continue
+			}
+			combos = append(combos, combo)
+		}
+	}
+	return combos
+}
+
+// parseOmahaFilter expands an Omaha filter like AA**ds.
+func parseOmahaFilter(item string, deadMask uint64) ([][]Card, error) {
+	suitFilter := ""
+	pattern := item
+	for _, suffix := range []string{"ds", "ss", "r"} {
+		if strings.HasSuffix(pattern, suffix) {
+			suitFilter = suffix
+			pattern = strings.TrimSuffix(pattern, suffix)
+			break
+		}
+	}
+
+	var required []Card
+	var rankCounts [13]int
+	numCards := 0
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' || c == 'x' || c == 'X':
+		default:
+			rank, ok := parseRank(c)
+			if !ok {
+				return nil, fmt.Errorf("Invalid range item %s", item)
+			}
+			if i+1 < len(pattern) && isSuit(pattern[i+1]) {
+				required = append(required, NewCard(string([]byte{strRanks[rank], pattern[i+1]})))
+				i++
+			} else {
+				rankCounts[rank]++
+			}
+		}
+		numCards++
+	}
+	if numCards < 4 || numCards > maxOmahaPlayerCards {
+		return nil, fmt.Errorf("Invalid Omaha filter %s. It must have 4 to %d cards", item, maxOmahaPlayerCards)
+	}
+	requiredMask := cardMask(required)
+	if requiredMask&deadMask != 0 {
+		return nil, nil
+	}
+
+	var live []Card
+	for _, card := range allCards {
+		if cardMask([]Card{card})&(deadMask|requiredMask) == 0 {
+			live = append(live, card)
+		}
+	}
+	var combos [][]Card
+	rest := make([]Card, numCards-len(required))
+	forEachCombination(len(live), len(rest), func(indexes []int) {
+		for i, index := range indexes {
+			rest[i] = live[index]
+		}
+		var counts [13]int
+		for _, card := range rest {
+			counts[card.Rank()]++
+		}
+		matches := true
+		for rank := range rankCounts {
+			if counts[rank] < rankCounts[rank] {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			return
+		}
+		combo := append(append([]Card{}, required...), rest...)
+		if !matchesSuitFilter(combo, suitFilter) {
+			return
+		}
+		combos = append(combos, sortCombo(combo))
+	})
+	return combos, nil
+}
+
+func matchesSuitFilter(cards []Card, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	var suitCounts [9]int
+	for _, card := range cards {
+		suitCounts[card.Suit()]++
+	}
+	suited := 0
+	for _, count := range suitCounts {
+		if count >= 2 {
+			suited++
+		}
+	}
+	switch filter {
+	case "ds":
+		return suited == 2
+	case "ss":
+		return suited == 1
+	default:
+		return suited == 0
+	}
+}
+
+// sortCombo sorts the cards from the highest rank.
+func sortCombo(cards []Card) []Card {
+	sort.Slice(cards, func(i, j int) bool {
+		return cardIndex(cards[i]) > cardIndex(cards[j])
+	})
+	return cards
+}
@@ -0,0 +1,72 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCards(t *testing.T) {
+	cards, err := ParseCards("AhKd")
+	require.NoError(t, err)
+	assert.Equal(t, []Card{NewCard("Ah"), NewCard("Kd")}, cards)
+	cards, err = ParseCards("Ah, kd 2c")
+	require.NoError(t, err)
+	assert.Equal(t, []Card{NewCard("Ah"), NewCard("Kd"), NewCard("2c")}, cards)
+
+	_, err = ParseCards("AhK")
+	assert.Error(t, err)
+	_, err = ParseCards("AhKx")
+	assert.Error(t, err)
+	_, err = ParseCards("AhAh")
+	assert.Error(t, err)
+}
+
+func TestParseRange(t *testing.T) {
+	counts := map[string]int{
+		"AhKh":         1,
+		"TT":           6,
+		"TT+":          30,
+		"TT-77":        24,
+		"77-TT":        24,
+		"AK":           16,
+		"AKs":          4,
+		"AKo":          12,
+		"ATs+":         16,
+		"KQo+":         12,
+		"A5s-A2s":      16,
+		"AKs, TT+, AK": 46,
+		"AA**ds":       864,
+		"****":         270725,
+		"****r":        28561,
+		"AsKs**":       1225,
+		"AAKK":         36,
+		"AhKhQhJh":     1,
+	}
+	for notation, expected := range counts {
+		r, err := ParseRange(notation, nil)
+		require.NoError(t, err, notation)
+		assert.Len(t, r.Combos, expected, notation)
+	}
+
+	r, err := ParseRange("AK", []Card{NewCard("As")})
+	require.NoError(t, err)
+	assert.Len(t, r.Combos, 12)
+	assert.Equal(t, 2, r.NumCards())
+
+	r, err = ParseRange("AA**ds", []Card{NewCard("As")})
+	require.NoError(t, err)
+	assert.Len(t, r.Combos, 432)
+	for _, combo := range r.Combos {
+		assert.Len(t, combo, 4)
+		assert.NotContains(t, combo, NewCard("As"))
+	}
+
+	for _, notation := range []string{"", "AKx", "AAs", "AK-QJ", "AK, AA**", "A**ss", "AhKh+"} {
+		_, err := ParseRange(notation, nil)
+		assert.Error(t, err, notation)
+	}
+	_, err = ParseRange("AhKh", []Card{NewCard("Kh")})
+	assert.Error(t, err)
+}
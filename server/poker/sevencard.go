@@ -0,0 +1,252 @@
+package poker
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// The direct lookup evaluator ranks 6 and 7 card hands without going through
+// the five card combinations.
+//
+// With 7 or fewer cards, a hand with 5 or more cards of a suit can't make
+// anything better than a flush, so the flush hands are looked up by the rank
+// bits of the flush suit (8192 entries). The other hands only depend on how
+// many cards of each rank there are. The rank counts are mapped to a dense
+// index (a perfect hash of the base 5 number made of the counts) that is used
+// to look up the rank and the ranks of the best five cards. The tables are
+// built at init from the five card lookup tables.
+
+// HandEvaluator selects how Evaluate ranks 6 and 7 card hands.
+type HandEvaluator int32
+
+const (
+	// LookupEvaluator looks up the hand in the 6 and 7 card tables.
+	LookupEvaluator HandEvaluator = iota
+	// CombinationEvaluator evaluates every five card combination of the hand.
+	CombinationEvaluator
+)
+
+var handEvaluator int32 = int32(LookupEvaluator)
+
+// SetHandEvaluator selects the evaluator that Evaluate uses for 6 and 7 cards.
+func SetHandEvaluator(evaluator HandEvaluator) {
+	atomic.StoreInt32(&handEvaluator, int32(evaluator))
+}
+
+// GetHandEvaluator returns the evaluator that Evaluate uses for 6 and 7 cards.
+func GetHandEvaluator() HandEvaluator {
+	return HandEvaluator(atomic.LoadInt32(&handEvaluator))
+}
+
+// ParseHandEvaluator converts "lookup" or "combinations" to the hand evaluator.
+func ParseHandEvaluator(s string) (HandEvaluator, error) {
+	switch s {
+	case "lookup":
+		return LookupEvaluator, nil
+	case "combinations":
+		return CombinationEvaluator, nil
+	}
+	return LookupEvaluator, fmt.Errorf("Invalid hand evaluator: %s. Valid values are lookup and combinations", s)
+}
+
+const maxLookupCards = 7
+
+var (
+	// rankCountWays[n][k] is the number of ways to have k cards in n ranks
+	// with at most 4 cards of a rank.
+	rankCountWays [14][maxLookupCards + 1]int32
+	// rankCountOffsets[i][c][k] is the index offset when the i-th rank (from
+	// the ace) has c cards and there are k cards in the ranks from the i-th.
+	rankCountOffsets [13][5][maxLookupCards + 1]int32
+
+	// unsuitedRanks[k] and unsuitedBest[k] have the rank and the best five
+	// cards of the hands of k cards without a flush. The best five cards are
+	// the card counts per rank, 3 bits per rank.
+	unsuitedRanks [maxLookupCards + 1][]int32
+	unsuitedBest  [maxLookupCards + 1][]uint64
+
+	// flushBestRanks and flushBestBits have the rank and the rank bits of the
+	// best five cards of a flush, indexed by the rank bits of the flush suit.
+	flushBestRanks [1 << 13]int32
+	flushBestBits  [1 << 13]int32
+)
+
+func init() {
+	buildRankCountIndex()
+	for k := 5; k <= maxLookupCards; k++ {
+		buildUnsuitedTable(k)
+	}
+	buildFlushTable()
+}
+
+func buildRankCountIndex() {
+	rankCountWays[0][0] = 1
+	for n := 1; n <= 13; n++ {
+		for k := 0; k <= maxLookupCards; k++ {
+			for c := 0; c <= 4 && c <= k; c++ {
+				rankCountWays[n][k] += rankCountWays[n-1][k-c]
+			}
+		}
+	}
+
+	for i := 0; i < 13; i++ {
+		remainingRanks := 13 - i - 1
+		for k := 0; k <= maxLookupCards; k++ {
+			var offset int32
+			for c := 0; c <= 4; c++ {
+				rankCountOffsets[i][c][k] = offset
+				if c <= k {
+					offset += rankCountWays[remainingRanks][k-c]
+				}
+			}
+		}
+	}
+}
+
+// rankCountIndex returns the dense index of the rank counts of k cards.
+func rankCountIndex(counts *[13]uint8, k int) int32 {
+	var index int32
+	for i := 0; i < 13; i++ {
+		c := counts[12-i]
+		index += rankCountOffsets[i][c][k]
+		k -= int(c)
+	}
+	return index
+}
+
+func buildUnsuitedTable(k int) {
+	size := rankCountWays[13][k]
+	unsuitedRanks[k] = make([]int32, size)
+	unsuitedBest[k] = make([]uint64, size)
+
+	var counts [13]uint8
+	var visit func(rank int, remaining int)
+	visit = func(rank int, remaining int) {
+		if rank == 13 {
+			if remaining == 0 {
+				index := rankCountIndex(&counts, k)
+				unsuitedRanks[k][index], unsuitedBest[k][index] = bestUnsuitedFive(&counts)
+			}
+			return
+		}
+		for c := 0; c <= 4 && c <= remaining; c++ {
+			counts[rank] = uint8(c)
+			visit(rank+1, remaining-c)
+		}
+		counts[rank] = 0
+	}
+	visit(0, k)
+}
+
+// bestUnsuitedFive returns the best rank of five cards taken from the rank
+// counts and the counts of the five cards.
+func bestUnsuitedFive(counts *[13]uint8) (int32, uint64) {
+	bestRank := int32(MaxHighCard + 1)
+	var bestCounts uint64
+	var used [13]uint8
+	var visit func(rank int, remaining int, prime int32)
+	visit = func(rank int, remaining int, prime int32) {
+		if remaining == 0 {
+			r := table.unsuitedLookup[prime]
+			if r < bestRank {
+				bestRank = r
+				bestCounts = 0
+				for i, c := range used {
+					bestCounts |= uint64(c) << (3 * uint(i))
+				}
+			}
+			return
+		}
+		if rank == 13 {
+			return
+		}
+		for c := 0; c <= int(counts[rank]) && c <= remaining; c++ {
+			used[rank] = uint8(c)
+			visit(rank+1, remaining-c, prime)
+			prime *= primes[rank]
+		}
+		used[rank] = 0
+	}
+	visit(0, 5, 1)
+	return bestRank, bestCounts
+}
+
+func buildFlushTable() {
+	for bits := int32(0); bits < 1<<13; bits++ {
+		n := popCount13(bits)
+		if n < 5 {
+			continue
+		}
+		if n == 5 {
+			flushBestRanks[bits] = table.flushRanks[bits]
+			flushBestBits[bits] = bits
+			continue
+		}
+		// best of the flushes without one of the cards
+		flushBestRanks[bits] = MaxHighCard + 1
+		for b := bits; b != 0; b &= b - 1 {
+			without := bits &^ (b & -b)
+			if flushBestRanks[without] < flushBestRanks[bits] {
+				flushBestRanks[bits] = flushBestRanks[without]
+				flushBestBits[bits] = flushBestBits[without]
+			}
+		}
+	}
+}
+
+func popCount13(bits int32) int {
+	n := 0
+	for ; bits != 0; bits &= bits - 1 {
+		n++
+	}
+	return n
+}
+
+// lookupEvaluate returns the rank and the best five cards of 5 to 7 cards.
+// The best five cards are in the order of the given cards. When there is more
+// than one way to pick the best five cards, the cards that come first are left
+// out, same as the combination evaluator.
+func lookupEvaluate(cards []Card) (int32, []Card) {
+	var suitCounts [9]uint8
+	var rankCounts [13]uint8
+	for _, card := range cards {
+		suitCounts[card.Suit()]++
+		rankCounts[card.Rank()]++
+	}
+
+	bestCards := make([]Card, 0, 5)
+	for _, suit := range [...]int32{1, 2, 4, 8} {
+		if suitCounts[suit] < 5 {
+			continue
+		}
+		var bits int32
+		for _, card := range cards {
+			if card.Suit() == suit {
+				bits |= card.BitRank()
+			}
+		}
+		bestBits := flushBestBits[bits]
+		for _, card := range cards {
+			if card.Suit() == suit && card.BitRank()&bestBits != 0 {
+				bestCards = append(bestCards, card)
+			}
+		}
+		return flushBestRanks[bits], bestCards
+	}
+
+	index := rankCountIndex(&rankCounts, len(cards))
+	best := unsuitedBest[len(cards)][index]
+	var drop [13]uint8
+	for rank, count := range rankCounts {
+		drop[rank] = count - uint8(best>>(3*uint(rank))&0x7)
+	}
+	for _, card := range cards {
+		rank := card.Rank()
+		if drop[rank] > 0 {
+			drop[rank]--
+			continue
+		}
+		bestCards = append(bestCards, card)
+	}
+	return unsuitedRanks[len(cards)][index], bestCards
+}
@@ -0,0 +1,129 @@
+package poker
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lookupDiffHands = flag.Int("lookup-diff-hands", 200000, "number of random hands to compare with the combination evaluator")
+
+func TestRankCountIndex(t *testing.T) {
+	assert.Equal(t, int32(6175), rankCountWays[13][5])
+	assert.Equal(t, int32(18395), rankCountWays[13][6])
+	assert.Equal(t, int32(49205), rankCountWays[13][7])
+
+	// every rank count is mapped to a different index
+	for k := 5; k <= maxLookupCards; k++ {
+		for i, rank := range unsuitedRanks[k] {
+			require.True(t, rank >= 1 && rank <= MaxHighCard, "%d cards index %d", k, i)
+		}
+	}
+}
+
+func TestLookupEvaluate(t *testing.T) {
+	cards := []Card{NewCard("Kd"), NewCard("Ks"), NewCard("3s"), NewCard("5s"), NewCard("8s"), NewCard("As"), NewCard("Qc")}
+	rank, bestCards := lookupEvaluate(cards)
+	assert.Equal(t, "Flush", RankString(rank))
+	assert.Equal(t, []Card{NewCard("Ks"), NewCard("3s"), NewCard("5s"), NewCard("8s"), NewCard("As")}, bestCards)
+
+	cards = []Card{NewCard("Kd"), NewCard("Ks"), NewCard("3s"), NewCard("3c"), NewCard("8s"), NewCard("Kc"), NewCard("8c")}
+	rank, bestCards = lookupEvaluate(cards)
+	assert.Equal(t, "Full House", RankString(rank))
+	assert.Equal(t, []Card{NewCard("Kd"), NewCard("Ks"), NewCard("8s"), NewCard("Kc"), NewCard("8c")}, bestCards)
+
+	cards = []Card{NewCard("Kd"), NewCard("Ks"), NewCard("Kc"), NewCard("9c"), NewCard("8s"), NewCard("2d")}
+	rank, bestCards = lookupEvaluate(cards)
+	assert.Equal(t, "Three of a Kind", RankString(rank))
+	assert.Equal(t, cards[:5], bestCards)
+
+	cards = []Card{NewCard("9d"), NewCard("Ts"), NewCard("Jh"), NewCard("Qc"), NewCard("Kd"), NewCard("As")}
+	rank, bestCards = lookupEvaluate(cards)
+	assert.Equal(t, "Straight", RankString(rank))
+	assert.Equal(t, cards[1:], bestCards)
+}
+
+func TestLookupEvaluateDifferential(t *testing.T) {
+	hands := *lookupDiffHands
+	if testing.Short() {
+		hands = 10000
+	}
+	r := rand.New(rand.NewSource(1))
+	deck := fullDeckCards()
+	for i := 0; i < hands; i++ {
+		cards := randomCards(r, deck, 6+i%2)
+		var expectedRank int32
+		var expectedCards []Card
+		if len(cards) == 6 {
+			expectedRank, expectedCards = six(cards...)
+		} else {
+			expectedRank, expectedCards = seven(cards...)
+		}
+		rank, bestCards := lookupEvaluate(cards)
+		require.Equal(t, expectedRank, rank, CardsToString(cards))
+		if expectedRank != MaxHighCard {
+			// The combination evaluator doesn't keep the cards of the worst possible hand.
+			require.Equal(t, expectedCards, bestCards, CardsToString(cards))
+		}
+	}
+}
+
+func TestEvaluateHandEvaluator(t *testing.T) {
+	defer SetHandEvaluator(GetHandEvaluator())
+
+	evaluator, err := ParseHandEvaluator("combinations")
+	require.NoError(t, err)
+	SetHandEvaluator(evaluator)
+	cards := []Card{NewCard("Ah"), NewCard("Ad"), NewCard("Kd"), NewCard("Ks"), NewCard("2c"), NewCard("7h"), NewCard("Kc")}
+	rank, bestCards := Evaluate(cards)
+
+	evaluator, err = ParseHandEvaluator("lookup")
+	require.NoError(t, err)
+	SetHandEvaluator(evaluator)
+	lookupRank, lookupBestCards := Evaluate(cards)
+	assert.Equal(t, rank, lookupRank)
+	assert.Equal(t, bestCards, lookupBestCards)
+
+	_, err = ParseHandEvaluator("two-plus-two")
+	assert.Error(t, err)
+}
+
+func TestLookupEvaluateAllocs(t *testing.T) {
+	cards := []Card{NewCard("Ah"), NewCard("Ad"), NewCard("Kd"), NewCard("Ks"), NewCard("2c"), NewCard("7h"), NewCard("Kc")}
+	allocs := testing.AllocsPerRun(100, func() {
+		lookupEvaluate(cards)
+	})
+	// only the best cards
+	assert.Equal(t, float64(1), allocs)
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	for _, numCards := range []int{6, 7} {
+		r := rand.New(rand.NewSource(1))
+		deck := fullDeckCards()
+		hands := make([][]Card, 1000)
+		for i := range hands {
+			hands[i] = append([]Card{}, randomCards(r, deck, numCards)...)
+		}
+		b.Run(fmt.Sprintf("%d-cards", numCards), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				lookupEvaluate(hands[i%len(hands)])
+			}
+		})
+		b.Run(fmt.Sprintf("%d-cards-combinations", numCards), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if numCards == 6 {
+					six(hands[i%len(hands)]...)
+				} else {
+					seven(hands[i%len(hands)]...)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package poker
+
+import (
+	"fmt"
+)
+
+// The hands dealt from a deck without the low ranks (MinRank above 0, like the
+// 36 card short deck) rank with the short deck rules:
+//
+//   - The ace also plays below the lowest rank of the deck. A-6-7-8-9 is the
+//     lowest straight of the short deck and takes the rank of the wheel.
+//   - A flush beats a full house. There are fewer flushes than full houses
+//     without the low ranks.
+//
+// A straight still beats three of a kind. The ranks compare like the standard
+// ranks (the lower rank wins), but the flushes are numbered before the full
+// houses. StandardRank converts a rank back to the standard numbering of the
+// same hand for RankClass, RankString and DescribeHand.
+
+const (
+	numFullHouseRanks = MaxFullHouse - MaxFourOfAKind
+	numFlushRanks     = MaxFlush - MaxFullHouse
+)
+
+// HasShortDeckRules returns whether the hands dealt from the deck rank with the
+// short deck rules.
+func (d DeckDefinition) HasShortDeckRules() bool {
+	return d.MinRank > 0
+}
+
+// Evaluate returns the rank and the best five cards of 5, 6 or 7 cards with the
+// hand ranking rules of the deck.
+func (d DeckDefinition) Evaluate(cards []Card) (int32, []Card) {
+	if !d.HasShortDeckRules() {
+		return Evaluate(cards)
+	}
+	if len(cards) < 5 || len(cards) > 7 {
+		panic("Only support 5, 6 and 7 cards.")
+	}
+	best := int32(MaxHighCard + 1)
+	hand := make([]Card, 5)
+	bestCards := make([]Card, 5)
+	forEachCombination(len(cards), 5, func(indexes []int) {
+		for i, index := range indexes {
+			hand[i] = cards[index]
+		}
+		rank := d.five(hand)
+		if rank < best {
+			best = rank
+			copy(bestCards, hand)
+		}
+	})
+	return best, bestCards
+}
+
+// EvaluateOmahaHand is EvaluateOmahaHand with the hand ranking rules of the deck.
+func (d DeckDefinition) EvaluateOmahaHand(playerCards []Card, boardCards []Card) OmahaHand {
+	if !d.HasShortDeckRules() {
+		return EvaluateOmahaHand(playerCards, boardCards)
+	}
+	if len(playerCards) > maxOmahaPlayerCards || len(boardCards) > maxBoardCards ||
+		omahaPairs[len(playerCards)] == nil || boardTriples[len(boardCards)] == nil {
+		panic(fmt.Sprintf("Omaha needs 2 to %d player cards and 3 to %d board cards. Got %d player cards and %d board cards",
+			maxOmahaPlayerCards, maxBoardCards, len(playerCards), len(boardCards)))
+	}
+	result := OmahaHand{
+		HiRank:  MaxHighCard + 1,
+		LowRank: 0x7FFFFFF,
+	}
+	for _, p := range omahaPairs[len(playerCards)] {
+		for _, t := range boardTriples[len(boardCards)] {
+			hand := omahaCards(playerCards, boardCards, p, t)
+			rank := d.five(hand[:])
+			if rank < result.HiRank {
+				result.HiRank = rank
+				result.HiCards = hand
+			}
+			var low int32
+			for _, card := range hand {
+				low |= lowRankBits[card.Rank()]
+			}
+			if low < result.LowRank && isLowRank(low) {
+				result.LowFound = true
+				result.LowRank = low
+				result.LowCards = hand
+			}
+		}
+	}
+	return result
+}
+
+// EvaluateOmaha is EvaluateOmaha with the hand ranking rules of the deck.
+func (d DeckDefinition) EvaluateOmaha(playerCards []Card, boardCards []Card) OmahaResult {
+	return omahaResult(d.EvaluateOmahaHand(playerCards, boardCards))
+}
+
+// StandardRank converts a rank returned by the evaluators of the deck to the
+// standard rank of the same hand.
+func (d DeckDefinition) StandardRank(rank int32) int32 {
+	if !d.HasShortDeckRules() {
+		return rank
+	}
+	switch {
+	case rank > MaxFourOfAKind && rank <= MaxFourOfAKind+numFlushRanks:
+		return rank + numFullHouseRanks
+	case rank > MaxFourOfAKind+numFlushRanks && rank <= MaxFlush:
+		return rank - numFlushRanks
+	}
+	return rank
+}
+
+// five ranks five cards with the short deck rules.
+func (d DeckDefinition) five(cards []Card) int32 {
+	rank, _ := five(cards...)
+	if d.isLowestStraight(cards) {
+		if cards[0]&cards[1]&cards[2]&cards[3]&cards[4]&0xF000 != 0 {
+			return MaxStraightFlush
+		}
+		return MaxStraight
+	}
+	switch {
+	case rank > MaxFourOfAKind && rank <= MaxFullHouse:
+		return rank + numFlushRanks
+	case rank > MaxFullHouse && rank <= MaxFlush:
+		return rank - numFullHouseRanks
+	}
+	return rank
+}
+
+// isLowestStraight returns whether the five cards are the ace and the four
+// lowest ranks of the deck.
+func (d DeckDefinition) isLowestStraight(cards []Card) bool {
+	var ranks int32
+	for _, card := range cards {
+		ranks |= 1 << uint(card.Rank())
+	}
+	return ranks == 1<<12|0xF<<uint(d.MinRank)
+}
@@ -0,0 +1,60 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortDeckStraights(t *testing.T) {
+	// A-6-7-8-9 is the lowest straight of the short deck
+	rank, cards := ShortDeck.Evaluate(newCards("As", "6h", "7d", "8c", "9s", "Kh", "Kd"))
+	assert.Equal(t, int32(Straight), RankClass(ShortDeck.StandardRank(rank)))
+	assert.Equal(t, "Straight, Nine high", DescribeHand(ShortDeck.StandardRank(rank), cards).Text())
+	sixHigh, _ := ShortDeck.Evaluate(newCards("6s", "7h", "8d", "9c", "Ts"))
+	assert.Less(t, sixHigh, rank, "6-7-8-9-T beats A-6-7-8-9")
+	trips, _ := ShortDeck.Evaluate(newCards("As", "Ah", "Ad", "8c", "9s"))
+	assert.Less(t, rank, trips)
+
+	// the standard rules don't know the short deck straight
+	rank, _ = StandardDeck.Evaluate(newCards("As", "6h", "7d", "8c", "9s"))
+	assert.Equal(t, int32(HighCard), RankClass(rank))
+
+	rank, _ = ShortDeck.Evaluate(newCards("Ah", "6h", "7h", "8h", "9h"))
+	assert.Equal(t, int32(StraightFlush), RankClass(ShortDeck.StandardRank(rank)))
+}
+
+func TestShortDeckFlushBeatsFullHouse(t *testing.T) {
+	flush, _ := ShortDeck.Evaluate(newCards("6h", "8h", "9h", "Jh", "Kh", "Ks", "Kd"))
+	fullHouse, cards := ShortDeck.Evaluate(newCards("Ks", "Kd", "Kc", "Ah", "As", "6d"))
+	assert.Less(t, flush, fullHouse)
+	assert.Equal(t, int32(Flush), RankClass(ShortDeck.StandardRank(flush)))
+	assert.Equal(t, int32(FullHouse), RankClass(ShortDeck.StandardRank(fullHouse)))
+	assert.Equal(t, "Full House, Kings full of Aces", DescribeHand(ShortDeck.StandardRank(fullHouse), cards).Text())
+
+	// the best flush and the worst full house keep their order
+	bestFlush, _ := ShortDeck.Evaluate(newCards("Ah", "Kh", "Qh", "Jh", "9h"))
+	worstFlush, _ := ShortDeck.Evaluate(newCards("6h", "7h", "8h", "9h", "Jh"))
+	bestFullHouse, _ := ShortDeck.Evaluate(newCards("As", "Ah", "Ad", "Kc", "Ks"))
+	fourOfAKind, _ := ShortDeck.Evaluate(newCards("6s", "6h", "6d", "6c", "7s"))
+	assert.Less(t, fourOfAKind, bestFlush)
+	assert.Less(t, bestFlush, worstFlush)
+	assert.Less(t, worstFlush, bestFullHouse)
+	standard, _ := StandardDeck.Evaluate(newCards("As", "Ah", "Ad", "Kc", "Ks"))
+	assert.Equal(t, standard, ShortDeck.StandardRank(bestFullHouse))
+}
+
+func TestShortDeckOmaha(t *testing.T) {
+	// the ace and the six of the player make the lowest straight, which beats
+	// the three kings
+	hand := ShortDeck.EvaluateOmahaHand(newCards("As", "6c", "Kd", "Kc"), newCards("7h", "8h", "9d", "Th", "Ks"))
+	assert.Equal(t, int32(Straight), RankClass(ShortDeck.StandardRank(hand.HiRank)))
+	assert.False(t, hand.LowFound)
+	hand = EvaluateOmahaHand(newCards("As", "6c", "Kd", "Kc"), newCards("7h", "8h", "9d", "Th", "Ks"))
+	assert.Equal(t, int32(ThreeOfAKind), RankClass(hand.HiRank))
+
+	flush := ShortDeck.EvaluateOmaha(newCards("Ah", "6h", "Kd", "Kc"), newCards("7h", "Jh", "Qh", "Ks", "Qs"))
+	assert.Equal(t, int32(Flush), RankClass(ShortDeck.StandardRank(flush.HiRank)))
+	standard := EvaluateOmaha(newCards("Ah", "6h", "Kd", "Kc"), newCards("7h", "Jh", "Qh", "Ks", "Qs"))
+	assert.Equal(t, int32(FullHouse), RankClass(standard.HiRank))
+}
@@ -1,73 +1,81 @@
 package poker
 
-//GenCombinations generates, from two natural numbers n > r,
-//all the possible combinations of r indexes taken from 0 to n-1.
-//For example if n=3 and r=2, the result will be:
-//[0,1], [0,2] and [1,2]
-func genCombinations(n, r int) <-chan []int {
-
+// indexCombinations generates, from two natural numbers n >= r,
+// all the possible combinations of r indexes taken from 0 to n-1
+// in lexicographic order.
+// For example if n=3 and r=2, the result will be:
+// [0,1], [0,2] and [1,2]
+func indexCombinations(n, r int) [][]uint8 {
 	if r > n {
 		panic("Invalid arguments")
 	}
 
-	ch := make(chan []int)
+	result := make([][]uint8, 0)
+	comb := make([]uint8, r)
+	for i := range comb {
+		comb[i] = uint8(i)
+	}
+	for {
+		temp := make([]uint8, r)
+		copy(temp, comb) // avoid overwriting of comb
+		result = append(result, temp)
 
-	go func() {
-		result := make([]int, r)
-		for i := range result {
-			result[i] = i
+		i := r - 1
+		for i >= 0 && int(comb[i]) == i+n-r {
+			i--
 		}
-
-		temp := make([]int, r)
-		copy(temp, result) // avoid overwriting of result
-		ch <- temp
-
-		for {
-			for i := r - 1; i >= 0; i-- {
-				if result[i] < i+n-r {
-					result[i]++
-					for j := 1; j < r-i; j++ {
-						result[i+j] = result[i] + j
-					}
-					temp := make([]int, r)
-					copy(temp, result) // avoid overwriting of result
-					ch <- temp
-					break
-				}
-			}
-			if result[0] >= n-r {
-				break
-			}
+		if i < 0 {
+			break
 		}
-		close(ch)
-
-	}()
-	return ch
+		comb[i]++
+		for j := i + 1; j < r; j++ {
+			comb[j] = comb[j-1] + 1
+		}
+	}
+	return result
 }
 
-//CombinationsInt generates all the combinations of r elements
-//extracted from an slice of integers
-func combinations(iterable []Card, r int) chan []Card {
-
-	length := len(iterable)
-	if length < r {
+// combinations generates all the combinations of r elements
+// extracted from a slice of cards.
+func combinations(iterable []Card, r int) [][]Card {
+	if len(iterable) < r {
 		panic("Invalid arguments")
 	}
-	ch := make(chan []Card)
 
-	go func() {
+	indexes := indexCombinations(len(iterable), r)
+	result := make([][]Card, len(indexes))
+	for i, comb := range indexes {
+		result[i] = make([]Card, r)
+		for j, idx := range comb {
+			result[i][j] = iterable[idx]
+		}
+	}
+	return result
+}
 
-		length := len(iterable)
+// forEachCombination calls fn with every combination of r indexes taken from
+// 0 to n-1 in lexicographic order. The slice is reused between the calls.
+func forEachCombination(n, r int, fn func(indexes []int)) {
+	if r > n {
+		return
+	}
+	comb := make([]int, r)
+	for i := range comb {
+		comb[i] = i
+	}
+	for {
+		fn(comb)
 
-		for comb := range genCombinations(length, r) {
-			result := make([]Card, r)
-			for i, val := range comb {
-				result[i] = iterable[val]
-			}
-			ch <- result
+		i := r - 1
+		for i >= 0 && comb[i] == i+n-r {
+			i--
 		}
-
-		close(ch)
-	}()
-	return ch
+		if i < 0 {
+			return
+		}
+		comb[i]++
+		for j := i + 1; j < r; j++ {
+			comb[j] = comb[j-1] + 1
+		}
+	}
 }
@@ -0,0 +1,141 @@
+package poker
+
+// The decks of DeckDefinition can have jokers and repeated cards, which the
+// lookup tables don't know about. A joker is wild: the hand is evaluated with
+// every card in place of each joker and the best rank wins. The best cards
+// have the cards the jokers play as. With more than one deck, a flush with a
+// repeated card is not a flush and five cards of a rank rank as the best four
+// of a kind of the rank. These hands are evaluated five cards at a time.
+
+// hasSpecialCards returns whether the cards have a joker or a repeated card.
+func hasSpecialCards(cards []Card, moreCards []Card) bool {
+	var seen uint64
+	for _, set := range [2][]Card{cards, moreCards} {
+		for _, card := range set {
+			if card.IsJoker() {
+				return true
+			}
+			bit := uint64(1) << uint(cardIndex(card))
+			if seen&bit != 0 {
+				return true
+			}
+			seen |= bit
+		}
+	}
+	return false
+}
+
+func jokerPositions(cards []Card) []int {
+	var positions []int
+	for i, card := range cards {
+		if card.IsJoker() {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// forEachWildCard puts every combination of the 52 cards in place of the
+// jokers at the positions and calls fn.
+func forEachWildCard(cards []Card, positions []int, from int, fn func()) {
+	if len(positions) == 0 {
+		fn()
+		return
+	}
+	for i := from; i < len(allCards); i++ {
+		cards[positions[0]] = allCards[i]
+		forEachWildCard(cards, positions[1:], i, fn)
+	}
+}
+
+// evaluateSpecial evaluates 5 to 7 cards with jokers or repeated cards.
+func evaluateSpecial(cards []Card) (int32, []Card) {
+	best := int32(MaxHighCard + 1)
+	var bestCards []Card
+	if jokers := jokerPositions(cards); len(jokers) > 0 {
+		hand := append([]Card{}, cards...)
+		forEachWildCard(hand, jokers, 0, func() {
+			rank, played := Evaluate(hand)
+			if rank < best {
+				best = rank
+				bestCards = append(bestCards[:0], played...)
+			}
+		})
+		return best, bestCards
+	}
+
+	hand := make([]Card, 5)
+	bestCards = make([]Card, 5)
+	forEachCombination(len(cards), 5, func(indexes []int) {
+		for i, index := range indexes {
+			hand[i] = cards[index]
+		}
+		rank, _ := five(hand...)
+		if rank < best {
+			best = rank
+			copy(bestCards, hand)
+		}
+	})
+	return best, bestCards
+}
+
+// evaluateOmahaSpecial is evaluateOmaha for the player and board cards with
+// jokers or repeated cards.
+func evaluateOmahaSpecial(playerCards []Card, boardCards []Card, evaluateLow bool) OmahaHand {
+	result := OmahaHand{
+		HiRank:  MaxHighCard + 1,
+		LowRank: 0x7FFFFFF,
+	}
+	all := append(append([]Card{}, playerCards...), boardCards...)
+	if jokers := jokerPositions(all); len(jokers) > 0 {
+		n := len(playerCards)
+		forEachWildCard(all, jokers, 0, func() {
+			hand := evaluateOmaha(all[:n], all[n:], evaluateLow)
+			if hand.HiRank < result.HiRank {
+				result.HiRank = hand.HiRank
+				result.HiCards = hand.HiCards
+			}
+			if hand.LowFound && hand.LowRank < result.LowRank {
+				result.LowFound = true
+				result.LowRank = hand.LowRank
+				result.LowCards = hand.LowCards
+			}
+		})
+		return result
+	}
+
+	for _, p := range omahaPairs[len(playerCards)] {
+		for _, t := range boardTriples[len(boardCards)] {
+			hand := omahaCards(playerCards, boardCards, p, t)
+			rank, _ := five(hand[:]...)
+			if rank < result.HiRank {
+				result.HiRank = rank
+				result.HiCards = hand
+			}
+			if !evaluateLow {
+				continue
+			}
+			var low int32
+			for _, card := range hand {
+				low |= lowRankBits[card.Rank()]
+			}
+			if low < result.LowRank && isLowRank(low) {
+				result.LowFound = true
+				result.LowRank = low
+				result.LowCards = hand
+			}
+		}
+	}
+	return result
+}
+
+// fiveOfAKindRank is the rank of five cards of the rank: the best four of a
+// kind of the rank.
+func fiveOfAKindRank(rank int32) int32 {
+	kicker := int32(12)
+	if rank == 12 {
+		kicker = 11
+	}
+	p := primes[rank]
+	return table.unsuitedLookup[p*p*p*p*primes[kicker]]
+}
@@ -0,0 +1,57 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCards(cards ...string) []Card {
+	result := make([]Card, len(cards))
+	for i, card := range cards {
+		result[i] = NewCard(card)
+	}
+	return result
+}
+
+func TestEvaluateJokers(t *testing.T) {
+	quads, _ := Evaluate(newCards("As", "Ah", "Ad", "Ac", "Kd", "2c", "3h"))
+	rank, cards := Evaluate(newCards("As", "Ah", "Ad", JokerString, "Kd", "2c", "3h"))
+	assert.Equal(t, quads, rank)
+	assert.NotContains(t, cards, Joker)
+	assert.Equal(t, "Four of a Kind, Aces, King kicker", DescribeHand(rank, cards).Text())
+
+	// two jokers make the royal flush
+	rank, _ = Evaluate(newCards("As", "Ks", JokerString, JokerString, "Qs", "2c", "3h"))
+	assert.Equal(t, int32(1), rank)
+
+	// the joker completes the straight
+	rank, cards = Evaluate(newCards("9s", "8h", "7d", JokerString, "5c"))
+	assert.Equal(t, "Straight, Nine high", DescribeHand(rank, cards).Text())
+}
+
+func TestEvaluateRepeatedCards(t *testing.T) {
+	// a repeated card is not a flush
+	rank, _ := Evaluate(newCards("Ah", "Ah", "Kh", "Qh", "Jh"))
+	assert.Equal(t, int32(Pair), RankClass(rank))
+
+	rank, _ = Evaluate(newCards("Ah", "Ah", "Kh", "Qh", "Jh", "2h", "3c"))
+	assert.Equal(t, int32(Flush), RankClass(rank))
+
+	// five of a kind is the best four of a kind of the rank
+	quads, _ := Evaluate(newCards("As", "Ah", "Ad", "Ac", "Kd"))
+	rank, _ = Evaluate(newCards("As", "Ah", "Ad", "Ac", "As", "2c", "3h"))
+	assert.Equal(t, quads, rank)
+}
+
+func TestEvaluateOmahaJokers(t *testing.T) {
+	board := newCards("Kh", "7h", "2h", "4d", "Qs")
+	hand := EvaluateOmahaHand(newCards("Ah", JokerString, "9c", "9d"), board)
+	assert.Equal(t, int32(Flush), RankClass(hand.HiRank))
+	assert.True(t, hand.LowFound)
+	assert.Equal(t, "7-4 low", DescribeLow(hand.LowCards[:]).Text())
+
+	// a repeated card on the board and in the hand is not a flush
+	hand = EvaluateOmahaHand(newCards("Kh", "3h", "9c", "9d"), board)
+	assert.Equal(t, int32(Pair), RankClass(hand.HiRank))
+}
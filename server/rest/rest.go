@@ -80,6 +80,13 @@ func RunRestServer(gameManager *nats.GameManager, endSystemTestCallback func())
 	r.POST("/end-game", endGame)
 	r.GET("/games", getGames)
 	r.GET("/current-hand-log", gameCurrentHandLog)
+	r.GET("/hand-state-snapshots", handStateSnapshots)
+	r.POST("/hand-for-hand", setHandForHand)
+	r.GET("/hand-for-hand", getHandForHand)
+	r.POST("/sit-and-go", createSitAndGo)
+	r.POST("/sit-and-go/register", registerSitAndGo)
+	r.GET("/sit-and-go", getSitAndGo)
+	r.POST("/internal/save-hand/tournamentId/:tournamentId/tableNo/:tableNo", saveSitAndGoHand)
 	if util.Env.IsSystemTest() {
 		onEndSystemTest = endSystemTestCallback
 		r.POST("/end-system-test", endSystemTest)
@@ -271,6 +278,64 @@ func gameCurrentHandLog(c *gin.Context) {
 	c.JSON(status, log)
 }
 
+// handStateSnapshots lists the hands that have hand state snapshots
+// (/hand-state-snapshots?game-code=<>), or returns the snapshots of a hand
+// (/hand-state-snapshots?game-code=<>&hand-num=<>). Add states=false to skip
+// the hand states and return only the snapshot list.
+func handStateSnapshots(c *gin.Context) {
+	gameCode := c.Query("game-code")
+	if gameCode == "" {
+		c.String(400, "Game code should be specified (e.g /hand-state-snapshots?game-code=<>&hand-num=<>)")
+		return
+	}
+
+	handNumStr := c.Query("hand-num")
+	if handNumStr == "" {
+		type payload struct {
+			GameCode string   `json:"gameCode"`
+			Hands    []uint32 `json:"hands"`
+		}
+		hands, err := game.GameManager.HandStateSnapshotHands(gameCode)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, appError{
+				Code:    http.StatusInternalServerError,
+				Message: err.Error(),
+			})
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, payload{GameCode: gameCode, Hands: hands})
+		return
+	}
+
+	handNum, err := strconv.ParseUint(handNumStr, 10, 32)
+	if err != nil {
+		c.String(400, "Failed to parse hand-num [%s] from hand state snapshots endpoint.", handNumStr)
+		return
+	}
+	snapshots, err := game.GameManager.HandStateSnapshots(gameCode, uint32(handNum))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, appError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		c.Error(err)
+		return
+	}
+	if c.Query("states") == "false" {
+		for _, snapshot := range snapshots {
+			snapshot.State = nil
+		}
+	}
+
+	type payload struct {
+		GameCode  string                    `json:"gameCode"`
+		HandNum   uint32                    `json:"handNum"`
+		Snapshots []*game.HandStateSnapshot `json:"snapshots"`
+	}
+	c.JSON(http.StatusOK, payload{GameCode: gameCode, HandNum: uint32(handNum), Snapshots: snapshots})
+}
+
 func endSystemTest(c *gin.Context) {
 	onEndSystemTest()
 	return
@@ -306,3 +371,45 @@ func leftGame(c *gin.Context) {
 		Msgf("Player %d left the game %s", playerID, gameCode)
 	natsGameManager.LeftGame(gameID, playerID)
 }
+
+func setHandForHand(c *gin.Context) {
+	type payload struct {
+		TournamentID     uint32 `json:"tournamentId"`
+		Enabled          bool   `json:"enabled"`
+		PlayersRemaining int    `json:"playersRemaining"`
+	}
+	var p payload
+	err := c.BindJSON(&p)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, appError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		c.Error(err)
+		return
+	}
+	err = natsGameManager.SetHandForHand(p.TournamentID, p.Enabled, p.PlayersRemaining)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, appError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, natsGameManager.HandForHandState(p.TournamentID))
+}
+
+func getHandForHand(c *gin.Context) {
+	tournamentIDStr := c.Query("tournament-id")
+	if tournamentIDStr == "" {
+		c.String(400, "Tournament id should be specified (e.g /hand-for-hand?tournament-id=<>")
+		return
+	}
+	tournamentID, err := strconv.ParseUint(tournamentIDStr, 10, 32)
+	if err != nil {
+		c.String(400, "Failed to parse tournament-id [%s] from hand-for-hand endpoint.", tournamentIDStr)
+		return
+	}
+	c.JSON(http.StatusOK, natsGameManager.HandForHandState(uint32(tournamentID)))
+}
@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"voyager.com/server/game"
+)
+
+func badRequest(c *gin.Context, err error) {
+	c.IndentedJSON(http.StatusBadRequest, appError{
+		Code:    http.StatusBadRequest,
+		Message: err.Error(),
+	})
+	c.Error(err)
+}
+
+func createSitAndGo(c *gin.Context) {
+	type level struct {
+		SmallBlind   float64 `json:"sb"`
+		BigBlind     float64 `json:"bb"`
+		Ante         float64 `json:"ante"`
+		DurationSecs uint32  `json:"durationSecs"`
+		Break        bool    `json:"break"`
+	}
+	type payload struct {
+		TournamentID    uint32  `json:"tournamentId"`
+		Seats           uint32  `json:"seats"`
+		StartingChips   float64 `json:"startingChips"`
+		GameType        string  `json:"gameType"`
+		HandsPerLevel   uint32  `json:"handsPerLevel"`
+		ActionTime      uint32  `json:"actionTime"`
+		ResultPauseTime uint32  `json:"resultPauseTime"`
+		PrizePool       float64 `json:"prizePool"`
+		Levels          []level `json:"levels"`
+	}
+	var p payload
+	err := c.BindJSON(&p)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	config := game.SitAndGoConfig{
+		Seats:           p.Seats,
+		StartingChips:   p.StartingChips,
+		GameType:        game.GameType_HOLDEM,
+		HandsPerLevel:   p.HandsPerLevel,
+		ActionTime:      p.ActionTime,
+		ResultPauseTime: p.ResultPauseTime,
+		PrizePool:       p.PrizePool,
+	}
+	if p.GameType != "" {
+		gameType, ok := game.GameType_value[p.GameType]
+		if !ok || game.GameType(gameType) == game.GameType_UNKNOWN {
+			badRequest(c, fmt.Errorf("Unknown game type: %s", p.GameType))
+			return
+		}
+		config.GameType = game.GameType(gameType)
+	}
+	for i, l := range p.Levels {
+		config.Levels = append(config.Levels, game.BlindLevel{
+			Level:      uint32(i + 1),
+			SmallBlind: l.SmallBlind,
+			BigBlind:   l.BigBlind,
+			Ante:       l.Ante,
+			Duration:   time.Duration(l.DurationSecs) * time.Second,
+			Break:      l.Break,
+		})
+	}
+	err = natsGameManager.CreateSitAndGo(p.TournamentID, config)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	state, _ := natsGameManager.SitAndGoState(p.TournamentID)
+	c.JSON(http.StatusOK, state)
+}
+
+func registerSitAndGo(c *gin.Context) {
+	type payload struct {
+		TournamentID  uint32 `json:"tournamentId"`
+		PlayerID      uint64 `json:"playerId"`
+		PlayerUUID    string `json:"playerUuid"`
+		Name          string `json:"name"`
+		EncryptionKey string `json:"encryptionKey"`
+	}
+	var p payload
+	err := c.BindJSON(&p)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	tableInfo, err := natsGameManager.RegisterSitAndGo(p.TournamentID, game.SitAndGoPlayer{
+		PlayerID:      p.PlayerID,
+		PlayerUUID:    p.PlayerUUID,
+		Name:          p.Name,
+		EncryptionKey: p.EncryptionKey,
+	})
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tableInfo)
+}
+
+func getSitAndGo(c *gin.Context) {
+	tournamentIDStr := c.Query("tournament-id")
+	if tournamentIDStr == "" {
+		c.String(400, "Tournament id should be specified (e.g /sit-and-go?tournament-id=<>")
+		return
+	}
+	tournamentID, err := strconv.ParseUint(tournamentIDStr, 10, 32)
+	if err != nil {
+		c.String(400, "Failed to parse tournament-id [%s] from sit-and-go endpoint.", tournamentIDStr)
+		return
+	}
+	state, err := natsGameManager.SitAndGoState(uint32(tournamentID))
+	if err != nil {
+		c.String(404, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// saveSitAndGoHand takes the hand results of the sit-and-go tables in place
+// of the tournament service.
+func saveSitAndGoHand(c *gin.Context) {
+	tournamentID, err := strconv.ParseUint(c.Param("tournamentId"), 10, 32)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	tableNo, err := strconv.ParseUint(c.Param("tableNo"), 10, 32)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	var result game.HandResultServer
+	err = protojson.Unmarshal(body, &result)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	err = natsGameManager.SitAndGoHandResult(uint32(tournamentID), uint32(tableNo), result.HandNum)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, game.SaveHandResult{
+		HandNum: int(result.HandNum),
+		Success: true,
+	})
+}
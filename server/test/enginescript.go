@@ -0,0 +1,600 @@
+package test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+	"voyager.com/server/game"
+	"voyager.com/server/poker"
+	"voyager.com/server/util"
+)
+
+// EngineScript runs a game script directly against the hand engine
+// (game.DealScriptedHand and game.Apply) instead of a game server. There is no
+// NATS or redis involved, so the scripts can run as regular go tests.
+//
+// The hand messages returned by the engine stand in for the messages the
+// observer receives in the test driver and are verified the same way.
+type EngineScript struct {
+	gameScript *game.GameScript
+	filename   string
+	result     *ScriptTestResult
+	seats      map[uint32]game.PlayerSeat
+	// positions and seats of the last hand (game-config move-button)
+	positions game.ButtonPositions
+	played    []uint32
+}
+
+// engineHand keeps track of the hand messages like the observer player does.
+type engineHand struct {
+	hand          *game.Hand
+	state         *game.HandState
+	newHand       *game.NewHand
+	lastMsgItem   *game.HandMessageItem
+	yourAction    *game.NextSeatAction
+	actionChange  *game.ActionChange
+	flop          *game.Flop
+	turn          *game.Turn
+	river         *game.River
+	showdown      *game.Showdown
+	noMoreActions *game.NoMoreActions
+	runItTwice    *game.RunItTwiceBoards
+	handResult    *game.HandResultClient
+}
+
+// RunEngineScript loads a game script and runs it with the hand engine.
+func RunEngineScript(filename string) (*ScriptTestResult, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var gameScript game.GameScript
+	err = yaml.Unmarshal(data, &gameScript)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScriptTestResult{Filename: filename, Failures: make([]error, 0)}
+	if gameScript.Disabled {
+		result.Disabled = true
+		return result, nil
+	}
+
+	s := &EngineScript{
+		gameScript: &gameScript,
+		filename:   filename,
+		result:     result,
+		seats:      make(map[uint32]game.PlayerSeat),
+	}
+	for _, seat := range gameScript.AssignSeat.Seats {
+		s.seats[seat.SeatNo] = seat
+	}
+	for i := range gameScript.Hands {
+		err := s.runHand(&gameScript.Hands[i])
+		if err != nil {
+			result.addError(err)
+			break
+		}
+	}
+	result.Passed = len(result.Failures) == 0
+	return result, nil
+}
+
+func (s *EngineScript) handConfig(handNum uint32, positions game.ButtonPositions) *game.HandConfig {
+	gameConfig := s.gameScript.GameConfig
+	chipUnit := game.ChipUnit_DOLLAR
+	if gameConfig.ChipUnit == "CENT" {
+		chipUnit = game.ChipUnit_CENT
+	}
+	return &game.HandConfig{
+		GameID:            1,
+		HandNum:           handNum,
+		GameType:          game.GameType(game.GameType_value[gameConfig.GameTypeStr]),
+		MaxPlayers:        uint32(gameConfig.MaxPlayers),
+		SmallBlind:        util.ChipsToCents(gameConfig.SmallBlind),
+		BigBlind:          util.ChipsToCents(gameConfig.BigBlind),
+		Ante:              util.ChipsToCents(gameConfig.Ante),
+		StraddleBet:       util.ChipsToCents(gameConfig.StraddleBet),
+		MandatoryStraddle: gameConfig.MandatoryStraddle,
+		BringIn:           util.ChipsToCents(gameConfig.BringIn),
+		RakePercentage:    gameConfig.RakePercentage,
+		RakeCap:           util.ChipsToCents(gameConfig.RakeCap),
+		ButtonPos:         positions.ButtonPos,
+		SbPos:             positions.SbPos,
+		BbPos:             positions.BbPos,
+		ActionTime:        uint32(gameConfig.ActionTime),
+		ChipUnit:          chipUnit,
+		Tournament:        gameConfig.Tournament,
+	}
+}
+
+// seatPlayers returns the players in the seats. Like the game server in the
+// script mode, every hand starts with the buy-in stacks.
+func (s *EngineScript) seatPlayers() []game.SeatPlayer {
+	names := make(map[uint64]string)
+	for _, player := range s.gameScript.Players {
+		names[player.ID] = player.Name
+	}
+	seats := make([]game.SeatPlayer, 0)
+	for seatNo := uint32(1); seatNo <= uint32(s.gameScript.GameConfig.MaxPlayers); seatNo++ {
+		seat, ok := s.seats[seatNo]
+		if !ok {
+			continue
+		}
+		seats = append(seats, game.SeatPlayer{
+			SeatNo:      seatNo,
+			PlayerID:    seat.Player,
+			PlayerUUID:  fmt.Sprintf("%d", seat.Player),
+			Name:        names[seat.Player],
+			Stack:       util.ChipsToCents(seat.BuyIn),
+			Status:      game.PlayerStatus_PLAYING,
+			Inhand:      true,
+			PostedBlind: seat.PostBlind,
+			RunItTwice:  seat.RunItTwice,
+		})
+	}
+	return seats
+}
+
+func (s *EngineScript) runHand(hand *game.Hand) error {
+	// the posted blinds are reset before every hand, only the new players can post
+	for seatNo, seat := range s.seats {
+		seat.PostBlind = false
+		s.seats[seatNo] = seat
+	}
+	for _, seatNo := range hand.Setup.LeftSeats {
+		delete(s.seats, seatNo)
+	}
+	for _, newPlayer := range hand.Setup.NewPlayers {
+		s.seats[newPlayer.SeatNo] = newPlayer
+	}
+	seats := s.seatPlayers()
+	seatNos := make([]uint32, len(seats))
+	for i, seat := range seats {
+		seatNos[i] = seat.SeatNo
+	}
+
+	// the button goes to the first player in the table unless the button moves
+	// or the hand setup says otherwise
+	var positions game.ButtonPositions
+	if len(seats) > 0 {
+		positions.ButtonPos = seats[0].SeatNo
+	}
+	if s.gameScript.GameConfig.MoveButton && s.played != nil {
+		positions = game.NextButtonPositions(uint32(s.gameScript.GameConfig.MaxPlayers), s.positions, s.played, seatNos)
+	}
+	if hand.Setup.ButtonPos > 0 {
+		positions = game.ButtonPositions{ButtonPos: hand.Setup.ButtonPos}
+	}
+
+	setup := &game.TestHandSetup{
+		HandNum:   hand.Num,
+		ButtonPos: hand.Setup.ButtonPos,
+		Board:     hand.Setup.Board,
+		Board2:    hand.Setup.Board2,
+		Flop:      hand.Setup.Flop,
+		Turn:      hand.Setup.Turn,
+		River:     hand.Setup.River,
+	}
+	for _, sc := range hand.Setup.SeatCards {
+		seatCards := &game.GameSetupSeatCards{Cards: sc.Cards}
+		setup.PlayerCards = append(setup.PlayerCards, seatCards)
+		if sc.SeatNo != 0 {
+			if setup.PlayerCardsBySeat == nil {
+				setup.PlayerCardsBySeat = make(map[uint32]*game.GameSetupSeatCards)
+			}
+			setup.PlayerCardsBySeat[sc.SeatNo] = seatCards
+		}
+	}
+	if hand.Setup.BombPot {
+		setup.BombPot = true
+		setup.BombPotBet = float64(hand.Setup.BombPotBet)
+		setup.DoubleBoard = hand.Setup.DoubleBoard
+	}
+
+	state, msgItems, err := game.DealScriptedHand(s.handConfig(hand.Num, positions), seats, setup)
+	if err != nil {
+		return fmt.Errorf("Hand %d: could not deal: %v", hand.Num, err)
+	}
+	s.positions = game.ButtonPositions{
+		ButtonPos: state.ButtonPos,
+		SbPos:     state.SmallBlindPos,
+		BbPos:     state.BigBlindPos,
+	}
+	s.played = seatNos
+	h := &engineHand{hand: hand, state: state}
+	h.received(msgItems)
+
+	err = s.verifySetup(h)
+	if err != nil {
+		return err
+	}
+
+	rounds := []*game.BettingRound{
+		&hand.PreflopAction,
+		&hand.FlopAction,
+		&hand.TurnAction,
+		&hand.RiverAction,
+	}
+	for _, round := range rounds {
+		err = s.bettingRound(h, round)
+		if err != nil {
+			return err
+		}
+		if h.handResult != nil {
+			break
+		}
+	}
+	if h.handResult == nil {
+		return fmt.Errorf("Hand %d: no results found after the river", hand.Num)
+	}
+	return s.verifyResult(h)
+}
+
+// received records the engine messages like the observer does with the
+// messages from the game server.
+func (h *engineHand) received(msgItems []*game.HandMessageItem) {
+	for _, msgItem := range game.ClientUnitMessages(msgItems) {
+		h.lastMsgItem = msgItem
+		switch msgItem.MessageType {
+		case game.HandNewHand:
+			h.newHand = msgItem.GetNewHand()
+		case game.HandYourAction:
+			h.yourAction = msgItem.GetSeatAction()
+		case game.HandNextAction:
+			h.actionChange = msgItem.GetActionChange()
+		case game.HandFlop:
+			h.flop = msgItem.GetFlop()
+		case game.HandTurn:
+			h.turn = msgItem.GetTurn()
+		case game.HandRiver:
+			h.river = msgItem.GetRiver()
+		case game.HandShowDown:
+			h.showdown = msgItem.GetShowdown()
+		case game.HandNoMoreActions:
+			h.noMoreActions = msgItem.GetNoMoreActions()
+		case game.HandRunItTwice:
+			h.runItTwice = msgItem.GetRunItTwice()
+		case game.HandResultMessage2:
+			h.handResult = msgItem.GetHandResultClient()
+		}
+	}
+}
+
+func (h *engineHand) apply(action *game.HandAction) error {
+	state, msgItems, err := game.Apply(h.state, action)
+	if err != nil {
+		return fmt.Errorf("Hand %d: seat %d action %s failed: %v", h.hand.Num, action.SeatNo, action.Action, err)
+	}
+	h.state = state
+	h.received(msgItems)
+	return nil
+}
+
+func (s *EngineScript) bettingRound(h *engineHand, round *game.BettingRound) error {
+	if h.noMoreActions == nil {
+		actions, err := scriptActions(round)
+		if err != nil {
+			return err
+		}
+		for _, action := range actions {
+			if action.VerifyAction != nil {
+				err := verifySeatAction(h.yourAction, action.VerifyAction)
+				if err != nil {
+					return fmt.Errorf("Hand %d seat %d: %v", h.hand.Num, action.SeatNo, err)
+				}
+			}
+			err := h.apply(&game.HandAction{
+				SeatNo: action.SeatNo,
+				Action: game.ACTION(game.ACTION_value[action.Action]),
+				Amount: util.ChipsToCents(action.Amount),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// answer the run-it-twice prompt
+	for h.state.RunItTwicePrompt {
+		rit := h.state.GetRunItTwice()
+		if rit.Seat1Responded && rit.Seat2Responded {
+			break
+		}
+		seatNo := rit.Seat1
+		if rit.Seat1Responded {
+			seatNo = rit.Seat2
+		}
+		response := game.ACTION_RUN_IT_TWICE_NO
+		if s.seats[seatNo].RunItTwicePromptResponse {
+			response = game.ACTION_RUN_IT_TWICE_YES
+		}
+		err := h.apply(&game.HandAction{SeatNo: seatNo, Action: response})
+		if err != nil {
+			return err
+		}
+	}
+
+	s.verifyBettingRound(h, &round.Verify)
+	return nil
+}
+
+// scriptActions returns the actions of the betting round including the ones
+// written as "seat, action, amount" strings.
+func scriptActions(round *game.BettingRound) ([]game.TestHandAction, error) {
+	if round.SeatActions == nil {
+		return round.Actions, nil
+	}
+	actions := make([]game.TestHandAction, len(round.SeatActions))
+	for i, actionStr := range round.SeatActions {
+		s := strings.Split(strings.TrimSpace(actionStr), ",")
+		if len(s) != 2 && len(s) != 3 {
+			return nil, fmt.Errorf("Invalid action found: %s", actionStr)
+		}
+		seatNo, _ := strconv.Atoi(strings.TrimSpace(s[0]))
+		actions[i] = game.TestHandAction{
+			SeatNo: uint32(seatNo),
+			Action: strings.TrimSpace(s[1]),
+		}
+		if len(s) == 3 {
+			actions[i].Amount, _ = strconv.ParseFloat(strings.TrimSpace(s[2]), 32)
+		}
+	}
+	return actions, nil
+}
+
+func verifySeatAction(seatAction *game.NextSeatAction, expected *game.VerifyAction) error {
+	if seatAction == nil {
+		return fmt.Errorf("No action was requested from the player")
+	}
+	actionsStr := convertActions(seatAction.AvailableActions)
+	if !IsEqual(actionsStr, expected.Actions) {
+		return fmt.Errorf("Actions does not match. Expected: %+v Actual: %+v", expected.Actions, actionsStr)
+	}
+	callAvailable := false
+	for _, action := range seatAction.AvailableActions {
+		if action == game.ACTION_CALL {
+			callAvailable = true
+			break
+		}
+	}
+	if callAvailable && seatAction.CallAmount != expected.CallAmount {
+		return fmt.Errorf("Call amount does not match. Expected: %+v Actual: %+v", expected.CallAmount, seatAction.CallAmount)
+	}
+	if seatAction.AllInAmount != expected.AllInAmount {
+		return fmt.Errorf("All in amount does not match. Expected: %+v Actual: %+v", expected.AllInAmount, seatAction.AllInAmount)
+	}
+	if seatAction.MinRaiseAmount != expected.MinRaiseAmount {
+		return fmt.Errorf("Min raise amount does not match. Expected: %+v Actual: %+v", expected.MinRaiseAmount, seatAction.MinRaiseAmount)
+	}
+	if seatAction.MaxRaiseAmount != expected.MaxRaiseAmount {
+		return fmt.Errorf("Max raise amount does not match. Expected: %+v Actual: %+v", expected.MaxRaiseAmount, seatAction.MaxRaiseAmount)
+	}
+	if expected.BetAmounts != nil {
+		if len(expected.BetAmounts) != len(seatAction.BetOptions) {
+			return fmt.Errorf("Bet options do not match. Expected: %+v Actual: %+v", expected.BetAmounts, seatAction.BetOptions)
+		}
+		for i, betOption := range seatAction.BetOptions {
+			if expected.BetAmounts[i].Text != betOption.Text || expected.BetAmounts[i].Amount != betOption.Amount {
+				return fmt.Errorf("Bet options do not match. Expected: %+v Actual: %+v", expected.BetAmounts, seatAction.BetOptions)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *EngineScript) verifySetup(h *engineHand) error {
+	verify := h.hand.Setup.Verify
+	actual := h.newHand
+	if actual == nil {
+		return fmt.Errorf("Hand %d: new hand message is not found", h.hand.Num)
+	}
+	passed := true
+	if verify.Button != 0 && actual.ButtonPos != verify.Button {
+		s.addError(h, fmt.Errorf("Button position did not match. Expected: %d actual: %d", verify.Button, actual.ButtonPos))
+		passed = false
+	}
+	if verify.SB != 0 && actual.SbPos != verify.SB {
+		s.addError(h, fmt.Errorf("SB position did not match. Expected: %d actual: %d", verify.SB, actual.SbPos))
+		passed = false
+	}
+	if verify.BB != 0 && actual.BbPos != verify.BB {
+		s.addError(h, fmt.Errorf("BB position did not match. Expected: %d actual: %d", verify.BB, actual.BbPos))
+		passed = false
+	}
+	if verify.NextActionPos != 0 && actual.NextActionSeat != verify.NextActionPos {
+		s.addError(h, fmt.Errorf("Next action position did not match. Expected: %d actual: %d", verify.NextActionPos, actual.NextActionSeat))
+		passed = false
+	}
+	state := h.state
+	for _, blindSeat := range verify.PostedBlinds {
+		playerAct := actual.PlayersActed[blindSeat]
+		if playerAct.GetAction() != game.ACTION_POST_BLIND {
+			s.addError(h, fmt.Errorf("Post blind did not match for seat %d. Expected: %d actual: %d",
+				blindSeat, game.ACTION_POST_BLIND, playerAct.GetAction()))
+			passed = false
+		}
+	}
+	if len(verify.State) != 0 && verify.State != state.CurrentState.String() {
+		s.addError(h, fmt.Errorf("Hand state does not match. Expected: %s actual: %s", verify.State, state.CurrentState))
+		passed = false
+	}
+	if !passed {
+		return fmt.Errorf("Hand %d: failed to verify at hand setup step", h.hand.Num)
+	}
+
+	for _, seat := range verify.DealtCards {
+		cards := make([]uint32, 0)
+		for _, card := range state.PlayersCards[seat.SeatNo] {
+			cards = append(cards, uint32(card))
+		}
+		playerCards := poker.ByteCardsToStringArray(cards)
+		if !reflect.DeepEqual(playerCards, seat.Cards) {
+			s.addError(h, fmt.Errorf("Player cards and dealt cards don't match. Seat pos: %d Expected: %v actual: %v",
+				seat.SeatNo, seat.Cards, playerCards))
+		}
+	}
+	return nil
+}
+
+func (s *EngineScript) verifyBettingRound(h *engineHand, verify *game.VerifyBettingRound) {
+	status := h.state.CurrentState.String()
+	switch verify.State {
+	case "FLOP", "TURN", "RESULT":
+		if status != verify.State {
+			s.addError(h, fmt.Errorf("Expected hand status as %s Actual: %s", verify.State, status))
+			return
+		}
+	}
+	if verify.Board != nil {
+		var board []uint32
+		switch verify.State {
+		case "FLOP":
+			board = h.flop.GetBoard()
+		case "TURN":
+			board = h.turn.GetBoard()
+		}
+		if board != nil && !reflect.DeepEqual(poker.ByteCardsToStringArray(board), verify.Board) {
+			s.addError(h, fmt.Errorf("Board cards did not match with expected cards. Expected: %s actual: %s",
+				poker.CardsToString(verify.Board), poker.CardsToString(board)))
+		}
+	}
+
+	if verify.Pots != nil {
+		gamePots := h.actionChange.GetSeatsPots()
+		switch verify.State {
+		case "FLOP":
+			gamePots = h.flop.GetSeatsPots()
+		case "TURN":
+			gamePots = h.turn.GetSeatsPots()
+		case "RIVER":
+			gamePots = h.river.GetSeatsPots()
+		case "SHOWDOWN":
+			gamePots = h.showdown.GetSeatsPots()
+		}
+		if h.noMoreActions != nil {
+			gamePots = h.noMoreActions.Pots
+		}
+		if h.runItTwice != nil {
+			gamePots = h.runItTwice.SeatsPots
+		}
+
+		if len(verify.Pots) != len(gamePots) {
+			s.addError(h, fmt.Errorf("Pot count does not match. Expected: %d actual: %d", len(verify.Pots), len(gamePots)))
+			return
+		}
+		for i, expectedPot := range verify.Pots {
+			actualPot := gamePots[i]
+			if expectedPot.Pot != actualPot.Pot {
+				s.addError(h, fmt.Errorf("Pot [%d] amount does not match. Expected: %f actual: %f", i, expectedPot.Pot, actualPot.Pot))
+			}
+			for _, seatNo := range expectedPot.SeatsInPot {
+				found := false
+				for _, actualSeat := range actualPot.Seats {
+					if actualSeat == seatNo {
+						found = true
+						break
+					}
+				}
+				if !found {
+					s.addError(h, fmt.Errorf("Pot [%d] seat %d is not in the pot", i, seatNo))
+				}
+			}
+		}
+	}
+
+	if verify.RunItTwice && h.runItTwice == nil {
+		s.addError(h, fmt.Errorf("Expected to run it twice"))
+	}
+
+	if verify.Stacks != nil {
+		var stacks map[uint32]float64
+		switch verify.State {
+		case "FLOP":
+			stacks = h.flop.GetPlayerBalance()
+		case "TURN":
+			stacks = h.turn.GetPlayerBalance()
+		case "RIVER":
+			stacks = h.river.GetPlayerBalance()
+		case "SHOWDOWN":
+			stacks = h.showdown.GetPlayerBalance()
+		}
+		for _, stack := range verify.Stacks {
+			playerStack, ok := stacks[stack.Seat]
+			if ok && playerStack != stack.Stack {
+				s.addError(h, fmt.Errorf("Player at seatNo [%d] stack did not match. Expected: %f Actual %f found at state: %s",
+					stack.Seat, stack.Stack, playerStack, verify.State))
+			} else if !ok && stack.Stack != 0 {
+				s.addError(h, fmt.Errorf("Player at seatNo [%d] stack is not found at state: %s", stack.Seat, verify.State))
+			}
+		}
+	}
+}
+
+func (s *EngineScript) verifyResult(h *engineHand) error {
+	expected := h.hand.Result
+	handResult := h.handResult
+	passed := true
+	if len(handResult.PotWinners) == 0 {
+		return fmt.Errorf("Hand %d: no pot winners in the result", h.hand.Num)
+	}
+	potWinner := handResult.PotWinners[0]
+	for idx, board := range expected.Boards {
+		if idx >= len(potWinner.BoardWinners) {
+			s.addError(h, fmt.Errorf("Board %d is not found in the result", idx+1))
+			passed = false
+			continue
+		}
+		if !s.verifyWinners(h, handResult.PlayerInfo, potWinner.BoardWinners[idx].HiWinners, board.Winners) {
+			passed = false
+		}
+	}
+	if expected.Winners != nil && !s.verifyWinners(h, handResult.PlayerInfo, potWinner.BoardWinners[0].HiWinners, expected.Winners) {
+		passed = false
+	}
+	if expected.LoWinners != nil && !s.verifyWinners(h, handResult.PlayerInfo, potWinner.BoardWinners[0].LowWinners, expected.LoWinners) {
+		passed = false
+	}
+	if expected.ActionEndedAt != "" && expected.ActionEndedAt != handResult.WonAt.String() {
+		s.addError(h, fmt.Errorf("Action won at is not matching. Expected %s, actual: %s", expected.ActionEndedAt, handResult.WonAt))
+		passed = false
+	}
+	if !passed {
+		return fmt.Errorf("Hand %d: failed when verifying the hand result", h.hand.Num)
+	}
+	return nil
+}
+
+func (s *EngineScript) verifyWinners(h *engineHand, playerInfo map[uint32]*game.PlayerHandInfo, actualWinners map[uint32]*game.Winner, expectedWinners []game.TestHandWinner) bool {
+	if len(actualWinners) != len(expectedWinners) {
+		s.addError(h, fmt.Errorf("Number of winners didn't match. Expected %d, actual: %d", len(expectedWinners), len(actualWinners)))
+		return false
+	}
+	passed := true
+	for _, expectedWinner := range expectedWinners {
+		handWinner, ok := actualWinners[expectedWinner.Seat]
+		if !ok {
+			s.addError(h, fmt.Errorf("Winner seat %d is not found in the result", expectedWinner.Seat))
+			passed = false
+			continue
+		}
+		if handWinner.Amount != expectedWinner.Receive {
+			s.addError(h, fmt.Errorf("Winner winning didn't match. Expected %f, actual: %f", expectedWinner.Receive, handWinner.Amount))
+			passed = false
+		}
+		if expectedWinner.Rake > 0 && expectedWinner.Rake != playerInfo[handWinner.SeatNo].RakePaid {
+			s.addError(h, fmt.Errorf("Winner rake amount didn't match. Expected %f, actual: %f",
+				expectedWinner.Rake, playerInfo[handWinner.SeatNo].RakePaid))
+			passed = false
+		}
+	}
+	return passed
+}
+
+func (s *EngineScript) addError(h *engineHand, e error) {
+	s.result.addError(fmt.Errorf("Hand %d: %v", h.hand.Num, e))
+}
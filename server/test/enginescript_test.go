@@ -0,0 +1,40 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEngineGameScripts(t *testing.T) {
+	var files []string
+	err := filepath.Walk("game-scripts", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".yaml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			result, err := RunEngineScript(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Disabled {
+				t.Skip("script is disabled")
+			}
+			for _, e := range result.Failures {
+				t.Error(e)
+			}
+		})
+	}
+}
@@ -480,7 +480,7 @@ func (h *TestHand) verifyHandResult(t *TestDriver, handResult *game.HandResult)
 
 			if seatNo == expectedStack.Seat {
 				if player.Balance.After != expectedStack.Stack {
-					h.addError(fmt.Errorf("Player %d seatNo: %d is not matching. Expected %f, actual: %f", player.Balance.After, seatNo,
+					h.addError(fmt.Errorf("Player %d seatNo: %d is not matching. Expected %f, actual: %f", player.Id, seatNo,
 						expectedStack.Stack, player.Balance.After))
 					passed = false
 				}
@@ -17,6 +17,8 @@ type TimerMsg struct {
 	ExpireAt         time.Time
 	RunItTwice       bool
 	ActionID         string
+	// set for the tournament buy-in prompts between hands
+	TournamentBuyIn bool
 }
 
 type TimerExtendMsg struct {
@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -29,6 +30,7 @@ type gameServerEnvironment struct {
 	PostgresHost           string
 	PostgresPort           string
 	PostgresCrashDB        string
+	PostgresHandStateDB    string
 	PostgresUser           string
 	PostgresPW             string
 	PostgresSSLMode        string
@@ -36,6 +38,13 @@ type gameServerEnvironment struct {
 	DebugConnectivityCheck string
 	SystemTest             string
 	LogLevel               string
+	ResultOutboxDir        string
+	MaxPendingResults      string
+	BoltDBPath             string
+	SnapshotDir            string
+	SnapshotHands          string
+	HandEvaluator          string
+	GameServerURL          string
 }
 
 // Env is a helper object for accessing environment variables.
@@ -55,6 +64,7 @@ var Env = &gameServerEnvironment{
 	PostgresHost:           "POSTGRES_HOST",
 	PostgresPort:           "POSTGRES_PORT",
 	PostgresCrashDB:        "POSTGRES_CRASH_DB",
+	PostgresHandStateDB:    "POSTGRES_HAND_STATE_DB",
 	PostgresUser:           "POSTGRES_USER",
 	PostgresPW:             "POSTGRES_PASSWORD",
 	PostgresSSLMode:        "POSTGRES_SSL_MODE",
@@ -62,6 +72,13 @@ var Env = &gameServerEnvironment{
 	DebugConnectivityCheck: "DEBUG_CONNECTIVITY_CHECK",
 	SystemTest:             "SYSTEM_TEST",
 	LogLevel:               "LOG_LEVEL",
+	ResultOutboxDir:        "RESULT_OUTBOX_DIR",
+	MaxPendingResults:      "MAX_PENDING_RESULTS",
+	BoltDBPath:             "BOLT_DB_PATH",
+	SnapshotDir:            "HAND_STATE_SNAPSHOT_DIR",
+	SnapshotHands:          "HAND_STATE_SNAPSHOT_HANDS",
+	HandEvaluator:          "HAND_EVALUATOR",
+	GameServerURL:          "GAME_SERVER_URL",
 }
 
 func (g *gameServerEnvironment) GetNatsURL() string {
@@ -215,6 +232,69 @@ func (g *gameServerEnvironment) GetPostgresCrashDB() string {
 	return v
 }
 
+func (g *gameServerEnvironment) GetPostgresHandStateDB() string {
+	v := os.Getenv(g.PostgresHandStateDB)
+	if v == "" {
+		msg := fmt.Sprintf("%s is not defined", g.PostgresHandStateDB)
+		environmentLogger.Error().Msg(msg)
+		panic(msg)
+	}
+	return v
+}
+
+func (g *gameServerEnvironment) GetBoltDBPath() string {
+	v := os.Getenv(g.BoltDBPath)
+	if v == "" {
+		return filepath.Join(os.TempDir(), "game-server-hand-state.db")
+	}
+	return v
+}
+
+func (g *gameServerEnvironment) GetSnapshotDir() string {
+	v := os.Getenv(g.SnapshotDir)
+	if v == "" {
+		return filepath.Join(os.TempDir(), "game-server-hand-state-snapshots")
+	}
+	return v
+}
+
+// GetSnapshotHands returns the number of latest hands per game to keep the
+// hand state snapshots for. 0 disables the snapshots.
+func (g *gameServerEnvironment) GetSnapshotHands() int {
+	s := os.Getenv(g.SnapshotHands)
+	if s == "" {
+		return 20
+	}
+	hands, err := strconv.Atoi(s)
+	if err != nil || hands < 0 {
+		msg := fmt.Sprintf("Invalid integer [%s] for hand state snapshot hands", s)
+		environmentLogger.Error().Msg(msg)
+		panic(msg)
+	}
+	return hands
+}
+
+// GetGameServerURL returns the URL the game server is reached at. The tables
+// of the sit-and-go tournaments hosted by this server send their hand results
+// here.
+func (g *gameServerEnvironment) GetGameServerURL() string {
+	v := os.Getenv(g.GameServerURL)
+	if v == "" {
+		return "http://localhost:8080"
+	}
+	return v
+}
+
+// GetHandEvaluator returns how the 6 and 7 card hands are evaluated, lookup
+// (default) or combinations.
+func (g *gameServerEnvironment) GetHandEvaluator() string {
+	v := os.Getenv(g.HandEvaluator)
+	if v == "" {
+		return "lookup"
+	}
+	return v
+}
+
 func (g *gameServerEnvironment) GetApiServerInternalURL() string {
 	url := os.Getenv(g.APIServerInternalURL)
 	if url == "" {
@@ -302,6 +382,28 @@ func (g *gameServerEnvironment) IsSystemTest() bool {
 	return g.GetSystemTest() == "1" || strings.ToLower(g.GetSystemTest()) == "true"
 }
 
+func (g *gameServerEnvironment) GetResultOutboxDir() string {
+	v := os.Getenv(g.ResultOutboxDir)
+	if v == "" {
+		return filepath.Join(os.TempDir(), "game-server-result-outbox")
+	}
+	return v
+}
+
+func (g *gameServerEnvironment) GetMaxPendingResults() int {
+	s := os.Getenv(g.MaxPendingResults)
+	if s == "" {
+		return 3
+	}
+	maxPending, err := strconv.Atoi(s)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid integer [%s] for max pending results value", s)
+		environmentLogger.Error().Msg(msg)
+		panic(msg)
+	}
+	return maxPending
+}
+
 func (g *gameServerEnvironment) GetLogLevel() string {
 	v := os.Getenv(g.LogLevel)
 	if v == "" {
@@ -0,0 +1,321 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"voyager.com/server/game"
+	"voyager.com/server/poker"
+	"voyager.com/server/util/random"
+)
+
+// The table balancing simulation plays tournaments of bots with
+// game.PlanTableBalance the way the game server does: the tables deal hands in
+// a random order, a table applies the moves of its players when its hand ends,
+// and the players bust at random until one player is left. It checks that the
+// seating stays valid and counts the moves.
+
+// bustProbability is the probability that a player busts in a hand.
+const bustProbability = 0.2
+
+// BalanceConfig is the configuration of SimulateTableBalancing.
+type BalanceConfig struct {
+	NumTournaments int
+	NumPlayers     int
+	TableSize      int
+	// Seed of the simulation (random if 0).
+	Seed uint64
+}
+
+// BalanceReport is the result of SimulateTableBalancing.
+type BalanceReport struct {
+	NumTournaments int    `json:"numTournaments"`
+	NumPlayers     int    `json:"numPlayers"`
+	TableSize      int    `json:"tableSize"`
+	Seed           uint64 `json:"seed"`
+	Hands          int    `json:"hands"`
+	Moves          int    `json:"moves"`
+	BrokenTables   int    `json:"brokenTables"`
+	// MovesPerPlayer is the average number of moves of a player.
+	MovesPerPlayer float64 `json:"movesPerPlayer"`
+	// MaxPlayerMoves is the most moves of a player in a tournament.
+	MaxPlayerMoves int `json:"maxPlayerMoves"`
+	// LateSeats counts the moved players who had to wait the whole orbit for
+	// the big blind because the seats after the big blind were taken.
+	LateSeats int `json:"lateSeats"`
+	// MaxTableDifference is the biggest difference in the number of players
+	// of two tables after a table applied its moves.
+	MaxTableDifference int `json:"maxTableDifference"`
+	// MaxFinalTableHands is the most hands dealt at the other tables after
+	// the players fit at the final table.
+	MaxFinalTableHands int      `json:"maxFinalTableHands"`
+	Errors             []string `json:"errors"`
+}
+
+// Pass returns whether the simulation didn't find invalid seating.
+func (r *BalanceReport) Pass() bool {
+	return len(r.Errors) == 0
+}
+
+// JSON returns the report as indented JSON.
+func (r *BalanceReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Text returns the report as a table.
+func (r *BalanceReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Table balancing: %d tournaments, %d players, %d seats per table, seed %d\n",
+		r.NumTournaments, r.NumPlayers, r.TableSize, r.Seed)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "hands", r.Hands)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "moves", r.Moves)
+	fmt.Fprintf(&b, "  %-28s %10.2f\n", "moves per player", r.MovesPerPlayer)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "max moves of a player", r.MaxPlayerMoves)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "broken tables", r.BrokenTables)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "late seats", r.LateSeats)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "max table difference", r.MaxTableDifference)
+	fmt.Fprintf(&b, "  %-28s %10d\n", "max hands before final table", r.MaxFinalTableHands)
+	for _, e := range r.Errors {
+		fmt.Fprintf(&b, "ERROR: %s\n", e)
+	}
+	if r.Pass() {
+		fmt.Fprintf(&b, "PASS\n")
+	} else {
+		fmt.Fprintf(&b, "FAIL\n")
+	}
+	return b.String()
+}
+
+type simTable struct {
+	tableNo      uint32
+	bigBlindSeat uint32
+	seats        map[uint32]uint64
+}
+
+type simTournament struct {
+	tableSize uint32
+	tables    map[uint32]*simTable
+	moves     map[uint64]int
+	report    *BalanceReport
+	rng       *rand.Rand
+}
+
+// SimulateTableBalancing plays the tournaments and returns the report.
+func SimulateTableBalancing(config BalanceConfig) (*BalanceReport, error) {
+	if config.TableSize < 2 || config.NumPlayers < 2 || config.NumTournaments < 1 {
+		return nil, fmt.Errorf("Invalid table balancing simulation %d tournaments of %d players at %d seat tables",
+			config.NumTournaments, config.NumPlayers, config.TableSize)
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = uint64(random.NewSeed())
+	}
+	report := &BalanceReport{
+		NumTournaments: config.NumTournaments,
+		NumPlayers:     config.NumPlayers,
+		TableSize:      config.TableSize,
+		Seed:           seed,
+	}
+	rng := poker.NewSeededRand(seed)
+	for i := 0; i < config.NumTournaments; i++ {
+		t := newSimTournament(config, rng, report)
+		err := t.play()
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Tournament %d: %s", i+1, err))
+		}
+	}
+	report.MovesPerPlayer = float64(report.Moves) / float64(config.NumTournaments*config.NumPlayers)
+	return report, nil
+}
+
+func newSimTournament(config BalanceConfig, rng *rand.Rand, report *BalanceReport) *simTournament {
+	t := &simTournament{
+		tableSize: uint32(config.TableSize),
+		tables:    make(map[uint32]*simTable),
+		moves:     make(map[uint64]int),
+		report:    report,
+		rng:       rng,
+	}
+	numTables := (config.NumPlayers + config.TableSize - 1) / config.TableSize
+	for i := 1; i <= numTables; i++ {
+		t.tables[uint32(i)] = &simTable{
+			tableNo: uint32(i),
+			seats:   make(map[uint32]uint64),
+		}
+	}
+	// the players draw their seats
+	for playerID := 1; playerID <= config.NumPlayers; playerID++ {
+		table := t.tables[uint32((playerID-1)%numTables+1)]
+		for {
+			seatNo := uint32(rng.Intn(config.TableSize) + 1)
+			if _, taken := table.seats[seatNo]; !taken {
+				table.seats[seatNo] = uint64(playerID)
+				break
+			}
+		}
+	}
+	for _, table := range t.tables {
+		seats := table.occupiedSeats(t.tableSize)
+		table.bigBlindSeat = seats[rng.Intn(len(seats))]
+	}
+	return t
+}
+
+// occupiedSeats returns the occupied seats starting from the big blind.
+func (s *simTable) occupiedSeats(tableSize uint32) []uint32 {
+	var seats []uint32
+	start := s.bigBlindSeat
+	if start == 0 {
+		start = 1
+	}
+	for i := uint32(0); i < tableSize; i++ {
+		seatNo := (start+i-1)%tableSize + 1
+		if _, ok := s.seats[seatNo]; ok {
+			seats = append(seats, seatNo)
+		}
+	}
+	return seats
+}
+
+func (t *simTournament) numPlayers() int {
+	n := 0
+	for _, table := range t.tables {
+		n += len(table.seats)
+	}
+	return n
+}
+
+func (t *simTournament) play() error {
+	maxHands := t.report.NumPlayers * 1000
+	hands := 0
+	finalTableHands := 0
+	for t.numPlayers() > 1 {
+		tableNos := make([]uint32, 0, len(t.tables))
+		for tableNo := range t.tables {
+			tableNos = append(tableNos, tableNo)
+		}
+		sort.Slice(tableNos, func(i, j int) bool { return tableNos[i] < tableNos[j] })
+		t.rng.Shuffle(len(tableNos), func(i, j int) { tableNos[i], tableNos[j] = tableNos[j], tableNos[i] })
+
+		for _, tableNo := range tableNos {
+			table, ok := t.tables[tableNo]
+			if !ok {
+				continue
+			}
+			err := t.applyMoves(table)
+			if err != nil {
+				return err
+			}
+			if _, ok := t.tables[tableNo]; !ok || len(table.seats) < 2 {
+				continue
+			}
+			if len(t.tables) > 1 && t.numPlayers() <= int(t.tableSize) {
+				finalTableHands++
+			}
+			t.playHand(table)
+			hands++
+			if hands > maxHands {
+				return fmt.Errorf("Tournament did not end in %d hands", maxHands)
+			}
+		}
+	}
+	if len(t.tables) != 1 {
+		return fmt.Errorf("Tournament ended with %d tables", len(t.tables))
+	}
+	t.report.Hands += hands
+	if finalTableHands > t.report.MaxFinalTableHands {
+		t.report.MaxFinalTableHands = finalTableHands
+	}
+	for _, moves := range t.moves {
+		if moves > t.report.MaxPlayerMoves {
+			t.report.MaxPlayerMoves = moves
+		}
+	}
+	return nil
+}
+
+// applyMoves plans the balance and moves the players of the table.
+func (t *simTournament) applyMoves(table *simTable) error {
+	tables := make([]game.BalanceTable, 0, len(t.tables))
+	for _, st := range t.tables {
+		bt := game.BalanceTable{
+			TableNo:      st.tableNo,
+			MaxSeats:     t.tableSize,
+			BigBlindSeat: st.bigBlindSeat,
+		}
+		for seatNo, playerID := range st.seats {
+			bt.Players = append(bt.Players, game.BalanceSeat{SeatNo: seatNo, PlayerID: playerID})
+		}
+		tables = append(tables, bt)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].TableNo < tables[j].TableNo })
+	plan := game.PlanTableBalance(tables)
+
+	for _, move := range plan.MovesFrom(table.tableNo) {
+		playerID, ok := table.seats[move.FromSeatNo]
+		if !ok || playerID != move.PlayerID {
+			return fmt.Errorf("Player %d is not at table %d seat %d", move.PlayerID, move.FromTableNo, move.FromSeatNo)
+		}
+		to, ok := t.tables[move.ToTableNo]
+		if !ok || plan.IsBroken(move.ToTableNo) {
+			return fmt.Errorf("Player %d is moved to table %d that is broken", move.PlayerID, move.ToTableNo)
+		}
+		if move.ToSeatNo < 1 || move.ToSeatNo > t.tableSize {
+			return fmt.Errorf("Player %d is moved to invalid seat %d", move.PlayerID, move.ToSeatNo)
+		}
+		if _, taken := to.seats[move.ToSeatNo]; taken {
+			return fmt.Errorf("Player %d is moved to table %d seat %d that is taken", move.PlayerID, move.ToTableNo, move.ToSeatNo)
+		}
+		if t.isLateSeat(to, move.ToSeatNo) {
+			t.report.LateSeats++
+		}
+		delete(table.seats, move.FromSeatNo)
+		to.seats[move.ToSeatNo] = playerID
+		t.moves[playerID]++
+		t.report.Moves++
+	}
+	if len(table.seats) == 0 {
+		delete(t.tables, table.tableNo)
+		t.report.BrokenTables++
+	}
+
+	min, max := int(t.tableSize), 0
+	for _, st := range t.tables {
+		if len(st.seats) < min {
+			min = len(st.seats)
+		}
+		if len(st.seats) > max {
+			max = len(st.seats)
+		}
+	}
+	if max-min > t.report.MaxTableDifference {
+		t.report.MaxTableDifference = max - min
+	}
+	return nil
+}
+
+// isLateSeat returns whether the empty seat posts the big blind after all the
+// players of the table.
+func (t *simTournament) isLateSeat(table *simTable, seatNo uint32) bool {
+	seats := table.occupiedSeats(t.tableSize)
+	if len(seats) == 0 {
+		return false
+	}
+	first := seats[0]
+	last := seats[len(seats)-1]
+	distance := func(s uint32) uint32 { return (s + t.tableSize - first) % t.tableSize }
+	return distance(seatNo) > distance(last)
+}
+
+// playHand busts a player at random and moves the big blind.
+func (t *simTournament) playHand(table *simTable) {
+	seats := table.occupiedSeats(t.tableSize)
+	if t.rng.Float64() < bustProbability {
+		delete(table.seats, seats[t.rng.Intn(len(seats))])
+	}
+	// the next big blind is the seat after the big blind of this hand
+	table.bigBlindSeat = seats[0]%t.tableSize + 1
+}
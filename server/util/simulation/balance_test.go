@@ -0,0 +1,21 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateTableBalancing(t *testing.T) {
+	report, err := SimulateTableBalancing(BalanceConfig{
+		NumTournaments: 200,
+		NumPlayers:     60,
+		TableSize:      9,
+		Seed:           7,
+	})
+	require.NoError(t, err)
+	assert.True(t, report.Pass(), report.Text())
+	assert.Greater(t, report.Moves, 0)
+	assert.Equal(t, 200*6, report.BrokenTables)
+}
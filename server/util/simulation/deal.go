@@ -6,9 +6,18 @@ import (
 
 	"voyager.com/server/game"
 	"voyager.com/server/poker"
+	"voyager.com/server/util/random"
 )
 
-func Run(numDeals int) error {
+// Run deals and counts the ranks. The deals are reproducible for the same
+// seed. A random seed is used if seed is 0.
+func Run(numDeals int, seed uint64) error {
+	if seed == 0 {
+		seed = uint64(random.NewSeed())
+	}
+	fmt.Printf("Deal seed: %d\n", seed)
+	rng := poker.NewSeededRand(seed)
+
 	gameType := game.GameType_HOLDEM
 	numPlayers := 9
 	numCardsPerPlayer := -1
@@ -33,7 +42,7 @@ func Run(numDeals int) error {
 		rankClasses[rc] = 0
 	}
 
-	deck := poker.NewDeck()
+	deck := poker.NewDeckWithRand(rng)
 
 	numEval := 0
 	numRoyalFlushes := 0
@@ -51,7 +60,7 @@ func Run(numDeals int) error {
 		// Start a new game every 100 hands.
 		handNum := (i % 100) + 1
 
-		playerCards, communityCards, err := shuffleAndDeal(deck, numCardsPerPlayer, numPlayers, gameType, handNum)
+		playerCards, communityCards, err := shuffleAndDeal(rng, deck, numCardsPerPlayer, numPlayers, gameType, handNum)
 		if err != nil {
 			return err
 		}
@@ -70,7 +79,7 @@ func Run(numDeals int) error {
 				}
 				rank, _ = poker.Evaluate(cards)
 			} else {
-				result := poker.EvaluateOmaha(pc, communityCards)
+				result := poker.EvaluateOmahaHand(pc, communityCards)
 				rank = result.HiRank
 			}
 			numEval++
@@ -155,7 +164,7 @@ func isBoardOnePair(cards []poker.Card) bool {
 	return rank > poker.MaxTwoPair && rank <= poker.MaxPair
 }
 
-func shuffleAndDeal(deck *poker.Deck, numCardsPerPlayer int, numPlayers int, gameType game.GameType, handNum int) (map[uint32][]poker.Card, []poker.Card, error) {
+func shuffleAndDeal(rng *rand.Rand, deck *poker.Deck, numCardsPerPlayer int, numPlayers int, gameType game.GameType, handNum int) (map[uint32][]poker.Card, []poker.Card, error) {
 	deck.Shuffle()
 	playerCards, communityCards, err := dealCards(deck, numCardsPerPlayer, numPlayers)
 	if err != nil {
@@ -169,7 +178,7 @@ func shuffleAndDeal(deck *poker.Deck, numCardsPerPlayer int, numPlayers int, gam
 		}
 	} else if handNum > 10 && handNum <= 20 {
 		if game.AnyoneHasHighHand(playerCards, communityCards, gameType, poker.MaxFourOfAKind) {
-			if rand.Int()%2 == 0 {
+			if rng.Int()%2 == 0 {
 				deck.Shuffle()
 				playerCards, communityCards, err = dealCards(deck, numCardsPerPlayer, numPlayers)
 			}
@@ -177,7 +186,7 @@ func shuffleAndDeal(deck *poker.Deck, numCardsPerPlayer int, numPlayers int, gam
 	}
 
 	if !game.AnyoneHasHighHand(playerCards, communityCards, gameType, poker.MaxFullHouse) {
-		if rand.Int()%2 == 0 {
+		if rng.Int()%2 == 0 {
 			maxReshuffleAllowed := 1
 			reshuffles := 0
 			for game.AnyoneHasHighHand(playerCards, communityCards, gameType, poker.MaxFullHouse) ||
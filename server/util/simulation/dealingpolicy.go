@@ -0,0 +1,195 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"voyager.com/server/game"
+	"voyager.com/server/poker"
+	"voyager.com/server/util/random"
+)
+
+// The dealing policy comparison deals the same number of Hold'em hands with
+// each dealing policy (game.DealHand with the same seed) and compares the
+// frequencies of the common and rare hands with the theoretical probabilities
+// of a fair deal. It quantifies how much the reshuffles of a policy change the
+// distribution the players see.
+
+// PolicyRate is the observed frequency of a hand category against the
+// probability of a fair deal.
+type PolicyRate struct {
+	Name     string  `json:"name"`
+	Count    int     `json:"count"`
+	Total    int     `json:"total"`
+	Rate     float64 `json:"rate"`
+	Expected float64 `json:"expected"`
+	// PValue is the two-sided p-value of the binomial z score.
+	PValue float64 `json:"pValue"`
+}
+
+// PolicyStats is the result of the deals with one dealing policy.
+type PolicyStats struct {
+	Policy             string         `json:"policy"`
+	Reshuffles         int            `json:"reshuffles"`
+	ReshufflesPerHand  float64        `json:"reshufflesPerHand"`
+	ReshufflesByReason map[string]int `json:"reshufflesByReason"`
+	Rates              []PolicyRate   `json:"rates"`
+}
+
+// PolicyComparison is the result of CompareDealingPolicies.
+type PolicyComparison struct {
+	NumDeals     int           `json:"numDeals"`
+	NumPlayers   int           `json:"numPlayers"`
+	Seed         uint64        `json:"seed"`
+	HandsPerGame int           `json:"handsPerGame"`
+	Policies     []PolicyStats `json:"policies"`
+}
+
+// probabilities of a fair deal
+const (
+	pocketPairProbability    = 1.0 / 17
+	flopPairedProbability    = 0.171765
+	riverPairedProbability   = 0.492917
+	fullHouseProbability     = 0.025963
+	fourOfAKindProbability   = 0.001680
+	straightFlushProbability = 0.000311 // including royal flushes
+)
+
+type dealtHandCounts struct {
+	pocketPairs     int
+	pairedFlops     int
+	pairedBoards    int
+	fullHouses      int
+	fourOfAKinds    int
+	straightFlushes int
+}
+
+// CompareDealingPolicies deals config.NumDeals hands with each policy. The
+// DealingPolicy of the config is ignored.
+func CompareDealingPolicies(config AuditConfig, policies []game.DealingPolicy) (*PolicyComparison, error) {
+	if config.NumDeals < 1 {
+		return nil, fmt.Errorf("Dealing policy comparison needs at least 1 deal")
+	}
+	if config.NumPlayers < 2 || config.NumPlayers > 9 {
+		return nil, fmt.Errorf("Invalid number of players %d. It must be 2 to 9", config.NumPlayers)
+	}
+	if config.Seed == 0 {
+		config.Seed = uint64(random.NewSeed())
+	}
+	if config.HandsPerGame == 0 {
+		config.HandsPerGame = 100
+	}
+
+	comparison := &PolicyComparison{
+		NumDeals:     config.NumDeals,
+		NumPlayers:   config.NumPlayers,
+		Seed:         config.Seed,
+		HandsPerGame: config.HandsPerGame,
+	}
+	seats := simulationSeats(config.NumPlayers)
+	for _, policy := range policies {
+		rng := poker.NewSeededRand(config.Seed)
+		counts := &dealtHandCounts{}
+		stats := PolicyStats{
+			Policy:             policy.String(),
+			ReshufflesByReason: make(map[string]int),
+		}
+		for i := 0; i < config.NumDeals; i++ {
+			h, err := dealHoldemHand(seats, i, config.HandsPerGame, policy, rng)
+			if err != nil {
+				return nil, err
+			}
+			for _, reshuffle := range h.Reshuffles {
+				stats.ReshufflesByReason[reshuffle.Reason]++
+			}
+			stats.Reshuffles += len(h.Reshuffles)
+			counts.add(h)
+		}
+		stats.ReshufflesPerHand = float64(stats.Reshuffles) / float64(config.NumDeals)
+
+		numHands := config.NumDeals * config.NumPlayers
+		stats.Rates = []PolicyRate{
+			newPolicyRate("pocket pairs", counts.pocketPairs, numHands, pocketPairProbability),
+			newPolicyRate("paired flops", counts.pairedFlops, config.NumDeals, flopPairedProbability),
+			newPolicyRate("paired boards", counts.pairedBoards, config.NumDeals, riverPairedProbability),
+			newPolicyRate("full houses", counts.fullHouses, numHands, fullHouseProbability),
+			newPolicyRate("four of a kinds", counts.fourOfAKinds, numHands, fourOfAKindProbability),
+			newPolicyRate("straight flushes", counts.straightFlushes, numHands, straightFlushProbability),
+		}
+		comparison.Policies = append(comparison.Policies, stats)
+	}
+	return comparison, nil
+}
+
+// add counts the pocket pairs, the paired boards and the best hands of the
+// players at the river.
+func (c *dealtHandCounts) add(h *game.HandState) {
+	board := poker.FromByteCards(h.BoardCards)
+	pairedAt := game.PairedAt(board)
+	if pairedAt > 0 && pairedAt <= 3 {
+		c.pairedFlops++
+	}
+	if pairedAt > 0 {
+		c.pairedBoards++
+	}
+	for _, playerCards := range h.PlayersCards {
+		cards := poker.FromByteCards(playerCards)
+		if cards[0].Rank() == cards[1].Rank() {
+			c.pocketPairs++
+		}
+		rank, _ := poker.Evaluate(append(cards, board...))
+		switch {
+		case rank <= poker.MaxStraightFlush:
+			c.straightFlushes++
+		case rank <= poker.MaxFourOfAKind:
+			c.fourOfAKinds++
+		case rank <= poker.MaxFullHouse:
+			c.fullHouses++
+		}
+	}
+}
+
+func newPolicyRate(name string, count int, total int, expected float64) PolicyRate {
+	n := float64(total)
+	z := (float64(count) - n*expected) / math.Sqrt(n*expected*(1-expected))
+	return PolicyRate{
+		Name:     name,
+		Count:    count,
+		Total:    total,
+		Rate:     float64(count) / n,
+		Expected: expected,
+		PValue:   normalPValue(z),
+	}
+}
+
+// JSON returns the comparison in JSON.
+func (c *PolicyComparison) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// Text returns the comparison as tables.
+func (c *PolicyComparison) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dealing policies: %d deals, %d players, seed %d, %d hands per game\n",
+		c.NumDeals, c.NumPlayers, c.Seed, c.HandsPerGame)
+	for _, stats := range c.Policies {
+		fmt.Fprintf(&b, "\n%s: %d reshuffles (%.4f per hand)\n", stats.Policy, stats.Reshuffles, stats.ReshufflesPerHand)
+		reasons := make([]string, 0, len(stats.ReshufflesByReason))
+		for reason := range stats.ReshufflesByReason {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			fmt.Fprintf(&b, "  %-32s %10d\n", reason, stats.ReshufflesByReason[reason])
+		}
+		fmt.Fprintf(&b, "  %-18s %20s %10s %10s %10s\n", "Hands", "Count", "Rate", "Expected", "p-value")
+		for _, rate := range stats.Rates {
+			fmt.Fprintf(&b, "  %-18s %20s %10.6f %10.6f %10.6f\n", rate.Name,
+				fmt.Sprintf("%d/%d", rate.Count, rate.Total), rate.Rate, rate.Expected, rate.PValue)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,461 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/pkg/errors"
+	"voyager.com/server/game"
+	"voyager.com/server/poker"
+	"voyager.com/server/util/random"
+)
+
+// The RNG audit deals Hold'em hands through the game server's dealing path
+// (game.DealHand, the same shuffle and reshuffle logic as the real games) and
+// runs statistical tests on the dealt decks and hole cards.
+//
+// A test passes when its p-value is at least AuditSignificance. With several
+// tests, an occasional failure of a single test is expected even with a perfect
+// RNG; repeat the audit with another seed before drawing conclusions.
+
+const AuditSignificance = 0.001
+
+// AuditConfig configures the RNG audit.
+type AuditConfig struct {
+	NumDeals   int
+	NumPlayers int
+	// Seed of the random number generator. A random seed is used if 0.
+	Seed uint64
+	// The hand numbers go from 1 to HandsPerGame (the dealing depends on the
+	// hand number).
+	HandsPerGame  int
+	DealingPolicy game.DealingPolicy
+}
+
+// AuditTest is the result of one statistical test.
+type AuditTest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Statistic   float64 `json:"statistic"`
+	DF          int     `json:"df,omitempty"`
+	PValue      float64 `json:"pValue"`
+	Pass        bool    `json:"pass"`
+	Details     string  `json:"details,omitempty"`
+}
+
+// AuditReport is the result of the RNG audit.
+type AuditReport struct {
+	NumDeals      int         `json:"numDeals"`
+	NumPlayers    int         `json:"numPlayers"`
+	Seed          uint64      `json:"seed"`
+	HandsPerGame  int         `json:"handsPerGame"`
+	DealingPolicy string      `json:"dealingPolicy"`
+	Significance  float64     `json:"significance"`
+	Tests         []AuditTest `json:"tests"`
+}
+
+// Pass returns whether all the tests passed.
+func (r *AuditReport) Pass() bool {
+	for _, test := range r.Tests {
+		if !test.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// JSON returns the report in JSON.
+func (r *AuditReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Text returns the report as a table.
+func (r *AuditReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RNG audit: %d deals, %d players, seed %d, %d hands per game, %s, significance %g\n\n",
+		r.NumDeals, r.NumPlayers, r.Seed, r.HandsPerGame, r.DealingPolicy, r.Significance)
+	fmt.Fprintf(&b, "%-28s %14s %6s %10s  %s\n", "Test", "Statistic", "DF", "p-value", "Result")
+	for _, test := range r.Tests {
+		result := "PASS"
+		if !test.Pass {
+			result = "FAIL"
+		}
+		df := ""
+		if test.DF != 0 {
+			df = fmt.Sprintf("%d", test.DF)
+		}
+		fmt.Fprintf(&b, "%-28s %14.3f %6s %10.6f  %s\n", test.Name, test.Statistic, df, test.PValue, result)
+	}
+	b.WriteString("\n")
+	for _, test := range r.Tests {
+		fmt.Fprintf(&b, "%s: %s", test.Name, test.Description)
+		if test.Details != "" {
+			fmt.Fprintf(&b, " (%s)", test.Details)
+		}
+		b.WriteString("\n")
+	}
+	overall := "PASS"
+	if !r.Pass() {
+		overall = "FAIL"
+	}
+	fmt.Fprintf(&b, "\nOverall: %s\n", overall)
+	return b.String()
+}
+
+const deckSize = 52
+
+// cardIndex returns the index of the card byte in 0..51 (rank major).
+func cardIndex(card byte) int {
+	rank := int(card >> 4)
+	suit := 0
+	switch card & 0xF {
+	case 2:
+		suit = 1
+	case 4:
+		suit = 2
+	case 8:
+		suit = 3
+	}
+	return rank*4 + suit
+}
+
+type auditCounts struct {
+	deals int
+	// positionCounts[position][card]
+	positionCounts [deckSize][deckSize]float64
+	// startingHands[class] for the 169 classes of two hole cards
+	startingHands [169]float64
+	holeCards     int
+	// suitRank[suit][rank] of the dealt cards
+	suitRank [4][13]float64
+	// adjacent cards in the deck
+	adjacentSuits [4][4]float64
+	adjacentRanks [13][13]float64
+	// the previous deck for the serial tests
+	prevDeck [deckSize]int
+	// serial sums per position for the correlation of consecutive hands
+	serialX, serialY, serialXX, serialYY, serialXY [deckSize]float64
+	serialPairs                                    int
+	repeats                                        float64
+}
+
+// startingHandClass returns the class of two hole cards in 0..168. The pairs
+// are 0..12, the suited hands 13..90 and the offsuit hands 91..168.
+func startingHandClass(c1 int, c2 int) int {
+	r1, r2 := c1/4, c2/4
+	if r1 == r2 {
+		return r1
+	}
+	if r1 < r2 {
+		r1, r2 = r2, r1
+	}
+	// index of the rank pair (r1 > r2) in 0..77
+	pair := r1*(r1-1)/2 + r2
+	if c1%4 == c2%4 {
+		return 13 + pair
+	}
+	return 91 + pair
+}
+
+func (c *auditCounts) add(deck []byte, dealtCards int, playerCards map[uint32][]byte) {
+	var indexes [deckSize]int
+	for i, card := range deck {
+		indexes[i] = cardIndex(card)
+		c.positionCounts[i][indexes[i]]++
+	}
+	for i := 0; i < deckSize-1; i++ {
+		c.adjacentSuits[indexes[i]%4][indexes[i+1]%4]++
+		c.adjacentRanks[indexes[i]/4][indexes[i+1]/4]++
+	}
+	for i := 0; i < dealtCards; i++ {
+		c.suitRank[indexes[i]%4][indexes[i]/4]++
+	}
+	for _, cards := range playerCards {
+		if len(cards) != 2 {
+			continue
+		}
+		c.startingHands[startingHandClass(cardIndex(cards[0]), cardIndex(cards[1]))]++
+		c.holeCards++
+	}
+	if c.deals > 0 {
+		for i := 0; i < deckSize; i++ {
+			x, y := float64(c.prevDeck[i]), float64(indexes[i])
+			c.serialX[i] += x
+			c.serialY[i] += y
+			c.serialXX[i] += x * x
+			c.serialYY[i] += y * y
+			c.serialXY[i] += x * y
+			if c.prevDeck[i] == indexes[i] {
+				c.repeats++
+			}
+		}
+		c.serialPairs++
+	}
+	c.prevDeck = indexes
+	c.deals++
+}
+
+// RunRNGAudit deals the hands and runs the statistical tests.
+func RunRNGAudit(config AuditConfig) (*AuditReport, error) {
+	if config.NumDeals < 2 {
+		return nil, fmt.Errorf("RNG audit needs at least 2 deals")
+	}
+	if config.NumPlayers < 2 || config.NumPlayers > 9 {
+		return nil, fmt.Errorf("Invalid number of players %d. It must be 2 to 9", config.NumPlayers)
+	}
+	if config.Seed == 0 {
+		config.Seed = uint64(random.NewSeed())
+	}
+	if config.HandsPerGame == 0 {
+		config.HandsPerGame = 100
+	}
+	rng := poker.NewSeededRand(config.Seed)
+
+	seats := simulationSeats(config.NumPlayers)
+	counts := &auditCounts{}
+	for i := 0; i < config.NumDeals; i++ {
+		if i > 0 && i%100000 == 0 {
+			fmt.Printf("Deal %d\n", i)
+		}
+		h, err := dealHoldemHand(seats, i, config.HandsPerGame, config.DealingPolicy, rng)
+		if err != nil {
+			return nil, err
+		}
+		counts.add(h.Deck, int(h.DeckIndex), h.PlayersCards)
+	}
+
+	report := &AuditReport{
+		NumDeals:      config.NumDeals,
+		NumPlayers:    config.NumPlayers,
+		Seed:          config.Seed,
+		HandsPerGame:  config.HandsPerGame,
+		DealingPolicy: config.DealingPolicy.String(),
+		Significance:  AuditSignificance,
+	}
+	report.Tests = []AuditTest{
+		counts.cardPositionTest(),
+		counts.startingHandTest(),
+		counts.pairSuitedTest(),
+		counts.suitRankTest(),
+		counts.adjacentSuitTest(),
+		counts.adjacentRankTest(),
+		counts.serialCorrelationTest(),
+		counts.serialRepeatTest(),
+	}
+	return report, nil
+}
+
+// simulationSeats returns the seats of the players dealt in the simulated hands.
+func simulationSeats(numPlayers int) []game.SeatPlayer {
+	seats := make([]game.SeatPlayer, numPlayers)
+	for i := range seats {
+		seats[i] = game.SeatPlayer{
+			SeatNo:   uint32(i + 1),
+			PlayerID: uint64(i + 1),
+			Name:     fmt.Sprintf("player%d", i+1),
+			Stack:    10000,
+			Status:   game.PlayerStatus_PLAYING,
+			Inhand:   true,
+		}
+	}
+	return seats
+}
+
+// dealHoldemHand deals the i-th simulated Hold'em hand through game.DealHand.
+// A new game starts every handsPerGame hands.
+func dealHoldemHand(seats []game.SeatPlayer, i int, handsPerGame int, policy game.DealingPolicy, rng *rand.Rand) (*game.HandState, error) {
+	handConfig := &game.HandConfig{
+		GameID:        1,
+		HandNum:       uint32(i%handsPerGame + 1),
+		GameType:      game.GameType_HOLDEM,
+		MaxPlayers:    9,
+		SmallBlind:    100,
+		BigBlind:      200,
+		ButtonPos:     uint32(i%len(seats) + 1),
+		ChipUnit:      game.ChipUnit_DOLLAR,
+		DealingPolicy: policy,
+		Rand:          rng,
+	}
+	h, _, err := game.DealHand(handConfig, seats, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error while dealing hand %d", i+1)
+	}
+	return h, nil
+}
+
+func newAuditTest(name string, description string, stat float64, df int) AuditTest {
+	p := chiSquarePValue(stat, df)
+	return AuditTest{
+		Name:        name,
+		Description: description,
+		Statistic:   stat,
+		DF:          df,
+		PValue:      p,
+		Pass:        p >= AuditSignificance,
+	}
+}
+
+func (c *auditCounts) cardPositionTest() AuditTest {
+	expected := make([]float64, deckSize)
+	for i := range expected {
+		expected[i] = float64(c.deals) / deckSize
+	}
+	stat := 0.0
+	worstPosition, worstP := 0, 1.0
+	for position := 0; position < deckSize; position++ {
+		positionStat := chiSquare(c.positionCounts[position][:], expected)
+		stat += positionStat
+		p := chiSquarePValue(positionStat, deckSize-1)
+		if p < worstP {
+			worstPosition, worstP = position, p
+		}
+	}
+	test := newAuditTest("card position frequency",
+		"chi-square of the frequency of every card at every deck position against the uniform 1/52",
+		stat, deckSize*(deckSize-1))
+	test.Details = fmt.Sprintf("lowest p-value %.6f at position %d", worstP, worstPosition+1)
+	return test
+}
+
+func (c *auditCounts) startingHandTest() AuditTest {
+	expected := make([]float64, 169)
+	for class := range expected {
+		combos := 12.0 // offsuit
+		if class < 13 {
+			combos = 6
+		} else if class < 91 {
+			combos = 4
+		}
+		expected[class] = float64(c.holeCards) * combos / 1326
+	}
+	return newAuditTest("starting hands",
+		"chi-square of the 169 Hold'em starting hands against 6/1326 per pair, 4/1326 per suited and 12/1326 per offsuit hand",
+		chiSquare(c.startingHands[:], expected), 168)
+}
+
+func (c *auditCounts) pairSuitedTest() AuditTest {
+	observed := make([]float64, 3)
+	for class, count := range c.startingHands {
+		if class < 13 {
+			observed[0] += count
+		} else if class < 91 {
+			observed[1] += count
+		} else {
+			observed[2] += count
+		}
+	}
+	n := float64(c.holeCards)
+	expected := []float64{n * 78 / 1326, n * 312 / 1326, n * 936 / 1326}
+	test := newAuditTest("pocket pairs",
+		"chi-square of the pocket pairs, suited and offsuit hole cards against 1/17, 4/17 and 12/17",
+		chiSquare(observed, expected), 2)
+	test.Details = fmt.Sprintf("pairs %.6f vs %.6f", observed[0]/n, 1.0/17)
+	return test
+}
+
+func (c *auditCounts) suitRankTest() AuditTest {
+	var suitTotals [4]float64
+	var rankTotals [13]float64
+	total := 0.0
+	for suit := 0; suit < 4; suit++ {
+		for rank := 0; rank < 13; rank++ {
+			suitTotals[suit] += c.suitRank[suit][rank]
+			rankTotals[rank] += c.suitRank[suit][rank]
+			total += c.suitRank[suit][rank]
+		}
+	}
+	observed := make([]float64, 0, 52)
+	expected := make([]float64, 0, 52)
+	for suit := 0; suit < 4; suit++ {
+		for rank := 0; rank < 13; rank++ {
+			observed = append(observed, c.suitRank[suit][rank])
+			expected = append(expected, suitTotals[suit]*rankTotals[rank]/total)
+		}
+	}
+	return newAuditTest("suit/rank independence",
+		"chi-square test of independence between the suit and the rank of the dealt cards",
+		chiSquare(observed, expected), 3*12)
+}
+
+func (c *auditCounts) adjacentSuitTest() AuditTest {
+	pairs := float64(c.deals * (deckSize - 1))
+	observed := make([]float64, 0, 16)
+	expected := make([]float64, 0, 16)
+	for s1 := 0; s1 < 4; s1++ {
+		for s2 := 0; s2 < 4; s2++ {
+			observed = append(observed, c.adjacentSuits[s1][s2])
+			if s1 == s2 {
+				expected = append(expected, pairs/4*12/51)
+			} else {
+				expected = append(expected, pairs/4*13/51)
+			}
+		}
+	}
+	return newAuditTest("adjacent suits",
+		"chi-square of the suits of adjacent cards in the deck against drawing without replacement",
+		chiSquare(observed, expected), 15)
+}
+
+func (c *auditCounts) adjacentRankTest() AuditTest {
+	pairs := float64(c.deals * (deckSize - 1))
+	observed := make([]float64, 0, 169)
+	expected := make([]float64, 0, 169)
+	for r1 := 0; r1 < 13; r1++ {
+		for r2 := 0; r2 < 13; r2++ {
+			observed = append(observed, c.adjacentRanks[r1][r2])
+			if r1 == r2 {
+				expected = append(expected, pairs/13*3/51)
+			} else {
+				expected = append(expected, pairs/13*4/51)
+			}
+		}
+	}
+	return newAuditTest("adjacent ranks",
+		"chi-square of the ranks of adjacent cards in the deck against drawing without replacement",
+		chiSquare(observed, expected), 168)
+}
+
+func (c *auditCounts) serialCorrelationTest() AuditTest {
+	n := float64(c.serialPairs)
+	stat := 0.0
+	worstPosition, worstR := 0, 0.0
+	for i := 0; i < deckSize; i++ {
+		cov := c.serialXY[i]/n - c.serialX[i]/n*c.serialY[i]/n
+		varX := c.serialXX[i]/n - c.serialX[i]/n*c.serialX[i]/n
+		varY := c.serialYY[i]/n - c.serialY[i]/n*c.serialY[i]/n
+		if varX <= 0 || varY <= 0 {
+			continue
+		}
+		r := cov / math.Sqrt(varX*varY)
+		// r * sqrt(n) is close to standard normal for uncorrelated hands
+		z := r * math.Sqrt(n)
+		stat += z * z
+		if math.Abs(r) > math.Abs(worstR) {
+			worstPosition, worstR = i, r
+		}
+	}
+	test := newAuditTest("serial correlation",
+		"correlation of the card at each deck position between consecutive hands, sum of the squared z scores",
+		stat, deckSize)
+	test.Details = fmt.Sprintf("largest correlation %.6f at position %d", worstR, worstPosition+1)
+	return test
+}
+
+func (c *auditCounts) serialRepeatTest() AuditTest {
+	// The number of cards at the same position as in the previous hand has
+	// mean 1 and variance 1 for a random permutation.
+	n := float64(c.serialPairs)
+	z := (c.repeats - n) / math.Sqrt(n)
+	p := normalPValue(z)
+	return AuditTest{
+		Name:        "serial repeats",
+		Description: "z score of the number of cards at the same deck position as in the previous hand against 1 per hand",
+		Statistic:   z,
+		PValue:      p,
+		Pass:        p >= AuditSignificance,
+		Details:     fmt.Sprintf("%.6f repeats per hand", c.repeats/n),
+	}
+}
@@ -0,0 +1,98 @@
+package simulation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"voyager.com/server/game"
+)
+
+func TestChiSquarePValue(t *testing.T) {
+	// values from the chi-square tables
+	assert.InDelta(t, 0.05, chiSquarePValue(3.841, 1), 1e-4)
+	assert.InDelta(t, 0.05, chiSquarePValue(5.991, 2), 1e-4)
+	assert.InDelta(t, 0.001, chiSquarePValue(29.588, 10), 1e-5)
+	assert.InDelta(t, 0.5, chiSquarePValue(167.334, 168), 1e-3)
+	assert.InDelta(t, 0.5, chiSquarePValue(2651.33, 2652), 1e-3)
+	assert.Equal(t, 1.0, chiSquarePValue(0, 5))
+	assert.InDelta(t, 0.05, normalPValue(1.96), 1e-4)
+}
+
+func TestStartingHandClass(t *testing.T) {
+	seen := make(map[int]int)
+	for c1 := 0; c1 < deckSize; c1++ {
+		for c2 := c1 + 1; c2 < deckSize; c2++ {
+			class := startingHandClass(c1, c2)
+			require.Equal(t, class, startingHandClass(c2, c1))
+			seen[class]++
+		}
+	}
+	require.Len(t, seen, 169)
+	for class, combos := range seen {
+		switch {
+		case class < 13:
+			assert.Equal(t, 6, combos, "class %d", class)
+		case class < 91:
+			assert.Equal(t, 4, combos, "class %d", class)
+		default:
+			assert.Equal(t, 12, combos, "class %d", class)
+		}
+	}
+}
+
+func TestRunRNGAudit(t *testing.T) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	defer zerolog.SetGlobalLevel(level)
+
+	config := AuditConfig{NumDeals: 2000, NumPlayers: 6, Seed: 5}
+	report, err := RunRNGAudit(config)
+	require.NoError(t, err)
+	assert.Len(t, report.Tests, 8)
+	assert.Equal(t, uint64(5), report.Seed)
+	assert.Contains(t, report.Text(), "card position frequency")
+
+	// the same seed gives the same report
+	again, err := RunRNGAudit(config)
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+
+	data, err := report.JSON()
+	require.NoError(t, err)
+	var parsed AuditReport
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, report.Tests[0].Name, parsed.Tests[0].Name)
+
+	_, err = RunRNGAudit(AuditConfig{NumDeals: 2000, NumPlayers: 1})
+	assert.Error(t, err)
+}
+
+func TestCompareDealingPolicies(t *testing.T) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	defer zerolog.SetGlobalLevel(level)
+
+	comparison, err := CompareDealingPolicies(AuditConfig{NumDeals: 1000, NumPlayers: 9, Seed: 3}, []game.DealingPolicy{
+		game.DealingPolicy_DEALING_PURE_RANDOM,
+		game.DealingPolicy_DEALING_LEGACY,
+	})
+	require.NoError(t, err)
+	require.Len(t, comparison.Policies, 2)
+
+	pure := comparison.Policies[0]
+	assert.Equal(t, "DEALING_PURE_RANDOM", pure.Policy)
+	assert.Zero(t, pure.Reshuffles)
+	assert.Len(t, pure.Rates, 6)
+	assert.Equal(t, 9000, pure.Rates[0].Total)
+
+	legacy := comparison.Policies[1]
+	assert.NotZero(t, legacy.Reshuffles)
+	assert.NotZero(t, legacy.ReshufflesByReason[game.ReshuffleSameHoleCardsOrPairedBoard])
+	assert.Contains(t, comparison.Text(), "SAME_HOLE_CARDS_OR_PAIRED_BOARD")
+
+	// the legacy policy deals fewer paired boards
+	assert.Less(t, legacy.Rates[2].Count, pure.Rates[2].Count)
+}
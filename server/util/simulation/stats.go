@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"math"
+)
+
+// chiSquarePValue returns the probability of a chi-square statistic at least
+// as large as x with df degrees of freedom.
+func chiSquarePValue(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return upperGammaRegularized(float64(df)/2, x/2)
+}
+
+// normalPValue returns the two-sided p-value of a standard normal z score.
+func normalPValue(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// upperGammaRegularized returns Q(a, x) = Γ(a, x) / Γ(a), using the series for
+// x < a+1 and the continued fraction otherwise (Numerical Recipes 6.2).
+func upperGammaRegularized(a float64, x float64) float64 {
+	const maxIterations = 100000
+	const epsilon = 1e-15
+	lgammaA, _ := math.Lgamma(a)
+	logPrefix := a*math.Log(x) - x - lgammaA
+
+	if x < a+1 {
+		sum := 1 / a
+		term := sum
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(logPrefix)
+	}
+
+	// modified Lentz's method
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return math.Exp(logPrefix) * h
+}
+
+// chiSquare returns the Pearson chi-square statistic of the observed counts
+// against the expected counts.
+func chiSquare(observed []float64, expected []float64) float64 {
+	stat := 0.0
+	for i := range observed {
+		if expected[i] == 0 {
+			continue
+		}
+		diff := observed[i] - expected[i]
+		stat += diff * diff / expected[i]
+	}
+	return stat
+}